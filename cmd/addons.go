@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyAddonsCmd = &cobra.Command{
+		Use:   "apply-addons",
+		Short: "Re-apply cloud-controller-manager, csi, custom manifests and add-ons to an existing K3s cluster",
+	}
+	aProvider = ""
+	ap        providers.Provider
+)
+
+func init() {
+	applyAddonsCmd.Flags().StringVarP(&aProvider, "provider", "p", aProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// ApplyAddonsCommand apply add-ons command.
+func ApplyAddonsCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			ap = reg
+		}
+
+		applyAddonsCmd.Flags().AddFlagSet(utils.ConvertFlags(applyAddonsCmd, ap.GetCredentialFlags()))
+		applyAddonsCmd.Flags().AddFlagSet(utils.ConvertFlags(applyAddonsCmd, ap.GetSSHFlags()))
+		applyAddonsCmd.Use = fmt.Sprintf("apply-addons -p %s", pStr)
+	}
+
+	applyAddonsCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if aProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := ap.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), ap); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	applyAddonsCmd.Run = func(cmd *cobra.Command, args []string) {
+		ap.GenerateClusterName()
+		if err := ap.ApplyAddons(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return applyAddonsCmd
+}
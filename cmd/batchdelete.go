@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchDeleteCmd = &cobra.Command{
+		Use:   "batch-delete <context-name>...",
+		Short: "Delete multiple K3s clusters concurrently",
+		Long: "Delete the clusters of the context names, e.g. myk3s.ap-guangzhou.tencent, with a bounded number of concurrent deletions.\n" +
+			"A summary of the deleted and failed clusters is printed, the command fails if any of the clusters isn't deleted.",
+		Example: `  autok3s batch-delete ci-1.ap-guangzhou.tencent ci-2.ap-guangzhou.tencent --concurrency 2 --yes`,
+		Args:    cobra.MinimumNArgs(1),
+	}
+	bdConcurrency = common.DefaultBatchConcurrency
+	bdYes         = false
+	bdForce       = false
+)
+
+func init() {
+	batchDeleteCmd.Flags().IntVar(&bdConcurrency, "concurrency", bdConcurrency, "The number of clusters deleted at the same time")
+	batchDeleteCmd.Flags().BoolVarP(&bdYes, "yes", "y", bdYes, "Delete the clusters without confirmation")
+	batchDeleteCmd.Flags().BoolVarP(&bdForce, "force", "f", bdForce, "Also delete the clusters with an operation in progress")
+}
+
+// BatchDeleteCommand batch delete command.
+func BatchDeleteCommand() *cobra.Command {
+	batchDeleteCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if bdConcurrency < 1 {
+			return fmt.Errorf("`--concurrency` must be at least 1, got %d", bdConcurrency)
+		}
+		return nil
+	}
+
+	batchDeleteCmd.Run = func(cmd *cobra.Command, args []string) {
+		if !bdYes && !utils.AskForConfirmationOrAssumeYes(fmt.Sprintf("are you sure to delete cluster(s) %s", strings.Join(args, ",")), false) {
+			return
+		}
+		results := common.DeleteClusters(args, bdConcurrency, bdForce)
+
+		out := new(tabwriter.Writer)
+		out.Init(os.Stdout, 0, 8, 2, ' ', 0)
+		_, _ = fmt.Fprintf(out, "CLUSTER\tRESULT\n")
+		failed := 0
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+				_, _ = fmt.Fprintf(out, "%s\tfailed: %v\n", r.ContextName, r.Err)
+				continue
+			}
+			_, _ = fmt.Fprintf(out, "%s\tdeleted\n", r.ContextName)
+		}
+		_ = out.Flush()
+		if failed > 0 {
+			logrus.Fatalf("%d of %d cluster(s) failed to be deleted", failed, len(results))
+		}
+	}
+
+	return batchDeleteCmd
+}
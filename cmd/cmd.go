@@ -80,6 +80,10 @@ func initCfg() {
 	common.ExplorerWatchers = map[string]context.CancelFunc{}
 	common.FileManager = &common.ConfigFileManager{}
 
+	if err := common.EnsureStorePermissions(); err != nil {
+		logrus.Errorf("failed to restrict permissions of cluster store, %v", err)
+	}
+
 	if err := common.SetupNewInstall(); err != nil {
 		logrus.Fatalln(err)
 	}
@@ -135,8 +139,9 @@ Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
   {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
 
 Global Environments:
-  AUTOK3S_CONFIG  Path to the cfg file to use for CLI requests (default ~/.autok3s)
-  AUTOK3S_RETRY   The number of retries waiting for the desired state (default 20)
+  AUTOK3S_ASSUME_YES  Confirm the deletion of clusters without prompt (default false)
+  AUTOK3S_CONFIG      Path to the cfg file to use for CLI requests (default ~/.autok3s)
+  AUTOK3S_RETRY       The number of retries waiting for the desired state (default 20)
 
 Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
 `
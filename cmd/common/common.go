@@ -1,18 +1,23 @@
 package common
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/cnrancher/autok3s/pkg/cluster"
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
 	"github.com/cnrancher/autok3s/pkg/utils"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
 )
 
 // BindEnvFlags used for bind env to flag.
@@ -33,8 +38,11 @@ func BindEnvFlags(cmd *cobra.Command) {
 // Borrowed from https://github.com/docker/machine/blob/master/commands/create.go#L267.
 func FlagHackLookup(flagName string) string {
 	// i.e. "-d" for "--driver"
-	flagPrefix := flagName[1:3]
+	return FlagHackLookupP(flagName, flagName[1:3])
+}
 
+// FlagHackLookupP is like FlagHackLookup, but accepts a shorthand which is not the initial of flag name.
+func FlagHackLookupP(flagName, flagPrefix string) string {
 	// TODO: Should we support -flag-name (single hyphen) syntax as well?
 	for i, arg := range os.Args {
 		if strings.Contains(arg, flagPrefix) {
@@ -55,8 +63,33 @@ func FlagHackLookup(flagName string) string {
 	return ""
 }
 
+// profileCredentialLoader is implemented by the providers which read the credential from a profile of credentials file.
+type profileCredentialLoader interface {
+	LoadProfileCredential() (map[string]string, error)
+}
+
+// sshKeySourceLoader is implemented by the providers which load the ssh key from a key source with their credential.
+type sshKeySourceLoader interface {
+	LoadSSHKeySource() error
+}
+
 // MakeSureCredentialFlag ensure credential is provided.
+// The credential flags which aren't set are filled from the profile if it's set, then from the stored credential.
+// The ssh key of the key source which requires the credential is loaded at last.
 func MakeSureCredentialFlag(flags *pflag.FlagSet, p providers.Provider) error {
+	if loader, ok := p.(profileCredentialLoader); ok {
+		secrets, err := loader.LoadProfileCredential()
+		if err != nil {
+			return err
+		}
+		for name, value := range secrets {
+			if flag := flags.Lookup(name); flag != nil && flag.Value.String() == "" {
+				if err = flags.Set(name, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	flags.VisitAll(func(flag *pflag.Flag) {
 		if isCredentialFlag(flag.Name, p) {
 			v, err := flags.GetString(flag.Name)
@@ -79,6 +112,9 @@ func MakeSureCredentialFlag(flags *pflag.FlagSet, p providers.Provider) error {
 			}
 		}
 	})
+	if loader, ok := p.(sshKeySourceLoader); ok {
+		return loader.LoadSSHKeySource()
+	}
 	return nil
 }
 
@@ -92,3 +128,104 @@ func isCredentialFlag(s string, p providers.Provider) bool {
 	}
 	return found
 }
+
+// clusterSpec is the layout of cluster spec file, status is managed by autok3s and can't be set.
+type clusterSpec struct {
+	types.Metadata `json:",inline"`
+	types.SSH      `json:",inline"`
+	Options        json.RawMessage `json:"options,omitempty"`
+}
+
+// ReadClusterSpec reads cluster spec from YAML file and returns provider name with its JSON content.
+func ReadClusterSpec(path string) (string, []byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+	b, err = yaml.YAMLToJSON(b)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	spec := &clusterSpec{}
+	if err = unmarshalStrict(b, spec); err != nil {
+		return "", nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return spec.Provider, b, nil
+}
+
+// ApplyClusterSpec sets cluster spec read by ReadClusterSpec as provider config,
+// unknown keys of provider options are rejected instead of being silently ignored.
+func ApplyClusterSpec(spec []byte, p providers.Provider) error {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(spec, &m); err != nil {
+		return err
+	}
+	if name, ok := m["provider"].(string); ok && name != "" && name != p.GetProviderName() {
+		return fmt.Errorf("provider %s of config file mismatches with --provider %s", name, p.GetProviderName())
+	}
+	opt, _ := m["options"].(map[string]interface{})
+	if opt == nil {
+		opt = map[string]interface{}{}
+	}
+	b, err := json.Marshal(opt)
+	if err != nil {
+		return err
+	}
+	target, err := p.GetProviderOptions([]byte("{}"))
+	if err != nil {
+		return err
+	}
+	if err = unmarshalStrict(b, target); err != nil {
+		return fmt.Errorf("invalid options of config file: %v", err)
+	}
+
+	// bool values are always merged by SetConfig, keep the defaults for the missing ones.
+	fillBoolDefaults(m, p.GetCreateFlags())
+	fillBoolDefaults(opt, p.GetOptionFlags())
+	m["options"] = opt
+	b, err = json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return p.SetConfig(b)
+}
+
+func fillBoolDefaults(m map[string]interface{}, fs []types.Flag) {
+	for _, f := range fs {
+		if v, ok := f.V.(bool); ok {
+			if _, exist := m[f.Name]; !exist {
+				m[f.Name] = v
+			}
+		}
+	}
+}
+
+func unmarshalStrict(b []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Exit codes of the errors which scripts may want to handle.
+const (
+	ExitCodeClusterExists   = 3
+	ExitCodeClusterNotFound = 4
+)
+
+// ExitWithError logs the error and exits with the code of the error, 1 is used for the errors without a dedicated code.
+func ExitWithError(err error) {
+	logrus.Errorln(err)
+	os.Exit(ExitCode(err))
+}
+
+// ExitCode returns the exit code of the error.
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, cluster.ErrClusterExists):
+		return ExitCodeClusterExists
+	case errors.Is(err, cluster.ErrClusterNotFound):
+		return ExitCodeClusterNotFound
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cordonCmd = &cobra.Command{
+		Use:   "cordon",
+		Short: "Mark the nodes of a K3s cluster as unschedulable",
+	}
+	uncordonCmd = &cobra.Command{
+		Use:   "uncordon",
+		Short: "Mark the nodes of a K3s cluster as schedulable",
+	}
+	coProvider = ""
+	coNode     = ""
+	cop        providers.Provider
+)
+
+func init() {
+	for _, c := range []*cobra.Command{cordonCmd, uncordonCmd} {
+		c.Flags().StringVarP(&coProvider, "provider", "p", coProvider, "Provider is a module which provides an interface for managing cloud resources")
+		c.Flags().StringVar(&coNode, "node", coNode, "Only the specified node is handled, all nodes of the cluster are handled if it's empty")
+	}
+}
+
+// CordonCommand cordon command.
+func CordonCommand() *cobra.Command {
+	return cordonCommand(cordonCmd, func() error {
+		return cop.CordonCluster(coNode)
+	})
+}
+
+// UncordonCommand uncordon command.
+func UncordonCommand() *cobra.Command {
+	return cordonCommand(uncordonCmd, func() error {
+		return cop.UncordonCluster(coNode)
+	})
+}
+
+func cordonCommand(cmd *cobra.Command, run func() error) *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		// cordon and uncordon share the same provider, so that flags of both commands are bound to it.
+		if cop == nil {
+			reg, err := providers.GetProvider(pStr)
+			if err != nil {
+				logrus.Fatalln(err)
+			}
+			cop = reg
+		}
+
+		cmd.Flags().AddFlagSet(utils.ConvertFlags(cmd, cop.GetSSHFlags()))
+		cmd.Use = fmt.Sprintf("%s -p %s", cmd.Name(), pStr)
+	}
+
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if coProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := cop.MergeClusterOptions(); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		cop.GenerateClusterName()
+		if err := run(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return cmd
+}
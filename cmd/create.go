@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/cnrancher/autok3s/cmd/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
 	"github.com/cnrancher/autok3s/pkg/utils"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -18,17 +23,37 @@ var (
 	}
 
 	cProvider = ""
+	cFile     = ""
+	cDryRun   = false
+	cCost     = false
+	cOutput   = ""
 	cp        providers.Provider
 )
 
 func init() {
 	createCmd.Flags().StringVarP(&cProvider, "provider", "p", cProvider, "Provider is a module which provides an interface for managing cloud resources")
+	createCmd.Flags().StringVarP(&cFile, "config-file", "f", cFile, "Cluster spec file in YAML format, the values can be overridden by flags")
+	createCmd.Flags().BoolVar(&cDryRun, "dry-run", cDryRun, "Run the preflight checks of creating the cluster without creating anything")
+	createCmd.Flags().BoolVar(&cCost, "cost", cCost, "Print the estimated cost of the cluster, only works with --dry-run")
+	createCmd.Flags().StringVarP(&cOutput, "output", "o", cOutput, "Print the summary of the created cluster to stdout in the format, only json is supported, e.g.(-o json)")
 }
 
 // CreateCommand create command.
 func CreateCommand() *cobra.Command {
 	// load dynamic provider flags.
 	pStr := common.FlagHackLookup("--provider")
+	var spec []byte
+	if fStr := common.FlagHackLookupP("--config-file", "-f"); fStr != "" {
+		name, b, err := common.ReadClusterSpec(fStr)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if pStr == "" && name != "" {
+			pStr = name
+			cProvider = name
+		}
+		spec = b
+	}
 	if pStr != "" {
 		if reg, err := providers.GetProvider(pStr); err != nil {
 			logrus.Fatalln(err)
@@ -39,6 +64,12 @@ func CreateCommand() *cobra.Command {
 		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cp.GetCredentialFlags()))
 		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cp.GetOptionFlags()))
 		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cp.GetCreateFlags()))
+		// values of spec file are set after flags registered, so that the flags can override them when parsing.
+		if spec != nil {
+			if err := common.ApplyClusterSpec(spec, cp); err != nil {
+				logrus.Fatalln(err)
+			}
+		}
 		createCmd.Example = cp.GetUsageExample("create")
 		createCmd.Use = fmt.Sprintf("create -p %s", pStr)
 	}
@@ -47,6 +78,12 @@ func CreateCommand() *cobra.Command {
 		if cProvider == "" {
 			logrus.Fatalln("required flag(s) \"--provider\" not set")
 		}
+		if cCost && !cDryRun {
+			logrus.Fatalln("flag \"--cost\" only works with \"--dry-run\"")
+		}
+		if cOutput != "" && cOutput != "json" {
+			logrus.Fatalf("unsupported output format %q of flag \"--output\", only json is supported", cOutput)
+		}
 		common.BindEnvFlags(cmd)
 		if err := common.MakeSureCredentialFlag(cmd.Flags(), cp); err != nil {
 			return err
@@ -57,19 +94,77 @@ func CreateCommand() *cobra.Command {
 
 	createCmd.Run = func(cmd *cobra.Command, args []string) {
 		// generate cluster name. i.e. input: "--name k3s1 --region cn-hangzhou" output: "k3s1.cn-hangzhou.<provider>".
-		cp.GenerateClusterName()
+		name := cp.GenerateClusterName()
 		if err := cp.BindCredential(); err != nil {
 			logrus.Fatalln(err)
 		}
+		if s, ok := cp.(keyPairSelector); ok && utils.IsTerm() {
+			if err := s.SelectKeyPair(); err != nil {
+				logrus.Fatalln(err)
+			}
+		}
 		if err := cp.CreateCheck(); err != nil {
-			logrus.Fatalln(err)
+			common.ExitWithError(err)
+		}
+
+		if cDryRun {
+			if cCost {
+				estimate, err := cp.EstimateCost()
+				if err != nil {
+					logrus.Fatalln(err)
+				}
+				printCostEstimate(estimate)
+			}
+			logrus.Infof("[%s] preflight checks of cluster %s passed, nothing is created in dry run", cProvider, name)
+			return
 		}
 
 		// create k3s cluster with generated cluster name.
 		if err := cp.CreateK3sCluster(); err != nil {
-			logrus.Fatalln(err)
+			common.ExitWithError(err)
+		}
+
+		if cOutput == "json" {
+			summary, err := cp.GetClusterSummary()
+			if err != nil {
+				logrus.Fatalln(err)
+			}
+			b, _ := json.MarshalIndent(summary, "", "  ")
+			fmt.Println(string(b))
 		}
 	}
 
 	return createCmd
 }
+
+// keyPairSelector is implemented by the providers which let the user choose the key pair of the account interactively.
+type keyPairSelector interface {
+	SelectKeyPair() error
+}
+
+func printCostEstimate(estimate *types.CostEstimate) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"Item", "Count", "Price", "Charge Unit", "Monthly"})
+
+	total := 0.0
+	for _, item := range estimate.Items {
+		monthly := "by usage"
+		if item.Monthly > 0 {
+			monthly = strconv.FormatFloat(item.Monthly, 'f', 2, 64)
+		}
+		table.Append([]string{
+			item.Name,
+			strconv.Itoa(item.Count),
+			strconv.FormatFloat(item.Price, 'f', -1, 64),
+			item.ChargeUnit,
+			monthly,
+		})
+		total += item.Monthly
+	}
+	table.SetFooter([]string{"", "", "", "Total", strconv.FormatFloat(total, 'f', 2, 64)})
+	table.Render()
+}
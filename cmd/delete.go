@@ -63,7 +63,7 @@ func DeleteCommand() *cobra.Command {
 	deleteCmd.Run = func(cmd *cobra.Command, args []string) {
 		dp.GenerateClusterName()
 		if err := dp.DeleteK3sCluster(force); err != nil {
-			logrus.Fatalln(err)
+			common.ExitWithError(err)
 		}
 	}
 
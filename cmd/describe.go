@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"text/tabwriter"
 
 	"github.com/cnrancher/autok3s/pkg/common"
@@ -21,11 +23,14 @@ var (
 	}
 	desProvider = ""
 	name        = ""
+	desSpec     = false
 )
 
 func init() {
 	describeCmd.Flags().StringVarP(&desProvider, "provider", "p", desProvider, "Provider is a module which provides an interface for managing cloud resources")
 	describeCmd.Flags().StringVarP(&name, "name", "n", name, "cluster name")
+	describeCmd.Flags().BoolVar(&desSpec, "spec", desSpec, "Show the autok3s version and the redacted args which the cluster is created with")
+	describeCmd.Flags().DurationVar(&common.DescribeTimeout, "describe-timeout", common.DescribeTimeout, "The time to wait for the api-server of a starting cluster to be ready, e.g. 1m, the unreachable cluster is reported at once")
 }
 
 // DescribeCommand returns the specified cluster details.
@@ -64,6 +69,16 @@ func describeCluster() {
 		}
 		provider.SetMetadata(&state.Metadata)
 		_ = provider.SetOptions(state.Options)
+		if desSpec {
+			spec, err := provider.GetClusterSpec()
+			if err != nil {
+				allErr = append(allErr, err.Error())
+				continue
+			}
+			b, _ := json.MarshalIndent(spec, "", "  ")
+			_, _ = fmt.Fprintf(out, "%s\n", b)
+			continue
+		}
 		isExist, _, err := provider.IsClusterExist()
 		if err != nil {
 			logrus.Errorf("failed to check cluster %s exist, got error: %v ", state.Name, err)
@@ -97,6 +112,17 @@ func describeCluster() {
 			_, _ = fmt.Fprintf(out, "    hostname: %s\n", node.HostName)
 			_, _ = fmt.Fprintf(out, "    container-runtime: %s\n", node.ContainerRuntimeVersion)
 			_, _ = fmt.Fprintf(out, "    version: %s\n", node.Version)
+			if len(node.Tags) > 0 {
+				keys := make([]string, 0, len(node.Tags))
+				for k := range node.Tags {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				_, _ = fmt.Fprintf(out, "    tags:%s\n", "")
+				for _, k := range keys {
+					_, _ = fmt.Fprintf(out, "      %s: %s\n", k, node.Tags[k])
+				}
+			}
 		}
 	}
 	for _, e := range allErr {
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execCmd = &cobra.Command{
+		Use:   "exec -- <command>",
+		Short: "Run a command on the nodes of a K3s cluster through SSH",
+		Long: "Run a command on all nodes, masters, workers or the node of the instance id concurrently, " +
+			"and print the output and exit code of each node.",
+		Args: cobra.MinimumNArgs(1),
+	}
+	exeProvider = ""
+	exeNode     = cluster.NodeSelectorAll
+	exep        providers.Provider
+)
+
+func init() {
+	execCmd.Flags().StringVarP(&exeProvider, "provider", "p", exeProvider, "Provider is a module which provides an interface for managing cloud resources")
+	execCmd.Flags().StringVar(&exeNode, "node", exeNode,
+		fmt.Sprintf("The nodes to run the command, one of %s, %s, %s or an instance id", cluster.NodeSelectorAll, cluster.NodeSelectorMasters, cluster.NodeSelectorWorkers))
+}
+
+// ExecCommand exec command.
+func ExecCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			exep = reg
+		}
+
+		execCmd.Flags().AddFlagSet(utils.ConvertFlags(execCmd, exep.GetSSHFlags()))
+		execCmd.Use = fmt.Sprintf("exec -p %s -- <command>", pStr)
+	}
+
+	execCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if exeProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := exep.MergeClusterOptions(); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	execCmd.Run = func(cmd *cobra.Command, args []string) {
+		exep.GenerateClusterName()
+		results, err := exep.SSHExec(exeNode, strings.Join(args, " "))
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		failed := false
+		for _, result := range results {
+			role := "worker"
+			if result.Master {
+				role = "master"
+			}
+			fmt.Printf("==> %s (%s) <==\n", result.InstanceID, role)
+			fmt.Print(result.Stdout)
+			fmt.Fprint(os.Stderr, result.Stderr)
+			if result.Error != "" {
+				failed = true
+				fmt.Fprintf(os.Stderr, "failed to run command: %s\n", result.Error)
+			} else if result.ExitCode != 0 {
+				failed = true
+				fmt.Fprintf(os.Stderr, "exit code: %d\n", result.ExitCode)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	}
+
+	return execCmd
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export a K3s cluster to an archive which can be imported by another machine",
+		Long: "Export the state, kubeconfig and ssh keys of a K3s cluster to an archive, " +
+			"so that another machine can manage the same cluster after importing it by `autok3s import`.",
+	}
+	exProvider = ""
+	exOutput   = ""
+	exp        providers.Provider
+
+	importCmd = &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Import a K3s cluster from the archive exported by `autok3s export`",
+		Args:  cobra.ExactArgs(1),
+	}
+	imForce = false
+)
+
+func init() {
+	exportCmd.Flags().StringVarP(&exProvider, "provider", "p", exProvider, "Provider is a module which provides an interface for managing cloud resources")
+	exportCmd.Flags().StringVarP(&exOutput, "output", "o", exOutput, "Path of the exported archive, default to <name>.tar.gz")
+	importCmd.Flags().BoolVarP(&imForce, "force", "f", imForce, "Overwrite the existing cluster with the same name")
+}
+
+// ExportCommand export cluster command.
+func ExportCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			exp = reg
+		}
+
+		exportCmd.Flags().AddFlagSet(utils.ConvertFlags(exportCmd, exp.GetSSHFlags()))
+		exportCmd.Use = fmt.Sprintf("export -p %s", pStr)
+	}
+
+	exportCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if exProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := exp.MergeClusterOptions(); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	exportCmd.Run = func(cmd *cobra.Command, args []string) {
+		name := exp.GenerateClusterName()
+		if exOutput == "" {
+			exOutput = name + ".tar.gz"
+		}
+		if err := exp.Export(exOutput); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return exportCmd
+}
+
+// ImportCommand import cluster command.
+func ImportCommand() *cobra.Command {
+	importCmd.Run = func(cmd *cobra.Command, args []string) {
+		providerName, err := cluster.GetArchiveProvider(args[0])
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		p, err := providers.GetProvider(providerName)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if err = p.Import(args[0], imForce); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return importCmd
+}
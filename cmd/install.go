@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installCmd = &cobra.Command{
+		Use:   "install",
+		Short: "Install K3s to the instances of a cluster created with --skip-install",
+	}
+	inProvider = ""
+	inp        providers.Provider
+)
+
+func init() {
+	installCmd.Flags().StringVarP(&inProvider, "provider", "p", inProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// InstallCommand install command.
+func InstallCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			inp = reg
+		}
+
+		installCmd.Flags().AddFlagSet(utils.ConvertFlags(installCmd, inp.GetCredentialFlags()))
+		installCmd.Flags().AddFlagSet(utils.ConvertFlags(installCmd, inp.GetSSHFlags()))
+		installCmd.Use = fmt.Sprintf("install -p %s", pStr)
+	}
+
+	installCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if inProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := inp.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), inp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	installCmd.Run = func(cmd *cobra.Command, args []string) {
+		inp.GenerateClusterName()
+		if err := inp.InstallK3sCluster(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return installCmd
+}
@@ -63,11 +63,11 @@ func JoinCommand() *cobra.Command {
 		// generate cluster name. i.e. input: "--name k3s1 --region cn-hangzhou" output: "k3s1.cn-hangzhou".
 		jp.GenerateClusterName()
 		if err := jp.JoinCheck(); err != nil {
-			logrus.Fatalln(err)
+			common.ExitWithError(err)
 		}
 		// join k3s node to the cluster which named with generated cluster name.
 		if err := jp.JoinK3sNode(); err != nil {
-			logrus.Fatalln(err)
+			common.ExitWithError(err)
 		}
 	}
 
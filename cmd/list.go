@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/sirupsen/logrus"
@@ -24,6 +25,7 @@ var (
 
 func init() {
 	listCmd.Flags().BoolVarP(&jsonOut, "json", "j", jsonOut, "json output")
+	listCmd.Flags().DurationVar(&common.DescribeTimeout, "describe-timeout", common.DescribeTimeout, "The time to wait for the api-server of a starting cluster to be ready, e.g. 1m, the unreachable cluster is reported at once")
 }
 
 // ListCommand returns clusters as list.
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsCmd = &cobra.Command{
+		Use:   "logs",
+		Short: "Print the operation log of a K3s cluster",
+	}
+	lProvider = ""
+	lFollow   = false
+	lSince    time.Duration
+	lp        providers.Provider
+)
+
+func init() {
+	logsCmd.Flags().StringVarP(&lProvider, "provider", "p", lProvider, "Provider is a module which provides an interface for managing cloud resources")
+	logsCmd.Flags().BoolVarP(&lFollow, "follow", "f", lFollow, "Specify if the log should be streamed")
+	logsCmd.Flags().DurationVar(&lSince, "since", lSince, "Only print the lines logged within the duration, e.g. 1h, the rotated logs are included")
+}
+
+// LogsCommand logs command.
+func LogsCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			lp = reg
+		}
+
+		logsCmd.Flags().AddFlagSet(utils.ConvertFlags(logsCmd, lp.GetSSHFlags()))
+		logsCmd.Use = fmt.Sprintf("logs -p %s", pStr)
+	}
+
+	logsCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if lProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	logsCmd.Run = func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		r, err := lp.GetLogs(name, lFollow, lSince)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		defer r.Close()
+		if _, err = io.Copy(os.Stdout, r); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return logsCmd
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reconcileCmd = &cobra.Command{
+		Use:   "reconcile",
+		Short: "Converge a K3s cluster to the desired number of masters and workers",
+		Long: "Join new instances or drain and terminate the newest instances, so that the cluster has the number of masters and workers set by --master and --worker.\n" +
+			"The number which isn't set is kept, it's safe to re-run as nothing is changed once the cluster matches.",
+	}
+	rcProvider = ""
+	rcYes      = false
+	rcp        providers.Provider
+)
+
+func init() {
+	reconcileCmd.Flags().StringVarP(&rcProvider, "provider", "p", rcProvider, "Provider is a module which provides an interface for managing cloud resources")
+	reconcileCmd.Flags().BoolVarP(&rcYes, "yes", "y", rcYes, "Terminate the removed instances without confirmation")
+}
+
+// ReconcileCommand reconcile command.
+func ReconcileCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			rcp = reg
+		}
+
+		reconcileCmd.Flags().AddFlagSet(utils.ConvertFlags(reconcileCmd, rcp.GetCredentialFlags()))
+		reconcileCmd.Flags().AddFlagSet(utils.ConvertFlags(reconcileCmd, rcp.GetJoinFlags()))
+		reconcileCmd.Use = fmt.Sprintf("reconcile -p %s", pStr)
+	}
+
+	reconcileCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if rcProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		// the number which isn't set keeps the current one.
+		for _, name := range []string{"master", "worker"} {
+			if f := cmd.Flags().Lookup(name); f != nil && !f.Changed {
+				_ = f.Value.Set("")
+			}
+		}
+		err := rcp.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), rcp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	reconcileCmd.Run = func(cmd *cobra.Command, args []string) {
+		rcp.GenerateClusterName()
+		if err := rcp.Reconcile(rcYes); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return reconcileCmd
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	refreshCmd = &cobra.Command{
+		Use:   "refresh",
+		Short: "Re-sync the saved state of a K3s cluster with the instances in cloud",
+	}
+	rProvider = ""
+	rp        providers.Provider
+)
+
+func init() {
+	refreshCmd.Flags().StringVarP(&rProvider, "provider", "p", rProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// RefreshCommand refresh command.
+func RefreshCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			rp = reg
+		}
+
+		refreshCmd.Flags().AddFlagSet(utils.ConvertFlags(refreshCmd, rp.GetCredentialFlags()))
+		refreshCmd.Flags().AddFlagSet(utils.ConvertFlags(refreshCmd, rp.GetSSHFlags()))
+		refreshCmd.Use = fmt.Sprintf("refresh -p %s", pStr)
+	}
+
+	refreshCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if rProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := rp.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), rp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	refreshCmd.Run = func(cmd *cobra.Command, args []string) {
+		rp.GenerateClusterName()
+		if err := rp.RefreshState(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return refreshCmd
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	registryCmd = &cobra.Command{
+		Use:   "update-registry",
+		Short: "Update the registry file of a K3s cluster on all nodes",
+		Long: "Update the registries.yaml of a K3s cluster on all nodes, e.g. to rotate the expired auth of private registries.\n" +
+			"K3s is restarted on the nodes one by one, the next node is only restarted after the current one is Ready again.",
+	}
+	urProvider = ""
+	urRegistry = ""
+	urp        providers.Provider
+)
+
+func init() {
+	registryCmd.Flags().StringVarP(&urProvider, "provider", "p", urProvider, "Provider is a module which provides an interface for managing cloud resources")
+	registryCmd.Flags().StringVar(&urRegistry, "registry", urRegistry, "K3s registry file with the new auth, see: https://docs.k3s.io/installation/private-registry")
+}
+
+// UpdateRegistryCommand update registry command.
+func UpdateRegistryCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			urp = reg
+		}
+
+		registryCmd.Flags().AddFlagSet(utils.ConvertFlags(registryCmd, urp.GetSSHFlags()))
+		registryCmd.Use = fmt.Sprintf("update-registry -p %s", pStr)
+	}
+
+	registryCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if urProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		if urRegistry == "" {
+			logrus.Fatalln("required flag(s) \"[registry]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := urp.MergeClusterOptions(); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	registryCmd.Run = func(cmd *cobra.Command, args []string) {
+		urp.GenerateClusterName()
+		if err := urp.UpdateRegistry(urRegistry); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return registryCmd
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replaceCmd = &cobra.Command{
+		Use:   "replace-node",
+		Short: "Replace a failed node of a K3s cluster with a new instance",
+		Long: "Delete the failed node from a K3s cluster, terminate its instance, and join a new instance of the same role to the cluster.\n" +
+			"The etcd member of a master is removed with its node, replacing a master requires the other masters to be healthy.",
+	}
+	rpProvider   = ""
+	rpInstanceID = ""
+	rpForce      = false
+	rpp          providers.Provider
+)
+
+func init() {
+	replaceCmd.Flags().StringVarP(&rpProvider, "provider", "p", rpProvider, "Provider is a module which provides an interface for managing cloud resources")
+	replaceCmd.Flags().StringVar(&rpInstanceID, "instance-id", rpInstanceID, "The id of the failed instance to be replaced")
+	replaceCmd.Flags().BoolVarP(&rpForce, "force", "f", rpForce, "Replace the instance without confirmation")
+}
+
+// ReplaceCommand replace node command.
+func ReplaceCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			rpp = reg
+		}
+
+		replaceCmd.Flags().AddFlagSet(utils.ConvertFlags(replaceCmd, rpp.GetCredentialFlags()))
+		replaceCmd.Flags().AddFlagSet(utils.ConvertFlags(replaceCmd, rpp.GetSSHFlags()))
+		replaceCmd.Use = fmt.Sprintf("replace-node -p %s", pStr)
+	}
+
+	replaceCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if rpProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		if rpInstanceID == "" {
+			logrus.Fatalln("required flag(s) \"[instance-id]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := rpp.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), rpp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	replaceCmd.Run = func(cmd *cobra.Command, args []string) {
+		rpp.GenerateClusterName()
+		if err := rpp.ReplaceNode(rpInstanceID, rpForce); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return replaceCmd
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetCmd = &cobra.Command{
+		Use:   "cluster-reset",
+		Short: "Reset the embedded etcd of a K3s cluster to a new cluster with a surviving master",
+		Long: "Reset the embedded etcd of a K3s cluster to a new single member cluster with a surviving master, and rejoin the other masters.\n" +
+			"It's a recovery tool for the cluster which lost its etcd quorum, the etcd data of the other masters is discarded.",
+	}
+	rsetProvider        = ""
+	rsetNode            = ""
+	rsetForceNewCluster = false
+	rsetp               providers.Provider
+)
+
+func init() {
+	resetCmd.Flags().StringVarP(&rsetProvider, "provider", "p", rsetProvider, "Provider is a module which provides an interface for managing cloud resources")
+	resetCmd.Flags().StringVar(&rsetNode, "node", rsetNode, "The instance id or ip of the surviving master running etcd, the first master running etcd is used if it's empty")
+	resetCmd.Flags().BoolVar(&rsetForceNewCluster, "force-new-cluster", rsetForceNewCluster, "Confirm to reset the etcd to a new cluster, which is destructive to the etcd quorum")
+}
+
+// ResetCommand cluster reset command.
+func ResetCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			rsetp = reg
+		}
+
+		resetCmd.Flags().AddFlagSet(utils.ConvertFlags(resetCmd, rsetp.GetSSHFlags()))
+		resetCmd.Use = fmt.Sprintf("cluster-reset -p %s", pStr)
+	}
+
+	resetCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if rsetProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		if !rsetForceNewCluster {
+			logrus.Fatalln("resetting control plane is destructive to the etcd quorum, " +
+				"please take a snapshot if it's possible and confirm it with `--force-new-cluster`")
+		}
+		common.BindEnvFlags(cmd)
+		if err := rsetp.MergeClusterOptions(); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	resetCmd.Run = func(cmd *cobra.Command, args []string) {
+		rsetp.GenerateClusterName()
+		if err := rsetp.ResetControlPlane(rsetNode); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return resetCmd
+}
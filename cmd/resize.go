@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resizeCmd = &cobra.Command{
+		Use:   "resize",
+		Short: "Change the instance type of a K3s cluster's node",
+	}
+	rsProvider     = ""
+	rsInstanceID   = ""
+	rsInstanceType = ""
+	rsForce        = false
+	rsp            providers.Provider
+)
+
+func init() {
+	resizeCmd.Flags().StringVarP(&rsProvider, "provider", "p", rsProvider, "Provider is a module which provides an interface for managing cloud resources")
+	resizeCmd.Flags().StringVar(&rsInstanceID, "instance-id", rsInstanceID, "The id of the instance to be resized")
+	resizeCmd.Flags().StringVar(&rsInstanceType, "instance-type", rsInstanceType, "The new instance type of the instance")
+	resizeCmd.Flags().BoolVarP(&rsForce, "force", "f", rsForce, "Resize the last master without confirmation")
+}
+
+// ResizeCommand resize command.
+func ResizeCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			rsp = reg
+		}
+
+		resizeCmd.Flags().AddFlagSet(utils.ConvertFlags(resizeCmd, rsp.GetCredentialFlags()))
+		resizeCmd.Flags().AddFlagSet(utils.ConvertFlags(resizeCmd, rsp.GetSSHFlags()))
+		resizeCmd.Use = fmt.Sprintf("resize -p %s", pStr)
+	}
+
+	resizeCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if rsProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		if rsInstanceID == "" || rsInstanceType == "" {
+			logrus.Fatalln("required flag(s) \"[instance-id]\" and \"[instance-type]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := rsp.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), rsp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	resizeCmd.Run = func(cmd *cobra.Command, args []string) {
+		rsp.GenerateClusterName()
+		if err := rsp.ResizeNode(rsInstanceID, rsInstanceType, rsForce); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return resizeCmd
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rotateCmd = &cobra.Command{
+		Use:   "rotate-kubeconfig",
+		Short: "Rotate the admin token of a K3s cluster and update the local kubeconfig",
+		Long: "Issue a new token of the autok3s-admin service account of a K3s cluster and revoke the old one, and update the local kubeconfig with the new token.\n" +
+			"K3s server isn't restarted, the api and the running workloads are not disrupted.",
+	}
+	rtProvider = ""
+	rtp        providers.Provider
+)
+
+func init() {
+	rotateCmd.Flags().StringVarP(&rtProvider, "provider", "p", rtProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// RotateCommand rotate kubeconfig command.
+func RotateCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			rtp = reg
+		}
+
+		rotateCmd.Flags().AddFlagSet(utils.ConvertFlags(rotateCmd, rtp.GetSSHFlags()))
+		rotateCmd.Use = fmt.Sprintf("rotate-kubeconfig -p %s", pStr)
+	}
+
+	rotateCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if rtProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := rtp.MergeClusterOptions(); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	rotateCmd.Run = func(cmd *cobra.Command, args []string) {
+		rtp.GenerateClusterName()
+		if err := rtp.RotateKubeconfig(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return rotateCmd
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scaleCmd = &cobra.Command{
+		Use:   "scale-workers",
+		Short: "Scale the workers of a K3s cluster while the masters are kept",
+		Long: "Drain and terminate the newest workers or join new ones, so that the cluster has the number of workers set by --count.\n" +
+			"The number of workers before scaling is recorded, use --count restore to scale them back, e.g. scale a dev cluster to zero workers and back.",
+	}
+	scProvider = ""
+	scCount    = ""
+	scYes      = false
+	scp        providers.Provider
+)
+
+func init() {
+	scaleCmd.Flags().StringVarP(&scProvider, "provider", "p", scProvider, "Provider is a module which provides an interface for managing cloud resources")
+	scaleCmd.Flags().StringVar(&scCount, "count", scCount, "The number of workers to scale to, or `restore` to scale back to the number before scaling")
+	scaleCmd.Flags().BoolVarP(&scYes, "yes", "y", scYes, "Terminate the removed instances without confirmation")
+}
+
+// ScaleCommand scale workers command.
+func ScaleCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			scp = reg
+		}
+
+		scaleCmd.Flags().AddFlagSet(utils.ConvertFlags(scaleCmd, scp.GetCredentialFlags()))
+		scaleCmd.Flags().AddFlagSet(utils.ConvertFlags(scaleCmd, scp.GetSSHFlags()))
+		scaleCmd.Use = fmt.Sprintf("scale-workers -p %s", pStr)
+	}
+
+	scaleCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if scProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		if scCount == "" {
+			logrus.Fatalln("required flag(s) \"[count]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := scp.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), scp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	scaleCmd.Run = func(cmd *cobra.Command, args []string) {
+		scp.GenerateClusterName()
+		if err := scp.ScaleWorkers(scCount, scYes); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return scaleCmd
+}
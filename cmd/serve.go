@@ -43,6 +43,10 @@ func ServeCommand() *cobra.Command {
 		go func(ctx context.Context) {
 			common.InitDashboard(ctx)
 		}(serveCmd.Context())
+		// start scheduler of upgrade window for K3s clusters
+		go func(ctx context.Context) {
+			common.InitUpgradeScheduler(ctx)
+		}(serveCmd.Context())
 
 		stopChan := make(chan struct{})
 		go func(c chan struct{}) {
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagCmd = &cobra.Command{
+		Use:   "tag",
+		Short: "Add tags to the cloud resources of a K3s cluster",
+	}
+	untagCmd = &cobra.Command{
+		Use:   "untag",
+		Short: "Remove tags from the cloud resources of a K3s cluster",
+	}
+	tProvider = ""
+	tTags     = make([]string, 0)
+	tKeys     = make([]string, 0)
+	tp        providers.Provider
+)
+
+func init() {
+	for _, c := range []*cobra.Command{tagCmd, untagCmd} {
+		c.Flags().StringVarP(&tProvider, "provider", "p", tProvider, "Provider is a module which provides an interface for managing cloud resources")
+	}
+	tagCmd.Flags().StringArrayVar(&tTags, "tag", tTags, "The tag to add in key=value format, the value is replaced if the key exists, e.g.(--tag cost-center=dev --tag team=infra)")
+	untagCmd.Flags().StringArrayVar(&tKeys, "key", tKeys, "The key of the tag to remove, e.g.(--key cost-center --key team)")
+}
+
+// TagCommand tag command.
+func TagCommand() *cobra.Command {
+	return tagCommand(tagCmd, func() error {
+		return tp.TagCluster(tTags)
+	})
+}
+
+// UntagCommand untag command.
+func UntagCommand() *cobra.Command {
+	return tagCommand(untagCmd, func() error {
+		return tp.UntagCluster(tKeys)
+	})
+}
+
+func tagCommand(cmd *cobra.Command, run func() error) *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+
+	if pStr != "" {
+		// tag and untag share the same provider, so that flags of both commands are bound to it.
+		if tp == nil {
+			reg, err := providers.GetProvider(pStr)
+			if err != nil {
+				logrus.Fatalln(err)
+			}
+			tp = reg
+		}
+
+		cmd.Flags().AddFlagSet(utils.ConvertFlags(cmd, tp.GetCredentialFlags()))
+		cmd.Flags().AddFlagSet(utils.ConvertFlags(cmd, tp.GetSSHFlags()))
+		cmd.Use = fmt.Sprintf("%s -p %s", cmd.Name(), pStr)
+	}
+
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if tProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := tp.MergeClusterOptions(); err != nil {
+			return err
+		}
+		if err := common.MakeSureCredentialFlag(cmd.Flags(), tp); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		tp.GenerateClusterName()
+		if err := run(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return cmd
+}
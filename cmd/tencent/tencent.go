@@ -0,0 +1,165 @@
+package tencent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/providers/tencent"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tencentCmd = &cobra.Command{
+		Use:   "tencent",
+		Short: "Tencent cloud specific operations.",
+	}
+	tkeClustersCmd = &cobra.Command{
+		Use:     "tke-clusters",
+		Short:   "List TKE managed clusters in the region, so that vpc/subnet settings can be consistent with them",
+		Example: `  autok3s tencent tke-clusters --region ap-guangzhou`,
+	}
+	pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the eips and key pairs leaked by the failed runs in the region",
+		Long: "List the eips allocated by autok3s but not associated, and the key pairs generated for the clusters " +
+			"which neither have state nor live instances. They're only removed with --confirm.",
+		Example: `  autok3s tencent prune --region ap-guangzhou --confirm`,
+	}
+	keyPairsCmd = &cobra.Command{
+		Use:     "key-pairs",
+		Short:   "List the CVM key pairs of the account, so that the id can be used by --keypair-id",
+		Example: `  autok3s tencent key-pairs --region ap-guangzhou`,
+	}
+	addonLogsCmd = &cobra.Command{
+		Use:     "addon-logs",
+		Short:   "Print the logs of the cloud-controller-manager and cbs csi driver pods of a cluster",
+		Example: `  autok3s tencent addon-logs --name myk3s --component ccm --tail 100`,
+	}
+	pruneConfirm   = false
+	addonLogsName  = ""
+	addonComponent = ""
+	addonLogsTail  = int64(0)
+)
+
+// Command returns tencent command.
+func Command() *cobra.Command {
+	reg, err := providers.GetProvider("tencent")
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+	p := reg.(*tencent.Tencent)
+	tkeClustersCmd.Flags().AddFlagSet(utils.ConvertFlags(tkeClustersCmd, p.GetCredentialFlags()))
+	tkeClustersCmd.Flags().StringVar(&p.Region, "region", p.Region, "CVM region")
+	_ = tkeClustersCmd.Flags().SetAnnotation("region", utils.BashCompEnvVarFlag, []string{"CVM_REGION"})
+
+	tkeClustersCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		common.BindEnvFlags(cmd)
+		if err := common.MakeSureCredentialFlag(cmd.Flags(), p); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+	tkeClustersCmd.Run = func(cmd *cobra.Command, args []string) {
+		clusters, err := p.ListTKEClusters()
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator("")
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"ID", "Name", "Version", "Status", "VPC", "ClusterCIDR", "Nodes"})
+		for _, c := range clusters {
+			table.Append([]string{c.ID, c.Name, c.Version, c.Status, c.VpcID, c.ClusterCidr, strconv.FormatUint(c.NodeNum, 10)})
+		}
+		table.Render()
+		if len(clusters) == 0 {
+			fmt.Printf("no TKE cluster found in region %s\n", p.Region)
+		}
+	}
+
+	pruneCmd.Flags().AddFlagSet(utils.ConvertFlags(pruneCmd, p.GetCredentialFlags()))
+	pruneCmd.Flags().StringVar(&p.Region, "region", p.Region, "CVM region")
+	_ = pruneCmd.Flags().SetAnnotation("region", utils.BashCompEnvVarFlag, []string{"CVM_REGION"})
+	pruneCmd.Flags().BoolVar(&pruneConfirm, "confirm", pruneConfirm, "Remove the listed resources, they're only listed without it")
+
+	pruneCmd.PreRunE = tkeClustersCmd.PreRunE
+	pruneCmd.Run = func(cmd *cobra.Command, args []string) {
+		resources, err := p.Prune(pruneConfirm)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if len(resources) == 0 {
+			fmt.Printf("no leaked resource found in region %s\n", p.Region)
+			return
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator("")
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"Type", "ID", "Description"})
+		for _, r := range resources {
+			table.Append([]string{r.Type, r.ID, r.Description})
+		}
+		table.Render()
+		if pruneConfirm {
+			fmt.Printf("%d resource(s) are removed\n", len(resources))
+		} else {
+			fmt.Printf("%d resource(s) will be removed, run again with --confirm to remove them\n", len(resources))
+		}
+	}
+
+	keyPairsCmd.Flags().AddFlagSet(utils.ConvertFlags(keyPairsCmd, p.GetCredentialFlags()))
+	keyPairsCmd.Flags().StringVar(&p.Region, "region", p.Region, "CVM region")
+	_ = keyPairsCmd.Flags().SetAnnotation("region", utils.BashCompEnvVarFlag, []string{"CVM_REGION"})
+
+	keyPairsCmd.PreRunE = tkeClustersCmd.PreRunE
+	keyPairsCmd.Run = func(cmd *cobra.Command, args []string) {
+		keyPairs, err := p.ListKeyPairs()
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator("")
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"ID", "Name", "Description", "Instances", "Created"})
+		for _, k := range keyPairs {
+			table.Append([]string{k.ID, k.Name, k.Description, strconv.Itoa(k.Instances), k.CreatedTime})
+		}
+		table.Render()
+		if len(keyPairs) == 0 {
+			fmt.Println("no key pair found in the account")
+		}
+	}
+
+	addonLogsCmd.Flags().StringVarP(&addonLogsName, "name", "n", addonLogsName, "Name of the cluster")
+	addonLogsCmd.Flags().StringVar(&addonComponent, "component", addonComponent, "Only print the logs of the add-on, ccm or csi, all the enabled add-ons are printed if it's empty")
+	addonLogsCmd.Flags().Int64Var(&addonLogsTail, "tail", addonLogsTail, "Only print the last lines of the logs of each container, all lines are printed if it's 0")
+
+	addonLogsCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if addonLogsName == "" {
+			logrus.Fatalln("`-n` or `--name` must set to specify a cluster, i.e. autok3s tencent addon-logs -n <cluster-name>")
+		}
+		return nil
+	}
+	addonLogsCmd.Run = func(cmd *cobra.Command, args []string) {
+		if err := p.AddonLogs(addonLogsName, addonComponent, addonLogsTail, os.Stdout); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	tencentCmd.AddCommand(tkeClustersCmd, pruneCmd, keyPairsCmd, addonLogsCmd)
+	return tencentCmd
+}
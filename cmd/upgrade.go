@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -19,6 +21,10 @@ var (
 	installScript = ""
 	uPackageName  = ""
 	uPackagePath  = ""
+	uWindow       = ""
+	uK3sSHA256    = ""
+	uCCMVersion   = ""
+	uCSIVersion   = ""
 )
 
 func init() {
@@ -29,6 +35,10 @@ func init() {
 	upgradeCmd.Flags().StringVarP(&installScript, "k3s-install-script", "", installScript, "Change the default upstream k3s install script address, see: https://docs.k3s.io/installation/configuration#options-for-installation-with-script")
 	upgradeCmd.Flags().StringVarP(&uPackageName, "package-name", "", uPackageName, "Airgap package name which you want to upgrade k3s with")
 	upgradeCmd.Flags().StringVarP(&uPackagePath, "package-path", "", uPackagePath, "Airgap package path which you want to upgrade k3s with")
+	upgradeCmd.Flags().StringVarP(&uK3sSHA256, "k3s-sha256", "", uK3sSHA256, "Expected sha256 of the new k3s binary, it's verified on each node before restarting K3s")
+	upgradeCmd.Flags().StringVarP(&uCCMVersion, "ccm-version", "", uCCMVersion, "Only upgrade the cloud-controller-manager of the cluster to the version, the credentials and configs are kept")
+	upgradeCmd.Flags().StringVarP(&uCSIVersion, "csi-version", "", uCSIVersion, "Only upgrade the csi driver of the cluster to the version, the credentials and configs are kept")
+	upgradeCmd.Flags().StringVarP(&uWindow, "upgrade-window", "", uWindow, "Only set the window of cron spec to upgrade k3s to the latest patch of the channel by `autok3s serve`, set to empty to disable it")
 }
 
 // UpgradeCommand help upgrade a K3s cluster to specified version
@@ -46,6 +56,14 @@ func UpgradeCommand() *cobra.Command {
 		return nil
 	}
 	upgradeCmd.Run = func(cmd *cobra.Command, args []string) {
+		if cmd.Flags().Changed("upgrade-window") {
+			setUpgradeWindow()
+			return
+		}
+		if uCCMVersion != "" || uCSIVersion != "" {
+			upgradeAddons()
+			return
+		}
 		upgradeCluster()
 	}
 	return upgradeCmd
@@ -56,8 +74,42 @@ func upgradeCluster() {
 	if err != nil {
 		logrus.Fatalf("failed to get provider %v: %v", uProvider, err)
 	}
-	err = up.UpgradeK3sCluster(clusterName, installScript, channel, version, uPackageName, uPackagePath)
+	err = up.UpgradeK3sCluster(clusterName, installScript, channel, version, uPackageName, uPackagePath, uK3sSHA256)
 	if err != nil {
 		logrus.Fatalf("[%s] failed to upgrade cluster %s, got error: %v", uProvider, clusterName, err)
 	}
 }
+
+func upgradeAddons() {
+	up, err := providers.GetProvider(uProvider)
+	if err != nil {
+		logrus.Fatalf("failed to get provider %v: %v", uProvider, err)
+	}
+	if err = up.UpgradeAddons(clusterName, uCCMVersion, uCSIVersion); err != nil {
+		logrus.Fatalf("[%s] failed to upgrade add-ons of cluster %s, got error: %v", uProvider, clusterName, err)
+	}
+}
+
+func setUpgradeWindow() {
+	if uWindow != "" {
+		if _, err := utils.ParseCronSchedule(uWindow); err != nil {
+			logrus.Fatalf("invalid `--upgrade-window` %q: %v", uWindow, err)
+		}
+	}
+	state, err := common.DefaultDB.GetCluster(clusterName, uProvider)
+	if err != nil {
+		logrus.Fatalf("[%s] failed to get cluster %s: %v", uProvider, clusterName, err)
+	}
+	if state == nil {
+		logrus.Fatalf("[%s] cluster %s is not exist", uProvider, clusterName)
+	}
+	state.UpgradeWindow = uWindow
+	if err = common.DefaultDB.SaveClusterState(state); err != nil {
+		logrus.Fatalf("[%s] failed to save upgrade window of cluster %s: %v", uProvider, clusterName, err)
+	}
+	if uWindow == "" {
+		logrus.Infof("[%s] disabled scheduled upgrade of cluster %s", uProvider, clusterName)
+		return
+	}
+	logrus.Infof("[%s] cluster %s will be upgraded in window %q by `autok3s serve`", uProvider, clusterName, uWindow)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the flags and spec file of creating a K3s cluster offline",
+		Long:  "Validate the flags and spec file of creating a K3s cluster without credentials, calling the provider api or creating anything, e.g. to lint a cluster spec in CI",
+	}
+
+	vProvider = ""
+	vFile     = ""
+	vp        providers.Provider
+)
+
+func init() {
+	validateCmd.Flags().StringVarP(&vProvider, "provider", "p", vProvider, "Provider is a module which provides an interface for managing cloud resources")
+	validateCmd.Flags().StringVarP(&vFile, "config-file", "f", vFile, "Cluster spec file in YAML format, the values can be overridden by flags")
+}
+
+// ValidateCommand validate command.
+func ValidateCommand() *cobra.Command {
+	// load dynamic provider flags.
+	pStr := common.FlagHackLookup("--provider")
+	var spec []byte
+	if fStr := common.FlagHackLookupP("--config-file", "-f"); fStr != "" {
+		name, b, err := common.ReadClusterSpec(fStr)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if pStr == "" && name != "" {
+			pStr = name
+			vProvider = name
+		}
+		spec = b
+	}
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			vp = reg
+		}
+
+		validateCmd.Flags().AddFlagSet(utils.ConvertFlags(validateCmd, vp.GetOptionFlags()))
+		validateCmd.Flags().AddFlagSet(utils.ConvertFlags(validateCmd, vp.GetCreateFlags()))
+		if spec != nil {
+			if err := common.ApplyClusterSpec(spec, vp); err != nil {
+				logrus.Fatalln(err)
+			}
+		}
+		validateCmd.Use = fmt.Sprintf("validate -p %s", pStr)
+	}
+
+	validateCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if vProvider == "" {
+			logrus.Fatalln("required flag(s) \"--provider\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	validateCmd.Run = func(cmd *cobra.Command, args []string) {
+		name := vp.GenerateClusterName()
+		if err := vp.Validate(); err != nil {
+			logrus.Fatalln(err)
+		}
+		logrus.Infof("[%s] cluster %s is valid", vProvider, name)
+	}
+
+	return validateCmd
+}
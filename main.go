@@ -11,6 +11,7 @@ import (
 	"github.com/cnrancher/autok3s/cmd/addon"
 	"github.com/cnrancher/autok3s/cmd/airgap"
 	"github.com/cnrancher/autok3s/cmd/sshkey"
+	"github.com/cnrancher/autok3s/cmd/tencent"
 	"github.com/cnrancher/autok3s/pkg/cli/kubectl"
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/metrics"
@@ -41,11 +42,13 @@ func main() {
 	}
 	os.Args[0] = args
 
+	common.AutoK3sVersion = gitVersion
 	rootCmd := cmd.Command()
 	rootCmd.AddCommand(cmd.CompletionCommand(), cmd.VersionCommand(gitVersion, gitCommit, gitTreeState, buildDate),
 		cmd.ListCommand(), cmd.CreateCommand(), cmd.JoinCommand(), cmd.KubectlCommand(), cmd.DeleteCommand(),
 		cmd.SSHCommand(), cmd.DescribeCommand(), cmd.ServeCommand(), cmd.ExplorerCommand(), cmd.UpgradeCommand(),
-		cmd.TelemetryCommand(), airgap.Command(), sshkey.Command(), cmd.DashboardCommand(), addon.Command())
+		cmd.TelemetryCommand(), cmd.ApplyAddonsCommand(), cmd.RefreshCommand(), cmd.ResizeCommand(), cmd.ResetCommand(), cmd.LogsCommand(), cmd.InstallCommand(), cmd.ValidateCommand(), cmd.RotateCommand(), cmd.UpdateRegistryCommand(), cmd.ReplaceCommand(), cmd.ReconcileCommand(), cmd.ScaleCommand(), cmd.BatchDeleteCommand(), cmd.ExecCommand(), cmd.ExportCommand(), cmd.ImportCommand(),
+		cmd.CordonCommand(), cmd.UncordonCommand(), cmd.TagCommand(), cmd.UntagCommand(), airgap.Command(), sshkey.Command(), cmd.DashboardCommand(), addon.Command(), tencent.Command())
 
 	rootCmd.PersistentPreRun = func(c *cobra.Command, args []string) {
 		common.InitLogger(logrus.StandardLogger())
@@ -59,7 +59,7 @@ var (
 )
 
 func ScpFiles(logger *logrus.Logger, clusterName string, pkg *common.Package, dialer *dialer.SSHDialer, extraArgs string) (er error) {
-	dataPath := getDataPath(extraArgs)
+	dataPath := GetDataPath(extraArgs)
 	conn := dialer.GetClient()
 	fieldLogger := logger.WithFields(logrus.Fields{
 		"cluster":   clusterName,
@@ -255,7 +255,8 @@ func getRemoteTmpDir(clustername string) string {
 	return filepath.Join(remoteTmpDir, clustername)
 }
 
-func getDataPath(extraArgs string) string {
+// GetDataPath returns the data dir of K3s set by --data-dir or -d in the extra args, or the default one.
+func GetDataPath(extraArgs string) string {
 	dataPath := defaultDataDirPath
 	args := strings.Split(extraArgs, " ")
 	for i, arg := range args {
@@ -21,7 +21,7 @@ func TestGetDataPath(t *testing.T) {
 		{name: "data dir args with short name and equal sign", args: "-d=/data", expectPath: "/data"},
 		{name: "wrong data dir args", args: "--data-dir", expectPath: defaultDataDirPath},
 	} {
-		path := getDataPath(c.args)
+		path := GetDataPath(c.args)
 		assert.Equalf(t, c.expectPath, path, "test: %s failed", c.name)
 	}
 }
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// SetClusterAddress sets the address of the api-server in front of the masters, e.g. a floating ip, it's used by
+// the local kubeconfig and the joining nodes. The address must be in the tls sans of the masters.
+func (p *ProviderBase) SetClusterAddress(address string) error {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) == 0 {
+		return fmt.Errorf("[%s] cluster %s has no master", p.Provider, p.Name)
+	}
+
+	logFile, err := common.GetLogFile(c.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+
+	cfg, err := p.getKubeconfig(&c, &c.MasterNodes[0])
+	if err != nil {
+		return err
+	}
+	if err = SaveCfg(cfg, address, c.ContextName); err != nil {
+		return err
+	}
+	c.IP = address
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.IP = address
+	p.Logger.Infof("[%s] the address of cluster %s is set to %s", p.Provider, p.Name, address)
+	return nil
+}
+
+// getKubeconfigAddress returns the server address of kubeconfig, the ip set by user, e.g. a load balancer, takes precedence,
+// otherwise it's the public ip of the first master.
+func getKubeconfigAddress(c *types.Cluster) string {
+	if c.IP != "" && !isMasterAddress(c.MasterNodes, c.IP) {
+		return c.IP
+	}
+	if addr := getFirstAddress(c.MasterNodes[0].PublicIPAddress); addr != "" {
+		return addr
+	}
+	return getFirstAddress(c.MasterNodes[0].InternalIPAddress)
+}
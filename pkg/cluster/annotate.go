@@ -0,0 +1,110 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// InstanceIDAnnotation is the annotation of node for the id of its instance.
+	InstanceIDAnnotation = "autok3s.io/instance-id"
+	// the provider id set by the embedded cloud controller of K3s is the node name instead of the instance id.
+	k3sProviderIDPrefix = "k3s://"
+)
+
+// annotateInstanceNodes stamps the instance id on the node of each instance of the cluster, the nodes are matched by
+// internal ip. The nodes with provider id of the cloud controller manager are skipped, as the instance id is set in it.
+// It's best effort, the failure is only logged.
+func (p *ProviderBase) annotateInstanceNodes(c *types.Cluster) {
+	client, err := GetClusterConfig(c.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to annotate instance id of nodes, can't load kubeconfig of cluster %s: %v", p.Provider, c.ContextName, err)
+		return
+	}
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to annotate instance id of nodes, can't list nodes of cluster %s: %v", p.Provider, c.ContextName, err)
+		return
+	}
+	instanceIDs := getInstanceIDsByAddress(c)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if hasCloudProviderID(node) {
+			continue
+		}
+		id := getInstanceIDByNodeAddress(node, instanceIDs)
+		if id == "" || node.Annotations[InstanceIDAnnotation] == id {
+			continue
+		}
+		patch, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{InstanceIDAnnotation: id},
+			},
+		})
+		if _, err = client.CoreV1().Nodes().Patch(context.TODO(), node.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			p.Logger.Warnf("[%s] failed to annotate instance id %s of node %s: %v", p.Provider, id, node.Name, err)
+			continue
+		}
+		p.Logger.Debugf("[%s] node %s is annotated with instance id %s", p.Provider, node.Name, id)
+	}
+}
+
+// getInstanceIDsByAddress returns the instance ids of the cluster by their internal ips.
+func getInstanceIDsByAddress(c *types.Cluster) map[string]string {
+	instanceIDs := map[string]string{}
+	for _, nodes := range [][]types.Node{c.MasterNodes, c.WorkerNodes} {
+		for _, n := range nodes {
+			for _, ip := range n.InternalIPAddress {
+				instanceIDs[ip] = n.InstanceID
+			}
+		}
+	}
+	return instanceIDs
+}
+
+func getInstanceIDByNodeAddress(node *v1.Node, instanceIDs map[string]string) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP && instanceIDs[addr.Address] != "" {
+			return instanceIDs[addr.Address]
+		}
+	}
+	return ""
+}
+
+// GetNodeInstanceID returns the instance id of the node by the annotation, or by the provider id set by
+// the cloud controller manager, e.g. `qcloud:///800002/ins-xxx`. It's empty if the node isn't matched yet.
+func GetNodeInstanceID(node *v1.Node) string {
+	if id := node.Annotations[InstanceIDAnnotation]; id != "" {
+		return id
+	}
+	if hasCloudProviderID(node) {
+		return node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+	}
+	return ""
+}
+
+func hasCloudProviderID(node *v1.Node) bool {
+	return node.Spec.ProviderID != "" && !strings.HasPrefix(node.Spec.ProviderID, k3sProviderIDPrefix)
+}
+
+// isNodeOfInstance matches the node by the instance id, the internal ips are only used if the node has no instance id,
+// so that the node of a terminated instance isn't matched by the reused ip.
+func isNodeOfInstance(node *v1.Node, instance *types.Node) bool {
+	if id := GetNodeInstanceID(node); id != "" {
+		return id == instance.InstanceID
+	}
+	ips := map[string]bool{}
+	for _, ip := range instance.InternalIPAddress {
+		ips[ip] = true
+	}
+	return isInstanceNode(node, ips)
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNodeInstanceID(t *testing.T) {
+	node := &v1.Node{}
+	node.Spec.ProviderID = "k3s://master-1"
+	assert.Equal(t, "", GetNodeInstanceID(node))
+
+	node.Annotations = map[string]string{InstanceIDAnnotation: "ins-1"}
+	assert.Equal(t, "ins-1", GetNodeInstanceID(node))
+
+	node = &v1.Node{Spec: v1.NodeSpec{ProviderID: "qcloud:///800002/ins-2"}}
+	assert.Equal(t, "ins-2", GetNodeInstanceID(node))
+}
+
+func TestIsNodeOfInstance(t *testing.T) {
+	c := &types.Cluster{}
+	c.MasterNodes = []types.Node{{InstanceID: "ins-1", InternalIPAddress: []string{"10.0.0.2"}}}
+	c.WorkerNodes = []types.Node{{InstanceID: "ins-2", InternalIPAddress: []string{"10.0.0.3"}}}
+	instanceIDs := getInstanceIDsByAddress(c)
+
+	node := &v1.Node{Status: v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.3"}}}}
+	assert.Equal(t, "ins-2", getInstanceIDByNodeAddress(node, instanceIDs))
+	assert.True(t, isNodeOfInstance(node, &c.WorkerNodes[0]))
+	assert.False(t, isNodeOfInstance(node, &c.MasterNodes[0]))
+
+	// the annotated node isn't matched by the reused ip.
+	node.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{InstanceIDAnnotation: "ins-0"}}
+	assert.False(t, isNodeOfInstance(node, &c.WorkerNodes[0]))
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	auditPolicyPath = "/etc/rancher/k3s/audit-policy.yaml"
+	auditLogDir     = "/var/lib/rancher/k3s/server/logs"
+)
+
+// handleAuditPolicy uploads the audit policy to the master and creates the directory of the audit log.
+func (p *ProviderBase) handleAuditPolicy(n *types.Node, c *types.Cluster) error {
+	policy, err := getAuditPolicy(c)
+	if err != nil {
+		return err
+	}
+	_, err = p.execute(n,
+		fmt.Sprintf("mkdir -p %s %s", filepath.Dir(auditPolicyPath), auditLogDir),
+		fmt.Sprintf("echo \"%s\" | base64 -d | tee \"%s\"", base64.StdEncoding.EncodeToString(policy), auditPolicyPath))
+	return err
+}
+
+// getAuditPolicy returns the audit policy of the cluster, the content takes precedence over the file.
+func getAuditPolicy(c *types.Cluster) ([]byte, error) {
+	if c.AuditPolicyFileContent != "" {
+		return []byte(c.AuditPolicyFileContent), nil
+	}
+	return os.ReadFile(c.AuditPolicyFile)
+}
+
+// getAuditArgs returns the api-server args of audit logging, the log path set by the extra args takes precedence.
+func getAuditArgs(cluster *types.Cluster, extraArgs []string) []string {
+	if cluster.AuditPolicyFile == "" && cluster.AuditPolicyFileContent == "" {
+		return nil
+	}
+	args := []string{"--kube-apiserver-arg=audit-policy-file=" + auditPolicyPath}
+	if !strings.Contains(strings.Join(extraArgs, " "), "--kube-apiserver-arg=audit-log-path=") {
+		args = append(args, fmt.Sprintf("--kube-apiserver-arg=audit-log-path=%s/audit.log", auditLogDir))
+	}
+	return args
+}
+
+// validateAuditPolicy checks the policy is a valid YAML of the audit Policy kind.
+func validateAuditPolicy(policy []byte) error {
+	meta := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}{}
+	if err := yaml.Unmarshal(policy, &meta); err != nil {
+		return fmt.Errorf("invalid YAML: %v", err)
+	}
+	if meta.Kind != "Policy" || !strings.HasPrefix(meta.APIVersion, "audit.k8s.io/") {
+		return fmt.Errorf("must be a Policy of audit.k8s.io, got %s %s", meta.APIVersion, meta.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAuditPolicy(t *testing.T) {
+	assert.NoError(t, validateAuditPolicy([]byte("apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: Metadata\n")))
+	assert.Error(t, validateAuditPolicy([]byte("apiVersion: v1\nkind: ConfigMap\n")))
+	assert.Error(t, validateAuditPolicy([]byte("kind: [Policy\n")))
+}
+
+func TestGetAuditArgs(t *testing.T) {
+	c := &types.Cluster{}
+	assert.Nil(t, getAuditArgs(c, nil))
+
+	c.AuditPolicyFileContent = "apiVersion: audit.k8s.io/v1\nkind: Policy\n"
+	assert.Equal(t, []string{
+		"--kube-apiserver-arg=audit-policy-file=/etc/rancher/k3s/audit-policy.yaml",
+		"--kube-apiserver-arg=audit-log-path=/var/lib/rancher/k3s/server/logs/audit.log",
+	}, getAuditArgs(c, nil))
+	assert.Equal(t, []string{
+		"--kube-apiserver-arg=audit-policy-file=/etc/rancher/k3s/audit-policy.yaml",
+	}, getAuditArgs(c, []string{" --kube-apiserver-arg=audit-log-path=/var/log/audit.log"}))
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -25,6 +26,7 @@ import (
 	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/syncmap"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -52,6 +54,8 @@ type ProviderBase struct {
 	ErrM           map[string]string
 	Logger         *logrus.Logger
 	Callbacks      map[string]*providerProcess
+	// SkipInstall only provisions the infrastructure of the cluster when creating, K3s is installed by InstallK3s later.
+	SkipInstall bool `json:"-"`
 }
 
 type providerProcess struct {
@@ -74,6 +78,10 @@ func NewBaseProvider() *ProviderBase {
 			ClusterCidr:   defaultCidr,
 			DockerScript:  dockerInstallScript,
 			Rollback:      true,
+			MinCPU:        defaultMinCPU,
+			MinMemory:     defaultMinMemory,
+			MinDisk:       defaultMinDisk,
+			JoinRetries:   defaultJoinRetries,
 		},
 		Status: types.Status{
 			MasterNodes: make([]types.Node, 0),
@@ -96,6 +104,90 @@ func (p *ProviderBase) GetCreateOptions() []types.Flag {
 			V:     p.Cluster,
 			Usage: "Form k3s cluster using embedded etcd (requires K8s >= 1.19), see: https://docs.k3s.io/installation/ha-embedded",
 		},
+		{
+			Name:  "cluster-domain",
+			P:     &p.ClusterDomain,
+			V:     p.ClusterDomain,
+			Usage: "K3s cluster domain (default \"cluster.local\"), it can only be set when creating the cluster, see: https://docs.k3s.io/reference/server-config#networking",
+		},
+		{
+			Name:  "advertise-address",
+			P:     &p.AdvertiseAddress,
+			V:     p.AdvertiseAddress,
+			Usage: "IP or CIDR of the address which K3s api-server advertises, the node's address within the CIDR is selected for instances with multiple NICs (default to the primary private ip)",
+		},
+		{
+			Name:  "node-ip",
+			P:     &p.NodeIP,
+			V:     p.NodeIP,
+			Usage: "IP or CIDR of the address which K3s node registers with, the node's address within the CIDR is selected for instances with multiple NICs",
+		},
+		{
+			Name:  "skip-install",
+			P:     &p.SkipInstall,
+			V:     p.SkipInstall,
+			Usage: "Only provision the infrastructure without installing K3s, K3s can be installed later by `autok3s install`",
+		},
+		{
+			Name:  "etcd-snapshot-schedule-cron",
+			P:     &p.EtcdSnapshotScheduleCron,
+			V:     p.EtcdSnapshotScheduleCron,
+			Usage: "Snapshot interval time in cron spec of embedded etcd, e.g. every 6 hours '0 */6 * * *', see: https://docs.k3s.io/datastore/backup-restore",
+		},
+		{
+			Name:  "etcd-snapshot-retention",
+			P:     &p.EtcdSnapshotRetention,
+			V:     p.EtcdSnapshotRetention,
+			Usage: "Number of snapshots of embedded etcd to retain, K3s retains 5 snapshots by default",
+		},
+		{
+			Name:  "etcd-snapshot-dir",
+			P:     &p.EtcdSnapshotDir,
+			V:     p.EtcdSnapshotDir,
+			Usage: "Directory on the masters to save snapshots of embedded etcd (default \"${data-dir}/db/snapshots\")",
+		},
+		{
+			Name:  "cni",
+			P:     &p.CNI,
+			V:     p.CNI,
+			Usage: "Replace flannel with the cni, supports calico, cilium and none. The cni isn't installed if it's none, it can only be set when creating the cluster",
+		},
+		{
+			Name:  "snapshotter",
+			P:     &p.Snapshotter,
+			V:     p.Snapshotter,
+			Usage: "Containerd snapshotter of all nodes, supports overlayfs, native and stargz (default \"overlayfs\"), the kernel of nodes must support overlay for overlayfs and fuse for stargz",
+		},
+		{
+			Name:  "min-cpu",
+			P:     &p.MinCPU,
+			V:     p.MinCPU,
+			Usage: "Minimum cpu cores of each node checked before installing K3s, 0 to skip the check",
+		},
+		{
+			Name:  "min-memory",
+			P:     &p.MinMemory,
+			V:     p.MinMemory,
+			Usage: "Minimum memory of each node checked before installing K3s, e.g. 2Gi, 0 to skip the check",
+		},
+		{
+			Name:  "min-disk",
+			P:     &p.MinDisk,
+			V:     p.MinDisk,
+			Usage: "Minimum free disk of K3s data dir on each node checked before installing K3s, e.g. 10Gi, 0 to skip the check",
+		},
+		{
+			Name:  "node-http-proxy",
+			P:     &p.NodeHTTPProxy,
+			V:     p.NodeHTTPProxy,
+			Usage: "HTTP proxy of nodes to download K3s and pull images, e.g. http://proxy.example.com:3128, it's written to the environment of K3s service",
+		},
+		{
+			Name:  "node-no-proxy",
+			P:     &p.NodeNoProxy,
+			V:     p.NodeNoProxy,
+			Usage: "Comma separated addresses which nodes access without `--node-http-proxy`, the local addresses, cluster cidrs and masters are always added",
+		},
 		{
 			Name:  "manifests",
 			P:     &p.Manifests,
@@ -152,6 +244,18 @@ func (p *ProviderBase) GetClusterOptions() []types.Flag {
 			V:     p.K3sChannel,
 			Usage: "Channel to use for fetching K3s download URL. Defaults to “stable”. Options include: stable, latest, testing",
 		},
+		{
+			Name:  "k3s-sha256",
+			P:     &p.K3sSHA256,
+			V:     p.K3sSHA256,
+			Usage: "Expected sha256 of the k3s binary, it's verified on each node after downloading or uploading the binary and K3s isn't started on mismatch",
+		},
+		{
+			Name:  "upgrade-window",
+			P:     &p.UpgradeWindow,
+			V:     p.UpgradeWindow,
+			Usage: "Upgrade K3s to the latest patch of the channel in the window of cron spec by `autok3s serve`, e.g. every saturday 02:00 '0 2 * * 6'",
+		},
 		{
 			Name:  "k3s-install-script",
 			P:     &p.InstallScript,
@@ -194,12 +298,42 @@ func (p *ProviderBase) GetClusterOptions() []types.Flag {
 			V:     p.Registry,
 			Usage: "K3s registry file, see: https://docs.k3s.io/installation/private-registry",
 		},
+		{
+			Name:  "registry-ca",
+			P:     &p.RegistryCA,
+			V:     p.RegistryCA,
+			Usage: "CA file trusted by containerd for the registry, in format [<registry>=]<file>, the ca without registry name is used by all registries of --registry, e.g.(--registry-ca mycustomreg:5000=/etc/autok3s/ca.pem)",
+		},
+		{
+			Name:  "registry-cert",
+			P:     &p.RegistryCert,
+			V:     p.RegistryCert,
+			Usage: "Client certificate file for the registry, in format <registry>=<file>, must set with --registry-key",
+		},
+		{
+			Name:  "registry-key",
+			P:     &p.RegistryKey,
+			V:     p.RegistryKey,
+			Usage: "Client key file for the registry, in format <registry>=<file>, must set with --registry-cert",
+		},
 		{
 			Name:  "system-default-registry",
 			P:     &p.SystemDefaultRegistry,
 			V:     p.SystemDefaultRegistry,
 			Usage: "K3s private registry to be used for all system images, see: https://docs.k3s.io/reference/server-config",
 		},
+		{
+			Name:  "pull-through-cache",
+			P:     &p.PullThroughCache,
+			V:     p.PullThroughCache,
+			Usage: "Deploy a node-local registry as pull-through cache and use it as the first mirror of docker.io, it works together with --registry",
+		},
+		{
+			Name:  "pull-through-cache-upstream",
+			P:     &p.PullThroughCacheUpstream,
+			V:     p.PullThroughCacheUpstream,
+			Usage: "Upstream of the pull-through cache, must set with --pull-through-cache (default \"" + DefaultPullThroughCacheUpstream + "\")",
+		},
 		{
 			Name:  "datastore",
 			P:     &p.DataStore,
@@ -224,11 +358,29 @@ func (p *ProviderBase) GetClusterOptions() []types.Flag {
 			V:     p.DataStoreKeyFile,
 			Usage: "TLS key file used for client certificate based authentication to your datastore, see: https://docs.k3s.io/installation/datastore#external-datastore-configuration-parameters",
 		},
+		{
+			Name:  "audit-policy-file",
+			P:     &p.AuditPolicyFile,
+			V:     p.AuditPolicyFile,
+			Usage: "Audit policy file of kube-apiserver, it's uploaded to masters and the audit log is written to " + auditLogDir + "/audit.log, see: https://kubernetes.io/docs/tasks/debug/debug-cluster/audit/",
+		},
+		{
+			Name:  "ca-cert-file",
+			P:     &p.CACertFile,
+			V:     p.CACertFile,
+			Usage: "Cert file of the CA which signs the certificates of K3s instead of the self-generated CAs, must be set with --ca-key-file, see: https://docs.k3s.io/cli/certificate#using-custom-ca-certificates",
+		},
+		{
+			Name:  "ca-key-file",
+			P:     &p.CAKeyFile,
+			V:     p.CAKeyFile,
+			Usage: "Key file of the CA of --ca-cert-file, it's uploaded to the first master when creating the cluster",
+		},
 		{
 			Name:  "token",
 			P:     &p.Token,
 			V:     p.Token,
-			Usage: "K3s token, if empty will automatically generated, see: https://docs.k3s.io/reference/server-config#cluster-options",
+			Usage: "K3s token shared by all nodes to join the cluster, if empty will automatically generated, see: https://docs.k3s.io/reference/server-config#cluster-options",
 		},
 		{
 			Name:  "tls-sans",
@@ -254,6 +406,18 @@ func (p *ProviderBase) GetClusterOptions() []types.Flag {
 			V:     p.Rollback,
 			Usage: "Whether to rollback when the K3s cluster installation or join nodes failed.",
 		},
+		{
+			Name:  "node-ready-timeout",
+			P:     &p.NodeReadyTimeout,
+			V:     p.NodeReadyTimeout,
+			Usage: "Time to wait for the joined nodes to be Ready, the nodes which aren't Ready in time aren't counted in the cluster state, 0 to skip waiting (default 5m)",
+		},
+		{
+			Name:  "join-retries",
+			P:     &p.JoinRetries,
+			V:     p.JoinRetries,
+			Usage: "Max retries of joining a worker which failed with transient errors, the current token is re-fetched from the server before each retry, 0 to disable",
+		},
 	}
 
 	fs = append(fs, p.GetSSHOptions()...)
@@ -280,7 +444,7 @@ func (p *ProviderBase) GetSSHOptions() []types.Flag {
 			Name:  "ssh-key-path",
 			P:     &p.SSHKeyPath,
 			V:     p.SSHKeyPath,
-			Usage: "SSH private key path",
+			Usage: "SSH private key path, or key source like `env:<name>`, `stdin` and `tencent-ssm:<secret-name>:<version-id>`",
 		},
 		{
 			Name:  "ssh-key-passphrase",
@@ -306,6 +470,12 @@ func (p *ProviderBase) GetSSHOptions() []types.Flag {
 			V:     p.SSHAgentAuth,
 			Usage: "Enable ssh agent",
 		},
+		{
+			Name:  "ssh-bastion",
+			P:     &p.SSHBastion,
+			V:     p.SSHBastion,
+			Usage: "The bastion of `[user@]host[:port]` to ssh to the private address of nodes through, the ssh user and key of nodes are used if the user isn't set",
+		},
 		{
 			Name:  "ssh-key-name",
 			P:     &p.SSHKeyName,
@@ -332,6 +502,19 @@ func (p *ProviderBase) GetCommonConfig(sshFunc func() *types.SSH) (map[string]sc
 	return metaConfig, nil
 }
 
+// GetOptionValues returns the fields of provider options with the current values as defaults, e.g. the values of the
+// saved cluster after SetOptions, so that the form of editing cluster is pre-filled. The credentials are excluded.
+func (p *ProviderBase) GetOptionValues(options interface{}, credentials []types.Flag) (map[string]schemas.Field, error) {
+	fields, err := utils.ConvertToFields(options)
+	if err != nil {
+		return nil, err
+	}
+	for _, credential := range credentials {
+		delete(fields, credential.Name)
+	}
+	return fields, nil
+}
+
 // InitCluster init K3S cluster.
 func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []string,
 	cloudInstanceFunc func(ssh *types.SSH) (*types.Cluster, error), customInstallK3s func() (string, string, error), rollbackInstance func(ids []string) error) (er error) {
@@ -340,6 +523,17 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 		return err
 	}
 
+	// record the autok3s version and the args which the cluster is created with.
+	spec, err := newClusterSpec(p.Metadata, options)
+	if err != nil {
+		return err
+	}
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	p.ClusterSpec = string(specBytes)
+
 	c := &types.Cluster{
 		Metadata: p.Metadata,
 		Options:  options,
@@ -381,7 +575,7 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 			}
 			_ = p.RollbackCluster(rollbackInstance)
 		}
-		if er == nil && len(p.Status.MasterNodes) > 0 {
+		if er == nil && len(p.Status.MasterNodes) > 0 && !p.SkipInstall {
 			p.Logger.Info(common.UsageInfoTitle)
 			p.Logger.Infof(common.UsageContext, p.ContextName)
 			p.Logger.Info(common.UsagePods)
@@ -394,6 +588,9 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 					ContextType: "cluster",
 					ContextName: p.ContextName,
 				}
+				if er == nil {
+					logEvent.Summary, _ = p.GetClusterSummary()
+				}
 				process.Fn(logEvent)
 			}
 		}
@@ -425,6 +622,15 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 	p.syncExistNodes()
 	c.Status = p.Status
 
+	if p.SkipInstall {
+		c.Status.Status = common.StatusInfraReady
+		if err = common.DefaultDB.SaveCluster(c); err != nil {
+			return err
+		}
+		p.Logger.Infof("[%s] successfully provisioned infrastructure of cluster %s, skip installing K3s", p.Provider, p.Name)
+		return nil
+	}
+
 	if customInstallK3s == nil {
 		// use install scripts to initialize K3s cluster.
 		if err = p.InitK3sCluster(c); err != nil {
@@ -449,6 +655,23 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 		}
 	}
 
+	p.recordClusterSpec(c)
+
+	cmds := p.getManifestCommands(deployPlugins)
+
+	// deploy custom manifests.
+	if len(cmds) > 0 {
+		if err = p.DeployExtraManifest(c, cmds); err != nil {
+			return err
+		}
+		p.Logger.Infof("[%s] successfully deployed custom manifests", p.Provider)
+	}
+
+	return
+}
+
+// getManifestCommands collects the commands which write provider, custom and add-on manifests to the K3s manifests dir.
+func (p *ProviderBase) getManifestCommands(deployPlugins func() []string) []string {
 	cmds := []string{}
 	if deployPlugins != nil {
 		// install additional manifests to the current cluster.
@@ -456,6 +679,14 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 		cmds = append(cmds, extraManifests...)
 	}
 
+	if cniCmd := getCNICommand(p.CNI, p.ClusterCidr); cniCmd != "" {
+		cmds = append(cmds, cniCmd)
+	}
+
+	if p.PullThroughCache {
+		cmds = append(cmds, getPullThroughCacheCommand(p.PullThroughCacheUpstream))
+	}
+
 	if p.Manifests != "" {
 		deployCmd, err := p.GetCustomManifests()
 		if err != nil {
@@ -476,15 +707,59 @@ func (p *ProviderBase) InitCluster(options interface{}, deployPlugins func() []s
 		}
 	}
 
-	// deploy custom manifests.
-	if len(cmds) > 0 {
-		if err = p.DeployExtraManifest(c, cmds); err != nil {
-			return err
-		}
-		p.Logger.Infof("[%s] successfully deployed custom manifests", p.Provider)
+	return cmds
+}
+
+// ApplyAddons re-applies the custom and add-on manifests to an existing cluster.
+func (p *ProviderBase) ApplyAddons() error {
+	return p.ApplyManifests(nil)
+}
+
+// ApplyManifests re-writes the provider, custom and add-on manifests to the K3s manifests dir of an existing cluster,
+// it's safe to run multiple times as K3s deploy controller skips the manifests whose checksum is not changed
+// and only reconciles the changed ones.
+func (p *ProviderBase) ApplyManifests(deployPlugins func() []string) error {
+	if p.Provider == "k3d" {
+		return errors.New("applying add-ons for K3d provider is not supported yet")
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	logFile, err := common.GetLogFile(state.ContextName)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+	p.Logger.Infof("[%s] begin to apply add-ons of cluster %s...", p.Provider, p.Name)
 
-	return
+	// manifests and add-ons are applied as they were set when creating the cluster.
+	p.Metadata = state.Metadata
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) == 0 {
+		return fmt.Errorf("[%s] cluster %s has no master node to apply add-ons", p.Provider, p.Name)
+	}
+	cmds := p.getManifestCommands(deployPlugins)
+	if len(cmds) == 0 {
+		p.Logger.Infof("[%s] no add-on is enabled for cluster %s", p.Provider, p.Name)
+		return nil
+	}
+	if err = p.DeployExtraManifest(&c, cmds); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully applied add-ons of cluster %s", p.Provider, p.Name)
+	return nil
+}
+
+// UpgradeAddons is not supported by default, providers which deploy cloud integrations override it.
+func (p *ProviderBase) UpgradeAddons(clusterName, ccmVersion, csiVersion string) error {
+	return fmt.Errorf("upgrading add-ons for %s provider is not supported yet", p.Provider)
 }
 
 // JoinNodes join K3S nodes.
@@ -503,7 +778,7 @@ func (p *ProviderBase) JoinNodes(cloudInstanceFunc func(ssh *types.SSH) (*types.
 		return err
 	}
 	if state == nil {
-		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+		return NewClusterNotFoundError("[%s] cluster %s is not exist", p.Provider, p.Name)
 	}
 	defer func() {
 		if er != nil || len(p.ErrM) > 0 {
@@ -648,13 +923,26 @@ func (p *ProviderBase) overwriteMetadata(matched *common.ClusterState) {
 	p.IP = matched.IP
 	p.UI = matched.UI
 	p.ClusterCidr = matched.ClusterCidr
+	p.ClusterDomain = matched.ClusterDomain
+	p.AdvertiseAddress = matched.AdvertiseAddress
+	p.NodeIP = matched.NodeIP
 	p.DataStore = matched.DataStore
 	p.Mirror = matched.Mirror
 	p.DockerMirror = matched.DockerMirror
 	p.InstallScript = matched.InstallScript
 	p.Network = matched.Network
+	p.CNI = matched.CNI
+	p.Snapshotter = matched.Snapshotter
+	p.EtcdSnapshotScheduleCron = matched.EtcdSnapshotScheduleCron
+	p.EtcdSnapshotRetention = matched.EtcdSnapshotRetention
+	p.EtcdSnapshotDir = matched.EtcdSnapshotDir
+	p.AuditPolicyFile = matched.AuditPolicyFile
+	p.AuditPolicyFileContent = matched.AuditPolicyFileContent
+	p.CACertFile = matched.CACertFile
+	p.CAKeyFile = matched.CAKeyFile
 	p.Cluster = matched.Cluster
 	p.Rollback = matched.Rollback
+	p.KubeconfigRotated = matched.KubeconfigRotated
 	// needed to be overwrite.
 	if p.K3sChannel == "" {
 		p.K3sChannel = matched.K3sChannel
@@ -662,6 +950,19 @@ func (p *ProviderBase) overwriteMetadata(matched *common.ClusterState) {
 	if p.K3sVersion == "" {
 		p.K3sVersion = matched.K3sVersion
 	}
+	if p.K3sSHA256 == "" {
+		p.K3sSHA256 = matched.K3sSHA256
+	}
+	if p.UpgradeWindow == "" {
+		p.UpgradeWindow = matched.UpgradeWindow
+	}
+	if p.NodeReadyTimeout == "" {
+		p.NodeReadyTimeout = matched.NodeReadyTimeout
+	}
+	if p.NodeHTTPProxy == "" {
+		p.NodeHTTPProxy = matched.NodeHTTPProxy
+		p.NodeNoProxy = matched.NodeNoProxy
+	}
 	if p.InstallScript == "" {
 		p.InstallScript = matched.InstallScript
 	}
@@ -674,9 +975,31 @@ func (p *ProviderBase) overwriteMetadata(matched *common.ClusterState) {
 	if p.Registry == "" {
 		p.Registry = matched.Registry
 	}
+	if len(p.RegistryCA) == 0 {
+		p.RegistryCA = matched.RegistryCA
+	}
+	if len(p.RegistryCert) == 0 {
+		p.RegistryCert = matched.RegistryCert
+		p.RegistryKey = matched.RegistryKey
+	}
+	// the saved files are kept for the nodes joined later, the files of joining are loaded over them.
+	for k, v := range matched.RegistryTLSContent {
+		if p.RegistryTLSContent == nil {
+			p.RegistryTLSContent = types.StringMap{}
+		}
+		if _, ok := p.RegistryTLSContent[k]; !ok {
+			p.RegistryTLSContent[k] = v
+		}
+	}
 	if p.SystemDefaultRegistry == "" {
 		p.SystemDefaultRegistry = matched.SystemDefaultRegistry
 	}
+	if !p.PullThroughCache {
+		p.PullThroughCache = matched.PullThroughCache
+	}
+	if p.PullThroughCacheUpstream == "" {
+		p.PullThroughCacheUpstream = matched.PullThroughCacheUpstream
+	}
 	if p.MasterExtraArgs == "" {
 		p.MasterExtraArgs = matched.MasterExtraArgs
 	}
@@ -685,27 +1008,16 @@ func (p *ProviderBase) overwriteMetadata(matched *common.ClusterState) {
 	}
 }
 
+// CheckCreateArgs validates the create args and checks that the cluster doesn't exist.
 func (p *ProviderBase) CheckCreateArgs(checkClusterExist func() (bool, []string, error)) error {
-	if p.Provider != "native" {
-		masterNum, err := strconv.Atoi(p.Master)
-		if masterNum < 1 || err != nil {
-			return fmt.Errorf("[%s] calling preflight error: `--master` number must >= 1",
-				p.Provider)
-		}
-		if p.Provider != "k3d" && masterNum > 1 && !p.Cluster && p.DataStore == "" {
-			return fmt.Errorf("[%s] calling preflight error: need to set `--cluster` or `--datastore` when `--master` number > 1",
-				p.Provider)
-		}
-		if p.Provider != "k3d" && strings.Contains(p.MasterExtraArgs, "--datastore-endpoint") && p.DataStore != "" {
-			return fmt.Errorf("[%s] calling preflight error: `--masterExtraArgs='--datastore-endpoint'` is duplicated with `--datastore`",
-				p.Provider)
-		}
-		if _, err = strconv.Atoi(p.Worker); err != nil {
-			return fmt.Errorf("[%s] calling preflight error: `--worker` must be number",
-				p.Provider)
-		}
+	if err := p.ValidateCreateArgs(); err != nil {
+		return err
 	}
+	return p.CheckClusterNotExist(checkClusterExist)
+}
 
+// CheckClusterNotExist checks that the cluster doesn't exist in both the state db and the provider.
+func (p *ProviderBase) CheckClusterNotExist(checkClusterExist func() (bool, []string, error)) error {
 	// check name exist.
 	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
 	if err != nil {
@@ -713,7 +1025,7 @@ func (p *ProviderBase) CheckCreateArgs(checkClusterExist func() (bool, []string,
 	}
 
 	if state != nil && state.Status != common.StatusFailed {
-		return fmt.Errorf("[%s] cluster %s is already exist", p.Provider, p.Name)
+		return NewClusterExistsError("[%s] cluster %s is already exist", p.Provider, p.Name)
 	}
 
 	exist, _, err := checkClusterExist()
@@ -722,35 +1034,185 @@ func (p *ProviderBase) CheckCreateArgs(checkClusterExist func() (bool, []string,
 	}
 
 	if exist {
-		return fmt.Errorf("[%s] calling preflight error: cluster `%s` is already exist",
+		return NewClusterExistsError("[%s] calling preflight error: cluster `%s` is already exist",
 			p.Provider, p.Name)
 	}
 
+	return nil
+}
+
+// Validate runs the offline preflight checks by default, providers which have more options override it.
+func (p *ProviderBase) Validate() error {
+	return p.ValidateCreateArgs()
+}
+
+// Prune is not supported by default, providers which tag the cloud resources override it.
+func (p *ProviderBase) Prune(confirm bool) ([]types.PruneResource, error) {
+	return nil, fmt.Errorf("pruning resources for %s provider is not supported yet", p.Provider)
+}
+
+// TagCluster is not supported by default, providers which tag the cloud resources override it.
+func (p *ProviderBase) TagCluster(tags []string) error {
+	return fmt.Errorf("tagging clusters for %s provider is not supported yet", p.Provider)
+}
+
+// UntagCluster is not supported by default, providers which tag the cloud resources override it.
+func (p *ProviderBase) UntagCluster(keys []string) error {
+	return fmt.Errorf("untagging clusters for %s provider is not supported yet", p.Provider)
+}
+
+// EstimateCost is not supported by default, providers which have price apis override it.
+func (p *ProviderBase) EstimateCost() (*types.CostEstimate, error) {
+	return nil, fmt.Errorf("estimating cost for %s provider is not supported yet", p.Provider)
+}
+
+// ValidateCreateArgs validates the create args without any side effect, i.e. it doesn't require credentials,
+// call the provider api or the state db, so that a cluster spec can be linted anywhere. All problems are returned
+// together as ValidationErrors.
+func (p *ProviderBase) ValidateCreateArgs() error {
+	errs := make([]error, 0)
+	if err := utils.ValidateFields(p.Metadata); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err))
+	}
+	if p.Provider != "native" {
+		masterNum, err := strconv.Atoi(p.Master)
+		if masterNum < 1 || err != nil {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--master` number must >= 1",
+				p.Provider))
+		}
+		if p.Provider != "k3d" && masterNum > 1 && !p.Cluster && p.DataStore == "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: need to set `--cluster` or `--datastore` when `--master` number > 1",
+				p.Provider))
+		}
+		if p.Provider != "k3d" && strings.Contains(p.MasterExtraArgs, "--datastore-endpoint") && p.DataStore != "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--masterExtraArgs='--datastore-endpoint'` is duplicated with `--datastore`",
+				p.Provider))
+		}
+		workerNum, workerErr := strconv.Atoi(p.Worker)
+		if workerErr != nil {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--worker` must be number",
+				p.Provider))
+		}
+		// the node addresses are matched with the node numbers, they're checked only if the numbers are valid.
+		if err == nil && masterNum >= 1 && workerErr == nil {
+			if err := p.checkNodeAddresses(masterNum, workerNum); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if p.ClusterDomain != "" {
+		if msgs := validation.IsDNS1123Subdomain(p.ClusterDomain); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid `--cluster-domain` %s: %s",
+				p.Provider, p.ClusterDomain, strings.Join(msgs, ", ")))
+		}
+	}
+
+	if p.UpgradeWindow != "" {
+		if _, err := utils.ParseCronSchedule(p.UpgradeWindow); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid `--upgrade-window` %q: %v", p.Provider, p.UpgradeWindow, err))
+		}
+	}
+
+	if _, err := p.getNodeReadyTimeout(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := p.getJoinRetries(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if p.EtcdSnapshotScheduleCron != "" || p.EtcdSnapshotRetention != 0 || p.EtcdSnapshotDir != "" {
+		if !p.Cluster || p.DataStore != "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: etcd snapshot options can only be set with embedded etcd `--cluster`", p.Provider))
+		}
+		if p.EtcdSnapshotRetention < 0 {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--etcd-snapshot-retention` must >= 0", p.Provider))
+		}
+		if p.EtcdSnapshotScheduleCron != "" {
+			if err := validateCronExpression(p.EtcdSnapshotScheduleCron); err != nil {
+				errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid `--etcd-snapshot-schedule-cron` %q: %v",
+					p.Provider, p.EtcdSnapshotScheduleCron, err))
+			}
+		}
+	}
+
+	switch p.CNI {
+	case "", CNICalico, CNICilium, CNINone:
+	default:
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--cni` only supports %s, %s or %s",
+			p.Provider, CNICalico, CNICilium, CNINone))
+	}
+	if p.CNI != "" && p.Network != "" {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--cni` can't be set with flannel backend `--network`", p.Provider))
+	}
+	if err := validateSnapshotter(p.Snapshotter); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err))
+	}
+	if err := validateMinResources(&p.Metadata); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err))
+	}
+	if err := validateNodeProxy(&p.Metadata); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err))
+	}
+	if err := validateK3sSHA256(p.K3sSHA256); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err))
+	}
+	weak, err := validateToken(p.Token)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err))
+	}
+	if weak {
+		logrus.Warnf("[%s] `--token` is weak, it's recommended to use at least %d characters of mixed letters, digits or symbols", p.Provider, minTokenLength)
+	}
+
 	// check file exists.
-	if p.SSHKeyPath != "" && !utils.IsFileExists(p.SSHKeyPath) {
-		return fmt.Errorf("[%s] failed to check --ssh-key-path %s", p.Provider, p.SSHKeyPath)
+	if path, ok := utils.SSHKeyFilePath(p.SSHKeyPath); p.SSHKeyPath != "" && ok && !utils.IsFileExists(path) {
+		errs = append(errs, fmt.Errorf("[%s] failed to check --ssh-key-path %s", p.Provider, p.SSHKeyPath))
 	}
 	if p.SSHCertPath != "" && !utils.IsFileExists(p.SSHCertPath) {
-		return fmt.Errorf("[%s] failed to check --ssh-cert-path %s", p.Provider, p.SSHCertPath)
+		errs = append(errs, fmt.Errorf("[%s] failed to check --ssh-cert-path %s", p.Provider, p.SSHCertPath))
 	}
 
 	if p.Registry != "" && !utils.IsFileExists(p.Registry) {
-		return fmt.Errorf("[%s] failed to check --registry %s", p.Provider, p.Registry)
+		errs = append(errs, fmt.Errorf("[%s] failed to check --registry %s", p.Provider, p.Registry))
+	}
+
+	if err := p.loadRegistryTLSContent(); err != nil {
+		errs = append(errs, err)
 	}
 
 	if p.DataStoreCAFile != "" && !utils.IsFileExists(p.DataStoreCAFile) {
-		return fmt.Errorf("[%s] failed to check --datastore-cafile %s", p.Provider, p.DataStoreCAFile)
+		errs = append(errs, fmt.Errorf("[%s] failed to check --datastore-cafile %s", p.Provider, p.DataStoreCAFile))
 	}
 
 	if p.DataStoreCertFile != "" && !utils.IsFileExists(p.DataStoreCertFile) {
-		return fmt.Errorf("[%s] failed to check --datastore-certfile %s", p.Provider, p.DataStoreCertFile)
+		errs = append(errs, fmt.Errorf("[%s] failed to check --datastore-certfile %s", p.Provider, p.DataStoreCertFile))
 	}
 
 	if p.DataStoreKeyFile != "" && !utils.IsFileExists(p.DataStoreKeyFile) {
-		return fmt.Errorf("[%s] failed to check --datastore-keyfile %s", p.Provider, p.DataStoreKeyFile)
+		errs = append(errs, fmt.Errorf("[%s] failed to check --datastore-keyfile %s", p.Provider, p.DataStoreKeyFile))
 	}
 
-	return nil
+	if p.AuditPolicyFile != "" || p.AuditPolicyFileContent != "" {
+		policy, err := getAuditPolicy(&types.Cluster{Metadata: p.Metadata})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[%s] failed to check --audit-policy-file %s: %v", p.Provider, p.AuditPolicyFile, err))
+		} else if err = validateAuditPolicy(policy); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid audit policy %s: %v", p.Provider, p.AuditPolicyFile, err))
+		}
+	}
+
+	if p.CACertFile != "" || p.CAKeyFile != "" {
+		if p.CACertFile == "" || p.CAKeyFile == "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--ca-cert-file` and `--ca-key-file` must be set together", p.Provider))
+		} else if cert, key, err := getCustomCA(&types.Cluster{Metadata: p.Metadata}); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] failed to check --ca-cert-file %s: %v", p.Provider, p.CACertFile, err))
+		} else if err = validateCustomCA(cert, key); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid custom CA %s: %v", p.Provider, p.CACertFile, err))
+		}
+	}
+
+	return NewValidationErrors(errs...)
 }
 
 func (p *ProviderBase) CheckJoinArgs(checkClusterExist func() (bool, []string, error)) error {
@@ -762,7 +1224,7 @@ func (p *ProviderBase) CheckJoinArgs(checkClusterExist func() (bool, []string, e
 	}
 
 	if !exist {
-		return fmt.Errorf("[%s] calling preflight error: cluster name `%s` do not exist",
+		return NewClusterNotFoundError("[%s] calling preflight error: cluster name `%s` do not exist",
 			p.Provider, p.ContextName)
 	}
 
@@ -790,8 +1252,46 @@ func (p *ProviderBase) CheckJoinArgs(checkClusterExist func() (bool, []string, e
 		if masterNum < 1 && workerNum < 1 {
 			return fmt.Errorf("[%s] calling preflight error: `--master` or `--worker` number must >= 1", p.Provider)
 		}
+		// the nodes already in the cluster use the ip as well.
+		if err := p.checkNodeAddresses(masterNum+1, workerNum); err != nil {
+			return err
+		}
+	}
+	if _, err := p.getNodeReadyTimeout(); err != nil {
+		return err
 	}
+	if _, err := p.getJoinRetries(); err != nil {
+		return err
+	}
+
+	return p.loadRegistryTLSContent()
+}
 
+// checkNodeAddresses validates `--advertise-address` and `--node-ip`, an ip can only be used by a single node,
+// the cidr is required to select the address of each node when there are multiple ones.
+func (p *ProviderBase) checkNodeAddresses(masterNum, workerNum int) error {
+	for _, option := range []struct {
+		flag    string
+		value   string
+		nodeNum int
+	}{
+		{flag: "--advertise-address", value: p.AdvertiseAddress, nodeNum: masterNum},
+		{flag: "--node-ip", value: p.NodeIP, nodeNum: masterNum + workerNum},
+	} {
+		if option.value == "" {
+			continue
+		}
+		if net.ParseIP(option.value) != nil {
+			if option.nodeNum > 1 {
+				return fmt.Errorf("[%s] calling preflight error: `%s` ip %s can only be used by a single node, use a CIDR instead",
+					p.Provider, option.flag, option.value)
+			}
+			continue
+		}
+		if _, _, err := net.ParseCIDR(option.value); err != nil {
+			return fmt.Errorf("[%s] calling preflight error: `%s` must be an ip or CIDR, got %s", p.Provider, option.flag, option.value)
+		}
+	}
 	return nil
 }
 
@@ -800,7 +1300,7 @@ func (p *ProviderBase) DeleteCluster(force bool, delete func(f bool) (string, er
 	isConfirmed := true
 
 	if !force {
-		isConfirmed = utils.AskForConfirmation(fmt.Sprintf("[%s] are you sure to delete cluster %s", p.Provider, p.Name), false)
+		isConfirmed = utils.AskForConfirmationOrAssumeYes(fmt.Sprintf("[%s] are you sure to delete cluster %s", p.Provider, p.Name), false)
 	}
 	if isConfirmed {
 		logFile, err := common.GetLogFile(p.ContextName)
@@ -881,6 +1381,12 @@ func (p *ProviderBase) GetClusterStatus(kubeCfg string, c *types.ClusterInfo, de
 	if kubeCfg == "" {
 		return c
 	}
+	// K3s isn't installed yet, so there is no api server to ask for.
+	if p.Status.Status == common.StatusInfraReady {
+		c.Status = common.StatusInfraReady
+		c.Version = types.ClusterStatusUnknown
+		return c
+	}
 
 	client, err := GetClusterConfig(p.ContextName, kubeCfg)
 	if err != nil {
@@ -890,7 +1396,13 @@ func (p *ProviderBase) GetClusterStatus(kubeCfg string, c *types.ClusterInfo, de
 		return c
 	}
 
-	c.Status = GetClusterStatus(client)
+	c.Status = WaitClusterStatus(client, common.DescribeTimeout)
+	if c.Status != types.ClusterStatusRunning {
+		if failover := p.failoverKubeconfig(kubeCfg); failover != nil {
+			client = failover
+			c.Status = GetClusterStatus(client)
+		}
+	}
 	if c.Status == types.ClusterStatusRunning {
 		c.Version = GetClusterVersion(client)
 	} else {
@@ -1084,7 +1596,13 @@ func (p *ProviderBase) Describe(kubeCfg string, c *types.ClusterInfo, describeIn
 		c.Version = types.ClusterStatusUnknown
 		return c
 	}
-	c.Status = GetClusterStatus(client)
+	c.Status = WaitClusterStatus(client, common.DescribeTimeout)
+	if c.Status != types.ClusterStatusRunning {
+		if failover := p.failoverKubeconfig(kubeCfg); failover != nil {
+			client = failover
+			c.Status = GetClusterStatus(client)
+		}
+	}
 	if describeInstance != nil {
 		instanceList, err := describeInstance()
 		if err != nil {
@@ -1103,6 +1621,7 @@ func (p *ProviderBase) Describe(kubeCfg string, c *types.ClusterInfo, describeIn
 				InternalIP:              instance.InternalIPAddress,
 				ExternalIP:              instance.PublicIPAddress,
 				Standalone:              instance.Standalone,
+				Tags:                    instance.Tags,
 				Status:                  types.ClusterStatusUnknown,
 				ContainerRuntimeVersion: types.ClusterStatusUnknown,
 				Version:                 types.ClusterStatusUnknown,
@@ -1317,10 +1836,13 @@ func (p *ProviderBase) RegisterCallbacks(name, event string, fn func(interface{}
 	}
 }
 
-func (p *ProviderBase) UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath string) error {
+func (p *ProviderBase) UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath, k3sSHA256 string) error {
 	if p.Provider == "k3d" {
 		return errors.New("the upgrade cluster for K3d provider is not supported yet")
 	}
+	if err := validateK3sSHA256(k3sSHA256); err != nil {
+		return err
+	}
 	state, err := common.DefaultDB.GetCluster(clusterName, p.Provider)
 	if err != nil {
 		return err
@@ -1376,6 +1898,15 @@ func (p *ProviderBase) UpgradeK3sCluster(clusterName, installScript, channel, ve
 		state.K3sVersion = version
 	}
 
+	// the binary is changed by the upgrade, so the expected sha256 of the old one can't be used.
+	if k3sSHA256 != "" || installScript != "" || channel != "" || version != "" || packageName != "" || packagePath != "" {
+		if k3sSHA256 == "" && c.K3sSHA256 != "" {
+			p.Logger.Warnf("[%s] sha256 of k3s binary isn't verified as `--k3s-sha256` isn't set for the upgrade", p.Provider)
+		}
+		c.K3sSHA256 = strings.ToLower(k3sSHA256)
+		state.K3sSHA256 = c.K3sSHA256
+	}
+
 	// if online install specified, clean up offline options and ignore package name/path input
 	if installScript != "" || channel != "" || version != "" {
 		c.PackageName = ""
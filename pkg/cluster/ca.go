@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/airgap"
+	"github.com/cnrancher/autok3s/pkg/hosts/dialer"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// customCAs are the CAs of K3s under ${data-dir}/server/tls, which are only generated at the first start of the
+// cluster if they don't exist. The provided CA is used for all of them, while the request-header-ca of the aggregated
+// apiservers is still self-generated, as it shouldn't trust the client certificates signed by the provided CA.
+var customCAs = []string{"server-ca", "client-ca", "etcd/server-ca", "etcd/peer-ca"}
+
+// handleCustomCA uploads the provided CA to the first master before K3s starts, so that the cluster is signed by it
+// instead of the self-generated CAs. The other masters get the CAs from the bootstrap data of the datastore.
+func (p *ProviderBase) handleCustomCA(n *types.Node, c *types.Cluster, extraArgs string) error {
+	cert, key, err := getCustomCA(c)
+	if err != nil {
+		return err
+	}
+	tlsDir := path.Join(airgap.GetDataPath(extraArgs), "server", "tls")
+	p.Logger.Infof("[cluster] uploading custom CA to %s of master %s", tlsDir, n.InstanceID)
+	sshDialer, err := dialer.NewSSHDialer(n, true, p.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sshDialer.Close()
+	}()
+	// the files are written with stdin, so that the key isn't logged with the commands.
+	for _, ca := range customCAs {
+		if err = sshDialer.WriteFile(fmt.Sprintf("%s/%s.crt", tlsDir, ca), cert, 0600); err != nil {
+			return err
+		}
+		if err = sshDialer.WriteFile(fmt.Sprintf("%s/%s.key", tlsDir, ca), key, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getCustomCA reads the cert and key of the custom CA.
+func getCustomCA(c *types.Cluster) ([]byte, []byte, error) {
+	cert, err := os.ReadFile(c.CACertFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := os.ReadFile(c.CAKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// validateCustomCA checks the key matches the cert, and the cert is a CA which is not expired.
+func validateCustomCA(cert, key []byte) error {
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return fmt.Errorf("the cert and key are not a valid pair: %v", err)
+	}
+	ca, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid cert: %v", err)
+	}
+	if !ca.IsCA {
+		return fmt.Errorf("the cert of %s is not a CA", ca.Subject.CommonName)
+	}
+	if time.Now().After(ca.NotAfter) {
+		return fmt.Errorf("the CA %s is expired at %s", ca.Subject.CommonName, ca.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
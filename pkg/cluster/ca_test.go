@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCert(t *testing.T, isCA bool, notAfter time.Time) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "autok3s-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestValidateCustomCA(t *testing.T) {
+	cert, key := newTestCert(t, true, time.Now().Add(24*time.Hour))
+	assert.NoError(t, validateCustomCA(cert, key))
+
+	// the key of another CA doesn't match.
+	_, otherKey := newTestCert(t, true, time.Now().Add(24*time.Hour))
+	assert.Error(t, validateCustomCA(cert, otherKey))
+
+	leaf, leafKey := newTestCert(t, false, time.Now().Add(24*time.Hour))
+	assert.EqualError(t, validateCustomCA(leaf, leafKey), "the cert of autok3s-ca is not a CA")
+
+	expired, expiredKey := newTestCert(t, true, time.Now().Add(-time.Minute))
+	assert.Error(t, validateCustomCA(expired, expiredKey))
+}
@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/rancher/wharfie/pkg/registries"
+)
+
+const (
+	pullThroughCacheRegistry = "docker.io"
+	pullThroughCachePort     = 5000
+	// DefaultPullThroughCacheUpstream default upstream of the pull-through cache.
+	DefaultPullThroughCacheUpstream = "https://registry-1.docker.io"
+)
+
+var (
+	deployPullThroughCacheCommand = "echo \"%s\" | base64 -d | tee \"%s/pull-through-cache.yaml\""
+	// the registry listens on localhost of each node, so containerd can reach it without any cluster networking.
+	pullThroughCacheTmpl = `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: pull-through-cache
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      app: pull-through-cache
+  template:
+    metadata:
+      labels:
+        app: pull-through-cache
+    spec:
+      hostNetwork: true
+      priorityClassName: system-node-critical
+      tolerations:
+        - operator: "Exists"
+      containers:
+        - name: registry
+          image: registry:2
+          env:
+            - name: REGISTRY_HTTP_ADDR
+              value: "127.0.0.1:%[1]d"
+            - name: REGISTRY_PROXY_REMOTEURL
+              value: "%[2]s"
+          volumeMounts:
+            - name: cache
+              mountPath: /var/lib/registry
+      volumes:
+        - name: cache
+          hostPath:
+            path: /var/lib/rancher/pull-through-cache
+            type: DirectoryOrCreate
+---
+`
+)
+
+// withPullThroughCache sets the node local pull-through cache as the first mirror endpoint of docker.io,
+// the endpoints of docker.io configured by --registry are kept as fallbacks.
+func withPullThroughCache(registry *registries.Registry) {
+	if registry.Mirrors == nil {
+		registry.Mirrors = map[string]registries.Mirror{}
+	}
+	endpoint := fmt.Sprintf("http://localhost:%d", pullThroughCachePort)
+	mirror := registry.Mirrors[pullThroughCacheRegistry]
+	for _, e := range mirror.Endpoints {
+		if e == endpoint {
+			return
+		}
+	}
+	mirror.Endpoints = append([]string{endpoint}, mirror.Endpoints...)
+	registry.Mirrors[pullThroughCacheRegistry] = mirror
+}
+
+// getPullThroughCacheCommand returns the command which deploys pull-through cache daemonset.
+func getPullThroughCacheCommand(upstream string) string {
+	if upstream == "" {
+		upstream = DefaultPullThroughCacheUpstream
+	}
+	tmpl := fmt.Sprintf(pullThroughCacheTmpl, pullThroughCachePort, upstream)
+	return fmt.Sprintf(deployPullThroughCacheCommand, base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir)
+}
@@ -0,0 +1,23 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/rancher/wharfie/pkg/registries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPullThroughCache(t *testing.T) {
+	registry := &registries.Registry{}
+	withPullThroughCache(registry)
+	assert.Equal(t, []string{"http://localhost:5000"}, registry.Mirrors["docker.io"].Endpoints)
+
+	registry = &registries.Registry{
+		Mirrors: map[string]registries.Mirror{
+			"docker.io": {Endpoints: []string{"https://mirror.example.com"}},
+		},
+	}
+	withPullThroughCache(registry)
+	withPullThroughCache(registry)
+	assert.Equal(t, []string{"http://localhost:5000", "https://mirror.example.com"}, registry.Mirrors["docker.io"].Endpoints)
+}
@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// verifyK3sBinaryCommand compares the sha256 of the installed k3s binary with the expected one, the install script
+// falls back to /opt/bin if /usr/local/bin is read-only.
+const verifyK3sBinaryCommand = `b=/usr/local/bin/k3s; [ -x "$b" ] || b=/opt/bin/k3s; s=$(sha256sum "$b" | cut -d ' ' -f 1); ` +
+	`if [ "$s" != "%[1]s" ]; then echo "sha256 $s of k3s binary $b doesn't match the expected %[1]s" >&2; exit 1; fi`
+
+var sha256Regexp = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+func validateK3sSHA256(sum string) error {
+	if sum != "" && !sha256Regexp.MatchString(sum) {
+		return fmt.Errorf("`--k3s-sha256` %q must be 64 hex characters", sum)
+	}
+	return nil
+}
+
+// getVerifiedStartCommand returns the command which starts K3s of the node only if the k3s binary matches the expected
+// sha256, or empty if it isn't set. The install command skips starting K3s when it's set.
+func getVerifiedStartCommand(cluster *types.Cluster, node types.Node) string {
+	if cluster.K3sSHA256 == "" {
+		return ""
+	}
+	restart := k3sAgentRestart
+	if node.Master {
+		restart = k3sRestart
+	}
+	return fmt.Sprintf("{ %s; } && { %s; }", fmt.Sprintf(verifyK3sBinaryCommand, strings.ToLower(cluster.K3sSHA256)), restart)
+}
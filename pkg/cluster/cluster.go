@@ -24,7 +24,9 @@ import (
 	"github.com/rancher/wharfie/pkg/registries"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -34,6 +36,7 @@ import (
 var (
 	registryPath              = "/etc/rancher/k3s"
 	datastoreCertificatesPath = "/etc/rancher/datastore"
+	describeRetryInterval     = 3 * time.Second
 )
 
 // InitK3sCluster initial K3S cluster.
@@ -62,6 +65,8 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster) error {
 		cluster.Token = token
 	}
 
+	// the first master initializes the cluster and serves the kubeconfig, so it must run both etcd and control-plane.
+	sortMasterNodesByRole(cluster.MasterNodes)
 	if len(cluster.MasterNodes) <= 0 || len(cluster.MasterNodes[0].InternalIPAddress) <= 0 {
 		return errors.New("[cluster] master node internal ip address can not be empty")
 	}
@@ -69,7 +74,11 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster) error {
 	publicIP := cluster.IP
 	if cluster.IP == "" {
 		cluster.IP = cluster.MasterNodes[0].InternalIPAddress[0]
-		publicIP = cluster.MasterNodes[0].PublicIPAddress[0]
+		publicIP = getFirstAddress(cluster.MasterNodes[0].PublicIPAddress)
+		if publicIP == "" {
+			// the master is private without public ip.
+			publicIP = cluster.IP
+		}
 	}
 
 	// initialize the first master node and worker node to validate the K3s configuration.
@@ -141,6 +150,7 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster) error {
 	if err := common.DefaultDB.SaveCluster(cluster); err != nil {
 		return err
 	}
+	p.annotateInstanceNodes(cluster)
 
 	p.Logger.Infof("[%s] deploying additional manifests", p.Provider)
 
@@ -198,15 +208,17 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 	}
 	publicIP := merged.IP
 
+	// the server of `--ip` address, which the cluster token is got from.
+	serverNode := types.Node{}
+	if len(added.MasterNodes) > 0 {
+		serverNode = added.MasterNodes[0]
+	} else if len(added.WorkerNodes) > 0 {
+		serverNode = added.WorkerNodes[0]
+	}
+	serverNode.PublicIPAddress = []string{merged.IP}
+
 	// get cluster token from `--ip` address.
 	if merged.Token == "" {
-		serverNode := types.Node{}
-		if len(added.MasterNodes) > 0 {
-			serverNode = added.MasterNodes[0]
-		} else {
-			serverNode = added.WorkerNodes[0]
-		}
-		serverNode.PublicIPAddress = []string{merged.IP}
 		token, err := p.execute(&serverNode, getTokenCommand)
 		if err != nil {
 			return err
@@ -258,7 +270,7 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 			if additionalExtraArgs != "" {
 				extraArgs += additionalExtraArgs
 			}
-			if err := p.initNode(false, publicIP, merged, full, extraArgs, pkg); err != nil {
+			if err := p.joinWorkerWithRetry(publicIP, merged, serverNode, full, extraArgs, pkg); err != nil {
 				l.Lock()
 				p.ErrM[full.InstanceID] = err.Error()
 				l.Unlock()
@@ -268,10 +280,6 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 	}
 	wg.Wait()
 
-	// sync master & worker numbers.
-	merged.Master = strconv.Itoa(len(merged.MasterNodes))
-	merged.Worker = strconv.Itoa(len(merged.WorkerNodes))
-
 	if p.Provider == "native" {
 		// check cluster context exists
 		kubeCfg := filepath.Join(common.CfgPath, common.KubeCfgFile)
@@ -282,11 +290,11 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 		contexts := clientConfig.Contexts
 		if _, ok := contexts[p.ContextName]; !ok {
 			// get k3s cluster config.
-			cfg, err := p.execute(&types.Node{
+			cfg, err := p.getKubeconfig(merged, &types.Node{
 				PublicIPAddress: []string{merged.IP},
 				SSH:             merged.SSH,
 				Master:          true,
-			}, catCfgCommand)
+			})
 			if err == nil {
 				// merge current cluster to kube config.
 				if err := SaveCfg(cfg, merged.IP, p.ContextName); err != nil {
@@ -299,12 +307,33 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 		}
 	}
 
+	// only the nodes which are Ready are counted, the others are kept in state so that they can still be managed
+	// and deleted with the cluster. The nodes can't be Ready without a CNI, which is deployed later with `--cni none`.
+	var notReady map[string]string
+	if merged.CNI != CNINone {
+		joined := make([]types.Node, 0, len(added.MasterNodes)+len(added.WorkerNodes))
+		for _, n := range append(append([]types.Node{}, added.MasterNodes...), added.WorkerNodes...) {
+			if _, ok := p.ErrM[n.InstanceID]; !ok {
+				joined = append(joined, n)
+			}
+		}
+		notReady = p.waitForJoinedNodesReady(joined)
+		for id, reason := range notReady {
+			p.Logger.Warnf("[%s] instance %s isn't counted in cluster state: %s", merged.Provider, id, reason)
+		}
+	}
+
+	// sync master & worker numbers.
+	merged.Master = strconv.Itoa(len(removeNodes(merged.MasterNodes, notReady)))
+	merged.Worker = strconv.Itoa(len(removeNodes(merged.WorkerNodes, notReady)))
+
 	merged.Status.Status = common.StatusRunning
 	// write current cluster to state file.
 	if err = common.DefaultDB.SaveCluster(merged); err != nil {
 		p.Logger.Errorf("failed to save cluster state: %v", err)
 		return nil
 	}
+	p.annotateInstanceNodes(merged)
 
 	p.Logger.Infof("[%s] successfully executed join k3s node logic", merged.Provider)
 	return nil
@@ -315,14 +344,14 @@ func SSHK3sNode(ip string, cluster *types.Cluster, ssh *types.SSH) error {
 	var node types.Node
 
 	for _, n := range cluster.Status.MasterNodes {
-		if n.PublicIPAddress[0] == ip || n.InstanceID == ip {
+		if getFirstAddress(n.PublicIPAddress) == ip || n.InstanceID == ip {
 			node = n
 			break
 		}
 	}
 
 	for _, n := range cluster.Status.WorkerNodes {
-		if n.PublicIPAddress[0] == ip || n.InstanceID == ip {
+		if getFirstAddress(n.PublicIPAddress) == ip || n.InstanceID == ip {
 			node = n
 			break
 		}
@@ -422,26 +451,38 @@ func SaveCfg(cfg, ip, context string) error {
 }
 
 // DeployExtraManifest deploy extra K3S manifest.
+// the commands only write manifest files, so it's safe to retry on transient ssh failures.
 func (p *ProviderBase) DeployExtraManifest(cluster *types.Cluster, cmds []string) error {
-	if _, err := p.execute(&cluster.MasterNodes[0], []string{fmt.Sprintf("mkdir -p %s", common.K3sManifestsDir)}...); err != nil {
+	if _, err := p.executeWithRetry(3, &cluster.MasterNodes[0], []string{fmt.Sprintf("mkdir -p %s", common.K3sManifestsDir)}...); err != nil {
 		return err
 	}
-	if _, err := p.execute(&cluster.MasterNodes[0], cmds...); err != nil {
+	if _, err := p.executeWithRetry(3, &cluster.MasterNodes[0], cmds...); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (p *ProviderBase) initNode(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node types.Node, extraArgs string, pkg *common.Package) error {
+	if err := p.checkNodeResources(&node, cluster); err != nil {
+		return err
+	}
+
+	if err := p.handleHostname(&node, extraArgs); err != nil {
+		return err
+	}
+
 	if strings.Contains(extraArgs, "--docker") {
 		dockerCmd := fmt.Sprintf(dockerCommand, cluster.DockerScript, cluster.DockerArg, cluster.DockerMirror)
+		if proxyEnv := getNodeProxyEnv(cluster, fixedIP); proxyEnv != "" {
+			dockerCmd = proxyEnv + " " + dockerCmd
+		}
 		p.Logger.Infof("[cluster] install docker command %s", dockerCmd)
 		if _, err := p.execute(&node, dockerCmd); err != nil {
 			return err
 		}
 	}
 
-	if cluster.Registry != "" || cluster.RegistryContent != "" {
+	if cluster.Registry != "" || cluster.RegistryContent != "" || cluster.PullThroughCache || len(cluster.RegistryTLSContent) > 0 {
 		if err := p.handleRegistry(&node, cluster); err != nil {
 			return err
 		}
@@ -455,6 +496,24 @@ func (p *ProviderBase) initNode(isFirstMaster bool, fixedIP string, cluster *typ
 		}
 	}
 
+	if node.Master && (cluster.AuditPolicyFile != "" || cluster.AuditPolicyFileContent != "") {
+		if err := p.handleAuditPolicy(&node, cluster); err != nil {
+			return err
+		}
+	}
+
+	if isFirstMaster && node.Master && cluster.CACertFile != "" && cluster.CAKeyFile != "" {
+		if err := p.handleCustomCA(&node, cluster, extraArgs); err != nil {
+			return err
+		}
+	}
+
+	if cluster.Snapshotter != "" {
+		if err := p.handleSnapshotter(&node, cluster); err != nil {
+			return err
+		}
+	}
+
 	if pkg != nil {
 		if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs); err != nil {
 			return err
@@ -538,7 +597,7 @@ func terminal(n *types.Node) error {
 }
 
 func (p *ProviderBase) handleRegistry(n *types.Node, c *types.Cluster) (err error) {
-	if c.Registry == "" && c.RegistryContent == "" {
+	if c.Registry == "" && c.RegistryContent == "" && !c.PullThroughCache && len(c.RegistryTLSContent) == 0 {
 		return nil
 	}
 	var cmd []string
@@ -547,11 +606,15 @@ func (p *ProviderBase) handleRegistry(n *types.Node, c *types.Cluster) (err erro
 	if err != nil {
 		return err
 	}
+	if c.PullThroughCache {
+		withPullThroughCache(registry)
+	}
 
 	tls, err := registryTLSMap(registry)
 	if err != nil {
 		return err
 	}
+	withRegistryTLSContent(registry, tls, c.RegistryTLSContent)
 
 	if tls != nil && len(tls) > 0 {
 		cmd, err = saveRegistryTLS(registry, tls)
@@ -674,6 +737,34 @@ func GetClusterStatus(c *kubernetes.Clientset) string {
 	return types.ClusterStatusRunning
 }
 
+// WaitClusterStatus get cluster status using cluster's /readyz API, it retries until the timeout if the api-server
+// is starting, i.e. it refuses the connection or isn't ready yet. The unreachable cluster is reported at once.
+func WaitClusterStatus(c *kubernetes.Clientset, timeout time.Duration) string {
+	var lastErr error
+	if err := wait.PollImmediate(describeRetryInterval, timeout, func() (bool, error) {
+		_, lastErr = c.RESTClient().Get().Timeout(15 * time.Second).RequestURI("/readyz").DoRaw(context.TODO())
+		if lastErr == nil {
+			return true, nil
+		}
+		if timeout <= 0 || !isAPIServerStarting(lastErr) {
+			return false, lastErr
+		}
+		logrus.Debugf("waiting for api-server to be ready: %v", lastErr)
+		return false, nil
+	}); err != nil {
+		return types.ClusterStatusStopped
+	}
+	return types.ClusterStatusRunning
+}
+
+// isAPIServerStarting returns true if the host of api-server is reachable, so it's worth to wait.
+func isAPIServerStarting(err error) bool {
+	if _, ok := err.(apierrors.APIStatus); ok {
+		return true
+	}
+	return utilnet.IsConnectionRefused(err)
+}
+
 // GetClusterVersion get kube cluster version.
 func GetClusterVersion(c *kubernetes.Clientset) string {
 	v, err := c.DiscoveryClient.ServerVersion()
@@ -792,7 +883,11 @@ func (p *ProviderBase) Upgrade(cluster *types.Cluster) error {
 	publicIP := cluster.IP
 	if cluster.IP == "" {
 		cluster.IP = cluster.MasterNodes[0].InternalIPAddress[0]
-		publicIP = cluster.MasterNodes[0].PublicIPAddress[0]
+		publicIP = getFirstAddress(cluster.MasterNodes[0].PublicIPAddress)
+		if publicIP == "" {
+			// the master is private without public ip.
+			publicIP = cluster.IP
+		}
 	}
 
 	// upgrade server nodes
@@ -810,6 +905,9 @@ func (p *ProviderBase) Upgrade(cluster *types.Cluster) error {
 				return err
 			}
 			cmd = k3sRestart
+			if verifiedStart := getVerifiedStartCommand(cluster, node); verifiedStart != "" {
+				cmd = verifiedStart
+			}
 		} else {
 			cmd = getCommand(i == 0, publicIP, cluster, node, []string{extraArgs})
 		}
@@ -835,6 +933,9 @@ func (p *ProviderBase) Upgrade(cluster *types.Cluster) error {
 				return err
 			}
 			cmd = k3sAgentRestart
+			if verifiedStart := getVerifiedStartCommand(cluster, node); verifiedStart != "" {
+				cmd = verifiedStart
+			}
 		} else {
 			cmd = getCommand(false, publicIP, cluster, node, []string{extraArgs})
 		}
@@ -849,6 +950,15 @@ func (p *ProviderBase) Upgrade(cluster *types.Cluster) error {
 	return nil
 }
 
+// sortMasterNodesByRole sorts the masters running both etcd and control-plane first, followed by the etcd-only ones,
+// so that the control-plane-only masters join after etcd members.
+func sortMasterNodesByRole(nodes []types.Node) {
+	order := map[string]int{"": 0, types.MasterRoleEtcd: 1, types.MasterRoleControlPlane: 2}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return order[nodes[i].MasterRole] < order[nodes[j].MasterRole]
+	})
+}
+
 func nodeByInstanceID(nodes []types.Node) map[string]types.Node {
 	rtn := make(map[string]types.Node, len(nodes))
 	for _, node := range nodes {
@@ -936,7 +1046,7 @@ func (p *ProviderBase) initControlNode(cluster *types.Cluster, provider provider
 		masterExtraArgs += providerExtraArgs
 	}
 
-	return p.initNode(isFirst, publicIP, cluster, controlNode, masterExtraArgs, pkg)
+	return p.initNodeWithRetry(isFirst, publicIP, cluster, controlNode, masterExtraArgs, pkg)
 }
 
 func (p *ProviderBase) initWorkerNode(cluster *types.Cluster, provider providers.Provider, publicIP string, pkg *common.Package, workerNode types.Node) error {
@@ -945,5 +1055,5 @@ func (p *ProviderBase) initWorkerNode(cluster *types.Cluster, provider providers
 	if providerExtraArgs != "" {
 		workerExtraArgs += providerExtraArgs
 	}
-	return p.initNode(false, publicIP, cluster, workerNode, workerExtraArgs, pkg)
+	return p.initNodeWithRetry(false, publicIP, cluster, workerNode, workerExtraArgs, pkg)
 }
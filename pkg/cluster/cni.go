@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+)
+
+const (
+	// CNICalico installs calico by tigera-operator instead of flannel.
+	CNICalico = "calico"
+	// CNICilium installs cilium instead of flannel.
+	CNICilium = "cilium"
+	// CNINone disables flannel and leaves the cni to be installed by users.
+	CNINone = "none"
+
+	calicoVersion = "v3.26.1"
+	ciliumVersion = "1.14.2"
+)
+
+var (
+	deployCNICommand = "echo \"%s\" | base64 -d | tee \"%s/cni-%s.yaml\""
+	// the charts are installed with bootstrap, so the helm jobs run on host network before any cni is ready.
+	calicoTmpl = `
+apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  name: tigera-operator
+  namespace: kube-system
+spec:
+  bootstrap: true
+  targetNamespace: tigera-operator
+  createNamespace: true
+  repo: https://docs.tigera.io/calico/charts
+  chart: tigera-operator
+  version: %[1]s
+  valuesContent: |-
+    installation:
+      calicoNetwork:
+        containerIPForwarding: Enabled
+        ipPools:
+          - cidr: %[2]s
+            encapsulation: VXLAN
+---
+`
+	ciliumTmpl = `
+apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  name: cilium
+  namespace: kube-system
+spec:
+  bootstrap: true
+  targetNamespace: kube-system
+  repo: https://helm.cilium.io
+  chart: cilium
+  version: %[1]s
+  valuesContent: |-
+    operator:
+      replicas: 1
+    ipam:
+      operator:
+        clusterPoolIPv4PodCIDRList:
+          - %[2]s
+---
+`
+)
+
+// CNIPort is a port which must be reachable between nodes for the pod network.
+type CNIPort struct {
+	Protocol    string
+	Port        string
+	Description string
+}
+
+// GetCNIPorts returns the ports required between nodes by the cni, flannel backend is used if cni is empty.
+func GetCNIPorts(cni, network string) []CNIPort {
+	switch cni {
+	case "":
+		if network == "" || network == "vxlan" {
+			return []CNIPort{{Protocol: "UDP", Port: "8472", Description: "k3s vxlan"}}
+		}
+	case CNICalico:
+		return []CNIPort{
+			{Protocol: "UDP", Port: "4789", Description: "calico vxlan"},
+			{Protocol: "TCP", Port: "5473", Description: "calico typha"},
+		}
+	case CNICilium:
+		return []CNIPort{
+			{Protocol: "UDP", Port: "8472", Description: "cilium vxlan"},
+			{Protocol: "TCP", Port: "4240", Description: "cilium health check"},
+		}
+	}
+	return nil
+}
+
+// getCNICommand returns the command which deploys the cni, it's empty if the cni isn't installed by autok3s.
+func getCNICommand(cni, clusterCidr string) string {
+	if clusterCidr == "" {
+		clusterCidr = defaultCidr
+	}
+	var tmpl string
+	switch cni {
+	case CNICalico:
+		tmpl = fmt.Sprintf(calicoTmpl, calicoVersion, clusterCidr)
+	case CNICilium:
+		tmpl = fmt.Sprintf(ciliumTmpl, ciliumVersion, clusterCidr)
+	default:
+		return ""
+	}
+	return fmt.Sprintf(deployCNICommand, base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir, cni)
+}
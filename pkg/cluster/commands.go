@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strings"
 
@@ -20,6 +21,42 @@ var (
 	workerUninstallCommand = "[ -x /usr/local/bin/k3s-agent-uninstall.sh ] && sh /usr/local/bin/k3s-agent-uninstall.sh || true"
 	k3sRestart             = `if [ -n "$(command -v systemctl)" ]; then systemctl restart k3s; elif [ -n "$(command -v service)" ]; then service k3s restart; fi`
 	k3sAgentRestart        = `if [ -n "$(command -v systemctl)" ]; then systemctl restart k3s-agent; elif [ -n "$(command -v service)" ]; then service k3s-agent restart; fi`
+	k3sStop                = `if [ -n "$(command -v systemctl)" ]; then systemctl stop k3s; elif [ -n "$(command -v service)" ]; then service k3s stop; fi`
+	k3sClusterReset        = `for f in /etc/systemd/system/k3s.service.env /etc/rancher/k3s/k3s.env; do if [ -f "$f" ]; then set -a && . "$f" && set +a; fi; done; k3s server --cluster-reset`
+	backupEtcdDataCommand  = "if [ -d /var/lib/rancher/k3s/server/db ]; then mv /var/lib/rancher/k3s/server/db /var/lib/rancher/k3s/server/db.bak-%d; fi"
+	k3sReadyCommand        = "k3s kubectl get --raw=/readyz"
+	// the local kubeconfig uses the token of the autok3s-admin service account, the token is revoked with its secret.
+	adminTokenManifestCommand = `cat <<EOF | k3s kubectl apply -f -
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: autok3s-admin
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: autok3s-admin
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: autok3s-admin
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: autok3s-admin-token
+  namespace: kube-system
+  annotations:
+    kubernetes.io/service-account.name: autok3s-admin
+type: kubernetes.io/service-account-token
+EOF`
+	deleteAdminTokenCommand = "k3s kubectl -n kube-system delete secret autok3s-admin-token --ignore-not-found"
+	getAdminTokenCommand    = "k3s kubectl -n kube-system get secret autok3s-admin-token -o jsonpath='{.data.token}'"
 )
 
 // getCommand first node should be init
@@ -54,15 +91,29 @@ func getCommand(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node
 	}
 
 	runArgs := getRunArgs(isFirstMaster, fixedIP, cluster, node)
+	if node.Master {
+		runArgs = append(runArgs, getAuditArgs(cluster, extraArgs)...)
+	}
 	runArgs = append(runArgs, extraArgs...)
 	envVar["INSTALL_K3S_EXEC"] = strings.Join(runArgs, " ")
+	verifiedStart := getVerifiedStartCommand(cluster, node)
+	if verifiedStart != "" {
+		envVar["INSTALL_K3S_SKIP_START"] = "true"
+	}
 
 	sortedEnvVars := []string{}
 	for k, v := range envVar {
 		sortedEnvVars = append(sortedEnvVars, fmt.Sprintf("%s='%s'", k, v))
 	}
 	sort.Strings(sortedEnvVars)
-	return strings.TrimSpace(fmt.Sprintf("%s %s %s", commandPrefix, strings.Join(sortedEnvVars, " "), commandSuffix))
+	if proxyEnv := getNodeProxyEnv(cluster, fixedIP); proxyEnv != "" {
+		commandPrefix = strings.TrimSpace(proxyEnv + " " + commandPrefix)
+	}
+	command := strings.TrimSpace(fmt.Sprintf("%s %s %s", commandPrefix, strings.Join(sortedEnvVars, " "), commandSuffix))
+	if verifiedStart != "" {
+		command = fmt.Sprintf("{ %s; } && %s", command, verifiedStart)
+	}
+	return command
 }
 
 func getTLSSans(cluster *types.Cluster) []string {
@@ -96,6 +147,25 @@ func getFirstAddress(addresses []string) string {
 	return ""
 }
 
+// getNodeAddress returns the address set by `--advertise-address` or `--node-ip` for the node.
+// The value is either an ip which is used as is, or a cidr which selects the node's address within it,
+// and the primary private ip of the node is used if none of the addresses is within the cidr.
+func getNodeAddress(value string, node types.Node) string {
+	if net.ParseIP(value) != nil {
+		return value
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return getFirstAddress(node.InternalIPAddress)
+	}
+	for _, addr := range append(append([]string{}, node.InternalIPAddress...), node.PublicIPAddress...) {
+		if ip := net.ParseIP(addr); ip != nil && ipNet.Contains(ip) {
+			return addr
+		}
+	}
+	return getFirstAddress(node.InternalIPAddress)
+}
+
 func getRunArgs(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node types.Node) []string {
 	runArgs := []string{}
 
@@ -126,25 +196,56 @@ func getRunArgs(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node
 			runArgs = append(runArgs, "--server="+fmt.Sprintf("https://%s:6443", fixedIP))
 		}
 
+		if isCluster {
+			// the cron expression contains spaces, it's quoted to be kept as one arg by the install script.
+			if cluster.EtcdSnapshotScheduleCron != "" {
+				runArgs = append(runArgs, fmt.Sprintf("--etcd-snapshot-schedule-cron=\"%s\"", cluster.EtcdSnapshotScheduleCron))
+			}
+			if cluster.EtcdSnapshotRetention > 0 {
+				runArgs = append(runArgs, fmt.Sprintf("--etcd-snapshot-retention=%d", cluster.EtcdSnapshotRetention))
+			}
+			if cluster.EtcdSnapshotDir != "" {
+				runArgs = append(runArgs, "--etcd-snapshot-dir="+cluster.EtcdSnapshotDir)
+			}
+		}
+
 		if cluster.ClusterCidr != "" {
 			runArgs = append(runArgs, "--cluster-cidr="+cluster.ClusterCidr)
 		}
 
+		if cluster.ClusterDomain != "" {
+			runArgs = append(runArgs, "--cluster-domain="+cluster.ClusterDomain)
+		}
+
+		// flannel and the embedded network policy controller are replaced by the cni.
+		if cluster.CNI != "" {
+			runArgs = append(runArgs, "--flannel-backend=none", "--disable-network-policy")
+		}
+
 		for _, san := range getTLSSans(cluster) {
 			runArgs = append(runArgs, tlsSanArg+"="+san)
 		}
 
 		internalIPAddress := getFirstAddress(node.InternalIPAddress)
+		if cluster.AdvertiseAddress != "" {
+			internalIPAddress = getNodeAddress(cluster.AdvertiseAddress, node)
+		}
 		if internalIPAddress != "" {
 			runArgs = append(runArgs, "--advertise-address="+internalIPAddress)
 		}
 	}
 
+	if cluster.NodeIP != "" {
+		if nodeIP := getNodeAddress(cluster.NodeIP, node); nodeIP != "" {
+			runArgs = append(runArgs, "--node-ip="+nodeIP)
+		}
+	}
+
 	if externalAddr := getFirstAddress(node.PublicIPAddress); externalAddr != "" {
 		runArgs = append(runArgs, "--node-external-ip="+externalAddr)
 	}
 
-	if cluster.Network != "" {
+	if cluster.Network != "" && cluster.CNI == "" {
 		runArgs = append(runArgs, "--flannel-backend="+cluster.Network)
 	}
 
@@ -152,6 +253,10 @@ func getRunArgs(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node
 		runArgs = append(runArgs, "--system-default-registry="+cluster.SystemDefaultRegistry)
 	}
 
+	if cluster.Snapshotter != "" {
+		runArgs = append(runArgs, "--snapshotter="+cluster.Snapshotter)
+	}
+
 	sort.Strings(runArgs)
 	if node.Master {
 		// ensure server arg is the first one
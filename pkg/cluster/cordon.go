@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// CordonCluster marks the nodes of the cluster as unschedulable, only the named node is cordoned if node is not empty.
+func (p *ProviderBase) CordonCluster(node string) error {
+	return p.setUnschedulable(node, true)
+}
+
+// UncordonCluster marks the nodes of the cluster as schedulable, only the named node is uncordoned if node is not empty.
+func (p *ProviderBase) UncordonCluster(node string) error {
+	return p.setUnschedulable(node, false)
+}
+
+func (p *ProviderBase) setUnschedulable(name string, desired bool) error {
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	client, err := GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return fmt.Errorf("[%s] failed to load kubeconfig of cluster %s: %v", p.Provider, p.ContextName, err)
+	}
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("[%s] failed to list nodes of cluster %s: %v", p.Provider, p.ContextName, err)
+	}
+
+	// only the nodes of the cluster's instances are touched, which are matched by internal ip.
+	instanceIPs := map[string]bool{}
+	for _, nodes := range [][]types.Node{p.Status.MasterNodes, p.Status.WorkerNodes} {
+		for _, n := range nodes {
+			for _, ip := range n.InternalIPAddress {
+				instanceIPs[ip] = true
+			}
+		}
+	}
+	found := false
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if name != "" && node.Name != name {
+			continue
+		}
+		if !isInstanceNode(node, instanceIPs) {
+			continue
+		}
+		found = true
+		helper := drain.NewCordonHelper(node)
+		if !helper.UpdateIfRequired(desired) {
+			continue
+		}
+		err, patchErr := helper.PatchOrReplaceWithContext(context.TODO(), client, false)
+		if patchErr != nil {
+			err = patchErr
+		}
+		if err != nil {
+			return fmt.Errorf("[%s] failed to set node %s unschedulable to %t: %v", p.Provider, node.Name, desired, err)
+		}
+		p.Logger.Infof("[%s] node %s is set unschedulable to %t", p.Provider, node.Name, desired)
+	}
+	if name != "" && !found {
+		return fmt.Errorf("[%s] node %s is not found in cluster %s", p.Provider, name, p.ContextName)
+	}
+	return nil
+}
+
+func isInstanceNode(node *v1.Node, instanceIPs map[string]bool) bool {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP && instanceIPs[addr.Address] {
+			return true
+		}
+	}
+	return false
+}
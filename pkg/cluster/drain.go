@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// DrainNodes cordons and drains all nodes of the cluster before the instances are terminated.
+// Evictions respect PodDisruptionBudgets until the timeout, draining is skipped if the api-server is unreachable.
+func (p *ProviderBase) DrainNodes(timeout time.Duration) error {
+	client, err := GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to load kubeconfig of cluster %s, skip draining nodes: %v", p.Provider, p.ContextName, err)
+		return nil
+	}
+	if GetClusterStatus(client) != types.ClusterStatusRunning {
+		p.Logger.Warnf("[%s] api-server of cluster %s is unreachable, skip draining nodes", p.Provider, p.ContextName)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("[%s] failed to list nodes of cluster %s: %v", p.Provider, p.ContextName, err)
+	}
+
+	out, errOut := p.Logger.Writer(), p.Logger.WriterLevel(logrus.WarnLevel)
+	defer func() {
+		_ = out.Close()
+		_ = errOut.Close()
+	}()
+	helper := &drain.Helper{
+		Ctx:                 ctx,
+		Client:              client,
+		Force:               true,
+		GracePeriodSeconds:  -1,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  true,
+		Timeout:             timeout,
+		Out:                 out,
+		ErrOut:              errOut,
+	}
+	// cordon all nodes first, so the evicted pods won't be scheduled to the nodes which are going to be drained.
+	for i := range nodes.Items {
+		if err = drain.RunCordonOrUncordon(helper, &nodes.Items[i], true); err != nil {
+			return fmt.Errorf("[%s] failed to cordon node %s: %v", p.Provider, nodes.Items[i].Name, err)
+		}
+	}
+	for _, node := range nodes.Items {
+		p.Logger.Infof("[%s] draining node %s...", p.Provider, node.Name)
+		if err = drain.RunNodeDrain(helper, node.Name); err != nil {
+			return fmt.Errorf("[%s] failed to drain node %s: %v", p.Provider, node.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	apiServerPort        = "6443"
+	endpointProbeTimeout = 3 * time.Second
+)
+
+// failoverKubeconfig switches the server of the kubeconfig to a reachable master, if the cluster has multiple masters
+// and the kubeconfig points to one of them, i.e. there is no load balancer or floating ip in front of the masters.
+// Every master serves with the ips of all masters in tls sans, so the kubeconfig works with any of them.
+// It returns the client of the switched kubeconfig, or nil if it's not switched.
+func (p *ProviderBase) failoverKubeconfig(kubeCfg string) *kubernetes.Clientset {
+	state, err := common.DefaultDB.GetClusterByID(p.ContextName)
+	if err != nil || state == nil {
+		return nil
+	}
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) < 2 {
+		return nil
+	}
+	config, err := clientcmd.LoadFromFile(kubeCfg)
+	if err != nil {
+		return nil
+	}
+	context, ok := config.Contexts[p.ContextName]
+	if !ok {
+		return nil
+	}
+	cluster, ok := config.Clusters[context.Cluster]
+	if !ok {
+		return nil
+	}
+	server, err := url.Parse(cluster.Server)
+	if err != nil || !isMasterAddress(c.MasterNodes, server.Hostname()) {
+		return nil
+	}
+	port := server.Port()
+	if port == "" {
+		port = apiServerPort
+	}
+	address := selectReachableMaster(c.MasterNodes, server.Hostname(), port)
+	if address == "" {
+		return nil
+	}
+	cluster.Server = fmt.Sprintf("https://%s", net.JoinHostPort(address, port))
+	if err = clientcmd.WriteToFile(*config, kubeCfg); err != nil {
+		p.Logger.Errorf("[%s] failed to switch kubeconfig of cluster %s to master %s: %v", p.Provider, p.ContextName, address, err)
+		return nil
+	}
+	p.Logger.Warnf("[%s] master %s of cluster %s is unreachable, kubeconfig is switched to master %s",
+		p.Provider, server.Hostname(), p.ContextName, address)
+	client, err := GetClusterConfig(p.ContextName, kubeCfg)
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+// selectReachableMaster returns the address of the first master which accepts connections of api-server,
+// the public ip is preferred. The excluded address is skipped, it's empty if none of the masters is reachable.
+func selectReachableMaster(masters []types.Node, exclude, port string) string {
+	for _, m := range masters {
+		address := getFirstAddress(m.PublicIPAddress)
+		if address == "" {
+			address = getFirstAddress(m.InternalIPAddress)
+		}
+		if address == "" || address == exclude || isMasterAddress([]types.Node{m}, exclude) {
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(address, port), endpointProbeTimeout)
+		if err != nil {
+			continue
+		}
+		_ = conn.Close()
+		return address
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package cluster
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectReachableMaster(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	masters := []types.Node{
+		{InstanceID: "ins-1", PublicIPAddress: []string{"1.2.3.4"}, InternalIPAddress: []string{"10.0.0.2"}},
+		{InstanceID: "ins-2", InternalIPAddress: []string{"127.0.0.1"}},
+	}
+	assert.Equal(t, "127.0.0.1", selectReachableMaster(masters, "1.2.3.4", port))
+	// the private ip of the excluded master is skipped as well.
+	assert.Equal(t, "", selectReachableMaster(masters, "127.0.0.1", port))
+}
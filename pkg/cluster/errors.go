@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrClusterExists is matched by errors.Is when creating a cluster which already exists.
+	ErrClusterExists = errors.New("cluster already exists")
+	// ErrClusterNotFound is matched by errors.Is when deleting or joining a cluster which doesn't exist.
+	ErrClusterNotFound = errors.New("cluster not found")
+)
+
+// clusterError keeps the message of the error, while it can be matched by errors.Is with the typed error.
+type clusterError struct {
+	err error
+	msg string
+}
+
+func (e *clusterError) Error() string {
+	return e.msg
+}
+
+func (e *clusterError) Unwrap() error {
+	return e.err
+}
+
+// NewClusterExistsError returns the formatted error which matches ErrClusterExists.
+func NewClusterExistsError(format string, a ...interface{}) error {
+	return &clusterError{err: ErrClusterExists, msg: fmt.Sprintf(format, a...)}
+}
+
+// NewClusterNotFoundError returns the formatted error which matches ErrClusterNotFound.
+func NewClusterNotFoundError(format string, a ...interface{}) error {
+	return &clusterError{err: ErrClusterNotFound, msg: fmt.Sprintf(format, a...)}
+}
+
+// ValidationErrors aggregates the problems found by the offline preflight checks, so that all of them are reported
+// at once instead of one per run. Each problem can still be matched by errors.Is and errors.As.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// NewValidationErrors returns nil if there's no problem, the error itself if there's only one, otherwise the
+// ValidationErrors of all problems. The nested ValidationErrors are flattened.
+func NewValidationErrors(errs ...error) error {
+	problems := make(ValidationErrors, 0, len(errs))
+	for _, err := range errs {
+		if nested, ok := err.(ValidationErrors); ok {
+			problems = append(problems, nested...)
+		} else if err != nil {
+			problems = append(problems, err)
+		}
+	}
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return problems[0]
+	}
+	return problems
+}
@@ -0,0 +1,38 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterError(t *testing.T) {
+	err := NewClusterExistsError("[%s] cluster %s is already exist", "tencent", "myk3s")
+	assert.EqualError(t, err, "[tencent] cluster myk3s is already exist")
+	assert.True(t, errors.Is(err, ErrClusterExists))
+	assert.False(t, errors.Is(err, ErrClusterNotFound))
+
+	err = fmt.Errorf("failed to join: %w", NewClusterNotFoundError("[%s] cluster %s is not exist", "tencent", "myk3s"))
+	assert.True(t, errors.Is(err, ErrClusterNotFound))
+	assert.False(t, errors.Is(err, ErrClusterExists))
+}
+
+func TestValidationErrors(t *testing.T) {
+	assert.Nil(t, NewValidationErrors())
+	assert.Nil(t, NewValidationErrors(nil, nil))
+
+	single := fmt.Errorf("[tencent] calling preflight error: `--master` number must >= 1")
+	assert.Equal(t, single, NewValidationErrors(nil, single))
+
+	notFound := NewClusterNotFoundError("[%s] cluster %s is not exist", "tencent", "myk3s")
+	err := NewValidationErrors(single, NewValidationErrors(notFound, errors.New("invalid `--zone`")))
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	// the nested problems are flattened.
+	assert.Len(t, errs, 3)
+	assert.EqualError(t, err, "[tencent] calling preflight error: `--master` number must >= 1\n"+
+		"[tencent] cluster myk3s is not exist\ninvalid `--zone`")
+	assert.True(t, errors.Is(err, ErrClusterNotFound))
+}
@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/hosts/dialer"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+const (
+	// NodeSelectorAll selects all the nodes of the cluster.
+	NodeSelectorAll = "all"
+	// NodeSelectorMasters selects the master nodes of the cluster.
+	NodeSelectorMasters = "masters"
+	// NodeSelectorWorkers selects the worker nodes of the cluster.
+	NodeSelectorWorkers = "workers"
+)
+
+// SSHExec runs the command on the nodes matched by the selector concurrently, the selector is one of
+// `all`, `masters`, `workers` or an instance id. The results are in the order of masters and then workers,
+// the failure of a node is recorded in its result instead of stopping the others.
+func (p *ProviderBase) SSHExec(selector, command string) ([]types.NodeCommandResult, error) {
+	if p.Provider == "k3d" {
+		return nil, errors.New("running command for K3d provider is not supported yet")
+	}
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	c := common.ConvertToCluster(state, true)
+	nodes, err := selectNodes(&c, selector)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] %v", p.Provider, err)
+	}
+
+	results := make([]types.NodeCommandResult, len(nodes))
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = p.execNodeCommand(&nodes[i], command)
+		}(i)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (p *ProviderBase) execNodeCommand(n *types.Node, command string) types.NodeCommandResult {
+	result := types.NodeCommandResult{InstanceID: n.InstanceID, Master: n.Master}
+	d, err := dialer.NewSSHDialer(n, true, p.Logger)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+	result.Stdout, result.Stderr, result.ExitCode, err = d.ExecuteCommand(command)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// selectNodes returns the nodes of the cluster matched by the selector.
+func selectNodes(c *types.Cluster, selector string) ([]types.Node, error) {
+	nodes := make([]types.Node, 0)
+	switch selector {
+	case NodeSelectorAll:
+		nodes = append(append(nodes, c.MasterNodes...), c.WorkerNodes...)
+	case NodeSelectorMasters:
+		nodes = append(nodes, c.MasterNodes...)
+	case NodeSelectorWorkers:
+		nodes = append(nodes, c.WorkerNodes...)
+	default:
+		for _, n := range append(append(nodes, c.MasterNodes...), c.WorkerNodes...) {
+			if n.InstanceID == selector {
+				return []types.Node{n}, nil
+			}
+		}
+		return nil, fmt.Errorf("node %s is not found in cluster %s, must be one of %s, %s, %s or an instance id",
+			selector, c.Name, NodeSelectorAll, NodeSelectorMasters, NodeSelectorWorkers)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no %s node is found in cluster %s", selector, c.Name)
+	}
+	return nodes, nil
+}
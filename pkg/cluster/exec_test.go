@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectNodes(t *testing.T) {
+	c := &types.Cluster{Metadata: types.Metadata{Name: "myk3s"}}
+	c.MasterNodes = []types.Node{{InstanceID: "ins-m1", Master: true}, {InstanceID: "ins-m2", Master: true}}
+	c.WorkerNodes = []types.Node{{InstanceID: "ins-w1"}}
+
+	nodes, err := selectNodes(c, NodeSelectorAll)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 3)
+	assert.Equal(t, "ins-w1", nodes[2].InstanceID)
+
+	nodes, err = selectNodes(c, NodeSelectorMasters)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	nodes, err = selectNodes(c, "ins-w1")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Node{{InstanceID: "ins-w1"}}, nodes)
+
+	_, err = selectNodes(c, "ins-w2")
+	assert.Error(t, err)
+
+	c.WorkerNodes = nil
+	_, err = selectNodes(c, NodeSelectorWorkers)
+	assert.Error(t, err)
+}
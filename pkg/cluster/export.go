@@ -0,0 +1,375 @@
+package cluster
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	exportVersion      = 1
+	exportManifestFile = "manifest.json"
+	exportStateFile    = "state.json"
+	exportKubeCfgFile  = "kubeconfig"
+	// exportFilesDir holds the files of cluster store by the path relative to the config path, e.g. the ssh keys.
+	exportFilesDir = "files/"
+)
+
+// exportManifest describes the exported cluster, the config path is used to relocate the file paths of the state.
+type exportManifest struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Provider    string `json:"provider"`
+	ContextName string `json:"context-name"`
+	CfgPath     string `json:"config-path"`
+}
+
+// clusterArchive is the content of an exported cluster.
+type clusterArchive struct {
+	manifest exportManifest
+	state    *common.ClusterState
+	kubeCfg  []byte
+	files    map[string][]byte
+}
+
+// Export writes the state, kubeconfig and the files of cluster store like ssh keys to a tar.gz archive,
+// which can be imported by another machine to manage the same cluster.
+func (p *ProviderBase) Export(path string) error {
+	if p.Provider == "k3d" {
+		return errors.New("exporting cluster for K3d provider is not supported yet")
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	archive := &clusterArchive{
+		manifest: exportManifest{
+			Version:     exportVersion,
+			Name:        state.Name,
+			Provider:    state.Provider,
+			ContextName: state.ContextName,
+			CfgPath:     common.CfgPath,
+		},
+		state: state,
+		files: map[string][]byte{},
+	}
+
+	kubeCfgFile, err := os.CreateTemp("", common.KubeCfgTempName)
+	if err != nil {
+		return err
+	}
+	_ = kubeCfgFile.Close()
+	defer func() {
+		_ = os.Remove(kubeCfgFile.Name())
+	}()
+	// the cluster without K3s installed, e.g. created with `--skip-install`, has no kubeconfig.
+	if err = common.FileManager.ExportCfg(state.ContextName, kubeCfgFile.Name()); err != nil {
+		logrus.Warnf("[%s] kubeconfig of cluster %s is not exported: %v", p.Provider, p.Name, err)
+		archive.kubeCfg = []byte{}
+	} else if archive.kubeCfg, err = os.ReadFile(kubeCfgFile.Name()); err != nil {
+		return err
+	}
+
+	for _, dir := range []string{common.GetClusterPath(state.Name, state.Provider), common.GetClusterContextPath(state.ContextName)} {
+		if err = archive.addFiles(dir); err != nil {
+			return err
+		}
+	}
+	c := common.ConvertToCluster(state, true)
+	for _, keyPath := range getSSHFilePaths(&c) {
+		if _, ok := getRelativeCfgPath(common.CfgPath, keyPath); !ok {
+			logrus.Warnf("[%s] %s is out of %s, it's not exported and must be copied to the same path of the new machine",
+				p.Provider, keyPath, common.CfgPath)
+		}
+	}
+
+	if err = archive.write(path); err != nil {
+		return err
+	}
+	logrus.Infof("[%s] successfully exported cluster %s to %s", p.Provider, p.Name, path)
+	return nil
+}
+
+// Import reads the archive of exported cluster into the local store, the existing cluster with the same name
+// is overwritten only if force is true.
+func (p *ProviderBase) Import(path string, force bool) error {
+	archive, err := readClusterArchive(path)
+	if err != nil {
+		return err
+	}
+	if archive.manifest.Provider != p.Provider {
+		return fmt.Errorf("[%s] the archive is exported from provider %s", p.Provider, archive.manifest.Provider)
+	}
+	existing, err := common.DefaultDB.GetCluster(archive.manifest.Name, archive.manifest.Provider)
+	if err != nil {
+		return err
+	}
+	if existing != nil && !force {
+		return fmt.Errorf("[%s] cluster %s is already exist, use `--force` to overwrite it", p.Provider, archive.manifest.Name)
+	}
+
+	for name, content := range archive.files {
+		target := filepath.Join(common.CfgPath, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(target, content, 0600); err != nil {
+			return err
+		}
+	}
+
+	if len(archive.kubeCfg) > 0 {
+		if err = importKubeCfg(archive.manifest.ContextName, archive.kubeCfg); err != nil {
+			return err
+		}
+	}
+
+	c := common.ConvertToCluster(archive.state, true)
+	relocateSSHFilePaths(&c, archive.manifest.CfgPath, common.CfgPath)
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	logrus.Infof("[%s] successfully imported cluster %s from %s", p.Provider, archive.manifest.Name, path)
+	return nil
+}
+
+func importKubeCfg(context string, kubeCfg []byte) error {
+	kubeCfgFile, err := os.CreateTemp("", common.KubeCfgTempName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(kubeCfgFile.Name())
+	}()
+	_, err = kubeCfgFile.Write(kubeCfg)
+	_ = kubeCfgFile.Close()
+	if err != nil {
+		return err
+	}
+	return common.FileManager.SaveCfg(context, kubeCfgFile.Name())
+}
+
+// GetArchiveProvider returns the provider of the exported cluster archive.
+func GetArchiveProvider(path string) (string, error) {
+	archive, err := readClusterArchive(path)
+	if err != nil {
+		return "", err
+	}
+	return archive.manifest.Provider, nil
+}
+
+// addFiles adds the files of the dir except logs to the archive, nothing is added if the dir doesn't exist.
+func (a *clusterArchive) addFiles(dir string) error {
+	return filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || file == common.GetClusterLogFilePath(a.manifest.ContextName) {
+			return nil
+		}
+		name, ok := getRelativeCfgPath(common.CfgPath, file)
+		if !ok {
+			return nil
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		a.files[name] = content
+		return nil
+	})
+}
+
+func (a *clusterArchive) write(path string) error {
+	manifest, err := json.MarshalIndent(a.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	state, err := json.MarshalIndent(a.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	entries := map[string][]byte{
+		exportManifestFile: manifest,
+		exportStateFile:    state,
+		exportKubeCfgFile:  a.kubeCfg,
+	}
+	for name, content := range a.files {
+		entries[exportFilesDir+name] = content
+	}
+	// the manifest is written first, so the archive can be identified by the first entry.
+	names := []string{exportManifestFile, exportStateFile, exportKubeCfgFile}
+	for name := range a.files {
+		names = append(names, exportFilesDir+name)
+	}
+	for _, name := range names {
+		if err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(entries[name]))}); err != nil {
+			return err
+		}
+		if _, err = tw.Write(entries[name]); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// readClusterArchive reads and validates the archive of exported cluster.
+func readClusterArchive(path string) (*clusterArchive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %v", path, err)
+	}
+	archive := &clusterArchive{files: map[string][]byte{}}
+	var manifest, state []byte
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %v", path, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case header.Name == exportManifestFile:
+			manifest = content
+		case header.Name == exportStateFile:
+			state = content
+		case header.Name == exportKubeCfgFile:
+			archive.kubeCfg = content
+		case strings.HasPrefix(header.Name, exportFilesDir):
+			name := strings.TrimPrefix(header.Name, exportFilesDir)
+			// the files must stay in the config path.
+			if !isSafeArchivePath(name) {
+				return nil, fmt.Errorf("invalid file %s in archive %s", header.Name, path)
+			}
+			archive.files[name] = content
+		}
+	}
+	if manifest == nil || state == nil || archive.kubeCfg == nil {
+		return nil, fmt.Errorf("invalid archive %s: %s, %s and %s are required", path, exportManifestFile, exportStateFile, exportKubeCfgFile)
+	}
+	if err = json.Unmarshal(manifest, &archive.manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s in archive %s: %v", exportManifestFile, path, err)
+	}
+	if archive.manifest.Version != exportVersion {
+		return nil, fmt.Errorf("unsupported version %d of archive %s", archive.manifest.Version, path)
+	}
+	archive.state = &common.ClusterState{}
+	if err = json.Unmarshal(state, archive.state); err != nil {
+		return nil, fmt.Errorf("invalid %s in archive %s: %v", exportStateFile, path, err)
+	}
+	if archive.state.Name != archive.manifest.Name || archive.state.Provider != archive.manifest.Provider ||
+		archive.state.ContextName != archive.manifest.ContextName {
+		return nil, fmt.Errorf("the state of archive %s doesn't match cluster %s", path, archive.manifest.ContextName)
+	}
+	if len(archive.kubeCfg) == 0 {
+		return archive, nil
+	}
+	kubeCfg, err := clientcmd.Load(archive.kubeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in archive %s: %v", exportKubeCfgFile, path, err)
+	}
+	if _, ok := kubeCfg.Contexts[archive.manifest.ContextName]; !ok {
+		return nil, fmt.Errorf("context %s is not exist in %s of archive %s", archive.manifest.ContextName, exportKubeCfgFile, path)
+	}
+	return archive, nil
+}
+
+func isSafeArchivePath(name string) bool {
+	return name != "" && !path.IsAbs(name) && path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+// getRelativeCfgPath returns the slash separated path relative to the config path if the file is in it.
+func getRelativeCfgPath(cfgPath, file string) (string, bool) {
+	rel, err := filepath.Rel(cfgPath, utils.StripUserHome(file))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// getSSHFilePaths returns the ssh key and certificate paths used by the cluster and its nodes.
+func getSSHFilePaths(c *types.Cluster) []string {
+	paths := make([]string, 0)
+	seen := map[string]bool{}
+	for _, ssh := range getClusterSSH(c) {
+		for _, file := range []string{ssh.SSHKeyPath, ssh.SSHCertPath} {
+			if keyPath, ok := utils.SSHKeyFilePath(file); file != "" && ok && !seen[keyPath] {
+				seen[keyPath] = true
+				paths = append(paths, keyPath)
+			}
+		}
+	}
+	return paths
+}
+
+// relocateSSHFilePaths replaces the old config path of the ssh file paths with the new one.
+func relocateSSHFilePaths(c *types.Cluster, oldCfgPath, newCfgPath string) {
+	for _, ssh := range getClusterSSH(c) {
+		for _, file := range []*string{&ssh.SSHKeyPath, &ssh.SSHCertPath} {
+			if keyPath, ok := utils.SSHKeyFilePath(*file); *file != "" && ok {
+				if rel, ok := getRelativeCfgPath(oldCfgPath, keyPath); ok {
+					*file = filepath.Join(newCfgPath, filepath.FromSlash(rel))
+				}
+			}
+		}
+	}
+}
+
+func getClusterSSH(c *types.Cluster) []*types.SSH {
+	ssh := []*types.SSH{&c.SSH}
+	for _, nodes := range [][]types.Node{c.MasterNodes, c.WorkerNodes} {
+		for i := range nodes {
+			ssh = append(ssh, &nodes[i].SSH)
+		}
+	}
+	return ssh
+}
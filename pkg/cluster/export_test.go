@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKubeCfg = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://10.0.0.2:6443
+  name: myk3s.ap-guangzhou.tencent
+contexts:
+- context:
+    cluster: myk3s.ap-guangzhou.tencent
+    user: myk3s.ap-guangzhou.tencent
+  name: myk3s.ap-guangzhou.tencent
+current-context: myk3s.ap-guangzhou.tencent
+users:
+- name: myk3s.ap-guangzhou.tencent
+  user:
+    token: token
+`
+
+func TestClusterArchive(t *testing.T) {
+	state := &common.ClusterState{Metadata: types.Metadata{
+		Name:        "myk3s",
+		Provider:    "tencent",
+		ContextName: "myk3s.ap-guangzhou.tencent",
+	}}
+	archive := &clusterArchive{
+		manifest: exportManifest{
+			Version:     exportVersion,
+			Name:        state.Name,
+			Provider:    state.Provider,
+			ContextName: state.ContextName,
+			CfgPath:     "/root/.autok3s",
+		},
+		state:   state,
+		kubeCfg: []byte(testKubeCfg),
+		files:   map[string][]byte{"tencent/clusters/myk3s.ap-guangzhou.tencent/id_rsa": []byte("key")},
+	}
+	path := filepath.Join(t.TempDir(), "myk3s.tar.gz")
+	assert.NoError(t, archive.write(path))
+
+	read, err := readClusterArchive(path)
+	assert.NoError(t, err)
+	assert.Equal(t, archive.manifest, read.manifest)
+	assert.Equal(t, state.ContextName, read.state.ContextName)
+	assert.Equal(t, archive.kubeCfg, read.kubeCfg)
+	assert.Equal(t, archive.files, read.files)
+
+	provider, err := GetArchiveProvider(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "tencent", provider)
+
+	// the context of manifest must be in the kubeconfig.
+	archive.manifest.ContextName = "other"
+	archive.state.ContextName = "other"
+	assert.NoError(t, archive.write(path))
+	_, err = readClusterArchive(path)
+	assert.Error(t, err)
+
+	// the archive without kubeconfig content is allowed, e.g. the cluster without K3s installed.
+	archive.kubeCfg = []byte{}
+	assert.NoError(t, archive.write(path))
+	_, err = readClusterArchive(path)
+	assert.NoError(t, err)
+}
+
+func TestIsSafeArchivePath(t *testing.T) {
+	assert.True(t, isSafeArchivePath("tencent/clusters/myk3s/id_rsa"))
+	assert.False(t, isSafeArchivePath(""))
+	assert.False(t, isSafeArchivePath(".."))
+	assert.False(t, isSafeArchivePath("../id_rsa"))
+	assert.False(t, isSafeArchivePath("tencent/../../id_rsa"))
+	assert.False(t, isSafeArchivePath("/root/.ssh/id_rsa"))
+}
+
+func TestRelocateSSHFilePaths(t *testing.T) {
+	c := &types.Cluster{}
+	c.SSHKeyPath = "/root/.autok3s/tencent/clusters/myk3s/id_rsa"
+	c.MasterNodes = []types.Node{{SSH: types.SSH{SSHKeyPath: "/root/.ssh/id_rsa"}}}
+	c.WorkerNodes = []types.Node{{SSH: types.SSH{SSHKeyPath: "env:SSH_PRIVATE_KEY"}}}
+
+	assert.Equal(t, []string{"/root/.autok3s/tencent/clusters/myk3s/id_rsa", "/root/.ssh/id_rsa"}, getSSHFilePaths(c))
+
+	relocateSSHFilePaths(c, "/root/.autok3s", "/home/user/.autok3s")
+	assert.Equal(t, "/home/user/.autok3s/tencent/clusters/myk3s/id_rsa", c.SSHKeyPath)
+	assert.Equal(t, "/root/.ssh/id_rsa", c.MasterNodes[0].SSHKeyPath)
+	assert.Equal(t, "env:SSH_PRIVATE_KEY", c.WorkerNodes[0].SSHKeyPath)
+}
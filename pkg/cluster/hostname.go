@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// setHostnameCommand sets the hostname if it's different, the hostname is also resolved locally,
+// so that sudo and K3s don't wait for dns. It's safe to run again when joining or upgrading.
+const setHostnameCommand = `if [ "$(hostname)" != "%[1]s" ]; then ` +
+	`if command -v hostnamectl >/dev/null 2>&1; then hostnamectl set-hostname "%[1]s"; else hostname "%[1]s" && echo "%[1]s" > /etc/hostname; fi; fi; ` +
+	`grep -q "[[:space:]]%[1]s$" /etc/hosts || echo "127.0.1.1 %[1]s" >> /etc/hosts`
+
+// handleHostname sets the hostname of the node to the local hostname before installing K3s if the provider names the
+// node by it with `--node-name`, so that the hostname and the node name agree, as some images ignore the instance name
+// for hostname.
+func (p *ProviderBase) handleHostname(n *types.Node, extraArgs string) error {
+	if n.LocalHostname == "" || !hasArg(extraArgs, "--node-name="+n.LocalHostname) {
+		return nil
+	}
+	if _, err := p.execute(n, fmt.Sprintf(setHostnameCommand, n.LocalHostname)); err != nil {
+		return fmt.Errorf("[cluster] failed to set hostname of node %s to %s: %v", n.InstanceID, n.LocalHostname, err)
+	}
+	return nil
+}
+
+func hasArg(args, arg string) bool {
+	for _, a := range strings.Fields(args) {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
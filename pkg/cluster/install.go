@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+)
+
+// InstallK3sCluster is not supported by default, providers which can skip installing K3s override it.
+func (p *ProviderBase) InstallK3sCluster() error {
+	return fmt.Errorf("installing K3s for %s provider is not supported yet", p.Provider)
+}
+
+// InstallK3s installs K3s and deploys the manifests to the instances provisioned with `--skip-install`.
+func (p *ProviderBase) InstallK3s(options interface{}, deployPlugins func() []string) (er error) {
+	if p.Provider == "k3d" {
+		return errors.New("installing K3s for K3d provider is not supported yet")
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if state.Status != common.StatusInfraReady {
+		return fmt.Errorf("[%s] cluster %s is %s, K3s can only be installed to the cluster created with `--skip-install`",
+			p.Provider, p.Name, state.Status)
+	}
+	logFile, err := common.GetLogFile(state.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+	p.Logger.Infof("[%s] begin to install K3s to cluster %s...", p.Provider, p.Name)
+
+	p.Metadata = state.Metadata
+	c := common.ConvertToCluster(state, true)
+	c.Options = options
+	p.Status = c.Status
+	defer func() {
+		if er != nil {
+			p.Logger.Errorf("%v", er)
+			// the instances are kept, so that installing can be retried after fixing the error.
+			c.Status.Status = common.StatusInfraReady
+			_ = common.DefaultDB.SaveCluster(&c)
+		}
+	}()
+
+	if err = p.InitK3sCluster(&c); err != nil {
+		return err
+	}
+	cmds := p.getManifestCommands(deployPlugins)
+	if len(cmds) > 0 {
+		if err = p.DeployExtraManifest(&c, cmds); err != nil {
+			return err
+		}
+		p.Logger.Infof("[%s] successfully deployed custom manifests", p.Provider)
+	}
+	p.Logger.Info(common.UsageInfoTitle)
+	p.Logger.Infof(common.UsageContext, p.ContextName)
+	p.Logger.Info(common.UsagePods)
+	return nil
+}
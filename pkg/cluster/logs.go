@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/hpcloud/tail"
+)
+
+// GetLogs returns a reader over the operation log of the cluster, the reader keeps tailing new lines if follow is true
+// until it's closed. The log is reopened when it's rotated or recreated. If since is set, the rotated logs are read
+// as well and only the lines logged within since are returned.
+func (p *ProviderBase) GetLogs(name string, follow bool, since time.Duration) (io.ReadCloser, error) {
+	state, err := common.DefaultDB.GetCluster(name, p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("[%s] cluster %s is not exist", p.Provider, name)
+	}
+	logFilePath := common.GetClusterLogFilePath(state.ContextName)
+	var current io.ReadCloser
+	if follow {
+		current, err = tailLogFile(logFilePath)
+	} else {
+		current, err = os.Open(logFilePath)
+	}
+	if err != nil || since <= 0 {
+		return current, err
+	}
+
+	// the rotated logs are read from the oldest one.
+	readers := make([]io.ReadCloser, 0, common.MaxLogBackups+1)
+	for i := common.MaxLogBackups; i >= 1; i-- {
+		f, err := os.Open(common.GetRotatedLogFilePath(state.ContextName, i))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			for _, r := range append(readers, current) {
+				_ = r.Close()
+			}
+			return nil, err
+		}
+		readers = append(readers, f)
+	}
+	return newSinceReader(append(readers, current), time.Now().Add(-since)), nil
+}
+
+func tailLogFile(logFilePath string) (io.ReadCloser, error) {
+	t, err := tail.TailFile(logFilePath, tail.Config{
+		Follow:    true,
+		ReOpen:    true,
+		MustExist: true,
+		Poll:      true,
+		Logger:    tail.DiscardingLogger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, w := io.Pipe()
+	go func() {
+		for line := range t.Lines {
+			if line.Err != nil {
+				_ = w.CloseWithError(line.Err)
+				return
+			}
+			if _, err := io.WriteString(w, line.Text+"\n"); err != nil {
+				return
+			}
+		}
+		_ = w.CloseWithError(t.Err())
+	}()
+	return &tailReader{PipeReader: r, t: t}, nil
+}
+
+// tailReader stops tailing the log when it's closed.
+type tailReader struct {
+	*io.PipeReader
+	t *tail.Tail
+}
+
+func (r *tailReader) Close() error {
+	_ = r.PipeReader.Close()
+	err := r.t.Stop()
+	r.t.Cleanup()
+	return err
+}
+
+// sinceReader reads the lines of the readers in order, the lines logged before since are skipped.
+// The lines without timestamp, e.g. the output of commands, follow the previous line.
+type sinceReader struct {
+	*io.PipeReader
+	readers []io.ReadCloser
+}
+
+func newSinceReader(readers []io.ReadCloser, since time.Time) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		included := false
+		for _, reader := range readers {
+			scanner := bufio.NewScanner(reader)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if t, ok := parseLogTime(line); ok {
+					included = !t.Before(since)
+				}
+				if !included {
+					continue
+				}
+				if _, err := io.WriteString(w, line+"\n"); err != nil {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				_ = w.CloseWithError(err)
+				return
+			}
+		}
+		_ = w.Close()
+	}()
+	return &sinceReader{PipeReader: r, readers: readers}
+}
+
+func (r *sinceReader) Close() error {
+	_ = r.PipeReader.Close()
+	var err error
+	for _, reader := range r.readers {
+		if e := reader.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// parseLogTime parses the timestamp of the line written by the text formatter of logrus, e.g. `time="2006-01-02T15:04:05Z07:00"`.
+func parseLogTime(line string) (time.Time, bool) {
+	const prefix = `time="`
+	if !strings.HasPrefix(line, prefix) {
+		return time.Time{}, false
+	}
+	value, _, ok := strings.Cut(line[len(prefix):], `"`)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, err == nil
+}
@@ -0,0 +1,27 @@
+package cluster
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSinceReader(t *testing.T) {
+	rotated := io.NopCloser(strings.NewReader(
+		"time=\"2023-01-01T10:00:00Z\" level=info msg=\"old\"\n" +
+			"old output\n"))
+	current := io.NopCloser(strings.NewReader(
+		"time=\"2023-01-01T11:00:00Z\" level=info msg=\"new\"\n" +
+			"new output\n" +
+			"time=\"2023-01-01T12:00:00+08:00\" level=info msg=\"earlier\"\n"))
+	since, _ := time.Parse(time.RFC3339, "2023-01-01T10:30:00Z")
+
+	r := newSinceReader([]io.ReadCloser{rotated, current}, since)
+	out, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Close())
+	assert.Equal(t, "time=\"2023-01-01T11:00:00Z\" level=info msg=\"new\"\nnew output\n", string(out))
+}
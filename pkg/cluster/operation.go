@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// GetOperationStatus is not supported by default, providers which have async task apis override it.
+func (p *ProviderBase) GetOperationStatus(taskID string) (*types.OperationStatus, error) {
+	return nil, fmt.Errorf("querying operation status for %s provider is not supported yet", p.Provider)
+}
+
+// SaveOperation records the status of the async operation of the cluster, so that it can be queried by the task id
+// while it's in-flight. It's best effort as the operation itself is not affected.
+func (p *ProviderBase) SaveOperation(taskID, action, status string) {
+	if common.DefaultDB == nil {
+		return
+	}
+	if err := common.DefaultDB.SaveOperation(&common.Operation{
+		TaskID:      taskID,
+		ContextName: p.ContextName,
+		Provider:    p.Provider,
+		Action:      action,
+		Status:      status,
+	}); err != nil {
+		p.Logger.Debugf("[%s] failed to save status of operation %s: %v", p.Provider, taskID, err)
+	}
+}
+
+// GetOperation returns the recorded async operation of the cluster, nil is returned if it's not recorded.
+func (p *ProviderBase) GetOperation(taskID string) (*common.Operation, error) {
+	if common.DefaultDB == nil {
+		return nil, nil
+	}
+	o, err := common.DefaultDB.GetOperation(taskID)
+	if err != nil || o == nil {
+		return nil, err
+	}
+	if o.ContextName != p.ContextName {
+		return nil, fmt.Errorf("[%s] operation %s doesn't belong to cluster %s", p.Provider, taskID, p.Name)
+	}
+	return o, nil
+}
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+const (
+	defaultServiceCidr   = "10.43.0.0/16"
+	defaultClusterDomain = "cluster.local"
+)
+
+func validateNodeProxy(m *types.Metadata) error {
+	if m.NodeHTTPProxy == "" {
+		if m.NodeNoProxy != "" {
+			return fmt.Errorf("must set `--node-http-proxy` if `--node-no-proxy` is set")
+		}
+		return nil
+	}
+	u, err := url.Parse(m.NodeHTTPProxy)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("`--node-http-proxy` %q must be an url like http://proxy.example.com:3128", m.NodeHTTPProxy)
+	}
+	if strings.ContainsAny(m.NodeHTTPProxy+m.NodeNoProxy, "' ") {
+		return fmt.Errorf("`--node-http-proxy` and `--node-no-proxy` can't contain quote or space")
+	}
+	return nil
+}
+
+// getNodeProxyEnv returns the command to export the proxy of nodes, it's run before the install commands, so that
+// the install script and binaries are downloaded through the proxy. K3s install script writes the proxy variables to
+// the environment file of the K3s service, so that containerd also pulls images through it.
+// The local addresses, the cidrs and domain of cluster and the masters are never proxied.
+func getNodeProxyEnv(cluster *types.Cluster, fixedIP string) string {
+	if cluster.NodeHTTPProxy == "" {
+		return ""
+	}
+	noProxy := make([]string, 0)
+	dedup := map[string]bool{}
+	add := func(values ...string) {
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if v != "" && !dedup[v] {
+				dedup[v] = true
+				noProxy = append(noProxy, v)
+			}
+		}
+	}
+	add(strings.Split(cluster.NodeNoProxy, ",")...)
+	clusterDomain := cluster.ClusterDomain
+	if clusterDomain == "" {
+		clusterDomain = defaultClusterDomain
+	}
+	clusterCidr := cluster.ClusterCidr
+	if clusterCidr == "" {
+		clusterCidr = defaultCidr
+	}
+	add("127.0.0.1", "localhost", ".svc", "."+clusterDomain, clusterCidr, defaultServiceCidr, fixedIP)
+	masterIPs := make([]string, 0)
+	for _, master := range cluster.MasterNodes {
+		masterIPs = append(masterIPs, master.InternalIPAddress...)
+	}
+	sort.Strings(masterIPs)
+	add(masterIPs...)
+
+	return fmt.Sprintf("export HTTP_PROXY='%s' HTTPS_PROXY='%s' NO_PROXY='%s';",
+		cluster.NodeHTTPProxy, cluster.NodeHTTPProxy, strings.Join(noProxy, ","))
+}
@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// defaultNodeReadyTimeout is used if `--node-ready-timeout` isn't set.
+	defaultNodeReadyTimeout = 5 * time.Minute
+	nodeReadyInterval       = 5 * time.Second
+)
+
+// getNodeReadyTimeout returns the time to wait for the joined nodes to be Ready, 0 means not waiting.
+func (p *ProviderBase) getNodeReadyTimeout() (time.Duration, error) {
+	if p.NodeReadyTimeout == "" {
+		return defaultNodeReadyTimeout, nil
+	}
+	timeout, err := time.ParseDuration(p.NodeReadyTimeout)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("[%s] calling preflight error: invalid `--node-ready-timeout` %q, must be a duration such as 5m", p.Provider, p.NodeReadyTimeout)
+	}
+	return timeout, nil
+}
+
+// waitForJoinedNodesReady waits until the nodes of the joined instances appear and report Ready, the instances which
+// aren't Ready within `--node-ready-timeout` are returned with the reasons. The gate is skipped if the cluster can't be
+// reached by the kubeconfig, as the nodes may still be fine.
+func (p *ProviderBase) waitForJoinedNodesReady(instances []types.Node) map[string]string {
+	timeout, err := p.getNodeReadyTimeout()
+	if err != nil || timeout == 0 || len(instances) == 0 {
+		return nil
+	}
+	client, err := GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		p.Logger.Warnf("[%s] skip waiting for joined nodes to be ready, failed to load kubeconfig of cluster %s: %v", p.Provider, p.ContextName, err)
+		return nil
+	}
+	p.Logger.Infof("[%s] waiting for %d joined nodes to be ready...", p.Provider, len(instances))
+	notReady := map[string]string{}
+	_ = wait.PollImmediate(nodeReadyInterval, timeout, func() (bool, error) {
+		nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			p.Logger.Debugf("[%s] failed to list nodes: %v", p.Provider, err)
+			return false, nil
+		}
+		notReady = getNotReadyInstances(nodes.Items, instances)
+		return len(notReady) == 0, nil
+	})
+	for id, reason := range notReady {
+		notReady[id] = fmt.Sprintf("%s in %s", reason, timeout)
+	}
+	return notReady
+}
+
+// getNotReadyInstances returns the instances whose nodes are missing or not Ready, with the reasons.
+func getNotReadyInstances(nodes []v1.Node, instances []types.Node) map[string]string {
+	notReady := map[string]string{}
+	for i := range instances {
+		instance := &instances[i]
+		reason := "node isn't registered"
+		for j := range nodes {
+			if !isNodeOfInstance(&nodes[j], instance) {
+				continue
+			}
+			reason = fmt.Sprintf("node %s isn't ready", nodes[j].Name)
+			if isNodeReady(&nodes[j]) {
+				reason = ""
+			}
+			break
+		}
+		if reason != "" {
+			notReady[instance.InstanceID] = reason
+		}
+	}
+	return notReady
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// removeNodes returns the nodes whose instances aren't in the removed ones.
+func removeNodes(nodes []types.Node, removed map[string]string) []types.Node {
+	kept := make([]types.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if _, ok := removed[n.InstanceID]; !ok {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
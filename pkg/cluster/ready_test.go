@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNotReadyInstances(t *testing.T) {
+	instances := []types.Node{
+		{InstanceID: "ins-1", InternalIPAddress: []string{"10.0.0.2"}},
+		{InstanceID: "ins-2", InternalIPAddress: []string{"10.0.0.3"}},
+		{InstanceID: "ins-3", InternalIPAddress: []string{"10.0.0.4"}},
+	}
+	nodes := []v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Annotations: map[string]string{InstanceIDAnnotation: "ins-1"}},
+			Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-2"},
+			Status: v1.NodeStatus{
+				Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.3"}},
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}},
+			},
+		},
+	}
+	assert.Equal(t, map[string]string{
+		"ins-2": "node worker-2 isn't ready",
+		"ins-3": "node isn't registered",
+	}, getNotReadyInstances(nodes, instances))
+
+	nodes[1].Status.Conditions[0].Status = v1.ConditionTrue
+	assert.Equal(t, map[string]string{"ins-3": "node isn't registered"}, getNotReadyInstances(nodes, instances))
+	assert.Equal(t, []types.Node{instances[0], instances[1]}, removeNodes(instances, map[string]string{"ins-3": ""}))
+
+	p := NewBaseProvider()
+	timeout, err := p.getNodeReadyTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultNodeReadyTimeout, timeout)
+	p.NodeReadyTimeout = "0"
+	timeout, err = p.getNodeReadyTimeout()
+	assert.NoError(t, err)
+	assert.Zero(t, timeout)
+	p.NodeReadyTimeout = "5"
+	_, err = p.getNodeReadyTimeout()
+	assert.Error(t, err)
+}
@@ -0,0 +1,216 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// defaultReconcileDrainTimeout is used to drain the removed nodes if the drain timeout isn't set.
+const defaultReconcileDrainTimeout = 5 * time.Minute
+
+// Reconcile is not supported by default, providers which can launch and terminate instances override it.
+func (p *ProviderBase) Reconcile(force bool) error {
+	return fmt.Errorf("reconciling cluster for %s provider is not supported yet", p.Provider)
+}
+
+// ReconcileCluster converges the nodes of the cluster to the master and worker numbers of metadata, an empty number
+// keeps the current one. The removed nodes are drained and deleted from the cluster through a remaining master, then
+// the instances are terminated by the terminate function. The missing nodes are joined by the join function.
+// The nodes are removed from the newest ones, and the first master which the kubeconfig points to is always kept.
+// It refuses to run while another operation of the cluster is in progress, so it's safe to re-run.
+func (p *ProviderBase) ReconcileCluster(force bool, drainTimeout time.Duration, terminate func(ids []string) error, join func(masterNum, workerNum int) error) error {
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if state.Status != common.StatusRunning {
+		return fmt.Errorf("[%s] cluster %s is %s, wait for the operation in progress to finish", p.Provider, p.Name, state.Status)
+	}
+	c := common.ConvertToCluster(state, true)
+
+	masterNum, err := getDesiredNodeNum(p.Master, len(c.MasterNodes), "master")
+	if err != nil {
+		return fmt.Errorf("[%s] %v", p.Provider, err)
+	}
+	workerNum, err := getDesiredNodeNum(p.Worker, len(c.WorkerNodes), "worker")
+	if err != nil {
+		return fmt.Errorf("[%s] %v", p.Provider, err)
+	}
+	if masterNum < 1 {
+		return fmt.Errorf("[%s] cluster %s must have at least 1 master", p.Provider, p.Name)
+	}
+	addMasters, addWorkers, removed := diffNodes(&c, masterNum, workerNum)
+	if addMasters < 0 {
+		return fmt.Errorf("[%s] cluster %s can't be reduced to %d masters, the first master and the master of ip %s are kept", p.Provider, p.Name, masterNum, c.IP)
+	}
+	if addMasters == 0 && addWorkers == 0 && len(removed) == 0 {
+		p.Logger.Infof("[%s] cluster %s already has %d masters and %d workers, nothing to reconcile", p.Provider, p.Name, masterNum, workerNum)
+		return nil
+	}
+
+	if len(removed) > 0 {
+		ids := make([]string, 0, len(removed))
+		for _, n := range removed {
+			ids = append(ids, n.InstanceID)
+		}
+		if !force && !utils.AskForConfirmation(fmt.Sprintf("[%s] instance(s) %s of cluster %s will be drained and terminated, are you sure to continue",
+			p.Provider, strings.Join(ids, ","), p.Name), false) {
+			return fmt.Errorf("[%s] reconciling cluster %s is canceled", p.Provider, p.Name)
+		}
+		if drainTimeout <= 0 {
+			drainTimeout = defaultReconcileDrainTimeout
+		}
+		if err = p.removeReconciledNodes(&c, removed, drainTimeout, terminate); err != nil {
+			return err
+		}
+	}
+
+	if addMasters > 0 || addWorkers > 0 {
+		p.Logger.Infof("[%s] joining %d masters and %d workers to cluster %s...", p.Provider, addMasters, addWorkers, p.Name)
+		if err = join(addMasters, addWorkers); err != nil {
+			return err
+		}
+	}
+	p.Logger.Infof("[%s] successfully reconciled cluster %s to %d masters and %d workers", p.Provider, p.Name, masterNum, workerNum)
+	return nil
+}
+
+func getDesiredNodeNum(num string, current int, role string) (int, error) {
+	if num == "" {
+		return current, nil
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("the desired %s number %s must be a non-negative number", role, num)
+	}
+	return n, nil
+}
+
+// diffNodes returns the numbers of masters and workers to be added, and the nodes to be removed from the newest ones.
+// The first master and the master of the fixed ip are never removed, as the kubeconfig and the joining nodes use them,
+// the number of masters is negative if they can't be reduced to masterNum.
+func diffNodes(c *types.Cluster, masterNum, workerNum int) (int, int, []types.Node) {
+	removed := make([]types.Node, 0)
+	addMasters, addWorkers := masterNum-len(c.MasterNodes), workerNum-len(c.WorkerNodes)
+	for i := len(c.WorkerNodes) - 1; i >= 0 && addWorkers < 0; i-- {
+		removed = append(removed, c.WorkerNodes[i])
+		addWorkers++
+	}
+	for i := len(c.MasterNodes) - 1; i > 0 && addMasters < 0; i-- {
+		if isMasterAddress(c.MasterNodes[i:i+1], c.IP) {
+			continue
+		}
+		removed = append(removed, c.MasterNodes[i])
+		addMasters++
+	}
+	return addMasters, addWorkers, removed
+}
+
+// removeReconciledNodes drains and deletes the nodes one by one, the state is saved after each instance is terminated,
+// so the nodes which are already removed are skipped when re-running after a failure.
+func (p *ProviderBase) removeReconciledNodes(c *types.Cluster, removed []types.Node, drainTimeout time.Duration, terminate func(ids []string) error) error {
+	c.Status.Status = common.StatusUpgrading
+	if err := common.DefaultDB.SaveCluster(c); err != nil {
+		return err
+	}
+	defer func() {
+		c.Status.Status = common.StatusRunning
+		_ = common.DefaultDB.SaveCluster(c)
+	}()
+
+	for _, node := range removed {
+		// the first master is never removed.
+		server := &c.MasterNodes[0]
+		if err := p.drainNode(node, drainTimeout); err != nil {
+			return err
+		}
+		if err := p.deleteClusterNode(server, node); err != nil {
+			return err
+		}
+		p.Logger.Infof("[%s] terminating instance %s...", p.Provider, node.InstanceID)
+		if err := terminate([]string{node.InstanceID}); err != nil {
+			return err
+		}
+		c.MasterNodes = removeNode(c.MasterNodes, node.InstanceID)
+		c.WorkerNodes = removeNode(c.WorkerNodes, node.InstanceID)
+		c.Master = strconv.Itoa(len(c.MasterNodes))
+		c.Worker = strconv.Itoa(len(c.WorkerNodes))
+		if err := common.DefaultDB.SaveCluster(c); err != nil {
+			return err
+		}
+	}
+	p.Status.MasterNodes = c.MasterNodes
+	p.Status.WorkerNodes = c.WorkerNodes
+	return nil
+}
+
+// drainNode cordons and drains the node of the instance, draining is skipped if the api-server is unreachable
+// or the node isn't found.
+func (p *ProviderBase) drainNode(instance types.Node, timeout time.Duration) error {
+	client, err := GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to load kubeconfig of cluster %s, skip draining instance %s: %v", p.Provider, p.ContextName, instance.InstanceID, err)
+		return nil
+	}
+	if GetClusterStatus(client) != types.ClusterStatusRunning {
+		p.Logger.Warnf("[%s] api-server of cluster %s is unreachable, skip draining instance %s", p.Provider, p.ContextName, instance.InstanceID)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("[%s] failed to list nodes of cluster %s: %v", p.Provider, p.ContextName, err)
+	}
+	out, errOut := p.Logger.Writer(), p.Logger.WriterLevel(logrus.WarnLevel)
+	defer func() {
+		_ = out.Close()
+		_ = errOut.Close()
+	}()
+	helper := &drain.Helper{
+		Ctx:                 ctx,
+		Client:              client,
+		Force:               true,
+		GracePeriodSeconds:  -1,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  true,
+		Timeout:             timeout,
+		Out:                 out,
+		ErrOut:              errOut,
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !isNodeOfInstance(node, &instance) {
+			continue
+		}
+		p.Logger.Infof("[%s] draining node %s of instance %s...", p.Provider, node.Name, instance.InstanceID)
+		if err = drain.RunCordonOrUncordon(helper, node, true); err != nil {
+			return fmt.Errorf("[%s] failed to cordon node %s: %v", p.Provider, node.Name, err)
+		}
+		if err = drain.RunNodeDrain(helper, node.Name); err != nil {
+			return fmt.Errorf("[%s] failed to drain node %s: %v", p.Provider, node.Name, err)
+		}
+		return nil
+	}
+	p.Logger.Warnf("[%s] node of instance %s is not found in cluster %s, skip draining it", p.Provider, instance.InstanceID, p.Name)
+	return nil
+}
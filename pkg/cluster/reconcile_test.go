@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffNodes(t *testing.T) {
+	nodes := func(master bool, ids ...string) []types.Node {
+		rtn := make([]types.Node, 0, len(ids))
+		for i, id := range ids {
+			rtn = append(rtn, types.Node{InstanceID: id, Master: master, InternalIPAddress: []string{"10.0.0." + string(rune('1'+i))}})
+		}
+		return rtn
+	}
+	ids := func(nodes []types.Node) []string {
+		rtn := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			rtn = append(rtn, n.InstanceID)
+		}
+		return rtn
+	}
+	c := &types.Cluster{Status: types.Status{
+		MasterNodes: nodes(true, "m1", "m2", "m3"),
+		WorkerNodes: nodes(false, "w1", "w2"),
+	}}
+
+	addMasters, addWorkers, removed := diffNodes(c, 3, 2)
+	assert.Equal(t, 0, addMasters)
+	assert.Equal(t, 0, addWorkers)
+	assert.Empty(t, removed)
+
+	addMasters, addWorkers, removed = diffNodes(c, 5, 3)
+	assert.Equal(t, 2, addMasters)
+	assert.Equal(t, 1, addWorkers)
+	assert.Empty(t, removed)
+
+	// the workers are removed first, and the newest nodes are removed.
+	addMasters, addWorkers, removed = diffNodes(c, 2, 0)
+	assert.Equal(t, 0, addMasters)
+	assert.Equal(t, 0, addWorkers)
+	assert.Equal(t, []string{"w2", "w1", "m3"}, ids(removed))
+
+	// the master of the fixed ip is kept.
+	c.IP = "10.0.0.3"
+	addMasters, _, removed = diffNodes(c, 2, 2)
+	assert.Equal(t, 0, addMasters)
+	assert.Equal(t, []string{"m2"}, ids(removed))
+
+	// the first master is kept.
+	addMasters, _, removed = diffNodes(c, 1, 2)
+	assert.Equal(t, -1, addMasters)
+	assert.Equal(t, []string{"m2"}, ids(removed))
+}
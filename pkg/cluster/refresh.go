@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// RefreshState is not supported by default, providers which can describe their instances override it.
+func (p *ProviderBase) RefreshState() error {
+	return fmt.Errorf("refreshing state for %s provider is not supported yet", p.Provider)
+}
+
+// RefreshClusterState re-syncs ips and statuses of the nodes in saved state with the instances described from cloud.
+// It only updates the local state and never creates or destroys any instance.
+func (p *ProviderBase) RefreshClusterState(describeInstance func() ([]types.Node, error)) error {
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if describeInstance == nil {
+		return errors.New("failed to describe instances: describe function is nil")
+	}
+	instances, err := describeInstance()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]types.Node, len(instances))
+	for _, n := range instances {
+		current[n.InstanceID] = n
+	}
+
+	c := common.ConvertToCluster(state, true)
+	tracked := map[string]bool{}
+	c.MasterNodes = p.refreshNodes(c.MasterNodes, current, tracked)
+	c.WorkerNodes = p.refreshNodes(c.WorkerNodes, current, tracked)
+	for id := range current {
+		if !tracked[id] {
+			p.Logger.Warnf("[%s] instance %s is tagged with cluster %s but not tracked in state, skip", p.Provider, id, p.Name)
+		}
+	}
+	c.Master = strconv.Itoa(len(c.MasterNodes))
+	c.Worker = strconv.Itoa(len(c.WorkerNodes))
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully refreshed state of cluster %s", p.Provider, p.Name)
+	return nil
+}
+
+// refreshNodes updates the addresses and status of nodes, the nodes whose instance no longer exists are removed.
+func (p *ProviderBase) refreshNodes(nodes []types.Node, current map[string]types.Node, tracked map[string]bool) []types.Node {
+	refreshed := make([]types.Node, 0, len(nodes))
+	for _, n := range nodes {
+		instance, ok := current[n.InstanceID]
+		if !ok {
+			p.Logger.Warnf("[%s] instance %s is not found, remove it from state", p.Provider, n.InstanceID)
+			continue
+		}
+		tracked[n.InstanceID] = true
+		if n.InstanceStatus != instance.InstanceStatus {
+			p.Logger.Infof("[%s] status of instance %s changed from %s to %s", p.Provider, n.InstanceID, n.InstanceStatus, instance.InstanceStatus)
+		}
+		n.InstanceStatus = instance.InstanceStatus
+		n.InternalIPAddress = instance.InternalIPAddress
+		n.PublicIPAddress = instance.PublicIPAddress
+		if instance.InstanceType != "" {
+			n.InstanceType = instance.InstanceType
+		}
+		refreshed = append(refreshed, n)
+	}
+	return refreshed
+}
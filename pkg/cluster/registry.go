@@ -0,0 +1,228 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/rancher/wharfie/pkg/registries"
+)
+
+const (
+	registryTLSCA   = "ca"
+	registryTLSCert = "cert"
+	registryTLSKey  = "key"
+	// allRegistries is the registry of the ca set by --registry-ca without registry name.
+	allRegistries = "*"
+)
+
+// parseRegistryTLSFlag parses the value `[<registry>=]<file>` of --registry-ca, --registry-cert and --registry-key,
+// the registry name is required unless it's allowed to apply the file to all registries.
+func parseRegistryTLSFlag(flag, value string, allowAll bool) (string, string, error) {
+	registry, path, found := strings.Cut(value, "=")
+	if !found {
+		if !allowAll {
+			return "", "", fmt.Errorf("`--%s` %q must be in format <registry>=<file>", flag, value)
+		}
+		registry, path = allRegistries, value
+	}
+	if registry == "" || path == "" {
+		return "", "", fmt.Errorf("`--%s` %q must be in format <registry>=<file>", flag, value)
+	}
+	return registry, path, nil
+}
+
+// loadRegistryTLSContent reads the files of --registry-ca, --registry-cert and --registry-key into the metadata, so that
+// they're saved with the cluster and uploaded again to the nodes joined later without the local files.
+// The files of joining take precedence over the saved ones of the same registry.
+func (p *ProviderBase) loadRegistryTLSContent() error {
+	certs, keys := map[string]bool{}, map[string]bool{}
+	for _, f := range []struct {
+		flag     string
+		kind     string
+		values   []string
+		allowAll bool
+		set      map[string]bool
+	}{
+		{flag: "registry-ca", kind: registryTLSCA, values: p.RegistryCA, allowAll: true},
+		{flag: "registry-cert", kind: registryTLSCert, values: p.RegistryCert, set: certs},
+		{flag: "registry-key", kind: registryTLSKey, values: p.RegistryKey, set: keys},
+	} {
+		for _, value := range f.values {
+			registry, path, err := parseRegistryTLSFlag(f.flag, value, f.allowAll)
+			if err != nil {
+				return fmt.Errorf("[%s] calling preflight error: %v", p.Provider, err)
+			}
+			if f.set != nil {
+				f.set[registry] = true
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				if content := p.RegistryTLSContent[registryTLSContentKey(registry, f.kind)]; content != "" && os.IsNotExist(err) {
+					// the file is only on the machine which created the cluster, the saved content is used.
+					continue
+				}
+				return fmt.Errorf("[%s] calling preflight error: failed to read `--%s` %s: %v", p.Provider, f.flag, path, err)
+			}
+			if p.RegistryTLSContent == nil {
+				p.RegistryTLSContent = types.StringMap{}
+			}
+			p.RegistryTLSContent[registryTLSContentKey(registry, f.kind)] = string(b)
+		}
+	}
+	for registry := range certs {
+		if !keys[registry] {
+			return fmt.Errorf("[%s] calling preflight error: `--registry-key` of registry %s must be set with `--registry-cert`", p.Provider, registry)
+		}
+	}
+	for registry := range keys {
+		if !certs[registry] {
+			return fmt.Errorf("[%s] calling preflight error: `--registry-cert` of registry %s must be set with `--registry-key`", p.Provider, registry)
+		}
+	}
+	if p.RegistryTLSContent[registryTLSContentKey(allRegistries, registryTLSCA)] != "" &&
+		p.Registry == "" && p.RegistryContent == "" {
+		return fmt.Errorf("[%s] calling preflight error: `--registry-ca` without registry name must be set with `--registry`", p.Provider)
+	}
+	return nil
+}
+
+func registryTLSContentKey(registry, kind string) string {
+	return registry + "/" + kind
+}
+
+// withRegistryTLSContent adds the saved ca and client certificate of registries to the tls files to be uploaded,
+// the ca without registry name is trusted by all the registries of configs and the https endpoints of mirrors.
+func withRegistryTLSContent(registry *registries.Registry, tls map[string]map[string][]byte, content types.StringMap) {
+	if len(content) == 0 {
+		return
+	}
+	if registry.Configs == nil {
+		registry.Configs = map[string]registries.RegistryConfig{}
+	}
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	// the ca of the named registry is applied after the one of all registries, so it takes precedence.
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.HasPrefix(keys[i], allRegistries+"/") && !strings.HasPrefix(keys[j], allRegistries+"/")
+	})
+	for _, k := range keys {
+		i := strings.LastIndex(k, "/")
+		name, kind := k[:i], k[i+1:]
+		names := []string{name}
+		if name == allRegistries {
+			names = getRegistryHosts(registry)
+		}
+		for _, n := range names {
+			config := registry.Configs[n]
+			if config.TLS == nil {
+				config.TLS = &registries.TLSConfig{}
+			}
+			registry.Configs[n] = config
+			if tls[n] == nil {
+				tls[n] = map[string][]byte{}
+			}
+			tls[n][kind] = []byte(content[k])
+		}
+	}
+}
+
+// getRegistryHosts returns the registries of configs and the hosts of the https mirror endpoints.
+func getRegistryHosts(registry *registries.Registry) []string {
+	hosts := map[string]bool{}
+	for name := range registry.Configs {
+		hosts[name] = true
+	}
+	for _, mirror := range registry.Mirrors {
+		for _, endpoint := range mirror.Endpoints {
+			if u, err := url.Parse(endpoint); err == nil && u.Scheme == "https" && u.Host != "" {
+				hosts[u.Host] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(hosts))
+	for name := range hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UpdateRegistry writes the registry file to registries.yaml of every node, e.g. to rotate the expired auth of private
+// registries without recreating nodes. K3s is restarted on the nodes one by one so that containerd loads the new
+// config, the next node is only restarted after the current one is Ready again, and the running containers are kept
+// by containerd. It's safe to run it again if it fails halfway.
+func (p *ProviderBase) UpdateRegistry(registry string) error {
+	if p.Provider == "k3d" {
+		return errors.New("updating registry for K3d provider is not supported yet")
+	}
+	if registry == "" {
+		return fmt.Errorf("[%s] `--registry` is required to update registry of cluster %s", p.Provider, p.Name)
+	}
+	// the registry file is validated before any node is changed.
+	if _, err := utils.VerifyRegistryFileContent(registry, ""); err != nil {
+		return fmt.Errorf("[%s] invalid registry file %s: %v", p.Provider, registry, err)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if state.Status != common.StatusRunning {
+		return fmt.Errorf("[%s] cluster %s is %s, only running cluster can update registry", p.Provider, p.Name, state.Status)
+	}
+	c := common.ConvertToCluster(state, true)
+
+	logFile, err := common.GetLogFile(c.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+
+	p.Registry, p.RegistryContent = registry, ""
+	c.Registry, c.RegistryContent = registry, ""
+	// the masters are updated first, so that the workers are restarted with the api-server available.
+	for i := range c.MasterNodes {
+		if err = p.updateNodeRegistry(&c, &c.MasterNodes[i], k3sRestart); err != nil {
+			return err
+		}
+	}
+	for i := range c.WorkerNodes {
+		if err = p.updateNodeRegistry(&c, &c.WorkerNodes[i], k3sAgentRestart); err != nil {
+			return err
+		}
+	}
+
+	// the nodes joined later use the new registry file as well.
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully updated registry of cluster %s", p.Provider, p.Name)
+	return nil
+}
+
+// updateNodeRegistry writes registries.yaml of the node and restarts K3s, then waits for the node to be Ready.
+func (p *ProviderBase) updateNodeRegistry(c *types.Cluster, node *types.Node, restart string) error {
+	p.Logger.Infof("[%s] updating registry of instance %s...", p.Provider, node.InstanceID)
+	if err := p.handleRegistry(node, c); err != nil {
+		return fmt.Errorf("[%s] failed to update registry of instance %s: %v", p.Provider, node.InstanceID, err)
+	}
+	if _, err := p.execute(node, restart); err != nil {
+		return fmt.Errorf("[%s] failed to restart K3s of instance %s: %v", p.Provider, node.InstanceID, err)
+	}
+	return p.waitForNodeReady(node)
+}
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/rancher/wharfie/pkg/registries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryTLSContent(t *testing.T) {
+	dir := t.TempDir()
+	ca, cert, key := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for _, f := range []string{ca, cert, key} {
+		assert.NoError(t, os.WriteFile(f, []byte(filepath.Base(f)), 0600))
+	}
+
+	p := NewBaseProvider()
+	p.Provider = "tencent"
+	p.RegistryCA = []string{ca}
+	// the ca of all registries requires --registry.
+	assert.Error(t, p.loadRegistryTLSContent())
+	p.Registry = filepath.Join(dir, "registries.yaml")
+	p.RegistryCert = []string{"mycustomreg:5000=" + cert}
+	assert.Error(t, p.loadRegistryTLSContent())
+	p.RegistryKey = []string{"mycustomreg:5000=" + key}
+	assert.NoError(t, p.loadRegistryTLSContent())
+	assert.Equal(t, types.StringMap{"*/ca": "ca.pem", "mycustomreg:5000/cert": "cert.pem", "mycustomreg:5000/key": "key.pem"}, p.RegistryTLSContent)
+
+	// the saved content is used when joining on another machine without the files.
+	p.RegistryCA = []string{"mycustomreg:5000=" + filepath.Join(dir, "missing.pem")}
+	assert.Error(t, p.loadRegistryTLSContent())
+	p.RegistryTLSContent["mycustomreg:5000/ca"] = "saved.pem"
+	assert.NoError(t, p.loadRegistryTLSContent())
+	assert.Equal(t, "saved.pem", p.RegistryTLSContent["mycustomreg:5000/ca"])
+
+	registry := &registries.Registry{
+		Mirrors: map[string]registries.Mirror{
+			"docker.io": {Endpoints: []string{"https://mirror.example.com", "http://localhost:5000"}},
+		},
+		Configs: map[string]registries.RegistryConfig{
+			"mycustomreg:5000": {Auth: &registries.AuthConfig{Username: "admin"}},
+		},
+	}
+	tls := map[string]map[string][]byte{}
+	withRegistryTLSContent(registry, tls, types.StringMap{
+		"*/ca":                  "all",
+		"mycustomreg:5000/ca":   "own",
+		"mycustomreg:5000/cert": "cert",
+	})
+	assert.Equal(t, map[string]map[string][]byte{
+		"mirror.example.com": {"ca": []byte("all")},
+		"mycustomreg:5000":   {"ca": []byte("own"), "cert": []byte("cert")},
+	}, tls)
+	assert.NotNil(t, registry.Configs["mirror.example.com"].TLS)
+	assert.Equal(t, "admin", registry.Configs["mycustomreg:5000"].Auth.Username)
+}
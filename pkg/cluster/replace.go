@@ -0,0 +1,173 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+)
+
+// nodeAddressesCommand lists the name and internal ips of each node, separated by tab.
+var nodeAddressesCommand = `k3s kubectl get nodes -o jsonpath='{range .items[*]}{.metadata.name}{"\t"}{.status.addresses[?(@.type=="InternalIP")].address}{"\n"}{end}'`
+
+// ReplaceNode is not supported by default, providers which can launch instances override it.
+func (p *ProviderBase) ReplaceNode(instanceID string, force bool) error {
+	return fmt.Errorf("replacing node for %s provider is not supported yet", p.Provider)
+}
+
+// ReplaceClusterNode replaces the failed instance with a new one of the same role. The node is deleted from the cluster
+// through a healthy master first, for the embedded etcd K3s removes the etcd member of the deleted master node, so the
+// new master joins a healthy quorum. Then the instance is terminated by the terminate function and removed from state,
+// the new instance is launched and joined to the cluster by the join function.
+func (p *ProviderBase) ReplaceClusterNode(instanceID string, force bool, terminate func(ids []string) error, join func(node types.Node) error) error {
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	c := common.ConvertToCluster(state, true)
+
+	var node *types.Node
+	for _, nodes := range [][]types.Node{c.MasterNodes, c.WorkerNodes} {
+		for i := range nodes {
+			if nodes[i].InstanceID == instanceID {
+				node = &nodes[i]
+			}
+		}
+	}
+	if node == nil {
+		return fmt.Errorf("[%s] instance %s is not found in cluster %s", p.Provider, instanceID, p.Name)
+	}
+	replaced := *node
+	if replaced.Master {
+		if err = checkMasterReplaceable(&c); err != nil {
+			return fmt.Errorf("[%s] failed to replace master %s: %v", p.Provider, instanceID, err)
+		}
+	}
+	var server *types.Node
+	for i := range c.MasterNodes {
+		if c.MasterNodes[i].InstanceID != instanceID {
+			server = &c.MasterNodes[i]
+			break
+		}
+	}
+	if server == nil {
+		return fmt.Errorf("[%s] cluster %s has no other master to replace instance %s", p.Provider, p.Name, instanceID)
+	}
+
+	if !force && !utils.AskForConfirmation(fmt.Sprintf("[%s] instance %s of cluster %s will be terminated and replaced by a new instance, are you sure to continue",
+		p.Provider, instanceID, p.Name), false) {
+		return fmt.Errorf("[%s] replacing instance %s is canceled", p.Provider, instanceID)
+	}
+
+	if err = p.deleteClusterNode(server, replaced); err != nil {
+		// the node object of a dead worker is harmless, but the etcd member of a dead master must be removed.
+		if replaced.Master {
+			return err
+		}
+		p.Logger.Warnf("%v, continue to replace instance %s", err, instanceID)
+	}
+
+	p.Logger.Infof("[%s] terminating instance %s...", p.Provider, instanceID)
+	if err = terminate([]string{instanceID}); err != nil {
+		return err
+	}
+
+	oldKubeconfigAddress := getKubeconfigAddress(&c)
+	c.MasterNodes = removeNode(c.MasterNodes, instanceID)
+	c.WorkerNodes = removeNode(c.WorkerNodes, instanceID)
+	c.Master = strconv.Itoa(len(c.MasterNodes))
+	c.Worker = strconv.Itoa(len(c.WorkerNodes))
+	// the new nodes join the cluster through the fixed ip, so it must not point to the terminated master.
+	if isMasterAddress([]types.Node{replaced}, c.IP) {
+		c.IP = getFirstAddress(server.InternalIPAddress)
+	}
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.IP = c.IP
+	p.Status.MasterNodes = c.MasterNodes
+	p.Status.WorkerNodes = c.WorkerNodes
+
+	p.Logger.Infof("[%s] launching a new instance to replace %s...", p.Provider, instanceID)
+	if err = join(replaced); err != nil {
+		return err
+	}
+
+	if replaced.Master && getKubeconfigAddress(&c) != oldKubeconfigAddress {
+		cfg, err := p.getKubeconfig(&c, server)
+		if err != nil {
+			return err
+		}
+		if err = SaveCfg(cfg, getKubeconfigAddress(&c), c.ContextName); err != nil {
+			return err
+		}
+	}
+	p.Logger.Infof("[%s] successfully replaced instance %s of cluster %s", p.Provider, instanceID, p.Name)
+	return nil
+}
+
+// checkMasterReplaceable checks that the cluster keeps working while the master is replaced.
+func checkMasterReplaceable(c *types.Cluster) error {
+	if len(c.MasterNodes) < 2 {
+		return fmt.Errorf("it's the only master of cluster %s, the cluster data can't be recovered by replacing it", c.Name)
+	}
+	// the etcd quorum of 2 members is lost with 1 member down, the member can't be removed then.
+	if c.Cluster && c.DataStore == "" && len(c.MasterNodes) < 3 {
+		return fmt.Errorf("the embedded etcd of cluster %s has lost its quorum, recover it by `autok3s cluster-reset` first", c.Name)
+	}
+	return nil
+}
+
+// deleteClusterNode deletes the node object of the instance through the server, nothing is deleted if it's not found.
+func (p *ProviderBase) deleteClusterNode(server *types.Node, node types.Node) error {
+	output, err := p.execute(server, nodeAddressesCommand)
+	if err != nil {
+		return fmt.Errorf("[%s] failed to list nodes on master %s: %v", p.Provider, server.InstanceID, err)
+	}
+	name := getNodeNameByAddress(output, node.InternalIPAddress)
+	if name == "" {
+		p.Logger.Warnf("[%s] node of instance %s is not found in cluster %s, skip deleting it", p.Provider, node.InstanceID, p.Name)
+		return nil
+	}
+	p.Logger.Infof("[%s] deleting node %s of instance %s...", p.Provider, name, node.InstanceID)
+	if _, err = p.execute(server, fmt.Sprintf("k3s kubectl delete node %s", name)); err != nil {
+		return fmt.Errorf("[%s] failed to delete node %s: %v", p.Provider, name, err)
+	}
+	return nil
+}
+
+// getNodeNameByAddress returns the name of the node which has one of the internal ips in the output of nodeAddressesCommand.
+func getNodeNameByAddress(output string, internalIPs []string) string {
+	ips := map[string]bool{}
+	for _, ip := range internalIPs {
+		ips[ip] = true
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		for i := 1; i < len(fields); i++ {
+			if ips[fields[i]] {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+func removeNode(nodes []types.Node, instanceID string) []types.Node {
+	rtn := make([]types.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.InstanceID != instanceID {
+			rtn = append(rtn, n)
+		}
+	}
+	return rtn
+}
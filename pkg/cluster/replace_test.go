@@ -0,0 +1,34 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeNameByAddress(t *testing.T) {
+	output := "master-1\t10.0.0.2\nworker-1\t10.0.0.3 192.168.0.3\nworker-2\t\n"
+	assert.Equal(t, "master-1", getNodeNameByAddress(output, []string{"10.0.0.2"}))
+	assert.Equal(t, "worker-1", getNodeNameByAddress(output, []string{"192.168.0.3"}))
+	assert.Equal(t, "", getNodeNameByAddress(output, []string{"10.0.0.4"}))
+	assert.Equal(t, "", getNodeNameByAddress("", []string{"10.0.0.2"}))
+}
+
+func TestCheckMasterReplaceable(t *testing.T) {
+	masters := func(num int) []types.Node {
+		return make([]types.Node, num)
+	}
+	c := &types.Cluster{Metadata: types.Metadata{Cluster: true}}
+	c.MasterNodes = masters(1)
+	assert.Error(t, checkMasterReplaceable(c))
+	c.MasterNodes = masters(2)
+	assert.Error(t, checkMasterReplaceable(c))
+	c.MasterNodes = masters(3)
+	assert.NoError(t, checkMasterReplaceable(c))
+
+	c = &types.Cluster{Metadata: types.Metadata{DataStore: "mysql://root@tcp(10.0.0.1:3306)/k3s"}}
+	c.MasterNodes = masters(2)
+	assert.NoError(t, checkMasterReplaceable(c))
+}
@@ -0,0 +1,146 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/airgap"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+)
+
+const etcdSnapshotSuggestion = "take a snapshot by `k3s etcd-snapshot save` on a healthy master before resetting if it's possible"
+
+// ResetControlPlane resets the embedded etcd of the master to a new single member cluster with `--cluster-reset`,
+// then the other masters are rejoined to it with their etcd data backed up.
+// It's destructive to the etcd quorum, so it's only used to recover a cluster which lost the quorum.
+func (p *ProviderBase) ResetControlPlane(node string) error {
+	if p.Provider == "k3d" {
+		return errors.New("resetting control plane for K3d provider is not supported yet")
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	c := common.ConvertToCluster(state, true)
+	if !c.Cluster || c.DataStore != "" {
+		return fmt.Errorf("[%s] cluster %s doesn't use embedded etcd, nothing to reset", p.Provider, p.Name)
+	}
+	index, err := getResetMasterIndex(c.MasterNodes, node)
+	if err != nil {
+		return fmt.Errorf("[%s] failed to reset cluster %s: %v", p.Provider, p.Name, err)
+	}
+	chosen := c.MasterNodes[index]
+
+	if !utils.AskForConfirmation(fmt.Sprintf("[%s] the etcd of cluster %s will be reset to a new cluster with the only member %s, "+
+		"the etcd data of the other %d masters will be discarded, %s. Are you sure to continue",
+		p.Provider, p.Name, getFirstAddress(chosen.InternalIPAddress), len(c.MasterNodes)-1, etcdSnapshotSuggestion), false) {
+		return fmt.Errorf("[%s] resetting control plane of cluster %s is canceled", p.Provider, p.Name)
+	}
+
+	logFile, err := common.GetLogFile(c.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+
+	provider, err := providers.GetProvider(p.Provider)
+	if err != nil {
+		return err
+	}
+	pkg, err := airgap.PreparePackage(&c)
+	if err != nil {
+		return err
+	}
+	// package's name is empty, it means that it is a temporary dir and it needs to be remove after.
+	if pkg != nil && pkg.Name == "" {
+		defer os.RemoveAll(pkg.FilePath)
+	}
+
+	// the chosen master becomes the first master, so that the joining nodes and the state point to it.
+	masters := append([]types.Node{chosen}, c.MasterNodes[:index]...)
+	masters = append(masters, c.MasterNodes[index+1:]...)
+	if c.IP == "" || isMasterAddress(c.MasterNodes, c.IP) {
+		c.IP = getFirstAddress(chosen.InternalIPAddress)
+	}
+	c.MasterNodes = masters
+
+	// all masters must be stopped before the reset, otherwise they keep serving the wedged etcd.
+	for i := range masters {
+		p.Logger.Infof("[%s] stopping k3s on master %s...", p.Provider, masters[i].InstanceID)
+		if _, err = p.execute(&masters[i], k3sStop); err != nil {
+			return err
+		}
+	}
+
+	p.Logger.Infof("[%s] resetting etcd on master %s...", p.Provider, chosen.InstanceID)
+	// the token is loaded from the env file of k3s service instead of the args, so that it isn't logged or listed.
+	if _, err = p.execute(&masters[0], k3sClusterReset); err != nil {
+		return err
+	}
+	if err = p.initControlNode(&c, provider, c.IP, pkg, masters[0], true); err != nil {
+		return err
+	}
+
+	for i := 1; i < len(masters); i++ {
+		p.Logger.Infof("[%s] rejoining master %s...", p.Provider, masters[i].InstanceID)
+		if _, err = p.execute(&masters[i], fmt.Sprintf(backupEtcdDataCommand, time.Now().Unix())); err != nil {
+			return err
+		}
+		if err = p.initControlNode(&c, provider, c.IP, pkg, masters[i], false); err != nil {
+			return err
+		}
+	}
+
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully reset control plane of cluster %s with master %s", p.Provider, p.Name, chosen.InstanceID)
+	return nil
+}
+
+// getResetMasterIndex returns the index of master matched by instance id or ip, the first master running etcd is used
+// if node is empty. The control-plane-only masters have no etcd data, so they can't be reset to.
+func getResetMasterIndex(masters []types.Node, node string) (int, error) {
+	for i, m := range masters {
+		if node == "" {
+			if m.MasterRole != types.MasterRoleControlPlane {
+				return i, nil
+			}
+			continue
+		}
+		if m.InstanceID == node || isMasterAddress([]types.Node{m}, node) {
+			if m.MasterRole == types.MasterRoleControlPlane {
+				return -1, fmt.Errorf("master %s only runs control-plane without etcd, choose a master running etcd", node)
+			}
+			return i, nil
+		}
+	}
+	if node == "" {
+		return -1, errors.New("no master running etcd is found")
+	}
+	return -1, fmt.Errorf("master %s is not found", node)
+}
+
+func isMasterAddress(masters []types.Node, ip string) bool {
+	for _, m := range masters {
+		for _, addrs := range [][]string{m.InternalIPAddress, m.PublicIPAddress} {
+			for _, addr := range addrs {
+				if addr == ip {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetResetMasterIndex(t *testing.T) {
+	masters := []types.Node{
+		{InstanceID: "ins-1", MasterRole: types.MasterRoleControlPlane, InternalIPAddress: []string{"10.0.0.1"}},
+		{InstanceID: "ins-2", MasterRole: types.MasterRoleEtcd, InternalIPAddress: []string{"10.0.0.2"}},
+		{InstanceID: "ins-3", InternalIPAddress: []string{"10.0.0.3"}},
+	}
+	cases := []struct {
+		name    string
+		masters []types.Node
+		node    string
+		index   int
+		wantErr bool
+	}{
+		{name: "default to the first master running etcd", masters: masters, index: 1},
+		{name: "by instance id", masters: masters, node: "ins-3", index: 2},
+		{name: "by ip", masters: masters, node: "10.0.0.2", index: 1},
+		{name: "control-plane-only master", masters: masters, node: "ins-1", index: -1, wantErr: true},
+		{name: "not found", masters: masters, node: "ins-4", index: -1, wantErr: true},
+		{name: "no master running etcd", masters: masters[:1], index: -1, wantErr: true},
+	}
+	for _, c := range cases {
+		index, err := getResetMasterIndex(c.masters, c.node)
+		assert.Equal(t, c.index, index, c.name)
+		assert.Equal(t, c.wantErr, err != nil, c.name)
+	}
+}
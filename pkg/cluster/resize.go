@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ResizeNode is not supported by default, providers which can change instance type override it.
+func (p *ProviderBase) ResizeNode(instanceID, instanceType string, force bool) error {
+	return fmt.Errorf("resizing node for %s provider is not supported yet", p.Provider)
+}
+
+// ResizeClusterNode changes the instance type of the node by the resize function, waits for the node to be Ready again
+// and records the new instance type in state.
+func (p *ProviderBase) ResizeClusterNode(instanceID, instanceType string, force bool, resize func(instanceID, instanceType string) error) error {
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	c := common.ConvertToCluster(state, true)
+
+	var node *types.Node
+	for _, nodes := range [][]types.Node{c.MasterNodes, c.WorkerNodes} {
+		for i := range nodes {
+			if nodes[i].InstanceID == instanceID {
+				node = &nodes[i]
+			}
+		}
+	}
+	if node == nil {
+		return fmt.Errorf("[%s] instance %s is not found in cluster %s", p.Provider, instanceID, p.Name)
+	}
+	if node.InstanceType == instanceType {
+		p.Logger.Infof("[%s] instance %s is already of type %s, skip", p.Provider, instanceID, instanceType)
+		return nil
+	}
+	// the cluster api is unavailable while the last master is stopped.
+	if node.Master && len(c.MasterNodes) == 1 && !force {
+		if !utils.AskForConfirmation(fmt.Sprintf("[%s] instance %s is the only master of cluster %s, the cluster will be unavailable during resizing, are you sure to continue",
+			p.Provider, instanceID, p.Name), false) {
+			return fmt.Errorf("[%s] resizing the last master %s is canceled", p.Provider, instanceID)
+		}
+	}
+
+	p.Logger.Infof("[%s] resizing instance %s to type %s...", p.Provider, instanceID, instanceType)
+	if err = resize(instanceID, instanceType); err != nil {
+		return err
+	}
+	if err = p.waitForNodeReady(node); err != nil {
+		return err
+	}
+
+	node.InstanceType = instanceType
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully resized instance %s to type %s", p.Provider, instanceID, instanceType)
+	return nil
+}
+
+// waitForNodeReady waits until the node of the instance rejoins the cluster in Ready status, the node is matched by
+// the instance id, or one of the internal ips if it's not annotated.
+func (p *ProviderBase) waitForNodeReady(instance *types.Node) error {
+	p.Logger.Infof("[%s] waiting for node of instance %s to be ready...", p.Provider, instance.InstanceID)
+	return wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		client, err := GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+		if err != nil {
+			return false, nil
+		}
+		nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return false, nil
+		}
+		for i := range nodes.Items {
+			node := &nodes.Items[i]
+			if isNodeOfInstance(node, instance) && isNodeReady(node) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
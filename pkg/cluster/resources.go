@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// the minimums of K3s, see: https://docs.k3s.io/installation/requirements#hardware
+	defaultMinCPU    = 1
+	defaultMinMemory = "512Mi"
+	defaultMinDisk   = "2Gi"
+	// the total memory reported by the kernel is less than the memory of instance, as part of it is reserved.
+	memoryTolerance = 0.1
+	// prints the cpu cores, the total memory in KiB and the free disk in KiB of the filesystem of K3s data dir,
+	// the nearest existing parent is used as the data dir isn't created before K3s is installed.
+	nodeResourcesCommand = `d=/var/lib/rancher/k3s; while [ ! -d "$d" ]; do d=$(dirname "$d"); done; ` +
+		`echo "$(nproc) $(awk '/^MemTotal:/{print $2}' /proc/meminfo) $(df -Pk "$d" | awk 'NR==2{print $4}')"`
+)
+
+func validateMinResources(m *types.Metadata) error {
+	if m.MinCPU < 0 {
+		return fmt.Errorf("`--min-cpu` must >= 0")
+	}
+	for flag, value := range map[string]string{"--min-memory": m.MinMemory, "--min-disk": m.MinDisk} {
+		if value == "" {
+			continue
+		}
+		if q, err := resource.ParseQuantity(value); err != nil || q.Sign() < 0 {
+			return fmt.Errorf("`%s` %q must be a non-negative quantity, e.g. 1Gi", flag, value)
+		}
+	}
+	return nil
+}
+
+// checkNodeResources fails the node early with a clear message if it doesn't meet the minimum cpu, memory and disk,
+// rather than letting K3s run out of memory or disk later. It's skipped if none of the minimums is set.
+func (p *ProviderBase) checkNodeResources(n *types.Node, c *types.Cluster) error {
+	if c.MinCPU == 0 && isZeroQuantity(c.MinMemory) && isZeroQuantity(c.MinDisk) {
+		return nil
+	}
+	output, err := p.execute(n, nodeResourcesCommand)
+	if err != nil {
+		return fmt.Errorf("[cluster] failed to check resources of node %s: %v", n.InstanceID, err)
+	}
+	cpu, memory, disk, err := parseNodeResources(output)
+	if err != nil {
+		return fmt.Errorf("[cluster] failed to check resources of node %s: %v", n.InstanceID, err)
+	}
+	if err = compareNodeResources(&c.Metadata, cpu, memory, disk); err != nil {
+		return fmt.Errorf("[cluster] node %s doesn't meet the minimum resources: %v, use a larger instance type or change the minimums by `--min-cpu`, `--min-memory` and `--min-disk`",
+			n.InstanceID, err)
+	}
+	return nil
+}
+
+// parseNodeResources parses the output of nodeResourcesCommand, the memory and disk are returned in bytes.
+func parseNodeResources(output string) (int, int64, int64, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 3 {
+		return 0, 0, 0, fmt.Errorf("unexpected output %q", strings.TrimSpace(output))
+	}
+	cpu, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid cpu cores %q", fields[0])
+	}
+	memory, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid total memory %q", fields[1])
+	}
+	disk, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid free disk %q", fields[2])
+	}
+	return cpu, memory * 1024, disk * 1024, nil
+}
+
+func compareNodeResources(m *types.Metadata, cpu int, memory, disk int64) error {
+	if cpu < m.MinCPU {
+		return fmt.Errorf("%d cpu cores is less than %d", cpu, m.MinCPU)
+	}
+	if !isZeroQuantity(m.MinMemory) {
+		min := resource.MustParse(m.MinMemory)
+		if float64(memory) < float64(min.Value())*(1-memoryTolerance) {
+			return fmt.Errorf("%dMi memory is less than %s", memory>>20, m.MinMemory)
+		}
+	}
+	if !isZeroQuantity(m.MinDisk) {
+		min := resource.MustParse(m.MinDisk)
+		if disk < min.Value() {
+			return fmt.Errorf("%dMi free disk is less than %s", disk>>20, m.MinDisk)
+		}
+	}
+	return nil
+}
+
+func isZeroQuantity(value string) bool {
+	if value == "" {
+		return true
+	}
+	q, err := resource.ParseQuantity(value)
+	return err != nil || q.IsZero()
+}
@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckNodeResources(t *testing.T) {
+	// 2 cores, 3.8Gi memory reported by the kernel of a 4Gi instance and 20Gi free disk.
+	cpu, memory, disk, err := parseNodeResources("2 3984588 20971520\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cpu)
+	assert.Equal(t, int64(3984588*1024), memory)
+	assert.Equal(t, int64(20<<30), disk)
+	_, _, _, err = parseNodeResources("2 3984588\n")
+	assert.Error(t, err)
+
+	for _, c := range []struct {
+		metadata types.Metadata
+		fail     bool
+	}{
+		{metadata: types.Metadata{MinCPU: defaultMinCPU, MinMemory: defaultMinMemory, MinDisk: defaultMinDisk}},
+		{metadata: types.Metadata{MinCPU: 2, MinMemory: "4Gi", MinDisk: "20Gi"}},
+		{metadata: types.Metadata{MinMemory: "0", MinDisk: "0"}},
+		{metadata: types.Metadata{MinCPU: 4}, fail: true},
+		{metadata: types.Metadata{MinMemory: "8Gi"}, fail: true},
+		{metadata: types.Metadata{MinDisk: "40Gi"}, fail: true},
+	} {
+		assert.NoError(t, validateMinResources(&c.metadata))
+		err = compareNodeResources(&c.metadata, cpu, memory, disk)
+		if c.fail {
+			assert.Error(t, err, c.metadata)
+		} else {
+			assert.NoError(t, err, c.metadata)
+		}
+	}
+
+	assert.Error(t, validateMinResources(&types.Metadata{MinMemory: "2GB"}))
+	assert.Error(t, validateMinResources(&types.Metadata{MinDisk: "-1Gi"}))
+	assert.Error(t, validateMinResources(&types.Metadata{MinCPU: -1}))
+}
@@ -0,0 +1,158 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+var (
+	// installRetryCount is the max attempts of installing K3s on a node.
+	installRetryCount = 3
+	// installRetryInterval is the interval between the install attempts.
+	installRetryInterval = 10 * time.Second
+)
+
+// defaultJoinRetries is the max retries of joining a worker if `--join-retries` isn't set.
+const defaultJoinRetries = 3
+
+// retryableInstallErrors are the messages of transient failures, e.g. ssh timeout, apt lock and download failure,
+// the install is retried on them. Other errors, e.g. the install script rejected the args, fail immediately.
+var retryableInstallErrors = []string{
+	// ssh connection.
+	"i/o timeout",
+	"connection refused",
+	"connection reset by peer",
+	"no route to host",
+	"handshake failed",
+	"broken pipe",
+	"unexpected EOF",
+	// package manager lock held by cloud-init or unattended upgrades.
+	"could not get lock",
+	"dpkg frontend lock",
+	"unable to acquire the dpkg",
+	"another app is currently holding the yum lock",
+	// download of install script, binaries and images.
+	"download failed",
+	"failed to download",
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"curl: (6)",
+	"curl: (7)",
+	"curl: (28)",
+	"curl: (35)",
+	"curl: (52)",
+	"curl: (56)",
+}
+
+// isRetryableInstallError returns true if the install error is a transient failure.
+func isRetryableInstallError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, retryable := range retryableInstallErrors {
+		if strings.Contains(msg, retryable) {
+			return true
+		}
+	}
+	return false
+}
+
+// initNodeWithRetry installs K3s on the node and retries on transient failures,
+// so that a single hiccup of ssh or mirror doesn't abort the whole cluster.
+func (p *ProviderBase) initNodeWithRetry(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node types.Node, extraArgs string, pkg *common.Package) error {
+	var err error
+	for attempt := 1; attempt <= installRetryCount; attempt++ {
+		if err = p.initNode(isFirstMaster, fixedIP, cluster, node, extraArgs, pkg); err == nil || !isRetryableInstallError(err) {
+			return err
+		}
+		if attempt < installRetryCount {
+			p.Logger.Warnf("[cluster] attempt %d/%d to install k3s on node %s failed with transient error, retry after %s: %v",
+				attempt, installRetryCount, getNodeName(node), installRetryInterval, err)
+			time.Sleep(installRetryInterval)
+		}
+	}
+	return err
+}
+
+func getNodeName(node types.Node) string {
+	if node.InstanceID != "" {
+		return node.InstanceID
+	}
+	return getFirstAddress(node.PublicIPAddress)
+}
+
+// retryableJoinErrors are the messages of the agent failures which may be recovered by the current token of the
+// cluster, e.g. the token is rotated on the masters, or the api-server is restarting.
+var retryableJoinErrors = []string{
+	"401 unauthorized",
+	"failed to validate token",
+	"failed to get ca certs",
+	"failed to retrieve configuration from server",
+	"k3s-agent.service",
+}
+
+// isRetryableJoinError returns true if the join of worker failed with a transient install error or an agent error
+// which may be fixed by refreshing the token.
+func isRetryableJoinError(err error) bool {
+	if isRetryableInstallError(err) {
+		return true
+	}
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, retryable := range retryableJoinErrors {
+		if strings.Contains(msg, retryable) {
+			return true
+		}
+	}
+	return false
+}
+
+// getJoinRetries returns the max retries of joining a worker, 0 means not retrying.
+func (p *ProviderBase) getJoinRetries() (int, error) {
+	if p.JoinRetries < 0 {
+		return 0, fmt.Errorf("[%s] calling preflight error: `--join-retries` must >= 0, got %d", p.Provider, p.JoinRetries)
+	}
+	return p.JoinRetries, nil
+}
+
+// joinWorkerWithRetry installs K3s agent on the worker, and re-fetches the current token from the server before
+// each retry, so that a rotated token or a restarting api-server only fails the worker after `--join-retries`.
+// The token is refreshed on a copy of the cluster, the other workers joining in parallel are not affected.
+func (p *ProviderBase) joinWorkerWithRetry(fixedIP string, cluster *types.Cluster, server, node types.Node, extraArgs string, pkg *common.Package) error {
+	retries, err := p.getJoinRetries()
+	if err != nil {
+		return err
+	}
+	c := *cluster
+	for attempt := 0; ; attempt++ {
+		if err = p.initNode(false, fixedIP, &c, node, extraArgs, pkg); err == nil || attempt >= retries || !isRetryableJoinError(err) {
+			return err
+		}
+		p.Logger.Warnf("[cluster] attempt %d/%d to join worker %s failed, retry after %s: %v",
+			attempt+1, retries+1, getNodeName(node), installRetryInterval, err)
+		time.Sleep(installRetryInterval)
+		token, tokenErr := p.execute(&server, getTokenCommand)
+		if tokenErr != nil {
+			p.Logger.Warnf("[cluster] failed to refresh token from server %s for worker %s, retry with the previous token: %v",
+				getFirstAddress(server.PublicIPAddress), getNodeName(node), tokenErr)
+			continue
+		}
+		if token = strings.TrimSpace(token); token != "" && token != c.Token {
+			p.Logger.Infof("[cluster] token of the cluster is changed, retry joining worker %s with the current token", getNodeName(node))
+			c.Token = token
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableInstallError(t *testing.T) {
+	for _, err := range []error{
+		errors.New("dial tcp 1.2.3.4:22: i/o timeout"),
+		fmt.Errorf("%w: %s", errors.New("Process exited with status 100"), "E: Could not get lock /var/lib/dpkg/lock-frontend"),
+		fmt.Errorf("%w: %s", errors.New("Process exited with status 1"), "[ERROR]  Download failed"),
+		errors.New("curl: (28) Connection timed out after 10001 milliseconds"),
+	} {
+		assert.True(t, isRetryableInstallError(err), err.Error())
+	}
+	for _, err := range []error{
+		nil,
+		fmt.Errorf("%w: %s", errors.New("Process exited with status 1"), "Error: unknown flag: --foo"),
+		errors.New("ssh: unable to authenticate, attempted methods [none publickey]"),
+	} {
+		assert.False(t, isRetryableInstallError(err))
+	}
+}
+
+func TestIsRetryableJoinError(t *testing.T) {
+	for _, err := range []error{
+		errors.New("dial tcp 1.2.3.4:22: connection refused"),
+		fmt.Errorf("%w: %s", errors.New("Process exited with status 1"), "Job for k3s-agent.service failed because the control process exited with error code."),
+		errors.New("failed to get CA certs: https://127.0.0.1:6444/cacerts: 401 Unauthorized"),
+	} {
+		assert.True(t, isRetryableJoinError(err), err.Error())
+	}
+	for _, err := range []error{
+		nil,
+		fmt.Errorf("%w: %s", errors.New("Process exited with status 1"), "Error: unknown flag: --foo"),
+	} {
+		assert.False(t, isRetryableJoinError(err))
+	}
+}
+
+func TestGetJoinRetries(t *testing.T) {
+	p := NewBaseProvider()
+	retries, err := p.getJoinRetries()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultJoinRetries, retries)
+	p.JoinRetries = -1
+	_, err = p.getJoinRetries()
+	assert.Error(t, err)
+}
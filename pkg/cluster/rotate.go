@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/hosts/dialer"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// RotateKubeconfig issues a new token of the autok3s-admin service account, revokes the old one, and updates the local
+// kubeconfig to use the new token. K3s isn't restarted, so neither the api nor the running workloads are disrupted.
+// It's safe to run it again if it fails halfway, the kubeconfig is only updated after the new token is issued.
+func (p *ProviderBase) RotateKubeconfig() error {
+	if p.Provider == "k3d" {
+		return errors.New("rotating kubeconfig for K3d provider is not supported yet")
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if state.Status != common.StatusRunning {
+		return fmt.Errorf("[%s] cluster %s is %s, only running cluster can rotate kubeconfig", p.Provider, p.Name, state.Status)
+	}
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) == 0 {
+		return fmt.Errorf("[%s] cluster %s has no master", p.Provider, p.Name)
+	}
+
+	logFile, err := common.GetLogFile(c.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+
+	master := &c.MasterNodes[0]
+	p.Logger.Infof("[%s] rotating admin token of cluster %s on master %s...", p.Provider, p.Name, master.InstanceID)
+	// deleting the secret revokes the old token, the token controller issues a new one for the secret applied again.
+	if _, err = p.executeWithRetry(3, master, deleteAdminTokenCommand, adminTokenManifestCommand); err != nil {
+		return err
+	}
+	c.KubeconfigRotated = true
+	cfg, err := p.getKubeconfig(&c, master)
+	if err != nil {
+		return err
+	}
+	if err = SaveCfg(cfg, getKubeconfigAddress(&c), c.ContextName); err != nil {
+		return err
+	}
+	// the kubeconfig saved later, e.g. by replacing the master, keeps using the token.
+	if err = common.DefaultDB.SaveCluster(&c); err != nil {
+		return err
+	}
+	p.KubeconfigRotated = true
+	p.Logger.Infof("[%s] successfully rotated kubeconfig of cluster %s", p.Provider, p.Name)
+	return nil
+}
+
+// getKubeconfig returns the kubeconfig of K3s on the master. Once the kubeconfig of the cluster is rotated, the admin
+// certificate is replaced with the token of autok3s-admin, so that the local kubeconfig doesn't go back to it.
+func (p *ProviderBase) getKubeconfig(c *types.Cluster, master *types.Node) (string, error) {
+	cfg, err := p.executeWithRetry(3, master, catCfgCommand)
+	if err != nil || !c.KubeconfigRotated {
+		return cfg, err
+	}
+	token, err := p.readAdminToken(master)
+	if err != nil {
+		return "", err
+	}
+	return useTokenKubeconfig(cfg, token)
+}
+
+// readAdminToken waits for the token controller to issue the token of the admin secret. The output isn't logged
+// because it's the credential of the cluster.
+func (p *ProviderBase) readAdminToken(master *types.Node) (string, error) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	var token []byte
+	err := wait.PollImmediate(2*time.Second, time.Minute, func() (bool, error) {
+		d, err := dialer.NewSSHDialer(master, true, logger)
+		if err != nil {
+			return false, nil
+		}
+		defer func() {
+			_ = d.Close()
+		}()
+		output, err := d.ExecuteCommands(getAdminTokenCommand)
+		if err != nil || strings.TrimSpace(output) == "" {
+			return false, nil
+		}
+		token, err = base64.StdEncoding.DecodeString(strings.TrimSpace(output))
+		return err == nil, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("[%s] failed to get admin token of cluster %s on master %s: %v", p.Provider, p.Name, master.InstanceID, err)
+	}
+	return string(token), nil
+}
+
+// useTokenKubeconfig replaces the admin certificate of the kubeconfig of K3s with the token.
+func useTokenKubeconfig(cfg, token string) (string, error) {
+	config, err := clientcmd.Load([]byte(cfg))
+	if err != nil {
+		return "", err
+	}
+	for _, authInfo := range config.AuthInfos {
+		authInfo.ClientCertificateData = nil
+		authInfo.ClientKeyData = nil
+		authInfo.Token = token
+	}
+	result, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
@@ -0,0 +1,40 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestUseTokenKubeconfig(t *testing.T) {
+	cfg := `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: Y2E=
+    server: https://127.0.0.1:6443
+  name: default
+contexts:
+- context:
+    cluster: default
+    user: default
+  name: default
+current-context: default
+kind: Config
+preferences: {}
+users:
+- name: default
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+`
+	result, err := useTokenKubeconfig(cfg, "new-token")
+	assert.Nil(t, err)
+	config, err := clientcmd.Load([]byte(result))
+	assert.Nil(t, err)
+	user := config.AuthInfos["default"]
+	assert.Equal(t, "new-token", user.Token)
+	assert.Empty(t, user.ClientCertificateData)
+	assert.Empty(t, user.ClientKeyData)
+	assert.Equal(t, []byte("ca"), config.Clusters["default"].CertificateAuthorityData)
+}
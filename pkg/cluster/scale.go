@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+)
+
+// WorkersRestore is the count of ScaleWorkers which restores the workers to the number before they were scaled.
+const WorkersRestore = "restore"
+
+// ScaleWorkers is not supported by default, providers which can launch and terminate instances override it.
+func (p *ProviderBase) ScaleWorkers(count string, force bool) error {
+	return fmt.Errorf("scaling workers for %s provider is not supported yet", p.Provider)
+}
+
+// ScaleWorkersCluster scales the workers of the cluster to count by ReconcileCluster while the masters are kept, e.g.
+// scale the workers of dev cluster to zero and keep the api-server alive. The number of workers before the first
+// scaling is recorded in the state, so that the count `restore` scales them back to it.
+func (p *ProviderBase) ScaleWorkersCluster(count string, force bool, drainTimeout time.Duration, terminate func(ids []string) error, join func(masterNum, workerNum int) error) error {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	current := len(common.ConvertToCluster(state, true).WorkerNodes)
+	workerNum, previous, err := getScaledWorkerNum(count, current, state.PreviousWorker)
+	if err != nil {
+		return fmt.Errorf("[%s] %v", p.Provider, err)
+	}
+
+	p.Master, p.Worker, p.PreviousWorker = "", strconv.Itoa(workerNum), previous
+	if err = p.ReconcileCluster(force, drainTimeout, terminate, join); err != nil {
+		return err
+	}
+
+	// the state is saved by the reconciling, so reload it to record the previous number.
+	if state, err = common.DefaultDB.GetCluster(p.Name, p.Provider); err != nil || state == nil {
+		return err
+	}
+	state.PreviousWorker = previous
+	if err = common.DefaultDB.SaveClusterState(state); err != nil {
+		return err
+	}
+	if previous != "" {
+		p.Logger.Infof("[%s] workers of cluster %s can be scaled back to %s by `%s`", p.Provider, p.Name, previous, WorkersRestore)
+	}
+	return nil
+}
+
+// getScaledWorkerNum returns the number of workers to scale to and the number to be recorded for restoring, the
+// number before the first scaling is kept until it's restored.
+func getScaledWorkerNum(count string, current int, previous string) (int, string, error) {
+	if count == WorkersRestore {
+		if previous == "" {
+			return 0, "", fmt.Errorf("the workers are not scaled, no number of workers to restore")
+		}
+		n, err := strconv.Atoi(previous)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid recorded number of workers %s", previous)
+		}
+		return n, "", nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return 0, "", fmt.Errorf("the count of workers %s must be a non-negative number or %s", count, WorkersRestore)
+	}
+	if previous == "" && n != current {
+		previous = strconv.Itoa(current)
+	}
+	return n, previous, nil
+}
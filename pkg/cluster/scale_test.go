@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetScaledWorkerNum(t *testing.T) {
+	// the number before the first scaling is recorded.
+	n, previous, err := getScaledWorkerNum("0", 3, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "3", previous)
+
+	// it's kept by the later scaling.
+	n, previous, err = getScaledWorkerNum("1", 0, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "3", previous)
+
+	// restore returns to it and clears it.
+	n, previous, err = getScaledWorkerNum(WorkersRestore, 1, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "", previous)
+
+	// nothing is recorded if the number isn't changed.
+	_, previous, err = getScaledWorkerNum("2", 2, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", previous)
+
+	_, _, err = getScaledWorkerNum(WorkersRestore, 2, "")
+	assert.NotNil(t, err)
+	_, _, err = getScaledWorkerNum("-1", 2, "")
+	assert.NotNil(t, err)
+}
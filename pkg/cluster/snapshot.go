@@ -0,0 +1,19 @@
+package cluster
+
+import (
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/utils"
+)
+
+// validateCronExpression validates the etcd snapshot schedule which is parsed by K3s as a standard cron expression,
+// i.e. 5 fields or a descriptor like `@daily` and `@every 6h`.
+func validateCronExpression(expr string) error {
+	expr = strings.TrimSpace(expr)
+	// the interval of `@every` is only supported by K3s, it's not a schedule of fixed minutes.
+	if strings.HasPrefix(expr, "@every ") {
+		return nil
+	}
+	_, err := utils.ParseCronSchedule(expr)
+	return err
+}
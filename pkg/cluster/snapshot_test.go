@@ -0,0 +1,16 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCronExpression(t *testing.T) {
+	for _, expr := range []string{"0 */12 * * *", "30 2 1-15 JAN,jul MON-FRI", "@daily", "@every 6h"} {
+		assert.Nil(t, validateCronExpression(expr), expr)
+	}
+	for _, expr := range []string{"0 */12 * *", "60 * * * *", "0 0 31-1 * *", "0 */0 * * *", "@often", "0 0 * FOO *"} {
+		assert.NotNil(t, validateCronExpression(expr), expr)
+	}
+}
@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+const (
+	// SnapshotterOverlayfs is the default snapshotter of K3s, it requires the overlay kernel module.
+	SnapshotterOverlayfs = "overlayfs"
+	// SnapshotterNative copies the layers without any kernel requirement, it's slow and uses more disk.
+	SnapshotterNative = "native"
+	// SnapshotterStargz pulls the eStargz images lazily by the embedded stargz plugin of K3s, it requires fuse.
+	SnapshotterStargz = "stargz"
+)
+
+// the kernel modules are loaded before K3s starts, so that the unsupported node fails with a clear message.
+var snapshotterPreflightCommands = map[string]string{
+	SnapshotterOverlayfs: "modprobe overlay 2>/dev/null; grep -qw overlay /proc/filesystems || " +
+		"(echo 'overlay filesystem is not supported by the kernel, use --snapshotter native instead' >&2; exit 1)",
+	SnapshotterStargz: "modprobe fuse 2>/dev/null; test -c /dev/fuse || " +
+		"(echo 'fuse is not supported by the kernel, which is required by stargz snapshotter' >&2; exit 1)",
+}
+
+func validateSnapshotter(snapshotter string) error {
+	switch snapshotter {
+	case "", SnapshotterOverlayfs, SnapshotterNative, SnapshotterStargz:
+		return nil
+	}
+	return fmt.Errorf("`--snapshotter` only supports %s, %s or %s", SnapshotterOverlayfs, SnapshotterNative, SnapshotterStargz)
+}
+
+// handleSnapshotter checks the kernel of the node supports the snapshotter, K3s generates the containerd config of
+// the snapshotter by `--snapshotter`, including the proxy plugin of stargz.
+func (p *ProviderBase) handleSnapshotter(n *types.Node, c *types.Cluster) error {
+	cmd, ok := snapshotterPreflightCommands[c.Snapshotter]
+	if !ok {
+		return nil
+	}
+	if _, err := p.execute(n, cmd); err != nil {
+		return fmt.Errorf("[cluster] node %s doesn't support snapshotter %s: %v", n.InstanceID, c.Snapshotter, err)
+	}
+	return nil
+}
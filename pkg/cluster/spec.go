@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterSpecConfigMap is the config map in kube-system which records the spec of the cluster.
+	ClusterSpecConfigMap = "autok3s-cluster-spec"
+	clusterSpecKey       = "spec.json"
+	redactedValue        = "<redacted>"
+)
+
+var (
+	// secretArgKeys are the parts of the arg names whose values are redacted from the spec.
+	secretArgKeys = []string{"secret", "password", "passphrase", "token", "access-key", "datastore"}
+	// secretFlagRegexp matches the values of the secret flags in the extra args.
+	secretFlagRegexp = regexp.MustCompile(`(--[\w-]*(?:secret|password|token|access-key|datastore-endpoint)[\w-]*[= ])\S+`)
+)
+
+// newClusterSpec returns the spec of the cluster created with the metadata and provider options,
+// the credentials, the contents of the files and the secret values of the extra args are redacted.
+func newClusterSpec(metadata types.Metadata, options interface{}) (*types.ClusterSpec, error) {
+	args := map[string]interface{}{}
+	if err := convertToMap(metadata, &args); err != nil {
+		return nil, err
+	}
+	delete(args, "cluster-spec")
+	if options != nil {
+		opts := map[string]interface{}{}
+		if err := convertToMap(options, &opts); err != nil {
+			return nil, err
+		}
+		redactArgs(opts)
+		args["options"] = opts
+	}
+	redactArgs(args)
+	return &types.ClusterSpec{
+		AutoK3sVersion: common.AutoK3sVersion,
+		CreatedAt:      time.Now().UTC(),
+		Args:           args,
+	}, nil
+}
+
+func convertToMap(v interface{}, m *map[string]interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, m)
+}
+
+func redactArgs(args map[string]interface{}) {
+	for k, v := range args {
+		if _, ok := v.(map[string]interface{}); ok {
+			continue
+		}
+		if isSecretArg(k) {
+			if v != "" && v != nil {
+				args[k] = redactedValue
+			}
+			continue
+		}
+		if s, ok := v.(string); ok && strings.HasSuffix(k, "extra-args") {
+			args[k] = secretFlagRegexp.ReplaceAllString(s, "${1}"+redactedValue)
+		}
+	}
+}
+
+func isSecretArg(key string) bool {
+	if strings.HasSuffix(key, "-content") {
+		return true
+	}
+	for _, s := range secretArgKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClusterSpec returns the autok3s version, the time and the redacted args which the cluster is created with.
+func (p *ProviderBase) GetClusterSpec() (*types.ClusterSpec, error) {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, NewClusterNotFoundError("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if state.ClusterSpec == "" {
+		return nil, fmt.Errorf("[%s] spec of cluster %s is not recorded, it's created by an older version of autok3s", p.Provider, p.Name)
+	}
+	spec := &types.ClusterSpec{}
+	if err = json.Unmarshal([]byte(state.ClusterSpec), spec); err != nil {
+		return nil, fmt.Errorf("[%s] failed to parse spec of cluster %s: %v", p.Provider, p.Name, err)
+	}
+	return spec, nil
+}
+
+// recordClusterSpec writes the spec of the cluster to the config map in kube-system.
+// It's best effort, the failure is only logged as the spec is saved in the state too.
+func (p *ProviderBase) recordClusterSpec(c *types.Cluster) {
+	if c.ClusterSpec == "" {
+		return
+	}
+	client, err := GetClusterConfig(c.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to record spec of cluster, can't load kubeconfig of cluster %s: %v", p.Provider, c.ContextName, err)
+		return
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterSpecConfigMap, Namespace: metav1.NamespaceSystem},
+		Data:       map[string]string{clusterSpecKey: c.ClusterSpec},
+	}
+	configMaps := client.CoreV1().ConfigMaps(metav1.NamespaceSystem)
+	_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to record spec of cluster %s: %v", p.Provider, c.ContextName, err)
+		return
+	}
+	p.Logger.Debugf("[%s] spec of cluster %s is recorded in config map %s/%s", p.Provider, c.ContextName, metav1.NamespaceSystem, ClusterSpecConfigMap)
+}
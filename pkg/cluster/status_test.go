@@ -0,0 +1,21 @@
+package cluster
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestIsAPIServerStarting(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	assert.True(t, isAPIServerStarting(refused))
+	assert.True(t, isAPIServerStarting(apierrors.NewInternalError(errors.New("readyz check failed"))))
+
+	unreachable := &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.EHOSTUNREACH}}
+	assert.False(t, isAPIServerStarting(unreachable))
+}
@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// GetClusterSummary returns the name, api-server endpoint, kubeconfig, UI URL and node addresses of the cluster,
+// it's read from the state, so the changes made by the provider after K3s is installed are included as well.
+func (p *ProviderBase) GetClusterSummary() (*types.ClusterSummary, error) {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, NewClusterNotFoundError("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	kubeCfg := filepath.Join(common.CfgPath, common.KubeCfgFile)
+	server := ""
+	if cfg, err := clientcmd.LoadFromFile(kubeCfg); err == nil {
+		if ctx, ok := cfg.Contexts[state.ContextName]; ok {
+			if cluster, ok := cfg.Clusters[ctx.Cluster]; ok {
+				server = cluster.Server
+			}
+		}
+	}
+	summary := newClusterSummary(common.ConvertToCluster(state, true), server)
+	if server != "" {
+		summary.KubeConfig = kubeCfg
+	}
+	if exp, err := common.DefaultDB.GetExplorer(state.ContextName); err == nil && exp != nil && exp.Enabled {
+		summary.UIURL = fmt.Sprintf("http://127.0.0.1:%d", exp.Port)
+	}
+	return summary, nil
+}
+
+// newClusterSummary returns the summary of the cluster with the api-server endpoint of the kubeconfig context.
+func newClusterSummary(c types.Cluster, server string) *types.ClusterSummary {
+	summary := &types.ClusterSummary{
+		Name:        c.Name,
+		ContextName: c.ContextName,
+		Provider:    c.Provider,
+		Status:      c.Status.Status,
+		APIServer:   server,
+		Nodes:       make([]types.SummaryNode, 0, len(c.MasterNodes)+len(c.WorkerNodes)),
+	}
+	for _, nodes := range []struct {
+		role  string
+		nodes []types.Node
+	}{
+		{role: "master", nodes: c.MasterNodes},
+		{role: "worker", nodes: c.WorkerNodes},
+	} {
+		for _, n := range nodes.nodes {
+			summary.Nodes = append(summary.Nodes, types.SummaryNode{
+				InstanceID: n.InstanceID,
+				Role:       nodes.role,
+				PublicIP:   getFirstAddress(n.PublicIPAddress),
+				InternalIP: getFirstAddress(n.InternalIPAddress),
+			})
+		}
+	}
+	return summary
+}
@@ -0,0 +1,32 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClusterSummary(t *testing.T) {
+	c := types.Cluster{}
+	c.Name = "myk3s"
+	c.ContextName = "myk3s.ap-guangzhou.tencent"
+	c.Provider = "tencent"
+	c.Status.Status = "Running"
+	c.MasterNodes = []types.Node{{InstanceID: "ins-1", PublicIPAddress: []string{"1.1.1.1"}, InternalIPAddress: []string{"10.0.0.2"}}}
+	c.WorkerNodes = []types.Node{{InstanceID: "ins-2", InternalIPAddress: []string{"", "10.0.0.3"}}}
+
+	summary := newClusterSummary(c, "https://1.1.1.1:6443")
+	assert.Equal(t, &types.ClusterSummary{
+		Name:        "myk3s",
+		ContextName: "myk3s.ap-guangzhou.tencent",
+		Provider:    "tencent",
+		Status:      "Running",
+		APIServer:   "https://1.1.1.1:6443",
+		Nodes: []types.SummaryNode{
+			{InstanceID: "ins-1", Role: "master", PublicIP: "1.1.1.1", InternalIP: "10.0.0.2"},
+			{InstanceID: "ins-2", Role: "worker", InternalIP: "10.0.0.3"},
+		},
+	}, summary)
+}
@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minTokenLength is the length of token below which it's treated as weak, the generated token has 32 characters.
+const minTokenLength = 16
+
+// secureTokenRegexp matches the full token format of K3s, i.e. `K10<sha256 of server ca>::<username>:<password>`.
+var secureTokenRegexp = regexp.MustCompile(`^K10[0-9a-f]{64}::[^:]+:.+$`)
+
+// validateToken checks that the token can be passed to the install script, the token in full format must be
+// generated by K3s. It returns whether the token, or the password of the full format, is weak.
+func validateToken(token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	if strings.ContainsAny(token, "'\"\\`$") || strings.IndexFunc(token, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return false, fmt.Errorf("`--token` must not contain whitespaces, quotes, backslashes, backticks or dollar signs")
+	}
+	password := token
+	if strings.HasPrefix(token, "K10") {
+		if !secureTokenRegexp.MatchString(token) {
+			return false, fmt.Errorf("`--token` starts with K10 must be in format K10<ca hash>::<username>:<password>")
+		}
+		password = token[strings.LastIndex(token, ":")+1:]
+	}
+	return isWeakToken(password), nil
+}
+
+// isWeakToken returns true if the token is shorter than minTokenLength or has only one kind of characters.
+func isWeakToken(token string) bool {
+	if len(token) < minTokenLength {
+		return true
+	}
+	var lower, upper, digit, other bool
+	for _, r := range token {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	kinds := 0
+	for _, b := range []bool{lower, upper, digit, other} {
+		if b {
+			kinds++
+		}
+	}
+	return kinds < 2
+}
@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateToken(t *testing.T) {
+	caHash := strings.Repeat("a1", 32)
+	for _, c := range []struct {
+		token   string
+		weak    bool
+		invalid bool
+	}{
+		{token: ""},
+		{token: "0123456789abcdef0123456789abcdef"},
+		{token: "short1", weak: true},
+		{token: "abcdefghijklmnopqrstuvwxyz", weak: true},
+		{token: "K10" + caHash + "::server:0123456789abcdef0123456789abcdef"},
+		{token: "K10" + caHash + "::server:secret", weak: true},
+		{token: "K10" + caHash + ":server:0123456789abcdef", invalid: true},
+		{token: "K10invalid", invalid: true},
+		{token: "token with space 1234", invalid: true},
+		{token: "token'with'quote1234", invalid: true},
+		{token: "token$withdollar1234", invalid: true},
+	} {
+		weak, err := validateToken(c.token)
+		if c.invalid {
+			assert.Error(t, err, c.token)
+			continue
+		}
+		assert.NoError(t, err, c.token)
+		assert.Equal(t, c.weak, weak, c.token)
+	}
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+)
+
+// DefaultBatchConcurrency is the number of clusters processed at the same time if the concurrency isn't set.
+const DefaultBatchConcurrency = 4
+
+// BatchResult is the result of a cluster in the batch operation, Err is nil if it succeeded.
+type BatchResult struct {
+	ContextName string
+	Err         error
+}
+
+// GetProviderByContext returns the provider of the cluster with the saved options, e.g. `myk3s.ap-guangzhou.tencent`.
+// A new provider instance is returned for each call, so the clusters can be operated concurrently.
+func GetProviderByContext(contextName string) (providers.Provider, error) {
+	state, err := DefaultDB.GetClusterByID(contextName)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("cluster %s is not exist", contextName)
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return nil, err
+	}
+	opt, err := provider.GetProviderOptions(state.Options)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(&types.Cluster{
+		Metadata: state.Metadata,
+		Options:  opt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.SetConfig(b); err != nil {
+		return nil, err
+	}
+	if err = provider.MergeClusterOptions(); err != nil {
+		return nil, err
+	}
+	provider.GenerateClusterName()
+	return provider, nil
+}
+
+// DeleteClusters deletes the clusters of the context names concurrently without confirmation, at most concurrency
+// clusters are deleted at the same time. Each cluster is marked as removing by its own deletion, the cluster with
+// an operation in progress is failed unless force is true. The results are returned in the order of the context names.
+func DeleteClusters(contextNames []string, concurrency int, force bool) []BatchResult {
+	// the prompt of each deletion is skipped by the env rather than force, as force also ignores the errors of deletion.
+	if assumeYes, ok := os.LookupEnv(utils.AssumeYesEnv); ok {
+		defer func() { _ = os.Setenv(utils.AssumeYesEnv, assumeYes) }()
+	} else {
+		defer func() { _ = os.Unsetenv(utils.AssumeYesEnv) }()
+	}
+	_ = os.Setenv(utils.AssumeYesEnv, "true")
+	return runBatch(contextNames, concurrency, func(contextName string) error {
+		state, err := DefaultDB.GetClusterByID(contextName)
+		if err != nil {
+			return err
+		}
+		if state != nil && !force && (state.Status == StatusUpgrading || state.Status == StatusRemoving) {
+			return fmt.Errorf("cluster %s is %s, wait for the operation in progress to finish or use force", contextName, state.Status)
+		}
+		provider, err := GetProviderByContext(contextName)
+		if err != nil {
+			return err
+		}
+		return provider.DeleteK3sCluster(force)
+	})
+}
+
+// runBatch runs fn for each of the distinct names with a bounded pool of goroutines and collects the errors.
+func runBatch(names []string, concurrency int, fn func(name string) error) []BatchResult {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	results := make([]BatchResult, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		results = append(results, BatchResult{ContextName: name})
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range results {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *BatchResult) {
+			defer func() {
+				if e := recover(); e != nil {
+					r.Err = fmt.Errorf("panic: %v", e)
+				}
+				<-sem
+				wg.Done()
+			}()
+			r.Err = fn(r.ContextName)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBatch(t *testing.T) {
+	var running, maxRunning int32
+	results := runBatch([]string{"a", "b", "c", "a", "d", "e"}, 2, func(name string) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		switch name {
+		case "b":
+			return fmt.Errorf("failed to delete %s", name)
+		case "d":
+			panic("unexpected")
+		}
+		return nil
+	})
+
+	assert.LessOrEqual(t, maxRunning, int32(2))
+	names := make([]string, 0, len(results))
+	failed := map[string]bool{}
+	for _, r := range results {
+		names = append(names, r.ContextName)
+		if r.Err != nil {
+			failed[r.ContextName] = true
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, names)
+	assert.Equal(t, map[string]bool{"b": true, "d": true}, failed)
+}
@@ -37,6 +37,8 @@ const (
 	StatusRemoving = "Removing"
 	// StatusUnknown instance unknown status
 	StatusUnknown = "Unknown"
+	// StatusInfraReady instances are provisioned but K3s isn't installed.
+	StatusInfraReady = "InfraReady"
 	// UsageInfoTitle usage info title.
 	UsageInfoTitle = "=========================== Prompt Info ==========================="
 	// UsageContext usage info context.
@@ -52,6 +54,8 @@ const (
 var (
 	// IsCLI means that you are not running serve command.
 	IsCLI = true
+	// AutoK3sVersion the version of autok3s, set by main.
+	AutoK3sVersion = "dev"
 	// Debug used to enable log debug level.
 	Debug = false
 	// CfgPath default config file dir.
@@ -62,6 +66,8 @@ var (
 		Factor:   1,
 		Steps:    20,
 	}
+	// DescribeTimeout the time to wait for the api-server to be ready when getting cluster status, 0 means no wait.
+	DescribeTimeout time.Duration
 	// DefaultDB default database store.
 	DefaultDB         *Store
 	ExplorerWatchers  map[string]context.CancelFunc
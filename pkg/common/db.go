@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"os"
 
 	"github.com/cnrancher/autok3s/pkg/settings"
 	"github.com/cnrancher/autok3s/pkg/types"
@@ -111,6 +112,10 @@ func InitStorage(ctx context.Context) error {
 	if err := utils.EnsureFileExist(dataSource); err != nil {
 		return err
 	}
+	// the database keeps the credentials and the tokens of clusters, it's only readable by the owner.
+	if err := os.Chmod(dataSource, 0600); err != nil {
+		return err
+	}
 
 	store, err := NewClusterDB(ctx)
 	if err != nil {
@@ -130,6 +135,7 @@ func InitStorage(ctx context.Context) error {
 		&Setting{},
 		&SSHKey{},
 		&Addon{},
+		&Operation{},
 	); err != nil {
 		return err
 	}
@@ -32,7 +32,7 @@ var DefaultTemplates = map[string]interface{}{
 		SpotDuration:            1,
 	},
 	"tencent": tencent.Options{
-		ImageID:                 "img-487zeit5", // Ubuntu 22.04 LTS 64 bit
+		ImageFamily:             "ubuntu-22.04", // the latest Ubuntu 22.04 LTS 64 bit of the region
 		InstanceType:            "S5.MEDIUM4",   // 2c/4g
 		InstanceChargeType:      "POSTPAID_BY_HOUR",
 		InternetMaxBandwidthOut: "5",
@@ -40,9 +40,16 @@ var DefaultTemplates = map[string]interface{}{
 		SystemDiskSize:          "50",
 		Region:                  "ap-guangzhou",
 		Zone:                    "ap-guangzhou-6",
+		VpcName:                 "autok3s-tencent-vpc",
+		SubnetName:              "autok3s-tencent-subnet",
+		SecurityGroupName:       "autok3s",
 		PublicIPAssignedEIP:     false,
 		Spot:                    false,
 		CloudControllerManager:  false,
+		CCMVersion:              "1.0.1",
+		EnableCSI:               false,
+		CSIVersion:              "v1.2.3",
+		CSIDiskType:             "CLOUD_PREMIUM",
 	},
 	"google": google.Options{
 		Region:       "us-central1",
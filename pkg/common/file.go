@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/cnrancher/autok3s/pkg/utils"
+
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/client-go/tools/clientcmd"
@@ -58,7 +60,10 @@ func (c *ConfigFileManager) OverwriteCfg(path string, context string, cfg func(s
 	if err != nil {
 		return err
 	}
-	return clientcmd.WriteToFile(*config, path)
+	if err = clientcmd.WriteToFile(*config, path); err != nil {
+		return err
+	}
+	return utils.EnsureFilePrivate(path)
 }
 
 // RemoveCfg removes kubectl config file.
@@ -98,3 +103,57 @@ func (c *ConfigFileManager) MergeCfg(context string, configAccess clientcmd.Conf
 	config, err := configAccess.GetStartingConfig()
 	return config, err
 }
+
+// ExportCfg writes the kube config of the specified context to the given path.
+func (c *ConfigFileManager) ExportCfg(context, path string) error {
+	c.mutex.RLock()
+	config, err := clientcmd.LoadFromFile(filepath.Join(CfgPath, KubeCfgFile))
+	c.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Contexts[context]; !ok {
+		return fmt.Errorf("context %s is not exist in kubeconfig file", context)
+	}
+	config.CurrentContext = context
+	if err = api.MinifyConfig(config); err != nil {
+		return err
+	}
+	// the existing file keeps its permission when it's overwritten, so it's restricted to 0600 if the group or others can access it.
+	if err = clientcmd.WriteToFile(*config, path); err != nil {
+		return err
+	}
+	return utils.EnsureFilePrivate(path)
+}
+
+// privateStoreFiles are the files of the cluster store which contain the ssh keys of clusters.
+var privateStoreFiles = []string{"id_rsa", "id_rsa.pub", "pub.cert"}
+
+// EnsureStorePermissions checks the kubeconfig and the ssh keys of the cluster store are only accessible by the current
+// user and repairs their permissions, the files written by the former versions may be world-readable depending on umask.
+// The files owned by other users are only warned.
+func EnsureStorePermissions() error {
+	files := []string{filepath.Join(CfgPath, KubeCfgFile)}
+	entries, err := os.ReadDir(CfgPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		for _, name := range privateStoreFiles {
+			files = append(files, filepath.Join(CfgPath, entry.Name(), name))
+		}
+	}
+	errs := make([]string, 0)
+	for _, file := range files {
+		if err = utils.EnsureFilePrivate(file); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -1,14 +1,25 @@
 package common
 
 import (
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// MaxLogSize the size of cluster log file to be rotated when an operation starts.
+	MaxLogSize int64 = 10 << 20
+	// MaxLogBackups the number of rotated cluster log files to keep.
+	MaxLogBackups = 5
+	// MaxLogAge the rotated cluster log files older than it are removed.
+	MaxLogAge = 30 * 24 * time.Hour
+)
+
 // NewLogger returns new logger struct.
 func NewLogger(w *os.File) (logger *logrus.Logger) {
 	if w != nil {
@@ -36,12 +47,20 @@ func GetClusterContextPath(clusterName string) string {
 	return filepath.Join(CfgPath, clusterName)
 }
 
-// GetLogFile open and return log file.
+// GetRotatedLogFilePath returns the path of the rotated cluster log file, 1 is the latest one.
+func GetRotatedLogFilePath(clusterName string, index int) string {
+	return fmt.Sprintf("%s.%d", GetClusterLogFilePath(clusterName), index)
+}
+
+// GetLogFile open and return log file, the log file is rotated first if it's larger than MaxLogSize.
 func GetLogFile(clusterName string) (logFile *os.File, err error) {
 	logFilePath := GetClusterLogFilePath(clusterName)
 	if err = os.MkdirAll(filepath.Dir(logFilePath), 0755); err != nil {
 		return nil, err
 	}
+	if err = rotateLogFile(clusterName); err != nil {
+		logrus.Warnf("failed to rotate log file of cluster %s: %v", clusterName, err)
+	}
 	// check file exist
 	_, err = os.Stat(logFilePath)
 	if err != nil {
@@ -55,6 +74,40 @@ func GetLogFile(clusterName string) (logFile *os.File, err error) {
 	return logFile, err
 }
 
+// rotateLogFile renames the log file to `log.1` and shifts the rotated ones, only MaxLogBackups of them are kept.
+// The log file is renamed instead of truncated, so the operation in progress which holds the file keeps writing to
+// the rotated one, and the tailing reader reopens the new one.
+func rotateLogFile(clusterName string) error {
+	logFilePath := GetClusterLogFilePath(clusterName)
+	info, err := os.Stat(logFilePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() >= MaxLogSize {
+		_ = os.Remove(GetRotatedLogFilePath(clusterName, MaxLogBackups))
+		for i := MaxLogBackups - 1; i >= 1; i-- {
+			if err = os.Rename(GetRotatedLogFilePath(clusterName, i), GetRotatedLogFilePath(clusterName, i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err = os.Rename(logFilePath, GetRotatedLogFilePath(clusterName, 1)); err != nil {
+			return err
+		}
+	}
+	for i := 1; i <= MaxLogBackups; i++ {
+		path := GetRotatedLogFilePath(clusterName, i)
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > MaxLogAge {
+			if err = os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func InitLogger(logger *logrus.Logger) {
 	if Debug {
 		logger.SetLevel(logrus.DebugLevel)
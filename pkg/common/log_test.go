@@ -0,0 +1,58 @@
+package common
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLogFileRotation(t *testing.T) {
+	cfgPath, maxLogSize, maxLogBackups := CfgPath, MaxLogSize, MaxLogBackups
+	defer func() {
+		CfgPath, MaxLogSize, MaxLogBackups = cfgPath, maxLogSize, maxLogBackups
+	}()
+	CfgPath, MaxLogSize, MaxLogBackups = t.TempDir(), 4, 2
+	name := "test.ap-guangzhou.tencent"
+
+	// the handle of the operation in progress keeps writing to the rotated file.
+	inProgress, err := GetLogFile(name)
+	assert.Nil(t, err)
+	defer inProgress.Close()
+	_, err = inProgress.WriteString("first")
+	assert.Nil(t, err)
+	for _, content := range []string{"second", "third"} {
+		f, err := GetLogFile(name)
+		assert.Nil(t, err)
+		_, err = f.WriteString(content)
+		assert.Nil(t, err)
+		_ = f.Close()
+	}
+	_, err = inProgress.WriteString("-done")
+	assert.Nil(t, err)
+	assertLogContents(t, map[string]string{
+		GetClusterLogFilePath(name):    "third",
+		GetRotatedLogFilePath(name, 1): "second",
+		GetRotatedLogFilePath(name, 2): "first-done",
+	})
+
+	// only MaxLogBackups rotated files are kept.
+	f, err := GetLogFile(name)
+	assert.Nil(t, err)
+	_ = f.Close()
+	assertLogContents(t, map[string]string{
+		GetClusterLogFilePath(name):    "",
+		GetRotatedLogFilePath(name, 1): "third",
+		GetRotatedLogFilePath(name, 2): "second",
+	})
+	_, err = os.Stat(GetRotatedLogFilePath(name, 3))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func assertLogContents(t *testing.T, contents map[string]string) {
+	for path, content := range contents {
+		b, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, string(b))
+	}
+}
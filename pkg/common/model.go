@@ -27,6 +27,7 @@ var (
 		&Package{},
 		&SSHKey{},
 		&Addon{},
+		&Operation{},
 	}
 )
 
@@ -107,6 +108,19 @@ func (s *Setting) GetID() string {
 	return s.Name
 }
 
+// Operation struct for the in-flight async operation of cloud provider, which is queried by its task id later.
+type Operation struct {
+	TaskID      string `json:"task-id" gorm:"primaryKey;not null"`
+	ContextName string `json:"context-name"`
+	Provider    string `json:"provider"`
+	Action      string `json:"action"`
+	Status      string `json:"status"`
+}
+
+func (o *Operation) GetID() string {
+	return o.TaskID
+}
+
 type event struct {
 	Name   string
 	Object interface{}
@@ -117,6 +131,8 @@ type LogEvent struct {
 	Name        string
 	ContextType string
 	ContextName string
+	// Summary is the result of the successfully created cluster, it's only set by the create event.
+	Summary *types.ClusterSummary
 }
 
 // Store holds broadcaster's API state.
@@ -417,6 +433,10 @@ func (d *Store) DeleteCluster(name, provider string) error {
 		return nil
 	}
 	result := d.DB.Where("name = ? AND provider = ?", name, provider).Delete(&ClusterState{})
+	if result.Error == nil {
+		// the operations of the cluster are useless after it's deleted.
+		_ = d.DeleteOperations(state.ContextName)
+	}
 	d.broadcaster.Broadcast(&event{
 		Name:   apitypes.RemoveAPIEvent,
 		Object: GetAPIObject(state),
@@ -691,6 +711,46 @@ func (d *Store) ListSettings() ([]*Setting, error) {
 	return list, result.Error
 }
 
+// SaveOperation saves the status of async operation.
+func (d *Store) SaveOperation(o *Operation) error {
+	e, err := d.GetOperation(o.TaskID)
+	if err != nil {
+		return err
+	}
+	if e != nil {
+		result := d.DB.Where("task_id = ? ", o.TaskID).Omit("task_id").Save(o)
+		return result.Error
+	}
+	result := d.DB.Create(o)
+	return result.Error
+}
+
+// GetOperation return the async operation by task id.
+func (d *Store) GetOperation(taskID string) (*Operation, error) {
+	o := &Operation{}
+	result := d.DB.Where("task_id = ? ", taskID).Find(o)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return o, nil
+}
+
+// ListOperations list the async operations of the cluster.
+func (d *Store) ListOperations(contextName string) ([]*Operation, error) {
+	list := make([]*Operation, 0)
+	result := d.DB.Where("context_name = ? ", contextName).Find(&list)
+	return list, result.Error
+}
+
+// DeleteOperations remove the async operations of the cluster.
+func (d *Store) DeleteOperations(contextName string) error {
+	result := d.DB.Where("context_name = ? ", contextName).Delete(&Operation{})
+	return result.Error
+}
+
 func GetAPIObject(v interface{}) *apitypes.APIObject {
 	rtn, ok := v.(ISchemaObject)
 	if ok {
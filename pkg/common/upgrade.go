@@ -0,0 +1,166 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	k3sChannelServer  = "https://update.k3s.io/v1-release/channels"
+	defaultK3sChannel = "stable"
+)
+
+var (
+	// k3sMinorVersionRegexp matches the minor version of K3s release like v1.28.5+k3s1, which is also a channel.
+	k3sMinorVersionRegexp = regexp.MustCompile(`^(v\d+\.\d+)\.\d+`)
+	// channelClient doesn't follow the redirect, the location of which is the latest release of the channel.
+	channelClient = &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	// scheduledUpgrades holds the clusters being upgraded by the scheduler, so a long upgrade isn't started twice.
+	scheduledUpgrades = sync.Map{}
+)
+
+// InitUpgradeScheduler checks the upgrade window of clusters every minute until ctx is done,
+// the clusters in window are upgraded to the latest patch release of their K3s channel.
+func InitUpgradeScheduler(ctx context.Context) {
+	logrus.Infof("starting k3s upgrade scheduler")
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			runScheduledUpgrades(now.Truncate(time.Minute))
+		}
+	}
+}
+
+func runScheduledUpgrades(now time.Time) {
+	states, err := DefaultDB.ListCluster("")
+	if err != nil {
+		logrus.Errorf("[upgrade-scheduler] failed to list clusters: %v", err)
+		return
+	}
+	for _, state := range states {
+		if state.UpgradeWindow == "" {
+			continue
+		}
+		schedule, err := utils.ParseCronSchedule(state.UpgradeWindow)
+		if err != nil {
+			logrus.Warnf("[upgrade-scheduler] invalid upgrade window %q of cluster %s: %v", state.UpgradeWindow, state.ContextName, err)
+			continue
+		}
+		if !schedule.Match(now) {
+			continue
+		}
+		if err := scheduleUpgrade(state); err != nil {
+			logrus.Warnf("[upgrade-scheduler] skip upgrading cluster %s: %v", state.ContextName, err)
+		}
+	}
+}
+
+// scheduleUpgrade starts the rolling upgrade of the cluster in background if there is a newer patch release.
+func scheduleUpgrade(state *ClusterState) error {
+	if state.Provider == "k3d" {
+		return fmt.Errorf("the upgrade cluster for K3d provider is not supported yet")
+	}
+	if state.PackageName != "" || state.PackagePath != "" {
+		return fmt.Errorf("the cluster is installed by airgap package")
+	}
+	// the upgrade without `--k3s-sha256` clears the pinned sha256, so the cluster is left to the manual upgrade
+	// which sets the sha256 of the new binary.
+	if state.K3sSHA256 != "" {
+		return fmt.Errorf("the sha256 of k3s binary is pinned by `--k3s-sha256`, the new version can't be verified, upgrade it with the sha256 of the new version instead")
+	}
+	// the status is updated by other operations, a cluster in progress isn't touched.
+	if state.Status != StatusRunning {
+		return fmt.Errorf("the cluster is %s", state.Status)
+	}
+	if _, loaded := scheduledUpgrades.LoadOrStore(state.ContextName, true); loaded {
+		return fmt.Errorf("the last scheduled upgrade is still running")
+	}
+
+	channel := getUpgradeChannel(state.K3sChannel, state.K3sVersion)
+	version, err := getChannelLatestVersion(channel)
+	if err != nil {
+		scheduledUpgrades.Delete(state.ContextName)
+		return err
+	}
+	if version == state.K3sVersion {
+		scheduledUpgrades.Delete(state.ContextName)
+		logrus.Infof("[upgrade-scheduler] cluster %s is already the latest version %s of channel %s", state.ContextName, version, channel)
+		return nil
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		scheduledUpgrades.Delete(state.ContextName)
+		return err
+	}
+
+	logrus.Infof("[upgrade-scheduler] upgrading cluster %s from %q to %s of channel %s", state.ContextName, state.K3sVersion, version, channel)
+	provider.RegisterCallbacks(state.ContextName, "update", DefaultDB.BroadcastObject)
+	go func(name, contextName string) {
+		defer scheduledUpgrades.Delete(contextName)
+		if err := provider.UpgradeK3sCluster(name, "", "", version, "", "", ""); err != nil {
+			logrus.Errorf("[upgrade-scheduler] failed to upgrade cluster %s: %v", contextName, err)
+			return
+		}
+		logrus.Infof("[upgrade-scheduler] successfully upgraded cluster %s to %s", contextName, version)
+	}(state.Name, state.ContextName)
+	return nil
+}
+
+// getUpgradeChannel returns the channel to upgrade with. The cluster pinned to a version without channel is upgraded
+// in the channel of its minor version, so only the patch release is upgraded.
+func getUpgradeChannel(channel, version string) string {
+	if channel != "" {
+		return channel
+	}
+	if m := k3sMinorVersionRegexp.FindStringSubmatch(version); m != nil {
+		return m[1]
+	}
+	return defaultK3sChannel
+}
+
+// getChannelLatestVersion returns the latest release of the channel from the redirect location of K3s channel server.
+func getChannelLatestVersion(channel string) (string, error) {
+	resp, err := channelClient.Get(fmt.Sprintf("%s/%s", k3sChannelServer, url.PathEscape(channel)))
+	if err != nil {
+		return "", fmt.Errorf("failed to get the latest version of channel %s: %v", channel, err)
+	}
+	defer resp.Body.Close()
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("failed to get the latest version of channel %s: got status %s", channel, resp.Status)
+	}
+	return parseReleaseVersion(location)
+}
+
+// parseReleaseVersion returns the version of release url like https://github.com/k3s-io/k3s/releases/tag/v1.28.5%2Bk3s1.
+func parseReleaseVersion(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	version := path.Base(u.Path)
+	if !k3sMinorVersionRegexp.MatchString(version) {
+		return "", fmt.Errorf("invalid release url %s", location)
+	}
+	return version, nil
+}
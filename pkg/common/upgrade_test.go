@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUpgradeChannel(t *testing.T) {
+	assert.Equal(t, "latest", getUpgradeChannel("latest", "v1.28.5+k3s1"))
+	assert.Equal(t, "v1.28", getUpgradeChannel("", "v1.28.5+k3s1"))
+	assert.Equal(t, defaultK3sChannel, getUpgradeChannel("", ""))
+}
+
+func TestParseReleaseVersion(t *testing.T) {
+	version, err := parseReleaseVersion("https://github.com/k3s-io/k3s/releases/tag/v1.28.5%2Bk3s1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.28.5+k3s1", version)
+	_, err = parseReleaseVersion("https://github.com/k3s-io/k3s/releases")
+	assert.NotNil(t, err)
+}
+
+func TestScheduleUpgradeSkipsPinnedSHA256(t *testing.T) {
+	state := &ClusterState{
+		Metadata: types.Metadata{Provider: "tencent", ContextName: "myk3s.ap-guangzhou.tencent", K3sSHA256: "abc"},
+		Status:   StatusRunning,
+	}
+	err := scheduleUpgrade(state)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "--k3s-sha256")
+	_, scheduled := scheduledUpgrades.Load(state.ContextName)
+	assert.False(t, scheduled)
+}
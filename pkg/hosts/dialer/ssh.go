@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +31,10 @@ var defaultBackoff = wait.Backoff{
 	Steps:    5,
 }
 
+// fallbackDialTimeout is the timeout of each attempt when there are multiple addresses of the node,
+// so that an unreachable public address doesn't block falling back to the private one for long.
+const fallbackDialTimeout = 10 * time.Second
+
 const scriptWrapper = `#!/bin/sh
 set -e
 %s
@@ -42,12 +48,17 @@ type SSHDialer struct {
 	sshKey          string
 	sshCert         string
 	sshAddress      string
+	sshAddresses    []string
 	username        string
 	password        string
 	passphrase      string
 	useSSHAgentAuth bool
+	bastionUser     string
+	bastionAddress  string
 
 	conn *ssh.Client
+	// bastions are the connections to the bastion which the connections to the node go through.
+	bastions []*ssh.Client
 
 	uid    int
 	logger *logrus.Logger
@@ -74,11 +85,12 @@ func NewSSHDialer(n *types.Node, timeout bool, logger *logrus.Logger) (*SSHDiale
 		uid:             -1,
 	}
 
-	// IP addresses are preferred.
-	if len(n.PublicIPAddress) > 0 {
-		d.sshAddress = fmt.Sprintf("%s:%s", n.PublicIPAddress[0], n.SSHPort)
-	} else {
-		d.sshAddress = n.InstanceID
+	// IP addresses are preferred, the private address is the fallback when the public one is unreachable,
+	// e.g. the eip isn't associated yet or autok3s runs in the same vpc behind NAT.
+	d.sshAddresses = GetSSHAddresses(n)
+	d.sshAddress = d.sshAddresses[0]
+	if n.SSHBastion != "" {
+		d.bastionUser, d.bastionAddress = parseBastion(n.SSHBastion)
 	}
 
 	if d.password == "" && d.sshKey == "" && !d.useSSHAgentAuth && len(n.SSHKeyPath) > 0 {
@@ -97,19 +109,31 @@ func NewSSHDialer(n *types.Node, timeout bool, logger *logrus.Logger) (*SSHDiale
 	}
 
 	try := 0
+	var lastErr error
 	if err := wait.ExponentialBackoff(defaultBackoff, func() (bool, error) {
 		try++
-		logger.Infof("the %d/%d time tring to ssh to %s with user %s", try, defaultBackoff.Steps, d.sshAddress, d.username)
-		c, err := d.Dial(timeout)
-		if err != nil {
-			return false, nil
-		}
-
-		d.conn = c
+		for i, address := range d.sshAddresses {
+			if i > 0 {
+				logger.Infof("failed to ssh to %s, falling back to %s", d.sshAddresses[i-1], address)
+			}
+			logger.Infof("the %d/%d time tring to ssh to %s with user %s", try, defaultBackoff.Steps, address, d.username)
+			c, err := d.dial(address, d.getDialTimeout(timeout))
+			if err != nil {
+				lastErr = err
+				continue
+			}
 
-		return true, nil
+			d.sshAddress = address
+			d.conn = c
+			return true, nil
+		}
+		return false, nil
 	}); err != nil {
-		return nil, fmt.Errorf("[ssh-dialer] init dialer [%s] error: %w", d.sshAddress, err)
+		// report the last dial error, e.g. an authentication failure, instead of the bare timeout.
+		if errors.Is(err, wait.ErrWaitTimeout) && lastErr != nil {
+			err = lastErr
+		}
+		return nil, fmt.Errorf("[ssh-dialer] init dialer %s error: %w", d.sshAddresses, err)
 	}
 
 	return d, nil
@@ -117,17 +141,88 @@ func NewSSHDialer(n *types.Node, timeout bool, logger *logrus.Logger) (*SSHDiale
 
 // Dial handshake with ssh address.
 func (d *SSHDialer) Dial(t bool) (*ssh.Client, error) {
-	timeout := defaultBackoff.Duration
+	return d.dial(d.sshAddress, d.getDialTimeout(t))
+}
+
+func (d *SSHDialer) getDialTimeout(t bool) time.Duration {
 	if !t {
-		timeout = 0
+		return 0
+	}
+	if len(d.sshAddresses) > 1 {
+		return fallbackDialTimeout
 	}
+	return defaultBackoff.Duration
+}
 
+func (d *SSHDialer) dial(address string, timeout time.Duration) (*ssh.Client, error) {
 	cfg, err := utils.GetSSHConfig(d.username, d.sshKey, d.passphrase, d.sshCert, d.password, timeout, d.useSSHAgentAuth)
 	if err != nil {
 		return nil, err
 	}
-	// establish connection with SSH server.
-	return ssh.Dial("tcp", d.sshAddress, cfg)
+	if d.bastionAddress == "" {
+		// establish connection with SSH server.
+		return ssh.Dial("tcp", address, cfg)
+	}
+
+	// the bastion is logged in with the same credential as the node, unless its user is set.
+	bastionCfg := *cfg
+	if d.bastionUser != "" {
+		bastionCfg.User = d.bastionUser
+	}
+	bastion, err := ssh.Dial("tcp", d.bastionAddress, &bastionCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ssh to bastion %s: %w", d.bastionAddress, err)
+	}
+	conn, err := bastion.Dial("tcp", address)
+	if err != nil {
+		_ = bastion.Close()
+		return nil, fmt.Errorf("failed to connect to %s through bastion %s: %w", address, d.bastionAddress, err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, address, cfg)
+	if err != nil {
+		_ = conn.Close()
+		_ = bastion.Close()
+		return nil, err
+	}
+	d.bastions = append(d.bastions, bastion)
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// parseBastion returns the user and address of the bastion in `[user@]host[:port]`, the port is 22 if it's not set.
+func parseBastion(bastion string) (string, string) {
+	user := ""
+	if i := strings.LastIndex(bastion, "@"); i >= 0 {
+		user, bastion = bastion[:i], bastion[i+1:]
+	}
+	if _, _, err := net.SplitHostPort(bastion); err != nil {
+		bastion = net.JoinHostPort(strings.Trim(bastion, "[]"), "22")
+	}
+	return user, bastion
+}
+
+// GetSSHAddresses returns the addresses to ssh to the node in order, the public address is tried first
+// and the private address is the fallback. Only the private address is used through the bastion.
+func GetSSHAddresses(n *types.Node) []string {
+	if n.SSHBastion != "" && len(n.InternalIPAddress) > 0 && n.InternalIPAddress[0] != "" {
+		return []string{fmt.Sprintf("%s:%s", n.InternalIPAddress[0], n.SSHPort)}
+	}
+	if len(n.PublicIPAddress) == 0 {
+		// the private node without public ip.
+		if len(n.InternalIPAddress) > 0 && n.InternalIPAddress[0] != "" {
+			return []string{fmt.Sprintf("%s:%s", n.InternalIPAddress[0], n.SSHPort)}
+		}
+		return []string{n.InstanceID}
+	}
+	addresses := []string{fmt.Sprintf("%s:%s", n.PublicIPAddress[0], n.SSHPort)}
+	if len(n.InternalIPAddress) > 0 && n.InternalIPAddress[0] != "" && n.InternalIPAddress[0] != n.PublicIPAddress[0] {
+		addresses = append(addresses, fmt.Sprintf("%s:%s", n.InternalIPAddress[0], n.SSHPort))
+	}
+	return addresses
+}
+
+// IsAuthError returns whether the error is caused by ssh authentication failure.
+func IsAuthError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unable to authenticate")
 }
 
 func (d *SSHDialer) GetClient() *ssh.Client {
@@ -157,6 +252,12 @@ func (d *SSHDialer) getUserID() error {
 }
 
 func (d *SSHDialer) Close() error {
+	defer func() {
+		for _, bastion := range d.bastions {
+			_ = bastion.Close()
+		}
+		d.bastions = nil
+	}()
 	if d.conn != nil {
 		return d.conn.Close()
 	}
@@ -202,6 +303,68 @@ func (d *SSHDialer) ExecuteCommands(cmds ...string) (string, error) {
 	return output.String(), err
 }
 
+// WriteFile writes the content to the file of the remote host through stdin, so that the content, e.g. credentials,
+// is neither in the command line of the host nor in the debug log. The parent dir is created if it doesn't exist.
+func (d *SSHDialer) WriteFile(filePath string, content []byte, mode os.FileMode) error {
+	if err := d.getUserID(); err != nil {
+		return err
+	}
+
+	sudo := ""
+	if d.uid > 0 {
+		sudo = "sudo"
+	}
+
+	session, err := d.conn.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	cmd := fmt.Sprintf("%s sh -c 'umask 077 && mkdir -p \"%s\" && cat > \"%s\" && chmod %o \"%s\"'",
+		sudo, path.Dir(filePath), filePath, mode.Perm(), filePath)
+	d.logger.Debugf("writing file: %s", filePath)
+	session.Stdin = bytes.NewReader(content)
+	if output, err := session.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to write file %s on remote host %s: %w: %s", filePath, d.sshAddress, err, output)
+	}
+	return nil
+}
+
+// ExecuteCommand runs the command like ExecuteCommands and returns its stdout, stderr and exit code separately,
+// the error is only returned if the command isn't run or exits without status, e.g. the connection is lost.
+func (d *SSHDialer) ExecuteCommand(cmd string) (stdout, stderr string, exitCode int, err error) {
+	if err = d.getUserID(); err != nil {
+		return "", "", 0, err
+	}
+
+	sudo := ""
+	if d.uid > 0 {
+		sudo = "sudo"
+	}
+
+	session, err := d.conn.NewSession()
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer session.Close()
+
+	script := fmt.Sprintf("echo \"%s\" | base64 -d | %s sh -", d.wrapCommands(cmd), sudo)
+	d.logger.Debugf("executing cmd: %s", script)
+
+	outBuffer := bytes.NewBuffer([]byte{})
+	errBuffer := bytes.NewBuffer([]byte{})
+	session.Stdout = outBuffer
+	session.Stderr = errBuffer
+	err = session.Run(script)
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return outBuffer.String(), errBuffer.String(), exitErr.ExitStatus(), nil
+	}
+
+	return outBuffer.String(), errBuffer.String(), 0, err
+}
+
 func (d *SSHDialer) OpenShell() (hosts.Shell, error) {
 	shell := &SSHShell{
 		dialer: d,
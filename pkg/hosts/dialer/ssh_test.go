@@ -0,0 +1,76 @@
+package dialer
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSSHAddresses(t *testing.T) {
+	cases := []struct {
+		name     string
+		node     types.Node
+		expected []string
+	}{
+		{
+			name:     "public and private",
+			node:     types.Node{PublicIPAddress: []string{"1.1.1.1"}, InternalIPAddress: []string{"10.0.0.1"}, SSH: types.SSH{SSHPort: "22"}},
+			expected: []string{"1.1.1.1:22", "10.0.0.1:22"},
+		},
+		{
+			name:     "public only",
+			node:     types.Node{PublicIPAddress: []string{"1.1.1.1"}, SSH: types.SSH{SSHPort: "2222"}},
+			expected: []string{"1.1.1.1:2222"},
+		},
+		{
+			name:     "same public and private",
+			node:     types.Node{PublicIPAddress: []string{"10.0.0.1"}, InternalIPAddress: []string{"10.0.0.1"}, SSH: types.SSH{SSHPort: "22"}},
+			expected: []string{"10.0.0.1:22"},
+		},
+		{
+			name:     "private only",
+			node:     types.Node{InternalIPAddress: []string{"10.0.0.1"}, SSH: types.SSH{SSHPort: "22"}},
+			expected: []string{"10.0.0.1:22"},
+		},
+		{
+			name:     "private through bastion",
+			node:     types.Node{PublicIPAddress: []string{"1.1.1.1"}, InternalIPAddress: []string{"10.0.0.1"}, SSH: types.SSH{SSHPort: "22", SSHBastion: "jump.example.com"}},
+			expected: []string{"10.0.0.1:22"},
+		},
+		{
+			name:     "no address",
+			node:     types.Node{InstanceID: "ins-1"},
+			expected: []string{"ins-1"},
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, GetSSHAddresses(&c.node), c.name)
+	}
+}
+
+func TestGetDialTimeout(t *testing.T) {
+	d := &SSHDialer{sshAddresses: []string{"1.1.1.1:22", "10.0.0.1:22"}}
+	assert.Equal(t, fallbackDialTimeout, d.getDialTimeout(true))
+	assert.Zero(t, d.getDialTimeout(false))
+	d.sshAddresses = d.sshAddresses[:1]
+	assert.Equal(t, defaultBackoff.Duration, d.getDialTimeout(true))
+}
+
+func TestParseBastion(t *testing.T) {
+	cases := []struct {
+		bastion string
+		user    string
+		address string
+	}{
+		{bastion: "jump.example.com", address: "jump.example.com:22"},
+		{bastion: "ops@jump.example.com:2222", user: "ops", address: "jump.example.com:2222"},
+		{bastion: "ops@[fd00::1]", user: "ops", address: "[fd00::1]:22"},
+	}
+	for _, c := range cases {
+		user, address := parseBastion(c.bastion)
+		assert.Equal(t, c.user, user, c.bastion)
+		assert.Equal(t, c.address, address, c.bastion)
+	}
+}
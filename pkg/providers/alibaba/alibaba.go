@@ -24,6 +24,7 @@ import (
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -109,6 +110,11 @@ func (p *Alibaba) GenerateManifest() []string {
 	return extraManifests
 }
 
+// ApplyAddons re-applies cloud-controller-manager and custom manifests to an existing cluster.
+func (p *Alibaba) ApplyAddons() error {
+	return p.ApplyManifests(p.GenerateManifest)
+}
+
 // CreateK3sCluster create K3S cluster.
 func (p *Alibaba) CreateK3sCluster() (err error) {
 	if p.SSHUser == "" {
@@ -288,6 +294,11 @@ func (p *Alibaba) GetProviderOptions(opt []byte) (interface{}, error) {
 	return options, err
 }
 
+// GetProviderOptionValues returns the fields of provider options with the current values.
+func (p *Alibaba) GetProviderOptionValues() (map[string]schemas.Field, error) {
+	return p.GetOptionValues(p.Options, p.GetCredentialFlags())
+}
+
 func (p *Alibaba) generateClientSDK() error {
 	client, err := ecs.NewClientWithAccessKey(p.Region, p.AccessKey, p.AccessSecret)
 	if err != nil {
@@ -384,7 +395,7 @@ func (p *Alibaba) deleteInstance(f bool) (string, error) {
 	if !exist {
 		p.Logger.Errorf("[%s] cluster %s is not exist", p.GetProviderName(), p.Name)
 		if !f {
-			return "", fmt.Errorf("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
+			return "", cluster.NewClusterNotFoundError("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
 		}
 		return p.ContextName, nil
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"github.com/sirupsen/logrus"
 )
 
@@ -113,6 +114,11 @@ func (p *Amazon) GenerateManifest() []string {
 	return nil
 }
 
+// ApplyAddons re-applies cloud-controller-manager and custom manifests to an existing cluster.
+func (p *Amazon) ApplyAddons() error {
+	return p.ApplyManifests(p.GenerateManifest)
+}
+
 // CreateK3sCluster create K3S cluster.
 func (p *Amazon) CreateK3sCluster() (err error) {
 	if p.SSHUser == "" {
@@ -122,8 +128,8 @@ func (p *Amazon) CreateK3sCluster() (err error) {
 }
 
 // UpgradeK3sCluster AWS Customized k3s upgrade
-func (p *Amazon) UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath string) error {
-	if err := p.ProviderBase.UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath); err != nil {
+func (p *Amazon) UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath, k3sSHA256 string) error {
+	if err := p.ProviderBase.UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath, k3sSHA256); err != nil {
 		return err
 	}
 	state, err := common.DefaultDB.GetCluster(clusterName, p.Provider)
@@ -251,6 +257,11 @@ func (p *Amazon) GetProviderOptions(opt []byte) (interface{}, error) {
 	return options, err
 }
 
+// GetProviderOptionValues returns the fields of provider options with the current values.
+func (p *Amazon) GetProviderOptionValues() (map[string]schemas.Field, error) {
+	return p.GetOptionValues(p.Options, p.GetCredentialFlags())
+}
+
 // SetConfig set cluster config.
 func (p *Amazon) SetConfig(config []byte) error {
 	c, err := p.SetClusterConfig(config)
@@ -1040,7 +1051,7 @@ func (p *Amazon) deleteInstance(f bool) (string, error) {
 	if !exist {
 		p.Logger.Errorf("[%s] cluster %s is not exist", p.GetProviderName(), p.Name)
 		if !f {
-			return "", fmt.Errorf("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
+			return "", cluster.NewClusterNotFoundError("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
 		}
 		return p.ContextName, nil
 	}
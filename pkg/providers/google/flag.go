@@ -7,6 +7,8 @@ import (
 	"github.com/cnrancher/autok3s/pkg/types"
 	"github.com/cnrancher/autok3s/pkg/types/google"
 	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/rancher/wrangler/v2/pkg/schemas"
 )
 
 const createUsageExample = `  autok3s -d create \
@@ -184,6 +186,11 @@ func (p *Google) GetProviderOptions(opt []byte) (interface{}, error) {
 	return options, err
 }
 
+// GetProviderOptionValues returns the fields of provider options with the current values.
+func (p *Google) GetProviderOptionValues() (map[string]schemas.Field, error) {
+	return p.GetOptionValues(p.Options, p.GetCredentialFlags())
+}
+
 func (p *Google) sharedFlags() []types.Flag {
 	return []types.Flag{
 		{
@@ -85,6 +85,11 @@ func (p *Google) GenerateManifest() []string {
 	return nil
 }
 
+// ApplyAddons re-applies cloud-controller-manager and custom manifests to an existing cluster.
+func (p *Google) ApplyAddons() error {
+	return p.ApplyManifests(p.GenerateManifest)
+}
+
 // CreateK3sCluster create K3S cluster on Google Cloud Provider.
 func (p *Google) CreateK3sCluster() error {
 	if p.SSHUser == "" {
@@ -129,7 +134,7 @@ func (p *Google) remove(force bool) (string, error) {
 	if !exist {
 		p.Logger.Errorf("[%s] cluster %s is not exist", p.GetProviderName(), p.Name)
 		if !force {
-			return "", fmt.Errorf("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
+			return "", cluster.NewClusterNotFoundError("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
 		}
 		return p.ContextName, nil
 	}
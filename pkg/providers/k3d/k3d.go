@@ -31,6 +31,7 @@ import (
 	k3d "github.com/k3d-io/k3d/v5/pkg/types"
 	k3dversion "github.com/k3d-io/k3d/v5/version"
 	"github.com/moby/term"
+	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -174,6 +175,11 @@ func (p *K3d) GetProviderOptions(opt []byte) (interface{}, error) {
 	return options, err
 }
 
+// GetProviderOptionValues returns the fields of provider options with the current values.
+func (p *K3d) GetProviderOptionValues() (map[string]schemas.Field, error) {
+	return p.GetOptionValues(p.Options, p.GetCredentialFlags())
+}
+
 // SetConfig set cluster config.
 func (p *K3d) SetConfig(config []byte) error {
 	c, err := p.SetClusterConfig(config)
@@ -491,7 +497,7 @@ func (p *K3d) deleteK3d(f bool) (string, error) {
 	if !exist {
 		p.Logger.Errorf("[%s] cluster %s is not exist", p.GetProviderName(), p.Name)
 		if !f {
-			return "", fmt.Errorf("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
+			return "", cluster.NewClusterNotFoundError("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
 		}
 		return p.ContextName, nil
 	}
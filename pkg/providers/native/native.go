@@ -18,6 +18,7 @@ import (
 	"github.com/cnrancher/autok3s/pkg/types/native"
 	"github.com/cnrancher/autok3s/pkg/utils"
 
+	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"github.com/rancher/wrangler/v2/pkg/slice"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -221,7 +222,7 @@ func (p *Native) JoinCheck() error {
 		return fmt.Errorf("[%s] calling preflight error: cluster %s is not exist", p.GetProviderName(), p.Name)
 	}
 	// check file exists.
-	if p.SSHKeyPath != "" && !utils.IsFileExists(p.SSHKeyPath) {
+	if path, ok := utils.SSHKeyFilePath(p.SSHKeyPath); p.SSHKeyPath != "" && ok && !utils.IsFileExists(path) {
 		return fmt.Errorf("[%s] calling preflight error: failed to get ssh-key-path", p.GetProviderName())
 	}
 
@@ -314,6 +315,11 @@ func (p *Native) GetProviderOptions(opt []byte) (interface{}, error) {
 	return options, err
 }
 
+// GetProviderOptionValues returns the fields of provider options with the current values.
+func (p *Native) GetProviderOptionValues() (map[string]schemas.Field, error) {
+	return p.GetOptionValues(p.Options, p.GetCredentialFlags())
+}
+
 func (p *Native) assembleNodeStatus(ssh *types.SSH) (*types.Cluster, error) {
 	if p.MasterIps != "" {
 		masterIps := strings.Split(p.MasterIps, ",")
@@ -2,11 +2,14 @@ package providers
 
 import (
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/cnrancher/autok3s/pkg/types"
 	"github.com/cnrancher/autok3s/pkg/types/apis"
 
+	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"github.com/sirupsen/logrus"
 )
 
@@ -63,6 +66,8 @@ type Provider interface {
 	SetConfig(config []byte) error
 	// validate create flags.
 	CreateCheck() error
+	// validate create flags offline without credentials or calling the provider api.
+	Validate() error
 	// merge metadata configs for provider.
 	SetMetadata(config *types.Metadata)
 	// merge provider options.
@@ -75,12 +80,63 @@ type Provider interface {
 	GetCreateOptions() []types.Flag
 	// convert options to specified provider option interface.
 	GetProviderOptions(opt []byte) (interface{}, error)
+	// get the fields of provider options with the current values, e.g. after SetOptions loads a saved cluster.
+	GetProviderOptionValues() (map[string]schemas.Field, error)
 	// persistent credential from flags to db.
 	BindCredential() error
 	// callback functions used for execute logic after create/join
 	RegisterCallbacks(name, event string, fn func(interface{}))
+	// ApplyAddons re-applies provider, custom and add-on manifests to an existing cluster.
+	ApplyAddons() error
+	// RefreshState re-syncs the saved cluster state from cloud without creating or destroying anything.
+	RefreshState() error
+	// CordonCluster marks the nodes of the cluster, or the named node, as unschedulable.
+	CordonCluster(node string) error
+	// UncordonCluster marks the nodes of the cluster, or the named node, as schedulable.
+	UncordonCluster(node string) error
+	// ResizeNode changes the instance type of the cluster's instance and waits for the node to be Ready again.
+	ResizeNode(instanceID, instanceType string, force bool) error
+	// ResetControlPlane resets the embedded etcd to a new cluster with the only member of the master and rejoins the other masters.
+	ResetControlPlane(node string) error
+	// RotateKubeconfig issues a new admin token, revokes the old one and updates the local kubeconfig.
+	RotateKubeconfig() error
+	// UpdateRegistry writes the registry file to the nodes and restarts K3s on them one by one, e.g. to rotate registry auth.
+	UpdateRegistry(registry string) error
+	// ReplaceNode terminates the failed instance and replaces it with a new one of the same role.
+	ReplaceNode(instanceID string, force bool) error
+	// Reconcile creates or removes instances to converge the cluster to the master and worker numbers of metadata.
+	Reconcile(force bool) error
+	// ScaleWorkers scales the workers to the count while the masters are kept, `restore` scales them back to the number before scaling.
+	ScaleWorkers(count string, force bool) error
+	// SSHExec runs the command on the nodes matched by the selector, i.e. all, masters, workers or an instance id.
+	SSHExec(selector, command string) ([]types.NodeCommandResult, error)
+	// Export writes the state, kubeconfig and ssh keys of the cluster to an archive for migration.
+	Export(path string) error
+	// Import reads the archive written by Export into the local store.
+	Import(path string, force bool) error
+	// Prune lists the resources leaked by the failed runs, and removes them if confirm is true.
+	Prune(confirm bool) ([]types.PruneResource, error)
+	// EstimateCost inquires the prices of the resources to be created without provisioning anything.
+	EstimateCost() (*types.CostEstimate, error)
+	// InstallK3sCluster installs K3s to the instances of the cluster created with `--skip-install`.
+	InstallK3sCluster() error
+	// GetLogs returns a reader over the operation log of the cluster, which keeps tailing the log if follow is true,
+	// only the lines logged within since are returned if since is set.
+	GetLogs(name string, follow bool, since time.Duration) (io.ReadCloser, error)
+	// GetClusterSpec returns the autok3s version, the time and the redacted args which the cluster is created with.
+	GetClusterSpec() (*types.ClusterSpec, error)
+	// GetClusterSummary returns the name, api-server endpoint, kubeconfig, UI URL and node addresses of the cluster.
+	GetClusterSummary() (*types.ClusterSummary, error)
+	// GetOperationStatus returns the status of the async operation of the cluster by its task id without waiting for it.
+	GetOperationStatus(taskID string) (*types.OperationStatus, error)
+	// TagCluster adds the tags in `key=value` format to the cloud resources of the cluster and saves them to state.
+	TagCluster(tags []string) error
+	// UntagCluster removes the tags of the keys from the cloud resources of the cluster and state.
+	UntagCluster(keys []string) error
+	// UpgradeAddons upgrades the cloud integrations deployed to the cluster, i.e. cloud-controller-manager and csi driver.
+	UpgradeAddons(clusterName, ccmVersion, csiVersion string) error
 	// UpgradeK3sCluster helps upgrade K3s cluster to specified version
-	UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath string) error
+	UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath, k3sSHA256 string) error
 }
 
 // RegisterProvider registers a provider.Factory by name.
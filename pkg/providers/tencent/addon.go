@@ -0,0 +1,247 @@
+package tencent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types/tencent"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// defaultCCMVersion is used by the clusters created before --ccm-version is added.
+	defaultCCMVersion       = "1.0.1"
+	ccmImage                = "tencentcloud-cloud-controller-manager"
+	csiImage                = "csi-tencentcloud-cbs"
+	csiControllerDeployment = "cbs-csi-controller"
+	csiNodeDaemonSet        = "cbs-csi-node"
+	addonRolloutTimeout     = 10 * time.Minute
+)
+
+var imageTagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// generateCCMManifest returns the command which writes the cloud-controller-manager manifest to the K3s manifests dir.
+func (p *Tencent) generateCCMManifest() string {
+	version := p.CCMVersion
+	if version == "" {
+		version = defaultCCMVersion
+	}
+	tencentCCM := &tencent.CloudControllerManager{
+		Region:                base64.StdEncoding.EncodeToString([]byte(p.Region)),
+		VpcID:                 base64.StdEncoding.EncodeToString([]byte(p.VpcID)),
+		NetworkRouteTableName: base64.StdEncoding.EncodeToString([]byte(p.NetworkRouteTableName)),
+	}
+	tencentCCM.SecretID = base64.StdEncoding.EncodeToString([]byte(p.SecretID))
+	tencentCCM.SecretKey = base64.StdEncoding.EncodeToString([]byte(p.SecretKey))
+	tmpl := fmt.Sprintf(tencentCCMTmpl, tencentCCM.Region, tencentCCM.SecretID, tencentCCM.SecretKey,
+		tencentCCM.VpcID, tencentCCM.NetworkRouteTableName, version, p.ClusterCidr)
+	return fmt.Sprintf(deployCCMCommand, base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir)
+}
+
+// generateCSIManifest returns the command which writes the cbs csi driver manifest to the K3s manifests dir.
+func (p *Tencent) generateCSIManifest() string {
+	tmpl := fmt.Sprintf(tencentCSITmpl,
+		base64.StdEncoding.EncodeToString([]byte(p.SecretID)),
+		base64.StdEncoding.EncodeToString([]byte(p.SecretKey)),
+		base64.StdEncoding.EncodeToString([]byte(p.Region)),
+		p.CSIVersion, p.CSIDiskType)
+	return fmt.Sprintf(deployCSICommand, base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir)
+}
+
+// UpgradeAddons upgrades the cloud-controller-manager and cbs csi driver of the cluster to the versions, an empty
+// version keeps the add-on as is. The manifests are re-rendered with the saved options and credentials, and
+// re-applied by the deploy controller of K3s, then it waits for the rollout to complete and saves the new versions.
+func (p *Tencent) UpgradeAddons(clusterName, ccmVersion, csiVersion string) error {
+	if ccmVersion == "" && csiVersion == "" {
+		return fmt.Errorf("[%s] must set the version of cloud-controller-manager or cbs csi driver to upgrade", p.GetProviderName())
+	}
+	for _, version := range []string{ccmVersion, csiVersion} {
+		if version != "" && !imageTagRegexp.MatchString(version) {
+			return fmt.Errorf("[%s] version %q is not a valid image tag", p.GetProviderName(), version)
+		}
+	}
+	state, err := common.DefaultDB.GetCluster(clusterName, p.GetProviderName())
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return cluster.NewClusterNotFoundError("[%s] cluster %s is not exist", p.GetProviderName(), clusterName)
+	}
+	if state.Status != common.StatusRunning {
+		return fmt.Errorf("[%s] cluster %s is %s, wait for the operation in progress to finish", p.GetProviderName(), clusterName, state.Status)
+	}
+	p.Metadata = state.Metadata
+	if err = p.SetOptions(state.Options); err != nil {
+		return err
+	}
+	if ccmVersion != "" && !p.CloudControllerManager {
+		return fmt.Errorf("[%s] cloud-controller-manager isn't enabled for cluster %s", p.GetProviderName(), clusterName)
+	}
+	if csiVersion != "" && !p.EnableCSI {
+		return fmt.Errorf("[%s] cbs csi driver isn't enabled for cluster %s", p.GetProviderName(), clusterName)
+	}
+	// the credentials of the add-ons are kept, they're read from the profile if they aren't saved.
+	if err = p.applyProfileCredential(); err != nil {
+		return err
+	}
+	if p.SecretID == "" || p.SecretKey == "" {
+		return fmt.Errorf("[%s] credentials of cluster %s aren't saved, set --profile or the credentials of the cluster to upgrade add-ons",
+			p.GetProviderName(), clusterName)
+	}
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) == 0 {
+		return fmt.Errorf("[%s] cluster %s has no master node to upgrade add-ons", p.GetProviderName(), clusterName)
+	}
+
+	logFile, err := common.GetLogFile(state.ContextName)
+	if err != nil {
+		return err
+	}
+	p.Logger = common.NewLogger(logFile)
+	state.Status = common.StatusUpgrading
+	if err = common.DefaultDB.SaveClusterState(state); err != nil {
+		return err
+	}
+	defer func() {
+		state.Status = common.StatusRunning
+		_ = common.DefaultDB.SaveClusterState(state)
+		_ = logFile.Close()
+	}()
+
+	cmds := make([]string, 0, 2)
+	if ccmVersion != "" {
+		p.CCMVersion = ccmVersion
+		cmds = append(cmds, p.generateCCMManifest())
+		p.Logger.Infof("[%s] upgrading cloud-controller-manager of cluster %s to %s...", p.GetProviderName(), clusterName, ccmVersion)
+	}
+	if csiVersion != "" {
+		p.CSIVersion = csiVersion
+		cmds = append(cmds, p.generateCSIManifest())
+		p.Logger.Infof("[%s] upgrading cbs csi driver of cluster %s to %s...", p.GetProviderName(), clusterName, csiVersion)
+	}
+	if err = p.DeployExtraManifest(&c, cmds); err != nil {
+		return err
+	}
+
+	client, err := cluster.GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return fmt.Errorf("[%s] failed to load kubeconfig of cluster %s: %v", p.GetProviderName(), p.ContextName, err)
+	}
+	if ccmVersion != "" {
+		if err = p.waitForAddonRollout(client, "deployment", ccmDeploymentName, ccmImage+":"+ccmVersion); err != nil {
+			return err
+		}
+		if err = p.waitForCCMReady(); err != nil {
+			return err
+		}
+	}
+	if csiVersion != "" {
+		if err = p.waitForAddonRollout(client, "deployment", csiControllerDeployment, csiImage+":"+csiVersion); err != nil {
+			return err
+		}
+		if err = p.waitForAddonRollout(client, "daemonset", csiNodeDaemonSet, csiImage+":"+csiVersion); err != nil {
+			return err
+		}
+	}
+
+	// only the versions are changed, the other saved options are kept as they are.
+	option := &tencent.Options{}
+	if err = json.Unmarshal(state.Options, option); err != nil {
+		return err
+	}
+	if ccmVersion != "" {
+		option.CCMVersion = ccmVersion
+	}
+	if csiVersion != "" {
+		option.CSIVersion = csiVersion
+	}
+	if state.Options, err = json.Marshal(option); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully upgraded add-ons of cluster %s", p.GetProviderName(), clusterName)
+	return nil
+}
+
+// waitForAddonRollout waits until the workload in kube-system is updated to the image by the deploy controller of K3s,
+// and all the pods of it are updated and available.
+func (p *Tencent) waitForAddonRollout(client kubernetes.Interface, kind, name, image string) error {
+	p.Logger.Infof("[%s] waiting for %s %s/%s to roll out %s...", p.GetProviderName(), kind, ccmNamespace, name, image)
+	var reason string
+	if err := wait.PollImmediate(ccmReadyInterval, addonRolloutTimeout, func() (bool, error) {
+		var done bool
+		if kind == "daemonset" {
+			ds, err := client.AppsV1().DaemonSets(ccmNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				reason = err.Error()
+				return false, nil
+			}
+			done, reason = isDaemonSetRolledOut(ds, image)
+		} else {
+			deployment, err := client.AppsV1().Deployments(ccmNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				reason = err.Error()
+				return false, nil
+			}
+			done, reason = isDeploymentRolledOut(deployment, image)
+		}
+		return done, nil
+	}); err != nil {
+		return fmt.Errorf("[%s] %s %s/%s is not rolled out in %s, %s", p.GetProviderName(), kind, ccmNamespace, name, addonRolloutTimeout, reason)
+	}
+	return nil
+}
+
+// isDeploymentRolledOut returns true if the deployment of the image is observed and all replicas are updated and
+// available, otherwise the reason is returned.
+func isDeploymentRolledOut(deployment *appsv1.Deployment, image string) (bool, string) {
+	if !hasContainerImage(deployment.Spec.Template.Spec, image) {
+		return false, fmt.Sprintf("image %s isn't applied yet", image)
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	if status.ObservedGeneration < deployment.Generation || status.UpdatedReplicas < replicas ||
+		status.Replicas > status.UpdatedReplicas || status.AvailableReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas are updated and %d are available", status.UpdatedReplicas, replicas, status.AvailableReplicas)
+	}
+	return true, ""
+}
+
+// isDaemonSetRolledOut returns true if the daemonset of the image is observed and all scheduled pods are updated and
+// available, otherwise the reason is returned.
+func isDaemonSetRolledOut(ds *appsv1.DaemonSet, image string) (bool, string) {
+	if !hasContainerImage(ds.Spec.Template.Spec, image) {
+		return false, fmt.Sprintf("image %s isn't applied yet", image)
+	}
+	status := ds.Status
+	if status.ObservedGeneration < ds.Generation || status.UpdatedNumberScheduled < status.DesiredNumberScheduled ||
+		status.NumberAvailable < status.DesiredNumberScheduled {
+		return false, fmt.Sprintf("%d of %d pods are updated and %d are available", status.UpdatedNumberScheduled,
+			status.DesiredNumberScheduled, status.NumberAvailable)
+	}
+	return true, ""
+}
+
+func hasContainerImage(spec v1.PodSpec, image string) bool {
+	for _, container := range spec.Containers {
+		if strings.HasSuffix(container.Image, "/"+image) {
+			return true
+		}
+	}
+	return false
+}
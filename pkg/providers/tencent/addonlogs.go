@@ -0,0 +1,116 @@
+package tencent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	addonCCM = "ccm"
+	addonCSI = "csi"
+)
+
+// addonSelectors are the label selectors of the pods of the add-ons in kube-system.
+var addonSelectors = map[string]string{
+	addonCCM: "app=" + ccmDeploymentName,
+	addonCSI: fmt.Sprintf("app in (%s,%s)", csiControllerDeployment, csiNodeDaemonSet),
+}
+
+// AddonLogs writes the logs of the cloud-controller-manager and cbs csi driver pods of the cluster to w, the
+// component is either ccm or csi, all the enabled add-ons are included if it's empty. The last tail lines of each
+// container are written if tail > 0. It only reads from the cluster, so it's safe while the add-ons are failing.
+func (p *Tencent) AddonLogs(clusterName, component string, tail int64, w io.Writer) error {
+	if _, ok := addonSelectors[component]; component != "" && !ok {
+		return fmt.Errorf("[%s] component must be %s or %s, got %q", p.GetProviderName(), addonCCM, addonCSI, component)
+	}
+	state, err := common.DefaultDB.GetCluster(clusterName, p.GetProviderName())
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return cluster.NewClusterNotFoundError("[%s] cluster %s is not exist", p.GetProviderName(), clusterName)
+	}
+	p.Metadata = state.Metadata
+	if err = p.SetOptions(state.Options); err != nil {
+		return err
+	}
+	components := make([]string, 0, 2)
+	for _, addon := range []struct {
+		name    string
+		enabled bool
+	}{
+		{name: addonCCM, enabled: p.CloudControllerManager},
+		{name: addonCSI, enabled: p.EnableCSI},
+	} {
+		if component != "" && component != addon.name {
+			continue
+		}
+		if !addon.enabled {
+			if component != "" {
+				return fmt.Errorf("[%s] %s isn't enabled for cluster %s", p.GetProviderName(), component, clusterName)
+			}
+			continue
+		}
+		components = append(components, addon.name)
+	}
+	if len(components) == 0 {
+		return fmt.Errorf("[%s] neither cloud-controller-manager nor cbs csi driver is enabled for cluster %s", p.GetProviderName(), clusterName)
+	}
+
+	client, err := cluster.GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return fmt.Errorf("[%s] failed to load kubeconfig of cluster %s: %v", p.GetProviderName(), p.ContextName, err)
+	}
+	for _, c := range components {
+		if err = writeAddonLogs(client, addonSelectors[c], tail, w); err != nil {
+			return fmt.Errorf("[%s] failed to get logs of %s: %v", p.GetProviderName(), c, err)
+		}
+	}
+	return nil
+}
+
+// writeAddonLogs writes the logs of each container of the pods matched by the selector, with a header of the
+// container. The failure of a container, e.g. it's still waiting to start, is written instead of aborting.
+func writeAddonLogs(client kubernetes.Interface, selector string, tail int64, w io.Writer) error {
+	pods, err := client.CoreV1().Pods(ccmNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		_, err = fmt.Fprintf(w, "no pod found in %s by selector %q\n", ccmNamespace, selector)
+		return err
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			if _, err = fmt.Fprintf(w, "==> %s/%s/%s (%s) <==\n", pod.Namespace, pod.Name, container.Name, pod.Spec.NodeName); err != nil {
+				return err
+			}
+			options := &v1.PodLogOptions{Container: container.Name}
+			if tail > 0 {
+				options.TailLines = &tail
+			}
+			stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(context.TODO())
+			if err != nil {
+				if _, err = fmt.Fprintf(w, "failed to get logs: %v\n", err); err != nil {
+					return err
+				}
+				continue
+			}
+			_, err = io.Copy(w, stream)
+			_ = stream.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package tencent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	ccmNamespace      = "kube-system"
+	ccmDeploymentName = "tencentcloud-cloud-controller-manager"
+	// the taint is set by kubelet with external cloud provider, and removed by ccm after the node is initialized.
+	uninitializedTaint   = "node.cloudprovider.kubernetes.io/uninitialized"
+	controlPlaneSelector = "node-role.kubernetes.io/control-plane=true"
+	ccmReadyTimeout      = 5 * time.Minute
+	ccmReadyInterval     = 5 * time.Second
+)
+
+// waitForCCMReady waits until the ccm deployment is available and the masters are initialized by it,
+// otherwise the nodes stay tainted and no pod can be scheduled, e.g. with invalid credentials in the secret.
+func (p *Tencent) waitForCCMReady() error {
+	p.Logger.Infof("[%s] waiting for cloud-controller-manager to be ready...", p.GetProviderName())
+	client, err := cluster.GetClusterConfig(p.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return fmt.Errorf("[%s] failed to load kubeconfig of cluster %s: %v", p.GetProviderName(), p.ContextName, err)
+	}
+	var reason string
+	if err = wait.PollImmediate(ccmReadyInterval, ccmReadyTimeout, func() (bool, error) {
+		deployment, err := client.AppsV1().Deployments(ccmNamespace).Get(context.TODO(), ccmDeploymentName, metav1.GetOptions{})
+		if err != nil {
+			reason = fmt.Sprintf("failed to get deployment %s/%s: %v", ccmNamespace, ccmDeploymentName, err)
+			return false, nil
+		}
+		if !isDeploymentAvailable(deployment) {
+			reason = fmt.Sprintf("deployment %s/%s is not available", ccmNamespace, ccmDeploymentName)
+			return false, nil
+		}
+		nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: controlPlaneSelector})
+		if err != nil {
+			reason = fmt.Sprintf("failed to list masters: %v", err)
+			return false, nil
+		}
+		if uninitialized := getUninitializedNodes(nodes.Items); len(uninitialized) > 0 {
+			reason = fmt.Sprintf("masters %s are still tainted with %s", strings.Join(uninitialized, ","), uninitializedTaint)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("[%s] cloud-controller-manager is not ready in %s, %s, check the credentials in secret %s/%s-config and the logs by `autok3s tencent addon-logs --name %s --component %s`",
+			p.GetProviderName(), ccmReadyTimeout, reason, ccmNamespace, ccmDeploymentName, p.Name, addonCCM)
+	}
+	p.Logger.Infof("[%s] cloud-controller-manager is ready", p.GetProviderName())
+	return nil
+}
+
+func isDeploymentAvailable(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// getUninitializedNodes returns the names of nodes which aren't initialized by the cloud controller manager.
+func getUninitializedNodes(nodes []v1.Node) []string {
+	names := make([]string, 0)
+	for _, node := range nodes {
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == uninitializedTaint {
+				names = append(names, node.Name)
+				break
+			}
+		}
+	}
+	return names
+}
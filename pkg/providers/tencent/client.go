@@ -0,0 +1,101 @@
+package tencent
+
+import (
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	kms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/kms/v20190118"
+	tag "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tag/v20180813"
+	tke "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tke/v20180525"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+// the provider only depends on the subset of sdk client methods below,
+// so that tests can inject fake clients instead of calling the real APIs.
+var (
+	_ cvmClient = &cvm.Client{}
+	_ vpcClient = &vpc.Client{}
+	_ tagClient = &tag.Client{}
+	_ tkeClient = &tke.Client{}
+	_ cbsClient = &cbs.Client{}
+	_ kmsClient = &kms.Client{}
+	_ clbClient = &clb.Client{}
+
+	_ launchTemplateClient = &cvmLaunchTemplateClient{}
+)
+
+type cvmClient interface {
+	RunInstances(request *cvm.RunInstancesRequest) (*cvm.RunInstancesResponse, error)
+	DescribeInstances(request *cvm.DescribeInstancesRequest) (*cvm.DescribeInstancesResponse, error)
+	DescribeInstancesStatus(request *cvm.DescribeInstancesStatusRequest) (*cvm.DescribeInstancesStatusResponse, error)
+	DescribeImages(request *cvm.DescribeImagesRequest) (*cvm.DescribeImagesResponse, error)
+	TerminateInstances(request *cvm.TerminateInstancesRequest) (*cvm.TerminateInstancesResponse, error)
+	StopInstances(request *cvm.StopInstancesRequest) (*cvm.StopInstancesResponse, error)
+	StartInstances(request *cvm.StartInstancesRequest) (*cvm.StartInstancesResponse, error)
+	ResetInstancesType(request *cvm.ResetInstancesTypeRequest) (*cvm.ResetInstancesTypeResponse, error)
+	InquiryPriceRunInstances(request *cvm.InquiryPriceRunInstancesRequest) (*cvm.InquiryPriceRunInstancesResponse, error)
+	DescribeKeyPairs(request *cvm.DescribeKeyPairsRequest) (*cvm.DescribeKeyPairsResponse, error)
+	DescribeInstanceTypeConfigs(request *cvm.DescribeInstanceTypeConfigsRequest) (*cvm.DescribeInstanceTypeConfigsResponse, error)
+	DescribeZoneInstanceConfigInfos(request *cvm.DescribeZoneInstanceConfigInfosRequest) (*cvm.DescribeZoneInstanceConfigInfosResponse, error)
+}
+
+type vpcClient interface {
+	AllocateAddresses(request *vpc.AllocateAddressesRequest) (*vpc.AllocateAddressesResponse, error)
+	ReleaseAddresses(request *vpc.ReleaseAddressesRequest) (*vpc.ReleaseAddressesResponse, error)
+	DescribeAddresses(request *vpc.DescribeAddressesRequest) (*vpc.DescribeAddressesResponse, error)
+	AssociateAddress(request *vpc.AssociateAddressRequest) (*vpc.AssociateAddressResponse, error)
+	DisassociateAddress(request *vpc.DisassociateAddressRequest) (*vpc.DisassociateAddressResponse, error)
+	DescribeTaskResult(request *vpc.DescribeTaskResultRequest) (*vpc.DescribeTaskResultResponse, error)
+	CreateVpc(request *vpc.CreateVpcRequest) (*vpc.CreateVpcResponse, error)
+	DescribeVpcs(request *vpc.DescribeVpcsRequest) (*vpc.DescribeVpcsResponse, error)
+	CreateSubnet(request *vpc.CreateSubnetRequest) (*vpc.CreateSubnetResponse, error)
+	DescribeSubnets(request *vpc.DescribeSubnetsRequest) (*vpc.DescribeSubnetsResponse, error)
+	CreateSecurityGroup(request *vpc.CreateSecurityGroupRequest) (*vpc.CreateSecurityGroupResponse, error)
+	DescribeSecurityGroups(request *vpc.DescribeSecurityGroupsRequest) (*vpc.DescribeSecurityGroupsResponse, error)
+	DeleteSecurityGroup(request *vpc.DeleteSecurityGroupRequest) (*vpc.DeleteSecurityGroupResponse, error)
+	CreateSecurityGroupPolicies(request *vpc.CreateSecurityGroupPoliciesRequest) (*vpc.CreateSecurityGroupPoliciesResponse, error)
+	DescribeSecurityGroupPolicies(request *vpc.DescribeSecurityGroupPoliciesRequest) (*vpc.DescribeSecurityGroupPoliciesResponse, error)
+	CreateHaVip(request *vpc.CreateHaVipRequest) (*vpc.CreateHaVipResponse, error)
+	DescribeHaVips(request *vpc.DescribeHaVipsRequest) (*vpc.DescribeHaVipsResponse, error)
+	DeleteHaVip(request *vpc.DeleteHaVipRequest) (*vpc.DeleteHaVipResponse, error)
+	HaVipAssociateAddressIp(request *vpc.HaVipAssociateAddressIpRequest) (*vpc.HaVipAssociateAddressIpResponse, error)
+	HaVipDisassociateAddressIp(request *vpc.HaVipDisassociateAddressIpRequest) (*vpc.HaVipDisassociateAddressIpResponse, error)
+	CreateNatGateway(request *vpc.CreateNatGatewayRequest) (*vpc.CreateNatGatewayResponse, error)
+	DescribeNatGateways(request *vpc.DescribeNatGatewaysRequest) (*vpc.DescribeNatGatewaysResponse, error)
+	DeleteNatGateway(request *vpc.DeleteNatGatewayRequest) (*vpc.DeleteNatGatewayResponse, error)
+	DescribeRouteTables(request *vpc.DescribeRouteTablesRequest) (*vpc.DescribeRouteTablesResponse, error)
+	CreateRoutes(request *vpc.CreateRoutesRequest) (*vpc.CreateRoutesResponse, error)
+}
+
+type tagClient interface {
+	DescribeResourcesByTags(request *tag.DescribeResourcesByTagsRequest) (*tag.DescribeResourcesByTagsResponse, error)
+	AttachResourcesTag(request *tag.AttachResourcesTagRequest) (*tag.AttachResourcesTagResponse, error)
+	DetachResourcesTag(request *tag.DetachResourcesTagRequest) (*tag.DetachResourcesTagResponse, error)
+}
+
+type tkeClient interface {
+	DescribeClusters(request *tke.DescribeClustersRequest) (*tke.DescribeClustersResponse, error)
+}
+
+type cbsClient interface {
+	DescribeDiskConfigQuota(request *cbs.DescribeDiskConfigQuotaRequest) (*cbs.DescribeDiskConfigQuotaResponse, error)
+}
+
+type kmsClient interface {
+	DescribeKey(request *kms.DescribeKeyRequest) (*kms.DescribeKeyResponse, error)
+}
+
+type clbClient interface {
+	DescribeLoadBalancers(request *clb.DescribeLoadBalancersRequest) (*clb.DescribeLoadBalancersResponse, error)
+	DescribeListeners(request *clb.DescribeListenersRequest) (*clb.DescribeListenersResponse, error)
+	CreateListener(request *clb.CreateListenerRequest) (*clb.CreateListenerResponse, error)
+	RegisterTargets(request *clb.RegisterTargetsRequest) (*clb.RegisterTargetsResponse, error)
+	DeregisterTargets(request *clb.DeregisterTargetsRequest) (*clb.DeregisterTargetsResponse, error)
+	DescribeTargets(request *clb.DescribeTargetsRequest) (*clb.DescribeTargetsResponse, error)
+	DescribeTaskStatus(request *clb.DescribeTaskStatusRequest) (*clb.DescribeTaskStatusResponse, error)
+}
+
+type launchTemplateClient interface {
+	DescribeLaunchTemplateVersions(request *describeLaunchTemplateVersionsRequest) (*describeLaunchTemplateVersionsResponse, error)
+	RunInstances(request *runInstancesRequest) (*cvm.RunInstancesResponse, error)
+}
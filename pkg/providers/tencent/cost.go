@@ -0,0 +1,186 @@
+package tencent
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	// hoursPerMonth is used to estimate the monthly cost of the hourly charged resources.
+	hoursPerMonth = 730
+	// prepaidInstanceChargeType is charged by month, the price is inquired for one month.
+	prepaidInstanceChargeType = "PREPAID"
+	chargeUnitHour            = "HOUR"
+	chargeUnitMonth           = "MONTH"
+)
+
+// EstimateCost inquires the prices of the instances and eips to be created, nothing is provisioned.
+// The traffic of public network is charged by usage, so it's listed by GB and not counted into the monthly cost.
+func (p *Tencent) EstimateCost() (*types.CostEstimate, error) {
+	if err := p.generateClientSDK(); err != nil {
+		return nil, err
+	}
+	pools, err := parseWorkerPools(p.Pools)
+	if err != nil {
+		return nil, err
+	}
+	if p.Spot {
+		p.InstanceChargeType = spotInstanceChargeType
+	}
+	// the instance type and image of the launch template are inquired.
+	if err = p.applyLaunchTemplate(); err != nil {
+		return nil, err
+	}
+	if err = p.resolveImageFamily(); err != nil {
+		return nil, err
+	}
+	masterNum, _ := strconv.Atoi(p.Master)
+	// the workers of pools are included in --worker by preflight check.
+	workerNum, _ := strconv.Atoi(p.Worker)
+	defaultWorkerNum := workerNum - getPoolWorkerCount(pools)
+
+	estimate := &types.CostEstimate{}
+	if masterNum > 0 {
+		items, err := p.inquiryInstancePrice("master", masterNum, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		estimate.Items = append(estimate.Items, items...)
+	}
+	if defaultWorkerNum > 0 {
+		items, err := p.inquiryInstancePrice("worker", defaultWorkerNum, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		estimate.Items = append(estimate.Items, items...)
+	}
+	for i := range pools {
+		items, err := p.inquiryInstancePrice("worker pool "+pools[i].Name, pools[i].Count, false, &pools[i])
+		if err != nil {
+			return nil, err
+		}
+		estimate.Items = append(estimate.Items, items...)
+	}
+
+	eipNum := 0
+	if p.useEIP(true) {
+		eipNum += masterNum
+	}
+	if p.useEIP(false) {
+		eipNum += workerNum
+	}
+	if eipNum > 0 {
+		item, err := p.inquiryEIPPrice(eipNum)
+		if err != nil {
+			return nil, err
+		}
+		estimate.Items = append(estimate.Items, item)
+	}
+	return estimate, nil
+}
+
+// inquiryInstancePrice inquires the price of one instance of the role with the request of running instances,
+// the price of public ip traffic is returned as well if the instances are assigned public ips.
+func (p *Tencent) inquiryInstancePrice(name string, num int, master bool, pool *workerPool) ([]types.CostItem, error) {
+	request, err := p.newInquiryPriceRunInstancesRequest(master, pool)
+	if err != nil {
+		return nil, err
+	}
+	price, err := p.inquiryPriceRunInstances(request)
+	if err != nil {
+		return nil, err
+	}
+	if price.InstancePrice == nil {
+		return nil, fmt.Errorf("[%s] no price of instance type %s is returned", p.GetProviderName(), *request.InstanceType)
+	}
+	items := []types.CostItem{newCostItem(fmt.Sprintf("%s instance (%s)", name, *request.InstanceType), num,
+		price.InstancePrice.UnitPrice, price.InstancePrice.DiscountPrice, price.InstancePrice.ChargeUnit)}
+	if !p.eipEnabled() && price.BandwidthPrice != nil {
+		items = append(items, newCostItem(name+" public ip traffic", num,
+			price.BandwidthPrice.UnitPrice, price.BandwidthPrice.DiscountPrice, price.BandwidthPrice.ChargeUnit))
+	}
+	return items, nil
+}
+
+// inquiryEIPPrice inquires the traffic price of eips. The vendored sdk can't inquire the price of allocating eips,
+// so it's inquired as the public ip traffic of a master, which is charged the same as the eip of its bandwidth.
+func (p *Tencent) inquiryEIPPrice(num int) (types.CostItem, error) {
+	request, err := p.newInquiryPriceRunInstancesRequest(true, nil)
+	if err != nil {
+		return types.CostItem{}, err
+	}
+	allocate := p.newAllocateAddressesRequest(1)
+	request.InternetAccessible = &cvm.InternetAccessible{
+		InternetChargeType:      allocate.InternetChargeType,
+		InternetMaxBandwidthOut: allocate.InternetMaxBandwidthOut,
+		PublicIpAssigned:        tencentCommon.BoolPtr(true),
+	}
+	price, err := p.inquiryPriceRunInstances(request)
+	if err != nil {
+		return types.CostItem{}, err
+	}
+	if price.BandwidthPrice == nil {
+		return types.CostItem{}, fmt.Errorf("[%s] no price of eip is returned", p.GetProviderName())
+	}
+	return newCostItem("eip traffic", num, price.BandwidthPrice.UnitPrice, price.BandwidthPrice.DiscountPrice, price.BandwidthPrice.ChargeUnit), nil
+}
+
+// newInquiryPriceRunInstancesRequest builds the price inquiry of one instance of the role from the request of running instances.
+func (p *Tencent) newInquiryPriceRunInstancesRequest(master bool, pool *workerPool) (*cvm.InquiryPriceRunInstancesRequest, error) {
+	run, err := p.newRunInstancesRequest(1, master, "", pool)
+	if err != nil {
+		return nil, err
+	}
+	request := cvm.NewInquiryPriceRunInstancesRequest()
+	request.Placement = run.Placement
+	request.ImageId = run.ImageId
+	request.InstanceType = run.InstanceType
+	request.InstanceChargeType = run.InstanceChargeType
+	request.SystemDisk = run.SystemDisk
+	request.InternetAccessible = run.InternetAccessible
+	request.InstanceCount = run.InstanceCount
+	if *run.InstanceChargeType == prepaidInstanceChargeType {
+		request.InstanceChargePrepaid = &cvm.InstanceChargePrepaid{Period: tencentCommon.Int64Ptr(1)}
+	}
+	return request, nil
+}
+
+func (p *Tencent) inquiryPriceRunInstances(request *cvm.InquiryPriceRunInstancesRequest) (*cvm.Price, error) {
+	response, err := p.c.InquiryPriceRunInstances(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling inquiryPriceRunInstances error, instance type: %s, msg: %v",
+			p.GetProviderName(), *request.InstanceType, err)
+	}
+	if response.Response.Price == nil {
+		return nil, fmt.Errorf("[%s] no price of instance type %s is returned", p.GetProviderName(), *request.InstanceType)
+	}
+	return response.Response.Price, nil
+}
+
+// newCostItem returns the cost item of the price, the postpaid resources have unit price per charge unit,
+// while the prepaid ones only have the price of the period, which is one month.
+func newCostItem(name string, count int, unitPrice, discountPrice *float64, chargeUnit *string) types.CostItem {
+	item := types.CostItem{Name: name, Count: count}
+	switch {
+	case unitPrice != nil:
+		item.Price = *unitPrice
+		if chargeUnit != nil {
+			item.ChargeUnit = *chargeUnit
+		}
+	case discountPrice != nil:
+		item.Price = *discountPrice
+		item.ChargeUnit = chargeUnitMonth
+	}
+	switch item.ChargeUnit {
+	case chargeUnitHour:
+		item.Monthly = item.Price * float64(count) * hoursPerMonth
+	case chargeUnitMonth:
+		item.Monthly = item.Price * float64(count)
+	}
+	return item
+}
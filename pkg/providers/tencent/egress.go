@@ -0,0 +1,52 @@
+package tencent
+
+import (
+	"fmt"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+// tencentInternalCidr is the range of tencent cloud internal services, e.g. metadata, dns, ntp and mirrors.
+const tencentInternalCidr = "169.254.0.0/16"
+
+// getRestrictedEgressPolicies returns the egress policies used by `--restrict-egress` instead of allowing all egress,
+// i.e. the traffic within the subnet and to tencent cloud internal services, plus the dns, ntp and https which K3s needs
+// to download the install script, binaries and images. The https is replaced by all traffic to `--egress-cidr` if it's set.
+func (p *Tencent) getRestrictedEgressPolicies(cidr string) []*vpc.SecurityGroupPolicy {
+	policies := []*vpc.SecurityGroupPolicy{
+		newAcceptPolicy("ALL", "all", cidr, "allow egress within subnet"),
+		newAcceptPolicy("ALL", "all", tencentInternalCidr, "allow egress to tencent cloud internal services"),
+		newAcceptPolicy("UDP", "53", ipRange, "allow egress for dns"),
+		newAcceptPolicy("TCP", "53", ipRange, "allow egress for dns"),
+		newAcceptPolicy("UDP", "123", ipRange, "allow egress for ntp"),
+	}
+	if len(p.EgressCIDRs) == 0 {
+		return append(policies, newAcceptPolicy("TCP", "443", ipRange, "allow egress for k3s mirror and registries"))
+	}
+	for _, egressCidr := range p.EgressCIDRs {
+		policies = append(policies, newAcceptPolicy("ALL", "all", egressCidr, "allow egress to "+egressCidr))
+	}
+	return policies
+}
+
+// hasAllowAllEgress returns true if the policies accept all egress traffic.
+func hasAllowAllEgress(policies []*vpc.SecurityGroupPolicy) bool {
+	allowAll := newSecurityPolicyKey(newAcceptPolicy("ALL", "all", ipRange, ""))
+	for _, policy := range policies {
+		if newSecurityPolicyKey(policy) == allowAll {
+			return true
+		}
+	}
+	return false
+}
+
+func newAcceptPolicy(protocol, port, cidr, description string) *vpc.SecurityGroupPolicy {
+	return &vpc.SecurityGroupPolicy{
+		Protocol:          tencentCommon.StringPtr(protocol),
+		Port:              tencentCommon.StringPtr(port),
+		CidrBlock:         tencentCommon.StringPtr(cidr),
+		Action:            tencentCommon.StringPtr("ACCEPT"),
+		PolicyDescription: tencentCommon.StringPtr(fmt.Sprintf("%s(generated by autok3s)", description)),
+	}
+}
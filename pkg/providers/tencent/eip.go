@@ -0,0 +1,181 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+const (
+	// eipStatusUnbind is the status of eip which isn't bound to any resource.
+	eipStatusUnbind = "UNBIND"
+	// maxAddressesPerCall is the max number of values of a filter of describeAddresses, which is also its max page size.
+	maxAddressesPerCall = 100
+)
+
+// eipEnabled returns true if eips are associated to the instances of any role.
+func (p *Tencent) eipEnabled() bool {
+	return p.PublicIPAssignedEIP || p.MasterEIP || p.WorkerEIP
+}
+
+// useEIP returns true if eips are associated to the instances of the role, `--eip` applies to both masters and workers,
+// the instances of the other role stay private with `--master-eip` or `--worker-eip`.
+func (p *Tencent) useEIP(master bool) bool {
+	if p.PublicIPAssignedEIP {
+		return true
+	}
+	if master {
+		return p.MasterEIP
+	}
+	return p.WorkerEIP
+}
+
+// resolveEIPAddresses finds the existing eips set by --eip-address, they're associated to the new instances
+// before allocating new ones. The eips must be unbound and in the region of the cluster.
+func (p *Tencent) resolveEIPAddresses(instanceNum int) error {
+	if len(p.EIPAddresses) == 0 {
+		return nil
+	}
+	if len(p.EIPAddresses) > instanceNum {
+		return fmt.Errorf("[%s] %d eips are set by --eip-address, but only %d instances will be added",
+			p.GetProviderName(), len(p.EIPAddresses), instanceNum)
+	}
+	request := vpc.NewDescribeAddressesRequest()
+	request.Filters = []*vpc.Filter{
+		{Name: tencentCommon.StringPtr("address-ip"), Values: tencentCommon.StringPtrs(p.EIPAddresses)},
+	}
+	response, err := p.v.DescribeAddresses(request)
+	if err != nil {
+		return fmt.Errorf("[%s] calling describeAddresses error, msg: %v", p.GetProviderName(), err)
+	}
+	found := map[string]*vpc.Address{}
+	for _, address := range response.Response.AddressSet {
+		if address.AddressIp != nil {
+			found[*address.AddressIp] = address
+		}
+	}
+	p.externalEIPs = make([]*vpc.Address, 0, len(p.EIPAddresses))
+	for _, ip := range p.EIPAddresses {
+		address, ok := found[ip]
+		if !ok {
+			return fmt.Errorf("[%s] eip %s is not found in region %s", p.GetProviderName(), ip, p.Region)
+		}
+		if address.AddressStatus == nil || *address.AddressStatus != eipStatusUnbind {
+			status := ""
+			if address.AddressStatus != nil {
+				status = *address.AddressStatus
+			}
+			return fmt.Errorf("[%s] eip %s(%s) must be unbound, got status %s", p.GetProviderName(), ip, *address.AddressId, status)
+		}
+		p.externalEIPs = append(p.externalEIPs, address)
+	}
+	return nil
+}
+
+// takeExternalEIPs returns at most num of the existing eips which are not associated yet.
+func (p *Tencent) takeExternalEIPs(num int) []*vpc.Address {
+	if num > len(p.externalEIPs) {
+		num = len(p.externalEIPs)
+	}
+	taken := p.externalEIPs[:num]
+	p.externalEIPs = p.externalEIPs[num:]
+	return taken
+}
+
+// isExternalEIP returns true if the eip is set by --eip-address.
+func (p *Tencent) isExternalEIP(address *vpc.Address) bool {
+	if address.AddressIp == nil {
+		return false
+	}
+	for _, ip := range p.EIPAddresses {
+		if ip == *address.AddressIp {
+			return true
+		}
+	}
+	return false
+}
+
+// disassociateExternalEIPs disassociates the eips of the cluster's nodes which are not allocated by autok3s,
+// i.e. set by --eip-address and not tagged with the cluster, they're never released.
+func (p *Tencent) disassociateExternalEIPs(allocated []string) {
+	allocatedIDs := map[string]bool{}
+	for _, id := range allocated {
+		allocatedIDs[id] = true
+	}
+	var taskIDs []uint64
+	for _, node := range append(append([]types.Node{}, p.MasterNodes...), p.WorkerNodes...) {
+		for _, id := range node.EipAllocationIds {
+			if allocatedIDs[id] {
+				continue
+			}
+			p.Logger.Infof("[%s] disassociating existing eip %s from instance %s", p.GetProviderName(), id, node.InstanceID)
+			taskID, err := p.disassociateAddress(id)
+			if err != nil {
+				p.Logger.Errorf("[%s] failed to disassociate eip %s, message: %v", p.GetProviderName(), id, err)
+				continue
+			}
+			if taskID != 0 {
+				taskIDs = append(taskIDs, taskID)
+			}
+		}
+	}
+	for _, taskID := range taskIDs {
+		if err := p.describeVpcTaskResult(taskID); err != nil {
+			p.Logger.Errorf("[%s] error when query eip disassociate task result, message: %v", p.GetProviderName(), err)
+		}
+	}
+}
+
+// validateEIPAddresses validates --eip-address offline.
+func (p *Tencent) validateEIPAddresses() error {
+	if len(p.EIPAddresses) == 0 {
+		return nil
+	}
+	if !p.eipEnabled() {
+		return fmt.Errorf("[%s] calling preflight error: must set `--eip`, `--master-eip` or `--worker-eip` if `--eip-address` is set", p.GetProviderName())
+	}
+	if len(utils.UniqueArray(p.EIPAddresses)) != len(p.EIPAddresses) {
+		return fmt.Errorf("[%s] calling preflight error: `--eip-address` is duplicated", p.GetProviderName())
+	}
+	return nil
+}
+
+// describeInstanceAddresses returns the eips of the instances by instance id. The instances are described in batches
+// of filtered calls instead of one call per instance, which is slow and throttled for large clusters.
+func (p *Tencent) describeInstanceAddresses(instanceIds []string) (map[string][]string, error) {
+	addresses := make(map[string][]string, len(instanceIds))
+	for start := 0; start < len(instanceIds); start += maxAddressesPerCall {
+		end := start + maxAddressesPerCall
+		if end > len(instanceIds) {
+			end = len(instanceIds)
+		}
+		request := vpc.NewDescribeAddressesRequest()
+		request.Filters = []*vpc.Filter{
+			{Name: tencentCommon.StringPtr("instance-id"), Values: tencentCommon.StringPtrs(instanceIds[start:end])},
+		}
+		request.Limit = tencentCommon.Int64Ptr(maxAddressesPerCall)
+		for offset := int64(0); ; offset += maxAddressesPerCall {
+			request.Offset = tencentCommon.Int64Ptr(offset)
+			response, err := p.v.DescribeAddresses(request)
+			if err != nil {
+				return nil, fmt.Errorf("[%s] calling describeAddresses error, msg: %v", p.GetProviderName(), err)
+			}
+			if response.Response == nil || len(response.Response.AddressSet) == 0 {
+				break
+			}
+			for _, address := range response.Response.AddressSet {
+				if address.InstanceId != nil && address.AddressId != nil {
+					addresses[*address.InstanceId] = append(addresses[*address.InstanceId], *address.AddressId)
+				}
+			}
+			if response.Response.TotalCount == nil || offset+maxAddressesPerCall >= *response.Response.TotalCount {
+				break
+			}
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,105 @@
+package tencent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	kms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/kms/v20190118"
+)
+
+const kmsKeyStateEnabled = "Enabled"
+
+// the local disks and basic cloud disks don't support encryption.
+var encryptUnsupportedDiskTypes = map[string]bool{
+	"LOCAL_BASIC": true,
+	"LOCAL_SSD":   true,
+	"CLOUD_BASIC": true,
+}
+
+// validateDiskEncryption checks the encryption options offline. The system disk can't be encrypted by RunInstances,
+// so the encryption applies to the data disk set by --data-disk-size.
+func (p *Tencent) validateDiskEncryption() error {
+	if p.KmsKeyID != "" && !p.DiskEncrypt {
+		return fmt.Errorf("[%s] calling preflight error: must set `--disk-encrypt` if `--kms-key-id` is set", p.GetProviderName())
+	}
+	if !p.DiskEncrypt {
+		return nil
+	}
+	if p.DataDiskSize == "" {
+		return fmt.Errorf("[%s] calling preflight error: must set `--data-disk-size` if `--disk-encrypt` is set, the system disk can't be encrypted", p.GetProviderName())
+	}
+	diskTypes := []string{p.SystemDiskType}
+	pools, _ := parseWorkerPools(p.Pools)
+	for _, pool := range pools {
+		if pool.DiskCategory != "" {
+			diskTypes = append(diskTypes, pool.DiskCategory)
+		}
+	}
+	for _, diskType := range diskTypes {
+		if encryptUnsupportedDiskTypes[diskType] {
+			return fmt.Errorf("[%s] calling preflight error: disk category %s doesn't support encryption, use CLOUD_PREMIUM, CLOUD_SSD or above", p.GetProviderName(), diskType)
+		}
+	}
+	return nil
+}
+
+// checkKMSKey checks the kms key exists in the region and is enabled, the default key of cbs is used if it's not set.
+func (p *Tencent) checkKMSKey() error {
+	if !p.DiskEncrypt || p.KmsKeyID == "" {
+		return nil
+	}
+	if p.k == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	request := kms.NewDescribeKeyRequest()
+	request.KeyId = tencentCommon.StringPtr(p.KmsKeyID)
+	response, err := p.k.DescribeKey(request)
+	if err != nil {
+		if te, ok := err.(*errors.TencentCloudSDKError); ok && strings.Contains(te.Code, "UnauthorizedOperation") {
+			return fmt.Errorf("[%s] calling preflight error: the account lacks permission `kms:DescribeKey` to use `--kms-key-id` %s: %s",
+				p.GetProviderName(), p.KmsKeyID, te.Message)
+		}
+		return fmt.Errorf("[%s] calling preflight error: failed to describe `--kms-key-id` %s in region %s: %v", p.GetProviderName(), p.KmsKeyID, p.Region, err)
+	}
+	key := response.Response.KeyMetadata
+	if key == nil || key.KeyState == nil || *key.KeyState != kmsKeyStateEnabled {
+		return fmt.Errorf("[%s] calling preflight error: `--kms-key-id` %s is not enabled in region %s", p.GetProviderName(), p.KmsKeyID, p.Region)
+	}
+	return nil
+}
+
+// newDataDisks returns the data disk of the instance, which is deleted with the instance.
+func (p *Tencent) newDataDisks(diskType string) []*cvm.DataDisk {
+	if p.DataDiskSize == "" {
+		return nil
+	}
+	size, _ := strconv.ParseInt(p.DataDiskSize, 10, 64)
+	disk := &cvm.DataDisk{
+		DiskType:           tencentCommon.StringPtr(diskType),
+		DiskSize:           tencentCommon.Int64Ptr(size),
+		DeleteWithInstance: tencentCommon.BoolPtr(true),
+	}
+	if p.DiskEncrypt {
+		disk.Encrypt = tencentCommon.BoolPtr(true)
+		if p.KmsKeyID != "" {
+			disk.KmsKeyId = tencentCommon.StringPtr(p.KmsKeyID)
+		}
+	}
+	return []*cvm.DataDisk{disk}
+}
+
+// isDiskEncrypted returns true if any data disk of the instance is encrypted.
+func isDiskEncrypted(instance *cvm.Instance) bool {
+	for _, disk := range instance.DataDisks {
+		if disk.Encrypt != nil && *disk.Encrypt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/hosts/dialer"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// etcdS3ConfigPath is the drop-in config of K3s with the etcd-s3 options, it's only readable by root as it has the
+// credentials, which must not be passed by the command line of K3s or logged with the install command.
+const etcdS3ConfigPath = "/etc/rancher/k3s/config.yaml.d/autok3s-etcd-s3.yaml"
+
+// useEtcdS3 returns true if the etcd snapshots of the node are uploaded to the cos bucket, only the masters which
+// run embedded etcd take snapshots.
+func (p *Tencent) useEtcdS3(node types.Node) bool {
+	return p.EtcdSnapshotCOSBucket != "" && p.Cluster && p.DataStore == "" &&
+		node.Master && node.MasterRole != types.MasterRoleControlPlane
+}
+
+// getEtcdS3Config returns the K3s config of uploading the etcd snapshots to the cos bucket, cos is compatible with
+// s3 api, so the snapshots are uploaded by K3s etcd-s3 options.
+func (p *Tencent) getEtcdS3Config() []byte {
+	return []byte(fmt.Sprintf("etcd-s3: true\netcd-s3-endpoint: %q\netcd-s3-region: %q\netcd-s3-bucket: %q\netcd-s3-access-key: %q\netcd-s3-secret-key: %q\n",
+		fmt.Sprintf("cos.%s.myqcloud.com", p.Region), p.Region, p.EtcdSnapshotCOSBucket, p.SecretID, p.SecretKey))
+}
+
+// setupEtcdS3Nodes writes the etcd-s3 config to the masters added by the current command.
+func (p *Tencent) setupEtcdS3Nodes() error {
+	if p.EtcdSnapshotCOSBucket == "" {
+		return nil
+	}
+	if err := p.runOnCurrentNodes(p.setupEtcdS3Node); err != nil {
+		return fmt.Errorf("[%s] failed to write etcd-s3 config of instances, %v", p.GetProviderName(), err)
+	}
+	return nil
+}
+
+func (p *Tencent) setupEtcdS3Node(node types.Node) error {
+	if !p.useEtcdS3(node) {
+		return nil
+	}
+	if err := p.waitForSSHReady(node); err != nil {
+		return err
+	}
+	sshDialer, err := dialer.NewSSHDialer(&node, true, p.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sshDialer.Close()
+	}()
+	p.Logger.Infof("[%s] writing etcd-s3 config of cos bucket %s to %s of instance %s", p.GetProviderName(), p.EtcdSnapshotCOSBucket, etcdS3ConfigPath, node.InstanceID)
+	return sshDialer.WriteFile(etcdS3ConfigPath, p.getEtcdS3Config(), 0600)
+}
@@ -60,6 +60,12 @@ func (p *Tencent) GetCreateFlags() []types.Flag {
 	p.SSH = *cSSH
 	fs := p.GetClusterOptions()
 	fs = append(fs, p.GetCreateOptions()...)
+	fs = append(fs, types.Flag{
+		Name:  "output-dir",
+		P:     &p.OutputDir,
+		V:     p.OutputDir,
+		Usage: "Copy the kubeconfig and log of the cluster to <output-dir>/<context-name> after created, the default store is kept as is",
+	})
 	return fs
 }
 
@@ -118,6 +124,12 @@ func (p *Tencent) GetDeleteFlags() []types.Flag {
 			Usage:  "CVM region",
 			EnvVar: "CVM_REGION",
 		},
+		{
+			Name:  "drain-timeout",
+			P:     &p.DrainTimeout,
+			V:     p.DrainTimeout,
+			Usage: "Cordon and drain the nodes before terminating instances, respecting PodDisruptionBudgets up to the timeout, e.g.(--drain-timeout 5m)",
+		},
 	}
 }
 
@@ -134,6 +146,15 @@ func (p *Tencent) MergeClusterOptions() error {
 		}
 		option := stateOption.(*tencent.Options)
 		p.CloudControllerManager = option.CloudControllerManager
+		// private ips are only used for the masters added this time.
+		option.MasterPrivateIPs = nil
+		// pools are only used for the workers added this time, the pool of node is tracked in state.
+		option.Pools = nil
+		// existing eips are only associated to the instances added this time.
+		option.EIPAddresses = nil
+		// the dedicated roles are only used for the masters added this time, the role of node is tracked in state.
+		option.EtcdOnlyMaster = ""
+		option.ControlPlaneOnlyMaster = ""
 
 		// merge options.
 		source := reflect.ValueOf(&p.Options).Elem()
@@ -162,15 +183,21 @@ func (p *Tencent) GetCredentialFlags() []types.Flag {
 			Required: true,
 			EnvVar:   "CVM_SECRET_KEY",
 		},
+		{
+			Name:  "profile",
+			P:     &p.Profile,
+			V:     p.Profile,
+			Usage: "Profile of tccli to read the secret id and key from ~/.tccli/<profile>.credential, --secret-id and --secret-key take precedence over it",
+		},
 	}
 
 	return fs
 }
 
 // GetSSHConfig returns tencent ssh config.
+// The ssh user is left empty as it depends on the image, it will be resolved when creating instances.
 func (p *Tencent) GetSSHConfig() *types.SSH {
 	ssh := &types.SSH{
-		SSHUser: defaultUser,
 		SSHPort: "22",
 	}
 	return ssh
@@ -215,6 +242,26 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			Usage:  "Private network subnet id, see: https://cloud.tencent.com/document/product/215/20046#.E5.AD.90.E7.BD.91",
 			EnvVar: "CVM_SUBNET_ID",
 		},
+		{
+			Name:  "subnet-strategy",
+			P:     &p.SubnetStrategy,
+			V:     p.SubnetStrategy,
+			Usage: "How instances are placed in the subnets of the vpc in the zone, i.e. single, round-robin or least-used, the default single uses --subnet only",
+		},
+		{
+			Name:   "vpc-name",
+			P:      &p.VpcName,
+			V:      p.VpcName,
+			Usage:  "Name of the default vpc which is found or created if --vpc isn't set, use different names to isolate the default networks of installs",
+			EnvVar: "CVM_VPC_NAME",
+		},
+		{
+			Name:   "subnet-name",
+			P:      &p.SubnetName,
+			V:      p.SubnetName,
+			Usage:  "Name prefix of the default subnet which is found or created in each zone if --subnet isn't set",
+			EnvVar: "CVM_SUBNET_NAME",
+		},
 		{
 			Name:   "keypair-id",
 			P:      &p.KeypairID,
@@ -228,6 +275,12 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			V:     p.ImageID,
 			Usage: "Specify the image to be used by the instance, see: https://cloud.tencent.com/document/product/213/4941",
 		},
+		{
+			Name:  "image-family",
+			P:     &p.ImageFamily,
+			V:     p.ImageFamily,
+			Usage: "Use the latest public image of the family in the region if `--image` isn't set, in <platform>-<version> format, e.g.(--image-family ubuntu-22.04)",
+		},
 		{
 			Name:   "instance-type",
 			P:      &p.InstanceType,
@@ -235,6 +288,18 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			Usage:  "Specify the type of VM instance, see: https://cloud.tencent.com/document/product/213/11518",
 			EnvVar: "CVM_INSTANCE_TYPE",
 		},
+		{
+			Name:  "launch-template-id",
+			P:     &p.LaunchTemplateID,
+			V:     p.LaunchTemplateID,
+			Usage: "Launch template which defines the zone, image, instance type, disks, network and security groups of instances, the values of template take precedence",
+		},
+		{
+			Name:  "launch-template-version",
+			P:     &p.LaunchTemplateVersion,
+			V:     p.LaunchTemplateVersion,
+			Usage: "Version of the launch template, the default version is used if it's not set",
+		},
 		{
 			Name:   "disk-category",
 			P:      &p.SystemDiskType,
@@ -249,6 +314,36 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			Usage:  "Specify the system disk size used by the instance",
 			EnvVar: "CVM_DISK_SIZE",
 		},
+		{
+			Name:  "master-disk-size",
+			P:     &p.MasterDiskSize,
+			V:     p.MasterDiskSize,
+			Usage: "Specify the system disk size used by master instances, overrides --disk-size for masters",
+		},
+		{
+			Name:  "worker-disk-size",
+			P:     &p.WorkerDiskSize,
+			V:     p.WorkerDiskSize,
+			Usage: "Specify the system disk size used by worker instances, overrides --disk-size for workers",
+		},
+		{
+			Name:  "data-disk-size",
+			P:     &p.DataDiskSize,
+			V:     p.DataDiskSize,
+			Usage: "Attach a data disk of the size (GB) and the same category as the system disk to each instance, it's deleted with the instance",
+		},
+		{
+			Name:  "disk-encrypt",
+			P:     &p.DiskEncrypt,
+			V:     p.DiskEncrypt,
+			Usage: "Encrypt the data disk set by --data-disk-size, the system disk can't be encrypted",
+		},
+		{
+			Name:  "kms-key-id",
+			P:     &p.KmsKeyID,
+			V:     p.KmsKeyID,
+			Usage: "KMS key to encrypt the data disk with, the default key of cbs is used if it's not set",
+		},
 		{
 			Name:   "security-group",
 			P:      &p.SecurityGroupIds,
@@ -256,6 +351,25 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			Usage:  "Specify the security group used by the instance, see: https://cloud.tencent.com/document/product/213/12452",
 			EnvVar: "CVM_SECURITY_GROUP",
 		},
+		{
+			Name:   "security-group-name",
+			P:      &p.SecurityGroupName,
+			V:      p.SecurityGroupName,
+			Usage:  "Name of the default security group which is found or created if --security-group isn't set",
+			EnvVar: "CVM_SECURITY_GROUP_NAME",
+		},
+		{
+			Name:  "restrict-egress",
+			P:     &p.RestrictEgress,
+			V:     p.RestrictEgress,
+			Usage: "Only add the egress policies required by K3s to the default security group instead of allowing all egress",
+		},
+		{
+			Name:  "egress-cidr",
+			P:     &p.EgressCIDRs,
+			V:     p.EgressCIDRs,
+			Usage: "CIDR which all egress traffic to is allowed instead of https to anywhere, must set with --restrict-egress, can be set multiple times, e.g.(--egress-cidr 10.0.0.0/8)",
+		},
 		{
 			Name:  "internet-max-bandwidth-out",
 			P:     &p.InternetMaxBandwidthOut,
@@ -268,6 +382,54 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			V:     p.Tags,
 			Usage: "Set instance additional tags, i.e.(--tags a=b --tags b=c), see: https://cloud.tencent.com/document/product/213/17131",
 		},
+		{
+			Name:  "master-private-ips",
+			P:     &p.MasterPrivateIPs,
+			V:     p.MasterPrivateIPs,
+			Usage: "Private ips of the master instances, the number must match with --master and the ips must be within the subnet, e.g.(--master-private-ips 192.168.3.10 --master-private-ips 192.168.3.11)",
+		},
+		{
+			Name:  "etcd-only-master",
+			P:     &p.EtcdOnlyMaster,
+			V:     p.EtcdOnlyMaster,
+			Usage: "Number of the masters which only run etcd, they are included in --master and the first master always runs both etcd and control-plane, only works with --cluster",
+		},
+		{
+			Name:  "control-plane-only-master",
+			P:     &p.ControlPlaneOnlyMaster,
+			V:     p.ControlPlaneOnlyMaster,
+			Usage: "Number of the masters which only run control-plane components without etcd, they are included in --master, only works with --cluster",
+		},
+		{
+			Name:  "pool",
+			P:     &p.Pools,
+			V:     p.Pools,
+			Usage: "Worker pool with its own instance configs, labels and taints, can be set multiple times, fields: name, count, type, disk-category, disk-size, spot, labels, taints, e.g.(--pool name=gpu,type=GN7.LARGE,count=2,labels=gpu=true;team=ml,taints=gpu=true:NoSchedule)",
+		},
+		{
+			Name:  "node-order",
+			P:     &p.NodeOrder,
+			V:     p.NodeOrder,
+			Usage: "Order of creating instances, the workers failed to be created are joined after the masters are initialized when creating cluster, i.e.(masters-first, workers-first)",
+		},
+		{
+			Name:  "kube-apiserver-arg",
+			P:     &p.KubeAPIServerArgs,
+			V:     p.KubeAPIServerArgs,
+			Usage: "Customized flag for kube-apiserver process on masters, can be set multiple times, e.g.(--kube-apiserver-arg audit-log-path=/var/log/k3s-audit.log)",
+		},
+		{
+			Name:  "kube-controller-manager-arg",
+			P:     &p.KubeControllerArgs,
+			V:     p.KubeControllerArgs,
+			Usage: "Customized flag for kube-controller-manager process on masters, can be set multiple times, e.g.(--kube-controller-manager-arg node-monitor-grace-period=20s)",
+		},
+		{
+			Name:  "kube-scheduler-arg",
+			P:     &p.KubeSchedulerArgs,
+			V:     p.KubeSchedulerArgs,
+			Usage: "Customized flag for kube-scheduler process on masters, can be set multiple times, e.g.(--kube-scheduler-arg v=2)",
+		},
 		{
 			Name:  "router",
 			P:     &p.NetworkRouteTableName,
@@ -280,12 +442,96 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			V:     p.PublicIPAssignedEIP,
 			Usage: "Enable eip, see: https://cloud.tencent.com/document/product/213/5733",
 		},
+		{
+			Name:  "master-eip",
+			P:     &p.MasterEIP,
+			V:     p.MasterEIP,
+			Usage: "Only associate eips to the masters, the workers are private without public ip",
+		},
+		{
+			Name:  "worker-eip",
+			P:     &p.WorkerEIP,
+			V:     p.WorkerEIP,
+			Usage: "Only associate eips to the workers, the masters are private without public ip",
+		},
+		{
+			Name:  "eip-address",
+			P:     &p.EIPAddresses,
+			V:     p.EIPAddresses,
+			Usage: "Address of existing unbound eip to associate to the new instances in order of masters and workers, must set with --eip, --master-eip or --worker-eip, can be set multiple times. The eips are only disassociated when deleting the cluster, e.g.(--eip-address 1.2.3.4 --eip-address 1.2.3.5)",
+		},
+		{
+			Name:  "ha-vip",
+			P:     &p.HaVip,
+			V:     p.HaVip,
+			Usage: "Enable a floating ip of the api-server for HA cluster, which is a havip failed over by keepalived on masters and associated with an eip, the eip is added to --tls-sans",
+		},
+		{
+			Name:  "nat-gateway",
+			P:     &p.NatGateway,
+			V:     p.NatGateway,
+			Usage: "Route the egress of the subnets to a nat gateway, so that the private nodes without public ip can pull images. The nat gateway is created with an eip and deleted with the cluster unless --nat-gateway-id is set",
+		},
+		{
+			Name:  "nat-gateway-id",
+			P:     &p.NatGatewayID,
+			V:     p.NatGatewayID,
+			Usage: "ID of existing nat gateway in the vpc to reuse, must set with --nat-gateway, it's not deleted with the cluster, e.g.(nat-xxxxxxxx)",
+		},
+		{
+			Name:  "ingress-lb",
+			P:     &p.IngressLB,
+			V:     p.IngressLB,
+			Usage: "ID of existing clb which the workers are registered to on the ingress ports 80 and 443, so the LoadBalancer services of servicelb are reachable by the clb vip, can't set with --cloud-controller-manager, e.g.(lb-xxxxxxxx)",
+		},
 		{
 			Name:  "cloud-controller-manager",
 			P:     &p.CloudControllerManager,
 			V:     p.CloudControllerManager,
 			Usage: "Enable cloud-controller-manager component, for more information, please check https://github.com/TencentCloud/tencentcloud-cloud-controller-manager/blob/master/docs/getting-started.md",
 		},
+		{
+			Name:  "ccm-version",
+			P:     &p.CCMVersion,
+			V:     p.CCMVersion,
+			Usage: "Version of cloud-controller-manager image, must set with --cloud-controller-manager",
+		},
+		{
+			Name:  "enable-csi",
+			P:     &p.EnableCSI,
+			V:     p.EnableCSI,
+			Usage: "Enable cbs csi driver and create default storage class, for more information, please check https://github.com/TencentCloud/kubernetes-csi-tencentcloud/blob/master/docs/README_CBS.md",
+		},
+		{
+			Name:  "csi-version",
+			P:     &p.CSIVersion,
+			V:     p.CSIVersion,
+			Usage: "Version of cbs csi driver image, must set with --enable-csi",
+		},
+		{
+			Name:  "csi-disk-type",
+			P:     &p.CSIDiskType,
+			V:     p.CSIDiskType,
+			Usage: "Disk type of the default cbs storage class, must set with --enable-csi, i.e.(CLOUD_PREMIUM, CLOUD_SSD)",
+		},
+		{
+			Name:  "gpu",
+			P:     &p.GPU,
+			V:     p.GPU,
+			Usage: "Install NVIDIA driver and container runtime on the nodes of GPU instance types (e.g. GN7) and deploy the NVIDIA device plugin",
+		},
+		{
+			Name:  "metadata-access",
+			P:     &p.MetadataAccess,
+			V:     p.MetadataAccess,
+			Usage: "Restrict the access to instance metadata on nodes, `host-only` blocks the pods which aren't in host network and `none` blocks the nodes as well, i.e.(all, host-only, none)",
+		},
+		{
+			Name:  "etcd-snapshot-cos-bucket",
+			P:     &p.EtcdSnapshotCOSBucket,
+			V:     p.EtcdSnapshotCOSBucket,
+			Usage: "Upload etcd snapshots to the cos bucket in the same region with the secret id and key, i.e.(examplebucket-1250000000), must set with --cluster",
+		},
 		{
 			Name:  "user-data-path",
 			P:     &p.UserDataPath,
@@ -298,12 +544,114 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			V:     p.UserDataContent,
 			Usage: "Set user data content, must be base64-encoded text. see: https://cloud.tencent.com/document/product/213/17525",
 		},
+		{
+			Name:  "master-user-data-path",
+			P:     &p.MasterUserDataPath,
+			V:     p.MasterUserDataPath,
+			Usage: "Set user data for master instances, overrides --user-data-path and --user-data-content for masters, i.e.( --master-user-data-path /file/path )",
+		},
+		{
+			Name:  "worker-user-data-path",
+			P:     &p.WorkerUserDataPath,
+			V:     p.WorkerUserDataPath,
+			Usage: "Set user data for worker instances, overrides --user-data-path and --user-data-content for workers, i.e.( --worker-user-data-path /file/path )",
+		},
 		{
 			Name:  "spot",
 			P:     &p.Spot,
 			V:     p.Spot,
 			Usage: "Use spot instance, see: https://cloud.tencent.com/document/product/213/17816",
 		},
+		{
+			Name:  "spot-fallback",
+			P:     &p.SpotFallback,
+			V:     p.SpotFallback,
+			Usage: "Launch on-demand instances instead if the spot instances are sold out",
+		},
+		{
+			Name:  "host-id",
+			P:     &p.HostIDs,
+			V:     p.HostIDs,
+			Usage: "ID of the dedicated host (CDH) to launch instances on, the nodes are labeled with " + hostNodeLabel + "=<host id>, can be set multiple times, e.g.(--host-id host-xxxxxxxx)",
+		},
+		{
+			Name:  "instance-name-template",
+			P:     &p.InstanceNameTemplate,
+			V:     p.InstanceNameTemplate,
+			Usage: "Instance name template, supported placeholders are {cluster}, {role}, {index} and {zone}, e.g.(--instance-name-template k3s-{cluster}-{role}-{index})",
+		},
+		{
+			Name:  "set-hostname",
+			P:     &p.SetHostname,
+			V:     p.SetHostname,
+			Usage: "Set the hostname and K3s node name of instances to the instance names rendered by --instance-name-template with {index}, or to the instance ids, it's ignored if --cloud-controller-manager is enabled",
+		},
+		{
+			Name:  "private-dns-zone",
+			P:     &p.PrivateDNSZone,
+			V:     p.PrivateDNSZone,
+			Usage: "Register A record \"<node-name>.<zone>\" with the node's internal ip in the specified private dns zone, the node name is the hostname set by --set-hostname or the instance id in lower case, see: https://cloud.tencent.com/document/product/1338",
+		},
+		{
+			Name:  "endpoint-url",
+			P:     &p.EndpointURL,
+			V:     p.EndpointURL,
+			Usage: "API endpoint of all the services which don't have their own endpoint set, e.g. a private api gateway",
+		},
+		{
+			Name:  "cvm-endpoint",
+			P:     &p.CVMEndpoint,
+			V:     p.CVMEndpoint,
+			Usage: "API endpoint of cvm service, default to --endpoint-url or cvm.tencentcloudapi.com",
+		},
+		{
+			Name:  "vpc-endpoint",
+			P:     &p.VPCEndpoint,
+			V:     p.VPCEndpoint,
+			Usage: "API endpoint of vpc service, default to --endpoint-url or vpc.tencentcloudapi.com",
+		},
+		{
+			Name:  "tag-endpoint",
+			P:     &p.TagEndpoint,
+			V:     p.TagEndpoint,
+			Usage: "API endpoint of tag service, default to --endpoint-url or tag.tencentcloudapi.com",
+		},
+		{
+			Name:  "tke-endpoint",
+			P:     &p.TKEEndpoint,
+			V:     p.TKEEndpoint,
+			Usage: "API endpoint of tke service, default to --endpoint-url or tke.tencentcloudapi.com",
+		},
+		{
+			Name:  "cbs-endpoint",
+			P:     &p.CBSEndpoint,
+			V:     p.CBSEndpoint,
+			Usage: "API endpoint of cbs service, default to --endpoint-url or cbs.tencentcloudapi.com",
+		},
+		{
+			Name:  "privatedns-endpoint",
+			P:     &p.PrivateDNSEndpoint,
+			V:     p.PrivateDNSEndpoint,
+			Usage: "API endpoint of privatedns service, default to --endpoint-url or privatedns.tencentcloudapi.com",
+		},
+		{
+			Name:  "api-qps",
+			P:     &p.APIQPS,
+			V:     p.APIQPS,
+			Usage: "Maximum requests per second to tencent api shared by all operations of the process, default to 10",
+		},
+		{
+			Name:  "api-burst",
+			P:     &p.APIBurst,
+			V:     p.APIBurst,
+			Usage: "Maximum burst of requests to tencent api shared by all operations of the process, default to 20",
+		},
+		{
+			Name:  "api-page-size",
+			P:     &p.APIPageSize,
+			V:     p.APIPageSize,
+			Usage: "Number of instances described by each request to tencent api, up to 100, default to 20",
+		},
 	}
 
 	return fs
@@ -0,0 +1,139 @@
+package tencent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	nvidiaDevicePluginImage = "nvcr.io/nvidia/k8s-device-plugin:v0.14.1"
+	// gpuNodeLabel is added to the nodes of GPU instances, the device plugin is only scheduled to them.
+	gpuNodeLabel = "nvidia.com/gpu.present=true"
+)
+
+var deployGPUCommand = "echo \"%s\" | base64 -d | tee \"%s/nvidia-device-plugin.yaml\""
+
+// setupGPUCommand installs the NVIDIA driver and container toolkit before K3s is installed, so that K3s detects
+// the nvidia container runtime and configures containerd with it when it starts. The driver is skipped if it's
+// already installed by the image.
+var setupGPUCommand = `set -e
+if ! nvidia-smi >/dev/null 2>&1; then
+  if command -v apt-get >/dev/null 2>&1; then
+    apt-get update -y
+    DEBIAN_FRONTEND=noninteractive apt-get install -y ubuntu-drivers-common
+    ubuntu-drivers autoinstall
+  elif command -v yum >/dev/null 2>&1; then
+    . /etc/os-release
+    yum install -y yum-utils "kernel-devel-$(uname -r)" "kernel-headers-$(uname -r)"
+    yum-config-manager --add-repo "https://developer.download.nvidia.com/compute/cuda/repos/rhel${VERSION_ID%%.*}/x86_64/cuda-rhel${VERSION_ID%%.*}.repo"
+    yum install -y nvidia-driver-latest-dkms
+  else
+    echo "unsupported os to install nvidia driver" >&2
+    exit 1
+  fi
+  modprobe nvidia || true
+fi
+if ! command -v nvidia-container-runtime >/dev/null 2>&1; then
+  if command -v apt-get >/dev/null 2>&1; then
+    curl -fsSL https://nvidia.github.io/libnvidia-container/gpgkey | gpg --batch --yes --dearmor -o /usr/share/keyrings/nvidia-container-toolkit-keyring.gpg
+    curl -fsSL https://nvidia.github.io/libnvidia-container/stable/deb/nvidia-container-toolkit.list | \
+      sed 's#deb https://#deb [signed-by=/usr/share/keyrings/nvidia-container-toolkit-keyring.gpg] https://#g' > /etc/apt/sources.list.d/nvidia-container-toolkit.list
+    apt-get update -y
+    DEBIAN_FRONTEND=noninteractive apt-get install -y nvidia-container-toolkit
+  else
+    curl -fsSL https://nvidia.github.io/libnvidia-container/stable/rpm/nvidia-container-toolkit.repo > /etc/yum.repos.d/nvidia-container-toolkit.repo
+    yum install -y nvidia-container-toolkit
+  fi
+fi
+nvidia-smi
+`
+
+// checkGPUInstanceTypes checks the instance types of the cluster have GPUs by the instance type configs of the zone
+// if `--gpu` is set, as the driver can't be installed on the other instances.
+func (p *Tencent) checkGPUInstanceTypes() error {
+	if !p.GPU {
+		return nil
+	}
+	if p.c == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	instanceTypes := []string{p.InstanceType}
+	pools, _ := parseWorkerPools(p.Pools)
+	for _, pool := range pools {
+		if pool.InstanceType != "" {
+			instanceTypes = append(instanceTypes, pool.InstanceType)
+		}
+	}
+	for _, instanceType := range instanceTypes {
+		gpu, err := p.describeInstanceTypeGPU(instanceType)
+		if err != nil {
+			return err
+		}
+		if gpu <= 0 {
+			return fmt.Errorf("[%s] calling preflight error: instance type %s has no GPU, `--gpu` requires GPU instance types, e.g. GN7.2XLARGE32",
+				p.GetProviderName(), instanceType)
+		}
+	}
+	return nil
+}
+
+// describeInstanceTypeGPU returns the number of GPUs of the instance type in the zone.
+func (p *Tencent) describeInstanceTypeGPU(instanceType string) (int64, error) {
+	family := instanceType
+	if i := strings.Index(instanceType, "."); i > 0 {
+		family = instanceType[:i]
+	}
+	request := cvm.NewDescribeInstanceTypeConfigsRequest()
+	request.Filters = []*cvm.Filter{
+		{Name: tencentCommon.StringPtr("zone"), Values: tencentCommon.StringPtrs([]string{p.Zone})},
+		{Name: tencentCommon.StringPtr("instance-family"), Values: tencentCommon.StringPtrs([]string{family})},
+	}
+	response, err := p.c.DescribeInstanceTypeConfigs(request)
+	if err != nil {
+		return 0, fmt.Errorf("[%s] calling describeInstanceTypeConfigs error, zone: %s, msg: %v", p.GetProviderName(), p.Zone, err)
+	}
+	if response.Response != nil {
+		for _, config := range response.Response.InstanceTypeConfigSet {
+			if config.InstanceType != nil && strings.EqualFold(*config.InstanceType, instanceType) {
+				if config.GPU == nil {
+					return 0, nil
+				}
+				return *config.GPU, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("[%s] calling preflight error: instance type %s is not available in zone %s", p.GetProviderName(), instanceType, p.Zone)
+}
+
+// setupGPUNodes installs the NVIDIA driver and container runtime on the instances added by the current command.
+func (p *Tencent) setupGPUNodes() error {
+	if err := p.runOnCurrentNodes(p.setupGPUNode); err != nil {
+		return fmt.Errorf("[%s] failed to set up NVIDIA driver and container runtime of instances, %v", p.GetProviderName(), err)
+	}
+	return nil
+}
+
+func (p *Tencent) setupGPUNode(node types.Node) error {
+	p.Logger.Infof("[%s] installing NVIDIA driver and container runtime on instance %s...", p.GetProviderName(), node.InstanceID)
+	if err := p.executeOnNode(node, setupGPUCommand); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully installed NVIDIA driver and container runtime on instance %s", p.GetProviderName(), node.InstanceID)
+	return nil
+}
+
+// generateGPUManifest returns the command which writes the nvidia runtime class and device plugin manifest to the K3s
+// manifests dir, the device plugin is scheduled to the nodes with the gpu label.
+func (p *Tencent) generateGPUManifest() string {
+	tmpl := fmt.Sprintf(nvidiaDevicePluginTmpl, nvidiaDevicePluginImage)
+	return fmt.Sprintf(deployGPUCommand, base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir)
+}
@@ -0,0 +1,179 @@
+package tencent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	keepalivedImage = "osixia/keepalived:2.0.20"
+	// tencent limits the length of havip name.
+	maxHaVipNameLength = 60
+)
+
+var deployKeepalivedCommand = "echo \"%s\" | base64 -d | tee \"%s/keepalived.yaml\""
+
+func (p *Tencent) getHaVipName() string {
+	name := "autok3s-" + p.ContextName
+	if len(name) > maxHaVipNameLength {
+		name = name[:maxHaVipNameLength]
+	}
+	return name
+}
+
+// configHaVip creates the havip in the subnet of the cluster and associates a new eip to it, the vip and eip are added
+// to the tls sans so the api-server can be accessed by them after keepalived is deployed.
+func (p *Tencent) configHaVip() error {
+	p.Logger.Infof("[%s] creating havip for cluster %s...", p.GetProviderName(), p.Name)
+	request := vpc.NewCreateHaVipRequest()
+	request.VpcId = tencentCommon.StringPtr(p.VpcID)
+	request.SubnetId = tencentCommon.StringPtr(p.SubnetID)
+	request.HaVipName = tencentCommon.StringPtr(p.getHaVipName())
+	response, err := p.v.CreateHaVip(request)
+	if err != nil {
+		return fmt.Errorf("[%s] calling createHaVip error, msg: %v", p.GetProviderName(), err)
+	}
+	haVip := response.Response.HaVip
+	p.HaVipAddress = *haVip.Vip
+
+	// the eip is tagged with the cluster, so it's released with the other eips when deleting the cluster.
+	addressIDs, taskID, err := p.allocateAddresses(1)
+	if err != nil {
+		return err
+	}
+	if err = p.describeVpcTaskResult(taskID); err != nil {
+		return err
+	}
+	addresses, err := p.describeAddresses(addressIDs, nil)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 || addresses[0].AddressIp == nil {
+		return fmt.Errorf("[%s] the allocated eip of havip is not found", p.GetProviderName())
+	}
+	eip := *addresses[0].AddressIp
+
+	associateRequest := vpc.NewHaVipAssociateAddressIpRequest()
+	associateRequest.HaVipId = haVip.HaVipId
+	associateRequest.AddressIp = tencentCommon.StringPtr(eip)
+	if _, err = p.v.HaVipAssociateAddressIp(associateRequest); err != nil {
+		return fmt.Errorf("[%s] calling haVipAssociateAddressIp error, havip: %s, msg: %v", p.GetProviderName(), *haVip.HaVipId, err)
+	}
+	p.TLSSans = append(p.TLSSans, p.HaVipAddress, eip)
+	p.Logger.Infof("[%s] havip %s with eip %s is created for cluster %s", p.GetProviderName(), p.HaVipAddress, eip, p.Name)
+	return nil
+}
+
+// useHaVipAddress sets the eip of havip as the address of the cluster after keepalived is deployed,
+// so that the kubeconfig and the joining nodes access the api-server through it.
+func (p *Tencent) useHaVipAddress() error {
+	haVip, err := p.describeHaVip()
+	if err != nil {
+		return err
+	}
+	if haVip == nil || haVip.AddressIp == nil || *haVip.AddressIp == "" {
+		return fmt.Errorf("[%s] eip of havip %s is not found", p.GetProviderName(), p.getHaVipName())
+	}
+	return p.SetClusterAddress(*haVip.AddressIp)
+}
+
+// describeHaVip returns the havip of the cluster, nil is returned if it's not exist.
+func (p *Tencent) describeHaVip() (*vpc.HaVip, error) {
+	request := vpc.NewDescribeHaVipsRequest()
+	request.Filters = []*vpc.Filter{
+		{
+			Name:   tencentCommon.StringPtr("havip-name"),
+			Values: tencentCommon.StringPtrs([]string{p.getHaVipName()}),
+		},
+	}
+	response, err := p.v.DescribeHaVips(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeHaVips error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response == nil || len(response.Response.HaVipSet) == 0 {
+		return nil, nil
+	}
+	return response.Response.HaVipSet[0], nil
+}
+
+// disassociateHaVipAddress disassociates the eip from the havip of the cluster, so that it can be released.
+func (p *Tencent) disassociateHaVipAddress() (*vpc.HaVip, error) {
+	haVip, err := p.describeHaVip()
+	if err != nil || haVip == nil {
+		return nil, err
+	}
+	if haVip.AddressIp != nil && *haVip.AddressIp != "" {
+		request := vpc.NewHaVipDisassociateAddressIpRequest()
+		request.HaVipId = haVip.HaVipId
+		if _, err = p.v.HaVipDisassociateAddressIp(request); err != nil {
+			return nil, fmt.Errorf("[%s] calling haVipDisassociateAddressIp error, havip: %s, msg: %v", p.GetProviderName(), *haVip.HaVipId, err)
+		}
+	}
+	return haVip, nil
+}
+
+// deleteHaVip retries until the havip is released by the terminated masters.
+func (p *Tencent) deleteHaVip(haVipID string) error {
+	p.Logger.Infof("[%s] havip %s will be deleted", p.GetProviderName(), haVipID)
+	request := vpc.NewDeleteHaVipRequest()
+	request.HaVipId = tencentCommon.StringPtr(haVipID)
+	var lastErr error
+	if err := wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		if _, lastErr = p.v.DeleteHaVip(request); lastErr != nil {
+			p.Logger.Debugf("[%s] waiting for havip %s to be released: %v", p.GetProviderName(), haVipID, lastErr)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("[%s] calling deleteHaVip error, havip: %s, msg: %v", p.GetProviderName(), haVipID, lastErr)
+	}
+	return nil
+}
+
+// releaseHaVip releases the eip and havip of the cluster when the creation is rolled back.
+func (p *Tencent) releaseHaVip() error {
+	haVip, err := p.disassociateHaVipAddress()
+	if err != nil || haVip == nil {
+		return err
+	}
+	if haVip.AddressIp != nil && *haVip.AddressIp != "" {
+		request := vpc.NewDescribeAddressesRequest()
+		request.Filters = []*vpc.Filter{
+			{Name: tencentCommon.StringPtr("address-ip"), Values: tencentCommon.StringPtrs([]string{*haVip.AddressIp})},
+		}
+		response, err := p.v.DescribeAddresses(request)
+		if err != nil {
+			return fmt.Errorf("[%s] calling describeAddresses error, msg: %v", p.GetProviderName(), err)
+		}
+		for _, address := range response.Response.AddressSet {
+			taskID, err := p.releaseAddresses([]string{*address.AddressId})
+			if err != nil {
+				return err
+			}
+			if err = p.describeVpcTaskResult(taskID); err != nil {
+				return err
+			}
+		}
+	}
+	return p.deleteHaVip(*haVip.HaVipId)
+}
+
+// generateKeepalivedManifest returns the command to deploy keepalived on masters, which holds the havip on one master
+// with a healthy api-server. The vrrp packets are unicast between the masters as multicast isn't supported in vpc.
+func (p *Tencent) generateKeepalivedManifest() string {
+	masterIPs := make([]string, 0, len(p.Status.MasterNodes))
+	for _, master := range p.Status.MasterNodes {
+		if len(master.InternalIPAddress) > 0 {
+			masterIPs = append(masterIPs, master.InternalIPAddress[0])
+		}
+	}
+	tmpl := fmt.Sprintf(keepalivedTmpl, keepalivedImage, strings.Join(masterIPs, " "), p.HaVipAddress)
+	return fmt.Sprintf(deployKeepalivedCommand, base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir)
+}
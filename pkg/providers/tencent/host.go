@@ -0,0 +1,63 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	// cdhInstanceChargeType is the charge type of the instances on the dedicated hosts, they're paid by the hosts.
+	cdhInstanceChargeType = "CDHPAID"
+	// hostNodeLabel is the failure domain of the dedicated host, so that the pods can be spread across the physical
+	// hosts by topologySpreadConstraints or anti-affinity with it as the topologyKey.
+	hostNodeLabel = "topology.autok3s.io/host"
+)
+
+// validateHostIDs checks the dedicated hosts are only used with the charge type of them.
+func (p *Tencent) validateHostIDs() error {
+	if len(p.HostIDs) == 0 {
+		return nil
+	}
+	if p.Spot {
+		return fmt.Errorf("[%s] calling preflight error: `--host-id` can't be set with `--spot`, the instances on dedicated hosts are paid by the hosts",
+			p.GetProviderName())
+	}
+	pools, _ := parseWorkerPools(p.Pools)
+	for _, pool := range pools {
+		if pool.Spot {
+			return fmt.Errorf("[%s] calling preflight error: `--host-id` can't be set with spot pool %s, the instances on dedicated hosts are paid by the hosts",
+				p.GetProviderName(), pool.Name)
+		}
+	}
+	return nil
+}
+
+// setHostPlacement launches the instances on the dedicated hosts of --host-id, which are picked randomly by tencent cloud.
+func (p *Tencent) setHostPlacement(request *cvm.RunInstancesRequest) {
+	if len(p.HostIDs) == 0 {
+		return
+	}
+	request.Placement.HostIds = tencentCommon.StringPtrs(p.HostIDs)
+	request.InstanceChargeType = tencentCommon.StringPtr(cdhInstanceChargeType)
+}
+
+// getInstanceHostID returns the dedicated host of the instance, it's empty if the instance isn't on a dedicated host.
+func getInstanceHostID(instance *cvm.Instance) string {
+	if instance.Placement == nil || instance.Placement.HostId == nil {
+		return ""
+	}
+	return *instance.Placement.HostId
+}
+
+// getHostLabelArgs returns the K3s arg which labels the node with its dedicated host, the nodes which aren't on
+// dedicated hosts are not labeled.
+func getHostLabelArgs(node types.Node) string {
+	if node.HostID == "" {
+		return ""
+	}
+	return fmt.Sprintf(" --node-label=%s=%s", hostNodeLabel, node.HostID)
+}
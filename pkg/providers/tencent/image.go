@@ -0,0 +1,136 @@
+package tencent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	// defaultImageFamily is used if neither --image nor --image-family is set.
+	defaultImageFamily = "ubuntu-22.04"
+	publicImageType    = "PUBLIC_IMAGE"
+	imageStateNormal   = "NORMAL"
+)
+
+// imageFamilyRegexp matches the family of images in `<platform>-<version>` format, e.g. ubuntu-22.04.
+var imageFamilyRegexp = regexp.MustCompile(`^([a-z]+)-([0-9]+(\.[0-9]+)*)$`)
+
+var (
+	imageFamilyCacheMutex sync.Mutex
+	// imageFamilyCache is the resolved image ids by region, family and instance type, they're shared by the
+	// clusters of the process, so that the images are only described once.
+	imageFamilyCache = map[string]string{}
+)
+
+// validateImageFamily checks the format of --image-family, it's only used if --image isn't set.
+func (p *Tencent) validateImageFamily() error {
+	if p.ImageFamily != "" && !imageFamilyRegexp.MatchString(p.ImageFamily) {
+		return fmt.Errorf("[%s] calling preflight error: invalid `--image-family` %q, must be <platform>-<version>, e.g. %s",
+			p.GetProviderName(), p.ImageFamily, defaultImageFamily)
+	}
+	return nil
+}
+
+// resolveImageFamily sets the image to the latest public image of --image-family in the region, so that the image ids
+// don't need to be tracked per region. The image of --image or the launch template takes precedence.
+func (p *Tencent) resolveImageFamily() error {
+	if p.ImageID != "" {
+		return nil
+	}
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	family := p.ImageFamily
+	if family == "" {
+		family = defaultImageFamily
+	}
+	key := strings.Join([]string{p.Region, family, p.InstanceType}, "/")
+	imageFamilyCacheMutex.Lock()
+	defer imageFamilyCacheMutex.Unlock()
+	if id, ok := imageFamilyCache[key]; ok {
+		p.ImageID = id
+		p.Logger.Infof("[%s] use image %s of family %s", p.GetProviderName(), id, family)
+		return nil
+	}
+	image, err := p.describeLatestImage(family)
+	if err != nil {
+		return err
+	}
+	imageFamilyCache[key] = *image.ImageId
+	p.ImageID = *image.ImageId
+	name := ""
+	if image.ImageName != nil {
+		name = *image.ImageName
+	}
+	p.Logger.Infof("[%s] use image %s (%s) of family %s", p.GetProviderName(), p.ImageID, name, family)
+	return nil
+}
+
+// describeLatestImage returns the latest public image of the family which supports the instance type.
+func (p *Tencent) describeLatestImage(family string) (*cvm.Image, error) {
+	matches := imageFamilyRegexp.FindStringSubmatch(family)
+	if matches == nil {
+		return nil, fmt.Errorf("[%s] invalid image family %q, must be <platform>-<version>, e.g. %s", p.GetProviderName(), family, defaultImageFamily)
+	}
+	platform, version := matches[1], matches[2]
+	request := cvm.NewDescribeImagesRequest()
+	request.Filters = []*cvm.Filter{
+		{Name: tencentCommon.StringPtr("image-type"), Values: tencentCommon.StringPtrs([]string{publicImageType})},
+	}
+	if p.InstanceType != "" {
+		request.InstanceType = tencentCommon.StringPtr(p.InstanceType)
+	}
+	request.Limit = tencentCommon.Uint64Ptr(maxAPIPageSize)
+	var latest *cvm.Image
+	for offset := uint64(0); ; offset += maxAPIPageSize {
+		request.Offset = tencentCommon.Uint64Ptr(offset)
+		response, err := p.c.DescribeImages(request)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] calling describeImages error, msg: %v", p.GetProviderName(), err)
+		}
+		if response.Response == nil {
+			break
+		}
+		for _, image := range response.Response.ImageSet {
+			if !isImageOfFamily(image, platform, version) {
+				continue
+			}
+			// the created time is in RFC3339 format, which is ordered as string.
+			if latest == nil || latest.CreatedTime == nil || (image.CreatedTime != nil && *image.CreatedTime > *latest.CreatedTime) {
+				latest = image
+			}
+		}
+		if len(response.Response.ImageSet) < maxAPIPageSize ||
+			(response.Response.TotalCount != nil && int64(offset)+maxAPIPageSize >= *response.Response.TotalCount) {
+			break
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("[%s] no public image of family %s is found in region %s", p.GetProviderName(), family, p.Region)
+	}
+	return latest, nil
+}
+
+// isImageOfFamily returns true if the image is a normal image of the platform, whose os name has the version,
+// e.g. `Ubuntu Server 22.04 LTS 64bit` is of family ubuntu-22.04, the patch versions such as 7.9.2009 match 7.9.
+func isImageOfFamily(image *cvm.Image, platform, version string) bool {
+	if image.ImageId == nil || image.Platform == nil || image.OsName == nil || !strings.EqualFold(*image.Platform, platform) {
+		return false
+	}
+	if image.ImageState != nil && *image.ImageState != imageStateNormal {
+		return false
+	}
+	for _, field := range strings.Fields(*image.OsName) {
+		if field == version || strings.HasPrefix(field, version+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	ingressLBProtocol = "TCP"
+	// clbTaskSucceed and clbTaskFailed are the status of the async tasks of clb, 2 means it's running.
+	clbTaskSucceed = 0
+	clbTaskFailed  = 1
+)
+
+// ingressPorts are the ports of ingress which servicelb listens on the nodes for the LoadBalancer service of traefik.
+var ingressPorts = []int64{80, 443}
+
+// validateIngressLB checks the ingress lb is only set with servicelb, the cloud-controller-manager disables servicelb
+// and creates the clb of LoadBalancer services itself.
+func (p *Tencent) validateIngressLB() error {
+	if p.IngressLB != "" && p.CloudControllerManager {
+		return fmt.Errorf("[%s] calling preflight error: `--ingress-lb` can't be set with `--cloud-controller-manager`, "+
+			"which disables servicelb and creates the clb of LoadBalancer services", p.GetProviderName())
+	}
+	return nil
+}
+
+// checkIngressLB checks the clb of --ingress-lb exists and is in the vpc of the cluster.
+func (p *Tencent) checkIngressLB() error {
+	if p.IngressLB == "" {
+		return nil
+	}
+	if p.l == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	request := clb.NewDescribeLoadBalancersRequest()
+	request.LoadBalancerIds = tencentCommon.StringPtrs([]string{p.IngressLB})
+	response, err := p.l.DescribeLoadBalancers(request)
+	if err != nil {
+		return fmt.Errorf("[%s] calling describeLoadBalancers error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response == nil || len(response.Response.LoadBalancerSet) == 0 {
+		return fmt.Errorf("[%s] calling preflight error: clb %s of `--ingress-lb` is not found in region %s", p.GetProviderName(), p.IngressLB, p.Region)
+	}
+	lb := response.Response.LoadBalancerSet[0]
+	if p.VpcID != "" && lb.VpcId != nil && *lb.VpcId != p.VpcID {
+		return fmt.Errorf("[%s] calling preflight error: clb %s of `--ingress-lb` is in vpc %s instead of vpc %s of the cluster",
+			p.GetProviderName(), p.IngressLB, *lb.VpcId, p.VpcID)
+	}
+	return nil
+}
+
+// ensureIngressListeners returns the listeners of the ingress ports of the clb by port, the missing tcp listeners
+// are created, the existing ones are reused whatever protocol they are.
+func (p *Tencent) ensureIngressListeners() (map[int64]string, error) {
+	request := clb.NewDescribeListenersRequest()
+	request.LoadBalancerId = tencentCommon.StringPtr(p.IngressLB)
+	response, err := p.l.DescribeListeners(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeListeners error, clb: %s, msg: %v", p.GetProviderName(), p.IngressLB, err)
+	}
+	listeners := map[int64]string{}
+	if response.Response != nil {
+		for _, listener := range response.Response.Listeners {
+			if listener.Port != nil && listener.ListenerId != nil {
+				listeners[*listener.Port] = *listener.ListenerId
+			}
+		}
+	}
+	for _, port := range ingressPorts {
+		if _, ok := listeners[port]; ok {
+			continue
+		}
+		createRequest := clb.NewCreateListenerRequest()
+		createRequest.LoadBalancerId = tencentCommon.StringPtr(p.IngressLB)
+		createRequest.Ports = []*int64{tencentCommon.Int64Ptr(port)}
+		createRequest.Protocol = tencentCommon.StringPtr(ingressLBProtocol)
+		createRequest.ListenerNames = tencentCommon.StringPtrs([]string{fmt.Sprintf("autok3s-ingress-%d", port)})
+		createResponse, err := p.l.CreateListener(createRequest)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] calling createListener error, clb: %s, port: %d, msg: %v", p.GetProviderName(), p.IngressLB, port, err)
+		}
+		if createResponse.Response == nil || len(createResponse.Response.ListenerIds) == 0 {
+			return nil, fmt.Errorf("[%s] the created listener of port %d is not found in clb %s", p.GetProviderName(), port, p.IngressLB)
+		}
+		if err = p.waitForCLBTask(createResponse.Response.RequestId); err != nil {
+			return nil, err
+		}
+		listeners[port] = *createResponse.Response.ListenerIds[0]
+		p.Logger.Infof("[%s] listener of port %d is created in clb %s", p.GetProviderName(), port, p.IngressLB)
+	}
+	return listeners, nil
+}
+
+// registerIngressTargets registers the workers added by the current command to the ingress ports of the clb.
+func (p *Tencent) registerIngressTargets() error {
+	ids := make([]string, 0)
+	p.M.Range(func(key, value interface{}) bool {
+		v := value.(types.Node)
+		if v.Current && !v.Master {
+			ids = append(ids, v.InstanceID)
+		}
+		return true
+	})
+	if len(ids) == 0 {
+		return nil
+	}
+	listeners, err := p.ensureIngressListeners()
+	if err != nil {
+		return err
+	}
+	for _, port := range ingressPorts {
+		request := clb.NewRegisterTargetsRequest()
+		request.LoadBalancerId = tencentCommon.StringPtr(p.IngressLB)
+		request.ListenerId = tencentCommon.StringPtr(listeners[port])
+		request.Targets = newIngressTargets(ids, port)
+		response, err := p.l.RegisterTargets(request)
+		if err != nil {
+			return fmt.Errorf("[%s] calling registerTargets error, clb: %s, port: %d, msg: %v", p.GetProviderName(), p.IngressLB, port, err)
+		}
+		if err = p.waitForCLBTask(response.Response.RequestId); err != nil {
+			return err
+		}
+	}
+	p.Logger.Infof("[%s] registered workers %s to ingress clb %s", p.GetProviderName(), ids, p.IngressLB)
+	return nil
+}
+
+// deregisterIngressTargets removes the instances from the ingress ports of the clb, the instances which aren't
+// registered are skipped, so it's safe for the masters and the rollback of instances not registered yet.
+func (p *Tencent) deregisterIngressTargets(instanceIds []string) error {
+	ids := make(map[string]bool, len(instanceIds))
+	for _, id := range instanceIds {
+		ids[id] = true
+	}
+	request := clb.NewDescribeTargetsRequest()
+	request.LoadBalancerId = tencentCommon.StringPtr(p.IngressLB)
+	response, err := p.l.DescribeTargets(request)
+	if err != nil {
+		return fmt.Errorf("[%s] calling describeTargets error, clb: %s, msg: %v", p.GetProviderName(), p.IngressLB, err)
+	}
+	if response.Response == nil {
+		return nil
+	}
+	for _, listener := range response.Response.Listeners {
+		if listener.ListenerId == nil || listener.Port == nil || !isIngressPort(*listener.Port) {
+			continue
+		}
+		registered := make([]string, 0)
+		for _, target := range listener.Targets {
+			if target.InstanceId != nil && ids[*target.InstanceId] {
+				registered = append(registered, *target.InstanceId)
+			}
+		}
+		if len(registered) == 0 {
+			continue
+		}
+		deregisterRequest := clb.NewDeregisterTargetsRequest()
+		deregisterRequest.LoadBalancerId = tencentCommon.StringPtr(p.IngressLB)
+		deregisterRequest.ListenerId = listener.ListenerId
+		deregisterRequest.Targets = newIngressTargets(registered, *listener.Port)
+		deregisterResponse, err := p.l.DeregisterTargets(deregisterRequest)
+		if err != nil {
+			return fmt.Errorf("[%s] calling deregisterTargets error, clb: %s, port: %d, msg: %v", p.GetProviderName(), p.IngressLB, *listener.Port, err)
+		}
+		if err = p.waitForCLBTask(deregisterResponse.Response.RequestId); err != nil {
+			return err
+		}
+		p.Logger.Infof("[%s] deregistered instances %s from port %d of ingress clb %s", p.GetProviderName(), registered, *listener.Port, p.IngressLB)
+	}
+	return nil
+}
+
+// waitForCLBTask waits for the async task of clb, the task id is the request id of the call.
+func (p *Tencent) waitForCLBTask(taskID *string) error {
+	if taskID == nil {
+		return nil
+	}
+	request := clb.NewDescribeTaskStatusRequest()
+	request.TaskId = taskID
+	return wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		response, err := p.l.DescribeTaskStatus(request)
+		if err != nil {
+			return false, fmt.Errorf("[%s] calling describeTaskStatus error, task: %s, msg: %v", p.GetProviderName(), *taskID, err)
+		}
+		if response.Response == nil || response.Response.Status == nil {
+			return false, nil
+		}
+		switch *response.Response.Status {
+		case clbTaskSucceed:
+			return true, nil
+		case clbTaskFailed:
+			return false, fmt.Errorf("[%s] clb task %s of ingress clb %s is failed", p.GetProviderName(), *taskID, p.IngressLB)
+		}
+		return false, nil
+	})
+}
+
+func newIngressTargets(ids []string, port int64) []*clb.Target {
+	targets := make([]*clb.Target, 0, len(ids))
+	for _, id := range ids {
+		targets = append(targets, &clb.Target{
+			InstanceId: tencentCommon.StringPtr(id),
+			Port:       tencentCommon.Int64Ptr(port),
+		})
+	}
+	return targets
+}
+
+func isIngressPort(port int64) bool {
+	for _, p := range ingressPorts {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package tencent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// KeyPair brief of CVM key pair, it helps to pick the `--keypair-id` of the account.
+type KeyPair struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedTime string `json:"created-time"`
+	Instances   int    `json:"instances"`
+}
+
+// ListKeyPairs lists the CVM key pairs of the account.
+func (p *Tencent) ListKeyPairs() ([]KeyPair, error) {
+	if err := p.generateClientSDK(); err != nil {
+		return nil, err
+	}
+	return p.describeKeyPairs()
+}
+
+func (p *Tencent) describeKeyPairs() ([]KeyPair, error) {
+	request := cvm.NewDescribeKeyPairsRequest()
+	limit := int64(100)
+	request.Limit = tencentCommon.Int64Ptr(limit)
+	offset := int64(0)
+	keyPairs := make([]KeyPair, 0)
+	for {
+		request.Offset = tencentCommon.Int64Ptr(offset)
+		response, err := p.c.DescribeKeyPairs(request)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] calling describeKeyPairs error, msg: %v", p.GetProviderName(), err)
+		}
+		if response.Response == nil || len(response.Response.KeyPairSet) == 0 {
+			break
+		}
+		for _, k := range response.Response.KeyPairSet {
+			keyPairs = append(keyPairs, convertKeyPair(k))
+		}
+		offset += limit
+		if response.Response.TotalCount == nil || offset >= *response.Response.TotalCount {
+			break
+		}
+	}
+	return keyPairs, nil
+}
+
+func convertKeyPair(k *cvm.KeyPair) KeyPair {
+	keyPair := KeyPair{Instances: len(k.AssociatedInstanceIds)}
+	if k.KeyId != nil {
+		keyPair.ID = *k.KeyId
+	}
+	if k.KeyName != nil {
+		keyPair.Name = *k.KeyName
+	}
+	if k.Description != nil {
+		keyPair.Description = *k.Description
+	}
+	if k.CreatedTime != nil {
+		keyPair.CreatedTime = *k.CreatedTime
+	}
+	return keyPair
+}
+
+// checkKeyPair makes sure the key pair of `--keypair-id` exists, the key pairs of the account are listed if it's not,
+// rather than failing when launching instances.
+func (p *Tencent) checkKeyPair() error {
+	if p.KeypairID == "" {
+		return nil
+	}
+	if p.c == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	keyPairs, err := p.describeKeyPairs()
+	if err != nil {
+		return err
+	}
+	if hasKeyPair(keyPairs, p.KeypairID) {
+		return nil
+	}
+	ids := make([]string, 0, len(keyPairs))
+	for _, k := range keyPairs {
+		ids = append(ids, fmt.Sprintf("%s(%s)", k.ID, k.Name))
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("[%s] calling preflight error: key pair %s is not found, there's no key pair in the account", p.GetProviderName(), p.KeypairID)
+	}
+	return fmt.Errorf("[%s] calling preflight error: key pair %s is not found, choose one of %s", p.GetProviderName(), p.KeypairID, strings.Join(ids, ", "))
+}
+
+// SelectKeyPair lets the user choose one of the key pairs of the account if the key pair of `--keypair-id` isn't found,
+// like choosing the node to ssh. It reads the choice from stdin, so it's only used by the CLI.
+func (p *Tencent) SelectKeyPair() error {
+	if p.KeypairID == "" {
+		return nil
+	}
+	keyPairs, err := p.ListKeyPairs()
+	if err != nil {
+		return err
+	}
+	if hasKeyPair(keyPairs, p.KeypairID) || len(keyPairs) == 0 {
+		// the missing key pair is reported by the preflight check.
+		return nil
+	}
+	items := make(map[string]string, len(keyPairs))
+	for _, k := range keyPairs {
+		items[strings.ToLower(k.ID)] = fmt.Sprintf("%s (%s)", k.ID, k.Name)
+	}
+	id := strings.Split(utils.AskForSelectItem(fmt.Sprintf("[%s] key pair %s is not found, choose the key pair to launch instances",
+		p.GetProviderName(), p.KeypairID), items), " (")[0]
+	if id == "" {
+		return fmt.Errorf("[%s] choose incorrect key pair", p.GetProviderName())
+	}
+	p.KeypairID = id
+	logrus.Infof("[%s] use key pair %s, the private key of which must be set by `--ssh-key-path`", p.GetProviderName(), id)
+	return nil
+}
+
+func hasKeyPair(keyPairs []KeyPair, id string) bool {
+	for _, k := range keyPairs {
+		if k.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package tencent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// the vendored tencentcloud sdk doesn't ship the launch templates of cvm yet,
+// so only the APIs used by autok3s are declared here.
+const cvmAPIVersion = "2017-03-12"
+
+type cvmLaunchTemplateClient struct {
+	tencentCommon.Client
+}
+
+func newLaunchTemplateClient(credential *tencentCommon.Credential, region string, clientProfile *profile.ClientProfile) *cvmLaunchTemplateClient {
+	client := &cvmLaunchTemplateClient{}
+	client.Init(region).
+		WithCredential(credential).
+		WithProfile(clientProfile)
+	return client
+}
+
+type launchTemplate struct {
+	LaunchTemplateID      *string `json:"LaunchTemplateId,omitempty" name:"LaunchTemplateId"`
+	LaunchTemplateVersion *uint64 `json:"LaunchTemplateVersion,omitempty" name:"LaunchTemplateVersion"`
+}
+
+// launchTemplateVersionData only has the fields of template which autok3s takes or overrides.
+type launchTemplateVersionData struct {
+	Placement           *cvm.Placement           `json:"Placement,omitempty" name:"Placement"`
+	InstanceType        *string                  `json:"InstanceType,omitempty" name:"InstanceType"`
+	ImageID             *string                  `json:"ImageId,omitempty" name:"ImageId"`
+	SystemDisk          *cvm.SystemDisk          `json:"SystemDisk,omitempty" name:"SystemDisk"`
+	VirtualPrivateCloud *cvm.VirtualPrivateCloud `json:"VirtualPrivateCloud,omitempty" name:"VirtualPrivateCloud"`
+	InternetAccessible  *cvm.InternetAccessible  `json:"InternetAccessible,omitempty" name:"InternetAccessible"`
+	SecurityGroupIds    []*string                `json:"SecurityGroupIds,omitempty" name:"SecurityGroupIds"`
+	InstanceChargeType  *string                  `json:"InstanceChargeType,omitempty" name:"InstanceChargeType"`
+}
+
+type launchTemplateVersionInfo struct {
+	LaunchTemplateVersion     *uint64                    `json:"LaunchTemplateVersion,omitempty" name:"LaunchTemplateVersion"`
+	LaunchTemplateVersionData *launchTemplateVersionData `json:"LaunchTemplateVersionData,omitempty" name:"LaunchTemplateVersionData"`
+}
+
+type describeLaunchTemplateVersionsRequest struct {
+	*tchttp.BaseRequest
+	LaunchTemplateID       *string   `json:"LaunchTemplateId,omitempty" name:"LaunchTemplateId"`
+	LaunchTemplateVersions []*uint64 `json:"LaunchTemplateVersions,omitempty" name:"LaunchTemplateVersions"`
+	DefaultVersion         *bool     `json:"DefaultVersion,omitempty" name:"DefaultVersion"`
+}
+
+type describeLaunchTemplateVersionsResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		TotalCount               *uint64                      `json:"TotalCount,omitempty" name:"TotalCount"`
+		LaunchTemplateVersionSet []*launchTemplateVersionInfo `json:"LaunchTemplateVersionSet,omitempty" name:"LaunchTemplateVersionSet"`
+		RequestID                *string                      `json:"RequestId,omitempty" name:"RequestId"`
+	} `json:"Response"`
+}
+
+func (c *cvmLaunchTemplateClient) DescribeLaunchTemplateVersions(request *describeLaunchTemplateVersionsRequest) (*describeLaunchTemplateVersionsResponse, error) {
+	request.BaseRequest = &tchttp.BaseRequest{}
+	request.Init().WithApiInfo("cvm", cvmAPIVersion, "DescribeLaunchTemplateVersions")
+	response := &describeLaunchTemplateVersionsResponse{BaseResponse: &tchttp.BaseResponse{}}
+	err := c.Send(request, response)
+	return response, err
+}
+
+// runInstancesRequest is the request of running instances with the launch template, the fields of
+// cvm.RunInstancesRequest are sent in the json body along with the template.
+type runInstancesRequest struct {
+	*cvm.RunInstancesRequest
+	LaunchTemplate *launchTemplate `json:"LaunchTemplate,omitempty" name:"LaunchTemplate"`
+}
+
+func (c *cvmLaunchTemplateClient) RunInstances(request *runInstancesRequest) (*cvm.RunInstancesResponse, error) {
+	request.RunInstancesRequest.Init().WithApiInfo("cvm", cvmAPIVersion, "RunInstances")
+	response := cvm.NewRunInstancesResponse()
+	err := c.Send(request, response)
+	return response, err
+}
+
+// validateLaunchTemplate checks the launch template options offline.
+func (p *Tencent) validateLaunchTemplate() error {
+	if p.LaunchTemplateID == "" {
+		if p.LaunchTemplateVersion != "" {
+			return fmt.Errorf("[%s] calling preflight error: must set `--launch-template-id` if `--launch-template-version` is set", p.GetProviderName())
+		}
+		return nil
+	}
+	if p.LaunchTemplateVersion != "" {
+		if version, err := strconv.ParseUint(p.LaunchTemplateVersion, 10, 64); err != nil || version == 0 {
+			return fmt.Errorf("[%s] calling preflight error: `--launch-template-version` %q must be a positive number", p.GetProviderName(), p.LaunchTemplateVersion)
+		}
+	}
+	return nil
+}
+
+// describeLaunchTemplate returns the data of the launch template version, the default version is used if it's not set.
+func (p *Tencent) describeLaunchTemplate() (*launchTemplateVersionData, error) {
+	request := &describeLaunchTemplateVersionsRequest{}
+	request.LaunchTemplateID = tencentCommon.StringPtr(p.LaunchTemplateID)
+	version := "default"
+	if p.LaunchTemplateVersion != "" {
+		v, _ := strconv.ParseUint(p.LaunchTemplateVersion, 10, 64)
+		request.LaunchTemplateVersions = []*uint64{tencentCommon.Uint64Ptr(v)}
+		version = p.LaunchTemplateVersion
+	} else {
+		request.DefaultVersion = tencentCommon.BoolPtr(true)
+	}
+	response, err := p.e.DescribeLaunchTemplateVersions(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeLaunchTemplateVersions error, launch template: %s, version: %s, msg: %v",
+			p.GetProviderName(), p.LaunchTemplateID, version, err)
+	}
+	if response.Response == nil || len(response.Response.LaunchTemplateVersionSet) == 0 ||
+		response.Response.LaunchTemplateVersionSet[0].LaunchTemplateVersionData == nil {
+		return nil, fmt.Errorf("[%s] launch template %s of version %s is not found in region %s", p.GetProviderName(), p.LaunchTemplateID, version, p.Region)
+	}
+	return response.Response.LaunchTemplateVersionSet[0].LaunchTemplateVersionData, nil
+}
+
+// applyLaunchTemplate checks that the launch template exists, and takes the zone, image, instance type, network and
+// security groups from it, so that the ssh user, the default network and the state match the launched instances.
+func (p *Tencent) applyLaunchTemplate() error {
+	if p.LaunchTemplateID == "" {
+		return nil
+	}
+	data, err := p.describeLaunchTemplate()
+	if err != nil {
+		return err
+	}
+	p.launchTemplate = data
+	if data.Placement != nil && data.Placement.Zone != nil && *data.Placement.Zone != "" {
+		p.Zone = *data.Placement.Zone
+	}
+	if data.ImageID != nil && *data.ImageID != "" {
+		p.ImageID = *data.ImageID
+	}
+	if data.InstanceType != nil && *data.InstanceType != "" {
+		p.InstanceType = *data.InstanceType
+	}
+	if vpc := data.VirtualPrivateCloud; vpc != nil && vpc.VpcId != nil && *vpc.VpcId != "" && vpc.SubnetId != nil {
+		p.VpcID = *vpc.VpcId
+		p.SubnetID = *vpc.SubnetId
+	}
+	if len(data.SecurityGroupIds) > 0 {
+		ids := make([]string, 0, len(data.SecurityGroupIds))
+		for _, id := range data.SecurityGroupIds {
+			if id != nil {
+				ids = append(ids, *id)
+			}
+		}
+		p.SecurityGroupIds = strings.Join(ids, ",")
+	}
+	return nil
+}
+
+// useLaunchTemplate returns the request of launching the instances with the launch template, the fields defined by the
+// template are removed from the request. Only the count, name, tags, user data and login settings are overridden, as well as
+// the options which the template can't know, i.e. spot, the instance type and disk of worker pool, the private ips of
+// masters, the subnets spread by --subnet-strategy, the data disk and eip.
+func (p *Tencent) useLaunchTemplate(request *cvm.RunInstancesRequest, master bool, pool *workerPool) *runInstancesRequest {
+	templateRequest := &runInstancesRequest{
+		RunInstancesRequest: request,
+		LaunchTemplate:      &launchTemplate{LaunchTemplateID: tencentCommon.StringPtr(p.LaunchTemplateID)},
+	}
+	if p.LaunchTemplateVersion != "" {
+		version, _ := strconv.ParseUint(p.LaunchTemplateVersion, 10, 64)
+		templateRequest.LaunchTemplate.LaunchTemplateVersion = tencentCommon.Uint64Ptr(version)
+	}
+	data := p.launchTemplate
+	if data == nil {
+		return templateRequest
+	}
+	if data.Placement != nil {
+		request.Placement = nil
+	}
+	if data.ImageID != nil {
+		request.ImageId = nil
+	}
+	if len(data.SecurityGroupIds) > 0 {
+		request.SecurityGroupIds = nil
+	}
+	if data.InstanceType != nil && (pool == nil || pool.InstanceType == "") {
+		request.InstanceType = nil
+	}
+	if data.SystemDisk != nil && (pool == nil || (pool.DiskCategory == "" && pool.DiskSize == "")) {
+		request.SystemDisk = nil
+	}
+	if data.InstanceChargeType != nil && *request.InstanceChargeType != spotInstanceChargeType {
+		request.InstanceChargeType = nil
+	}
+	if data.VirtualPrivateCloud != nil && (!master || len(p.MasterPrivateIPs) == 0) && !p.spreadSubnets() {
+		request.VirtualPrivateCloud = nil
+	}
+	if data.InternetAccessible != nil && !p.eipEnabled() {
+		request.InternetAccessible = nil
+	}
+	return templateRequest
+}
@@ -0,0 +1,88 @@
+package tencent
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// masterRoleTagKey is the tag of the masters which only run etcd or control-plane.
+const masterRoleTagKey = "master-role"
+
+// masterGroup is the masters of the same role which are launched by one request.
+type masterGroup struct {
+	Role  string
+	Count int
+}
+
+// validateMasterRoles checks the numbers of etcd-only and control-plane-only masters against the masters added this time,
+// the first master of a new cluster must run both etcd and control-plane as it initializes the cluster.
+func (p *Tencent) validateMasterRoles(creating bool) error {
+	etcdNum, _ := strconv.Atoi(p.EtcdOnlyMaster)
+	controlPlaneNum, _ := strconv.Atoi(p.ControlPlaneOnlyMaster)
+	if etcdNum == 0 && controlPlaneNum == 0 {
+		return nil
+	}
+	if !p.Cluster || p.DataStore != "" {
+		return fmt.Errorf("[%s] calling preflight error: `--etcd-only-master` and `--control-plane-only-master` can only be set with embedded etcd `--cluster`",
+			p.GetProviderName())
+	}
+	masterNum, _ := strconv.Atoi(p.Master)
+	if creating {
+		masterNum--
+	}
+	if etcdNum+controlPlaneNum > masterNum {
+		return fmt.Errorf("[%s] calling preflight error: %d etcd-only and %d control-plane-only masters exceed the %d masters which can have a dedicated role",
+			p.GetProviderName(), etcdNum, controlPlaneNum, masterNum)
+	}
+	return nil
+}
+
+// getMasterGroups splits the masters into groups by role, the masters running both etcd and control-plane come first.
+func (p *Tencent) getMasterGroups(masterNum int) []masterGroup {
+	etcdNum, _ := strconv.Atoi(p.EtcdOnlyMaster)
+	controlPlaneNum, _ := strconv.Atoi(p.ControlPlaneOnlyMaster)
+	groups := make([]masterGroup, 0, 3)
+	for _, group := range []masterGroup{
+		{Count: masterNum - etcdNum - controlPlaneNum},
+		{Role: types.MasterRoleEtcd, Count: etcdNum},
+		{Role: types.MasterRoleControlPlane, Count: controlPlaneNum},
+	} {
+		if group.Count > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// getMasterRoleArgs returns the K3s args which disable the components not run by the master's role.
+func getMasterRoleArgs(node types.Node) string {
+	switch node.MasterRole {
+	case types.MasterRoleEtcd:
+		return " --disable-apiserver --disable-controller-manager --disable-scheduler"
+	case types.MasterRoleControlPlane:
+		return " --disable-etcd"
+	}
+	return ""
+}
+
+// getLaunchedCount returns the number of instances of the role launched by the previous requests of this operation,
+// so that the private ips and the instance names of the next request continue from them.
+func (p *Tencent) getLaunchedCount(master bool) int {
+	count := 0
+	p.M.Range(func(_, value interface{}) bool {
+		if node := value.(types.Node); node.RollBack && node.Master == master {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+func (g masterGroup) displayRole() string {
+	if g.Role == "" {
+		return "etcd and control-plane"
+	}
+	return g.Role + "-only"
+}
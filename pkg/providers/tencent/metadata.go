@@ -0,0 +1,85 @@
+package tencent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+const (
+	metadataAccessAll      = "all"
+	metadataAccessHostOnly = "host-only"
+	metadataAccessNone     = "none"
+	// metadataAddress is the address of metadata.tencentyun.com.
+	metadataAddress = "169.254.0.23/32"
+)
+
+// metadataAccessCommand installs a service which drops the packets to the instance metadata at boot, the rules are in
+// the raw table so that they're not bypassed by the accept rules of K3s. The traffic forwarded from pods goes through
+// PREROUTING and the traffic from the host, including the pods of host network, goes through OUTPUT.
+var metadataAccessCommand = `set -e
+cat > /etc/systemd/system/autok3s-metadata-access.service <<EOF
+[Unit]
+Description=Restrict the access to instance metadata
+After=network.target cloud-final.service
+Before=k3s.service k3s-agent.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/bin/sh -c '%s'
+
+[Install]
+WantedBy=multi-user.target
+EOF
+systemctl daemon-reload
+systemctl enable --now autok3s-metadata-access.service
+`
+
+// validateMetadataAccess checks the add-ons which read the instance metadata are allowed to access it. The
+// cloud-controller-manager and cbs csi driver run in host network, so they only conflict with `none`.
+func (p *Tencent) validateMetadataAccess() error {
+	if p.MetadataAccess != metadataAccessNone {
+		return nil
+	}
+	if p.CloudControllerManager || p.EnableCSI {
+		return fmt.Errorf("[%s] calling preflight error: `--metadata-access %s` can't be set with `--cloud-controller-manager` or `--enable-csi`, "+
+			"which read the instance metadata, use `%s` instead", p.GetProviderName(), metadataAccessNone, metadataAccessHostOnly)
+	}
+	return nil
+}
+
+// getMetadataAccessRules returns the iptables commands which restrict the access to instance metadata.
+func (p *Tencent) getMetadataAccessRules() []string {
+	chains := make([]string, 0)
+	switch p.MetadataAccess {
+	case metadataAccessHostOnly:
+		chains = append(chains, "PREROUTING")
+	case metadataAccessNone:
+		chains = append(chains, "PREROUTING", "OUTPUT")
+	}
+	rules := make([]string, 0, len(chains))
+	for _, chain := range chains {
+		rule := fmt.Sprintf("%s -d %s -j DROP", chain, metadataAddress)
+		rules = append(rules, fmt.Sprintf("iptables -t raw -C %s 2>/dev/null || iptables -t raw -I %s", rule, rule))
+	}
+	return rules
+}
+
+// setupMetadataAccess restricts the access to instance metadata on the instances added by the current command before
+// K3s is installed. The tencent cloud api doesn't provide the metadata options of instances, so it's done on the nodes.
+func (p *Tencent) setupMetadataAccess() error {
+	rules := p.getMetadataAccessRules()
+	if len(rules) == 0 {
+		return nil
+	}
+	command := fmt.Sprintf(metadataAccessCommand, strings.Join(rules, "; "))
+	if err := p.runOnCurrentNodes(func(node types.Node) error {
+		p.Logger.Infof("[%s] restricting access to instance metadata of instance %s to %s", p.GetProviderName(), node.InstanceID, p.MetadataAccess)
+		return p.executeOnNode(node, command)
+	}); err != nil {
+		return fmt.Errorf("[%s] failed to restrict access to instance metadata of instances, %v", p.GetProviderName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	natGatewayAvailable = "AVAILABLE"
+	natGatewayType      = "NAT"
+	defaultRouteCidr    = "0.0.0.0/0"
+)
+
+func (p *Tencent) getNatGatewayName() string {
+	name := "autok3s-" + p.ContextName
+	if len(name) > maxHaVipNameLength {
+		name = name[:maxHaVipNameLength]
+	}
+	return name
+}
+
+// configNatGateway creates the nat gateway of the cluster with a new eip or reuses the one of `--nat-gateway-id`,
+// then routes the egress of the subnets of instances to it, so that the private nodes can reach the internet.
+func (p *Tencent) configNatGateway() error {
+	var (
+		nat *vpc.NatGateway
+		err error
+	)
+	if p.NatGatewayID != "" {
+		if nat, err = p.describeNatGateway("nat-gateway-id", p.NatGatewayID); err != nil {
+			return err
+		}
+		if nat == nil {
+			return fmt.Errorf("[%s] nat gateway %s is not found in vpc %s", p.GetProviderName(), p.NatGatewayID, p.VpcID)
+		}
+	} else {
+		if nat, err = p.createNatGateway(); err != nil {
+			return err
+		}
+		p.NatGatewayID = *nat.NatGatewayId
+	}
+
+	subnetIDs := []string{p.SubnetID}
+	if p.spreadSubnets() {
+		subnets, err := p.describeZoneSubnets()
+		if err != nil {
+			return err
+		}
+		for _, s := range subnets {
+			if s.SubnetID != p.SubnetID {
+				subnetIDs = append(subnetIDs, s.SubnetID)
+			}
+		}
+	}
+	routeTableIDs, err := p.describeSubnetRouteTables(subnetIDs)
+	if err != nil {
+		return err
+	}
+	for _, routeTableID := range routeTableIDs {
+		if err = p.addNatGatewayRoute(routeTableID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createNatGateway creates the nat gateway tagged with the cluster in the vpc, the eip of it is tagged as well so
+// it's released with the other eips when deleting the cluster.
+func (p *Tencent) createNatGateway() (*vpc.NatGateway, error) {
+	p.Logger.Infof("[%s] creating nat gateway for cluster %s...", p.GetProviderName(), p.Name)
+	addressIDs, taskID, err := p.allocateAddresses(1)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.describeVpcTaskResult(taskID); err != nil {
+		return nil, err
+	}
+	addresses, err := p.describeAddresses(addressIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 || addresses[0].AddressIp == nil {
+		return nil, fmt.Errorf("[%s] the allocated eip of nat gateway is not found", p.GetProviderName())
+	}
+
+	request := vpc.NewCreateNatGatewayRequest()
+	request.NatGatewayName = tencentCommon.StringPtr(p.getNatGatewayName())
+	request.VpcId = tencentCommon.StringPtr(p.VpcID)
+	request.PublicIpAddresses = []*string{addresses[0].AddressIp}
+	request.Tags = []*vpc.Tag{
+		{Key: tencentCommon.StringPtr("autok3s"), Value: tencentCommon.StringPtr("true")},
+		{Key: tencentCommon.StringPtr("cluster"), Value: tencentCommon.StringPtr(common.TagClusterPrefix + p.ContextName)},
+	}
+	response, err := p.v.CreateNatGateway(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling createNatGateway error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response == nil || len(response.Response.NatGatewaySet) == 0 {
+		return nil, fmt.Errorf("[%s] the created nat gateway is not found", p.GetProviderName())
+	}
+	natGatewayID := *response.Response.NatGatewaySet[0].NatGatewayId
+
+	// the routes can only be added after the nat gateway is available.
+	var nat *vpc.NatGateway
+	if err = wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		if nat, err = p.describeNatGateway("nat-gateway-id", natGatewayID); err != nil {
+			return false, err
+		}
+		return nat != nil && nat.State != nil && *nat.State == natGatewayAvailable, nil
+	}); err != nil {
+		return nil, fmt.Errorf("[%s] waiting for nat gateway %s to be available error, msg: %v", p.GetProviderName(), natGatewayID, err)
+	}
+	p.Logger.Infof("[%s] nat gateway %s with eip %s is created for cluster %s", p.GetProviderName(), natGatewayID, *addresses[0].AddressIp, p.Name)
+	return nat, nil
+}
+
+// describeNatGateway returns the nat gateway in the vpc matched by the filter, nil is returned if it's not exist.
+func (p *Tencent) describeNatGateway(filter, value string) (*vpc.NatGateway, error) {
+	request := vpc.NewDescribeNatGatewaysRequest()
+	request.Filters = []*vpc.Filter{
+		{Name: tencentCommon.StringPtr("vpc-id"), Values: tencentCommon.StringPtrs([]string{p.VpcID})},
+		{Name: tencentCommon.StringPtr(filter), Values: tencentCommon.StringPtrs([]string{value})},
+	}
+	response, err := p.v.DescribeNatGateways(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeNatGateways error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response == nil || len(response.Response.NatGatewaySet) == 0 {
+		return nil, nil
+	}
+	return response.Response.NatGatewaySet[0], nil
+}
+
+// describeSubnetRouteTables returns the distinct route tables associated with the subnets.
+func (p *Tencent) describeSubnetRouteTables(subnetIDs []string) ([]string, error) {
+	request := vpc.NewDescribeSubnetsRequest()
+	request.Filters = []*vpc.Filter{
+		{Name: tencentCommon.StringPtr("subnet-id"), Values: tencentCommon.StringPtrs(subnetIDs)},
+	}
+	response, err := p.v.DescribeSubnets(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeSubnets error, msg: %v", p.GetProviderName(), err)
+	}
+	routeTableIDs := make([]string, 0)
+	seen := map[string]bool{}
+	if response.Response != nil {
+		for _, s := range response.Response.SubnetSet {
+			if s.RouteTableId == nil || seen[*s.RouteTableId] {
+				continue
+			}
+			seen[*s.RouteTableId] = true
+			routeTableIDs = append(routeTableIDs, *s.RouteTableId)
+		}
+	}
+	return routeTableIDs, nil
+}
+
+// addNatGatewayRoute adds the default route to the nat gateway in the route table, it's skipped if the route exists.
+// The existing default route to the other gateway isn't replaced, as it may be used by the other workloads of the vpc.
+func (p *Tencent) addNatGatewayRoute(routeTableID string) error {
+	describeRequest := vpc.NewDescribeRouteTablesRequest()
+	describeRequest.RouteTableIds = tencentCommon.StringPtrs([]string{routeTableID})
+	response, err := p.v.DescribeRouteTables(describeRequest)
+	if err != nil {
+		return fmt.Errorf("[%s] calling describeRouteTables error, route table: %s, msg: %v", p.GetProviderName(), routeTableID, err)
+	}
+	if response.Response != nil {
+		for _, table := range response.Response.RouteTableSet {
+			for _, route := range table.RouteSet {
+				if route.DestinationCidrBlock == nil || *route.DestinationCidrBlock != defaultRouteCidr {
+					continue
+				}
+				if route.GatewayId != nil && *route.GatewayId == p.NatGatewayID {
+					return nil
+				}
+				return fmt.Errorf("[%s] route table %s already has default route, can't route it to nat gateway %s",
+					p.GetProviderName(), routeTableID, p.NatGatewayID)
+			}
+		}
+	}
+
+	request := vpc.NewCreateRoutesRequest()
+	request.RouteTableId = tencentCommon.StringPtr(routeTableID)
+	request.Routes = []*vpc.Route{
+		{
+			DestinationCidrBlock: tencentCommon.StringPtr(defaultRouteCidr),
+			GatewayType:          tencentCommon.StringPtr(natGatewayType),
+			GatewayId:            tencentCommon.StringPtr(p.NatGatewayID),
+			RouteDescription:     tencentCommon.StringPtr("egress of " + p.ContextName + "(generated by autok3s)"),
+		},
+	}
+	if _, err = p.v.CreateRoutes(request); err != nil {
+		return fmt.Errorf("[%s] calling createRoutes error, route table: %s, msg: %v", p.GetProviderName(), routeTableID, err)
+	}
+	p.Logger.Infof("[%s] default route of route table %s is added to nat gateway %s", p.GetProviderName(), routeTableID, p.NatGatewayID)
+	return nil
+}
+
+// deleteNatGateway deletes the nat gateway created by autok3s for the cluster and waits for it to be gone, so that
+// its eip can be released. The routes to it are removed by tencent cloud with it, the reused one is kept.
+func (p *Tencent) deleteNatGateway() (*vpc.NatGateway, error) {
+	if p.NatGatewayID == "" {
+		return nil, nil
+	}
+	nat, err := p.describeNatGateway("nat-gateway-id", p.NatGatewayID)
+	if err != nil || nat == nil || !isClusterTagged(nat.TagSet, common.TagClusterPrefix+p.ContextName) {
+		return nil, err
+	}
+	p.Logger.Infof("[%s] nat gateway %s will be deleted", p.GetProviderName(), p.NatGatewayID)
+	request := vpc.NewDeleteNatGatewayRequest()
+	request.NatGatewayId = tencentCommon.StringPtr(p.NatGatewayID)
+	if _, err = p.v.DeleteNatGateway(request); err != nil {
+		return nil, fmt.Errorf("[%s] calling deleteNatGateway error, nat gateway: %s, msg: %v", p.GetProviderName(), p.NatGatewayID, err)
+	}
+	if err = wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		deleted, err := p.describeNatGateway("nat-gateway-id", p.NatGatewayID)
+		if err != nil {
+			return false, err
+		}
+		return deleted == nil, nil
+	}); err != nil {
+		return nil, fmt.Errorf("[%s] waiting for nat gateway %s to be deleted error, msg: %v", p.GetProviderName(), p.NatGatewayID, err)
+	}
+	return nat, nil
+}
+
+// releaseNatGateway releases the nat gateway and its eip when the creation is rolled back.
+func (p *Tencent) releaseNatGateway() error {
+	nat, err := p.deleteNatGateway()
+	if err != nil || nat == nil {
+		return err
+	}
+	for _, address := range nat.PublicIpAddressSet {
+		if address.AddressId == nil {
+			continue
+		}
+		taskID, err := p.releaseAddresses([]string{*address.AddressId})
+		if err != nil {
+			return err
+		}
+		if err = p.describeVpcTaskResult(taskID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isClusterTagged returns true if the resource is tagged with the cluster.
+func isClusterTagged(tags []*vpc.Tag, cluster string) bool {
+	for _, tag := range tags {
+		if tag.Key != nil && *tag.Key == "cluster" && tag.Value != nil && *tag.Value == cluster {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package tencent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/types/tencent"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// poolTagKey is the instance tag which records the worker pool of the instance.
+const poolTagKey = "pool"
+
+// workerPool is a group of workers sharing the same instance configs, labels and taints,
+// it's defined by `--pool name=gpu,type=GN7.LARGE,count=2,labels=gpu=true;team=ml,taints=gpu=true:NoSchedule`.
+type workerPool struct {
+	Name         string
+	Count        int
+	InstanceType string
+	DiskCategory string
+	DiskSize     string
+	Spot         bool
+	Labels       []string
+	Taints       []string
+}
+
+// parseWorkerPools parses and validates the pool specs, the configs which are not set fall back to the cluster's.
+func parseWorkerPools(specs []string) ([]workerPool, error) {
+	pools := make([]workerPool, 0, len(specs))
+	names := map[string]bool{}
+	for _, spec := range specs {
+		pool, err := parseWorkerPool(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool %q: %v", spec, err)
+		}
+		if names[pool.Name] {
+			return nil, fmt.Errorf("pool %s is duplicated", pool.Name)
+		}
+		names[pool.Name] = true
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+func parseWorkerPool(spec string) (workerPool, error) {
+	pool := workerPool{}
+	for _, field := range strings.Split(spec, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return pool, fmt.Errorf("field %q must be in key=value format", field)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "name":
+			if errs := validation.IsDNS1123Label(value); len(errs) > 0 {
+				return pool, fmt.Errorf("invalid name %s: %s", value, strings.Join(errs, ", "))
+			}
+			pool.Name = value
+		case "count":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return pool, fmt.Errorf("count must be a number >= 1, got %s", value)
+			}
+			pool.Count = count
+		case "type":
+			pool.InstanceType = value
+		case "disk-category":
+			pool.DiskCategory = value
+		case "disk-size":
+			if _, err := strconv.Atoi(value); err != nil {
+				return pool, fmt.Errorf("disk-size must be a number, got %s", value)
+			}
+			pool.DiskSize = value
+		case "spot":
+			spot, err := strconv.ParseBool(value)
+			if err != nil {
+				return pool, fmt.Errorf("spot must be true or false, got %s", value)
+			}
+			pool.Spot = spot
+		case "labels":
+			for _, label := range strings.Split(value, ";") {
+				if !strings.Contains(label, "=") {
+					return pool, fmt.Errorf("label %q must be in key=value format", label)
+				}
+				pool.Labels = append(pool.Labels, label)
+			}
+		case "taints":
+			for _, taint := range strings.Split(value, ";") {
+				if err := validateTaint(taint); err != nil {
+					return pool, err
+				}
+				pool.Taints = append(pool.Taints, taint)
+			}
+		default:
+			return pool, fmt.Errorf("unknown field %s", key)
+		}
+	}
+	if pool.Name == "" || pool.Count == 0 {
+		return pool, fmt.Errorf("name and count are required")
+	}
+	return pool, nil
+}
+
+func validateTaint(taint string) error {
+	i := strings.LastIndex(taint, ":")
+	if i <= 0 {
+		return fmt.Errorf("taint %q must be in key=value:effect or key:effect format", taint)
+	}
+	switch taint[i+1:] {
+	case "NoSchedule", "PreferNoSchedule", "NoExecute":
+		return nil
+	}
+	return fmt.Errorf("taint %q has unsupported effect, must be one of NoSchedule, PreferNoSchedule and NoExecute", taint)
+}
+
+// spec returns the pool in the format of --pool, which is parsed to the same pool.
+func (pool workerPool) spec() string {
+	fields := []string{"name=" + pool.Name, "count=" + strconv.Itoa(pool.Count)}
+	if pool.InstanceType != "" {
+		fields = append(fields, "type="+pool.InstanceType)
+	}
+	if pool.DiskCategory != "" {
+		fields = append(fields, "disk-category="+pool.DiskCategory)
+	}
+	if pool.DiskSize != "" {
+		fields = append(fields, "disk-size="+pool.DiskSize)
+	}
+	if pool.Spot {
+		fields = append(fields, "spot=true")
+	}
+	if len(pool.Labels) > 0 {
+		fields = append(fields, "labels="+strings.Join(pool.Labels, ";"))
+	}
+	if len(pool.Taints) > 0 {
+		fields = append(fields, "taints="+strings.Join(pool.Taints, ";"))
+	}
+	return strings.Join(fields, ",")
+}
+
+// getPoolWorkerCount returns the number of workers of all pools.
+func getPoolWorkerCount(pools []workerPool) int {
+	count := 0
+	for _, pool := range pools {
+		count += pool.Count
+	}
+	return count
+}
+
+// getPoolExtraArgs returns the K3s args which apply the labels and taints of the node's pool.
+func getPoolExtraArgs(option tencent.Options, node types.Node) string {
+	if node.Pool == "" {
+		return ""
+	}
+	pools, err := parseWorkerPools(option.Pools)
+	if err != nil {
+		return ""
+	}
+	extraArgs := ""
+	for _, pool := range pools {
+		if pool.Name != node.Pool {
+			continue
+		}
+		for _, label := range pool.Labels {
+			extraArgs += " --node-label=" + label
+		}
+		for _, taint := range pool.Taints {
+			extraArgs += " --node-taint=" + taint
+		}
+	}
+	return extraArgs
+}
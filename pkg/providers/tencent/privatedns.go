@@ -0,0 +1,103 @@
+package tencent
+
+import (
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+)
+
+// the vendored tencentcloud sdk doesn't ship the privatedns service yet,
+// so only the APIs used by autok3s are declared here.
+const privateDNSAPIVersion = "2020-10-28"
+
+type privateDNSClient struct {
+	tencentCommon.Client
+}
+
+func newPrivateDNSClient(credential *tencentCommon.Credential, region string, clientProfile *profile.ClientProfile) (*privateDNSClient, error) {
+	client := &privateDNSClient{}
+	client.Init(region).
+		WithCredential(credential).
+		WithProfile(clientProfile)
+	return client, nil
+}
+
+type privateDNSFilter struct {
+	Name   *string   `json:"Name,omitempty" name:"Name"`
+	Values []*string `json:"Values,omitempty" name:"Values"`
+}
+
+type privateZone struct {
+	ZoneID *string `json:"ZoneId,omitempty" name:"ZoneId"`
+	Domain *string `json:"Domain,omitempty" name:"Domain"`
+}
+
+type describePrivateZoneListRequest struct {
+	*tchttp.BaseRequest
+	Offset  *int64              `json:"Offset,omitempty" name:"Offset"`
+	Limit   *int64              `json:"Limit,omitempty" name:"Limit"`
+	Filters []*privateDNSFilter `json:"Filters,omitempty" name:"Filters"`
+}
+
+type describePrivateZoneListResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		TotalCount     *int64         `json:"TotalCount,omitempty" name:"TotalCount"`
+		PrivateZoneSet []*privateZone `json:"PrivateZoneSet,omitempty" name:"PrivateZoneSet"`
+		RequestID      *string        `json:"RequestId,omitempty" name:"RequestId"`
+	} `json:"Response"`
+}
+
+func (c *privateDNSClient) DescribePrivateZoneList(request *describePrivateZoneListRequest) (*describePrivateZoneListResponse, error) {
+	request.BaseRequest = &tchttp.BaseRequest{}
+	request.Init().WithApiInfo("privatedns", privateDNSAPIVersion, "DescribePrivateZoneList")
+	response := &describePrivateZoneListResponse{BaseResponse: &tchttp.BaseResponse{}}
+	err := c.Send(request, response)
+	return response, err
+}
+
+type createPrivateZoneRecordRequest struct {
+	*tchttp.BaseRequest
+	ZoneID      *string `json:"ZoneId,omitempty" name:"ZoneId"`
+	RecordType  *string `json:"RecordType,omitempty" name:"RecordType"`
+	SubDomain   *string `json:"SubDomain,omitempty" name:"SubDomain"`
+	RecordValue *string `json:"RecordValue,omitempty" name:"RecordValue"`
+	TTL         *int64  `json:"TTL,omitempty" name:"TTL"`
+}
+
+type createPrivateZoneRecordResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		RecordID  *string `json:"RecordId,omitempty" name:"RecordId"`
+		RequestID *string `json:"RequestId,omitempty" name:"RequestId"`
+	} `json:"Response"`
+}
+
+func (c *privateDNSClient) CreatePrivateZoneRecord(request *createPrivateZoneRecordRequest) (*createPrivateZoneRecordResponse, error) {
+	request.BaseRequest = &tchttp.BaseRequest{}
+	request.Init().WithApiInfo("privatedns", privateDNSAPIVersion, "CreatePrivateZoneRecord")
+	response := &createPrivateZoneRecordResponse{BaseResponse: &tchttp.BaseResponse{}}
+	err := c.Send(request, response)
+	return response, err
+}
+
+type deletePrivateZoneRecordRequest struct {
+	*tchttp.BaseRequest
+	ZoneID      *string   `json:"ZoneId,omitempty" name:"ZoneId"`
+	RecordIDSet []*string `json:"RecordIdSet,omitempty" name:"RecordIdSet"`
+}
+
+type deletePrivateZoneRecordResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		RequestID *string `json:"RequestId,omitempty" name:"RequestId"`
+	} `json:"Response"`
+}
+
+func (c *privateDNSClient) DeletePrivateZoneRecord(request *deletePrivateZoneRecordRequest) (*deletePrivateZoneRecordResponse, error) {
+	request.BaseRequest = &tchttp.BaseRequest{}
+	request.Init().WithApiInfo("privatedns", privateDNSAPIVersion, "DeletePrivateZoneRecord")
+	response := &deletePrivateZoneRecordResponse{BaseResponse: &tchttp.BaseResponse{}}
+	err := c.Send(request, response)
+	return response, err
+}
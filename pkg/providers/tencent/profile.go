@@ -0,0 +1,68 @@
+package tencent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/utils"
+)
+
+// credentialDir returns the directory of tccli, where the credential of each profile is saved in
+// <profile>.credential, e.g. ~/.tccli/default.credential.
+var credentialDir = func() string {
+	return filepath.Join(utils.UserHome(), ".tccli")
+}
+
+// profileCredential is the credential file of tccli.
+type profileCredential struct {
+	SecretID  string `json:"secretId"`
+	SecretKey string `json:"secretKey"`
+}
+
+// LoadProfileCredential returns the credential of the profile set by --profile, it's nil if the profile isn't set.
+func (p *Tencent) LoadProfileCredential() (map[string]string, error) {
+	if p.Profile == "" {
+		return nil, nil
+	}
+	if strings.ContainsAny(p.Profile, `/\`) || p.Profile == "." || p.Profile == ".." {
+		return nil, fmt.Errorf("[%s] invalid `--profile` %q", p.GetProviderName(), p.Profile)
+	}
+	path := filepath.Join(credentialDir(), p.Profile+".credential")
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] failed to read credential of profile %s: %v", p.GetProviderName(), p.Profile, err)
+	}
+	credential := &profileCredential{}
+	if err = json.Unmarshal(b, credential); err != nil {
+		return nil, fmt.Errorf("[%s] invalid credential file %s of profile %s: %v", p.GetProviderName(), path, p.Profile, err)
+	}
+	if credential.SecretID == "" || credential.SecretKey == "" {
+		return nil, fmt.Errorf("[%s] secretId and secretKey must be set in credential file %s of profile %s", p.GetProviderName(), path, p.Profile)
+	}
+	return map[string]string{
+		secretID:  credential.SecretID,
+		secretKey: credential.SecretKey,
+	}, nil
+}
+
+// applyProfileCredential fills the missing secret id and key from the profile, the ones set by flags,
+// environment variables or options take precedence.
+func (p *Tencent) applyProfileCredential() error {
+	if p.SecretID != "" && p.SecretKey != "" {
+		return nil
+	}
+	secrets, err := p.LoadProfileCredential()
+	if err != nil || secrets == nil {
+		return err
+	}
+	if p.SecretID == "" {
+		p.SecretID = secrets[secretID]
+	}
+	if p.SecretKey == "" {
+		p.SecretKey = secrets[secretKey]
+	}
+	return nil
+}
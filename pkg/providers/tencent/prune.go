@@ -0,0 +1,150 @@
+package tencent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+const (
+	pruneTypeEIP     = "eip"
+	pruneTypeKeyPair = "key-pair"
+	// the eip which isn't associated with any instance, nat gateway or havip.
+	eipStatusUnbind = "UNBIND"
+)
+
+// Prune lists the eips allocated by autok3s but not associated, and the key pairs generated for the clusters of
+// the region which neither have state nor live instances. They're removed if confirm is true.
+func (p *Tencent) Prune(confirm bool) ([]types.PruneResource, error) {
+	if p.v == nil || p.c == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return nil, err
+		}
+	}
+	addresses, err := p.describeUnassociatedAddresses()
+	if err != nil {
+		return nil, err
+	}
+	keyPairs, err := p.getOrphanedKeyPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]types.PruneResource, 0, len(addresses)+len(keyPairs))
+	addressIDs := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		addressIDs = append(addressIDs, *address.AddressId)
+		resource := types.PruneResource{Type: pruneTypeEIP, ID: *address.AddressId}
+		if address.AddressIp != nil {
+			resource.Description = *address.AddressIp
+		}
+		resources = append(resources, resource)
+	}
+	for _, contextName := range keyPairs {
+		resources = append(resources, types.PruneResource{Type: pruneTypeKeyPair, ID: contextName,
+			Description: common.GetDefaultSSHKeyPath(contextName, p.GetProviderName())})
+	}
+	if !confirm {
+		return resources, nil
+	}
+
+	if len(addressIDs) > 0 {
+		taskID, err := p.releaseAddresses(addressIDs)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.describeVpcTaskResult(taskID); err != nil {
+			return nil, err
+		}
+	}
+	for _, contextName := range keyPairs {
+		for _, path := range []string{common.GetDefaultSSHKeyPath(contextName, p.GetProviderName()),
+			common.GetDefaultSSHPublicKeyPath(contextName, p.GetProviderName())} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("[%s] failed to remove key pair %s: %v", p.GetProviderName(), path, err)
+			}
+		}
+	}
+	return resources, nil
+}
+
+// describeUnassociatedAddresses returns the eips tagged by autok3s in the region which aren't associated.
+func (p *Tencent) describeUnassociatedAddresses() ([]*vpc.Address, error) {
+	request := vpc.NewDescribeAddressesRequest()
+	request.Filters = []*vpc.Filter{
+		{Name: tencentCommon.StringPtr("tag:autok3s"), Values: tencentCommon.StringPtrs([]string{"true"})},
+	}
+	limit := int64(100)
+	request.Limit = tencentCommon.Int64Ptr(limit)
+	addresses := make([]*vpc.Address, 0)
+	for offset := int64(0); ; offset += limit {
+		request.Offset = tencentCommon.Int64Ptr(offset)
+		response, err := p.v.DescribeAddresses(request)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] calling describeAddresses error, msg: %v", p.GetProviderName(), err)
+		}
+		for _, address := range response.Response.AddressSet {
+			if address.AddressStatus != nil && *address.AddressStatus == eipStatusUnbind && (address.InstanceId == nil || *address.InstanceId == "") {
+				addresses = append(addresses, address)
+			}
+		}
+		if len(response.Response.AddressSet) == 0 || offset+limit >= *response.Response.TotalCount {
+			break
+		}
+	}
+	return addresses, nil
+}
+
+// getOrphanedKeyPairs returns the context names of the clusters in the region which have generated key pairs,
+// but neither have state nor live instances, e.g. the creation is failed and rolled back.
+func (p *Tencent) getOrphanedKeyPairs() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(common.CfgPath, p.GetProviderName(), "clusters"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	instances, err := p.describeInstancesByFilters([]*cvm.Filter{
+		{Name: tencentCommon.StringPtr("tag:autok3s"), Values: tencentCommon.StringPtrs([]string{"true"})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]bool{}
+	for _, instance := range instances {
+		for _, tag := range instance.Tags {
+			if *tag.Key == "cluster" {
+				live[strings.TrimPrefix(*tag.Value, common.TagClusterPrefix)] = true
+			}
+		}
+	}
+
+	suffix := fmt.Sprintf(".%s.%s", p.Region, p.GetProviderName())
+	contextNames := make([]string, 0)
+	for _, entry := range entries {
+		contextName := entry.Name()
+		if !entry.IsDir() || !strings.HasSuffix(contextName, suffix) || live[contextName] {
+			continue
+		}
+		if _, err := os.Stat(common.GetDefaultSSHKeyPath(contextName, p.GetProviderName())); err != nil {
+			continue
+		}
+		state, err := common.DefaultDB.GetClusterByID(contextName)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			contextNames = append(contextNames, contextName)
+		}
+	}
+	return contextNames, nil
+}
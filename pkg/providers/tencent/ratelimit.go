@@ -0,0 +1,83 @@
+package tencent
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// tencent limits most of the apis to 20 requests per second for each account.
+	defaultAPIQPS   = 10
+	defaultAPIBurst = 20
+	// the page size of describing instances, tencent allows up to 100.
+	defaultAPIPageSize = 20
+	maxAPIPageSize     = 100
+)
+
+var (
+	apiRateLimiterMutex sync.Mutex
+	apiRateLimiter      flowcontrol.RateLimiter
+	apiRateLimiterQPS   float32
+	apiRateLimiterBurst int
+)
+
+// rateLimitedTransport waits for the token of the shared rate limiter before each request to tencent api.
+type rateLimitedTransport struct {
+	limiter   flowcontrol.RateLimiter
+	transport http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// newRateLimitedTransport returns the transport which shares one token bucket with all the clients of the process,
+// so the operations of concurrent clusters are limited together.
+func (p *Tencent) newRateLimitedTransport() http.RoundTripper {
+	qps, burst := p.getAPIRateLimit()
+	return &rateLimitedTransport{
+		limiter:   getAPIRateLimiter(qps, burst),
+		transport: http.DefaultTransport,
+	}
+}
+
+func (p *Tencent) getAPIRateLimit() (float32, int) {
+	qps, err := strconv.ParseFloat(p.APIQPS, 32)
+	if err != nil || qps <= 0 {
+		qps = defaultAPIQPS
+	}
+	burst, err := strconv.Atoi(p.APIBurst)
+	if err != nil || burst <= 0 {
+		burst = defaultAPIBurst
+	}
+	return float32(qps), burst
+}
+
+// getAPIRateLimiter returns the shared rate limiter, it's replaced when the limit is changed.
+func getAPIRateLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	apiRateLimiterMutex.Lock()
+	defer apiRateLimiterMutex.Unlock()
+	if apiRateLimiter == nil || apiRateLimiterQPS != qps || apiRateLimiterBurst != burst {
+		apiRateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+		apiRateLimiterQPS, apiRateLimiterBurst = qps, burst
+	}
+	return apiRateLimiter
+}
+
+// getAPIPageSize returns the page size of describing instances, a larger one saves the round trips for big clusters.
+func (p *Tencent) getAPIPageSize() int64 {
+	size, err := strconv.ParseInt(p.APIPageSize, 10, 64)
+	if err != nil || size <= 0 {
+		return defaultAPIPageSize
+	}
+	if size > maxAPIPageSize {
+		return maxAPIPageSize
+	}
+	return size
+}
@@ -0,0 +1,77 @@
+package tencent
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	sdkErrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// apiRetryBackoff retries the throttled or failed api calls 5 times, total about 30 seconds.
+var apiRetryBackoff = wait.Backoff{
+	Duration: 2 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+}
+
+// transientAPIErrorCodes are the prefixes of the error codes which are worth retrying, i.e. the request is throttled
+// or the api fails internally. The other errors, e.g. auth failures and invalid parameters, fail immediately.
+var transientAPIErrorCodes = []string{
+	"RequestLimitExceeded",
+	"InternalError",
+	"ClientError.NetworkError",
+}
+
+// isTransientAPIError returns true if the error of tencent api is a transient failure.
+func isTransientAPIError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var te *sdkErrors.TencentCloudSDKError
+	if errors.As(err, &te) {
+		for _, code := range transientAPIErrorCodes {
+			if strings.HasPrefix(te.Code, code) {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryTransient calls fn until it succeeds or fails with a non-transient error, the last error is returned if
+// the retries are exhausted.
+func (p *Tencent) retryTransient(action string, fn func() error) error {
+	var lastErr error
+	attempt := 0
+	err := wait.ExponentialBackoff(apiRetryBackoff, func() (bool, error) {
+		attempt++
+		lastErr = fn()
+		if lastErr == nil {
+			return true, nil
+		}
+		if !isTransientAPIError(lastErr) {
+			return false, lastErr
+		}
+		if attempt < apiRetryBackoff.Steps {
+			logger := p.Logger
+			if logger == nil {
+				logger = common.NewLogger(nil)
+			}
+			logger.Warnf("[%s] attempt %d/%d of %s failed with transient error, retrying: %v",
+				p.GetProviderName(), attempt, apiRetryBackoff.Steps, action, lastErr)
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package tencent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// getSecurityGroupTags returns the tags of the security group created by autok3s,
+// the `cluster` tag records the cluster which creates it and the custom `--tags` are applied as well.
+func (p *Tencent) getSecurityGroupTags() ([]*vpc.Tag, error) {
+	tags := []*vpc.Tag{
+		{Key: tencentCommon.StringPtr("autok3s"), Value: tencentCommon.StringPtr("true")},
+		{Key: tencentCommon.StringPtr("cluster"), Value: tencentCommon.StringPtr(common.TagClusterPrefix + p.ContextName)},
+	}
+	for _, v := range p.Tags {
+		ss := strings.Split(v, "=")
+		if len(ss) != 2 {
+			return nil, fmt.Errorf("tags %s invalid", v)
+		}
+		tags = append(tags, &vpc.Tag{Key: tencentCommon.StringPtr(ss[0]), Value: tencentCommon.StringPtr(ss[1])})
+	}
+	return tags, nil
+}
+
+// deleteUnusedSecurityGroups deletes the security groups of the cluster which are created by autok3s,
+// the ones still referenced by the instances of other clusters are kept, as the default security group is shared.
+func (p *Tencent) deleteUnusedSecurityGroups(ids []string) error {
+	if p.SecurityGroupIds == "" {
+		return nil
+	}
+	request := vpc.NewDescribeSecurityGroupsRequest()
+	request.SecurityGroupIds = tencentCommon.StringPtrs(strings.Split(p.SecurityGroupIds, ","))
+	request.Filters = []*vpc.Filter{
+		{
+			Name:   tencentCommon.StringPtr("tag:autok3s"),
+			Values: tencentCommon.StringPtrs([]string{"true"}),
+		},
+	}
+	response, err := p.v.DescribeSecurityGroups(request)
+	if err != nil {
+		return fmt.Errorf("[%s] calling describeSecurityGroups error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response == nil {
+		return nil
+	}
+	for _, group := range response.Response.SecurityGroupSet {
+		groupID := *group.SecurityGroupId
+		shared, err := p.isSecurityGroupShared(groupID, ids)
+		if err != nil {
+			return err
+		}
+		if shared {
+			p.Logger.Infof("[%s] security group %s is used by other instances, skip deleting it", p.GetProviderName(), groupID)
+			continue
+		}
+		if err = p.deleteSecurityGroup(groupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSecurityGroupShared returns true if the security group is referenced by any instance out of the ids.
+func (p *Tencent) isSecurityGroupShared(groupID string, ids []string) (bool, error) {
+	owned := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		owned[id] = true
+	}
+	request := cvm.NewDescribeInstancesRequest()
+	request.Filters = []*cvm.Filter{
+		{
+			Name:   tencentCommon.StringPtr("security-group-id"),
+			Values: tencentCommon.StringPtrs([]string{groupID}),
+		},
+	}
+	// the security group is shared if there are more instances than the cluster ones, so one page is enough.
+	request.Limit = tencentCommon.Int64Ptr(100)
+	response, err := p.c.DescribeInstances(request)
+	if err != nil {
+		return false, fmt.Errorf("[%s] calling describeInstances error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response == nil {
+		return false, nil
+	}
+	if response.Response.TotalCount != nil && *response.Response.TotalCount > int64(len(ids)) {
+		return true, nil
+	}
+	for _, instance := range response.Response.InstanceSet {
+		if !owned[*instance.InstanceId] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteSecurityGroup retries until the terminated instances release the security group.
+func (p *Tencent) deleteSecurityGroup(groupID string) error {
+	p.Logger.Infof("[%s] security group %s will be deleted", p.GetProviderName(), groupID)
+	request := vpc.NewDeleteSecurityGroupRequest()
+	request.SecurityGroupId = tencentCommon.StringPtr(groupID)
+	var lastErr error
+	if err := wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		if _, lastErr = p.v.DeleteSecurityGroup(request); lastErr != nil {
+			p.Logger.Debugf("[%s] waiting for security group %s to be released: %v", p.GetProviderName(), groupID, lastErr)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("[%s] calling deleteSecurityGroup error, security group: %s, msg: %v", p.GetProviderName(), groupID, lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package tencent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+)
+
+// the vendored tencentcloud sdk doesn't ship the ssm service yet,
+// so only the API used by autok3s is declared here.
+const (
+	ssmAPIVersion = "2019-09-23"
+	// ssmKeySourceScheme references the ssh private key stored in tencent ssm by `tencent-ssm:<secret-name>:<version-id>`.
+	ssmKeySourceScheme = "tencent-ssm"
+)
+
+type ssmClient struct {
+	tencentCommon.Client
+}
+
+func newSSMClient(credential *tencentCommon.Credential, region string, clientProfile *profile.ClientProfile) *ssmClient {
+	client := &ssmClient{}
+	client.Init(region).
+		WithCredential(credential).
+		WithProfile(clientProfile)
+	return client
+}
+
+type getSecretValueRequest struct {
+	*tchttp.BaseRequest
+	SecretName *string `json:"SecretName,omitempty" name:"SecretName"`
+	VersionID  *string `json:"VersionId,omitempty" name:"VersionId"`
+}
+
+type getSecretValueResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		SecretName   *string `json:"SecretName,omitempty" name:"SecretName"`
+		VersionID    *string `json:"VersionId,omitempty" name:"VersionId"`
+		SecretBinary *string `json:"SecretBinary,omitempty" name:"SecretBinary"`
+		SecretString *string `json:"SecretString,omitempty" name:"SecretString"`
+		RequestID    *string `json:"RequestId,omitempty" name:"RequestId"`
+	} `json:"Response"`
+}
+
+func (c *ssmClient) GetSecretValue(request *getSecretValueRequest) (*getSecretValueResponse, error) {
+	request.BaseRequest = &tchttp.BaseRequest{}
+	request.Init().WithApiInfo("ssm", ssmAPIVersion, "GetSecretValue")
+	response := &getSecretValueResponse{BaseResponse: &tchttp.BaseResponse{}}
+	err := c.Send(request, response)
+	return response, err
+}
+
+func init() {
+	// the key of ssm is loaded by the provider of cluster, so that the credential and region of the cluster are used.
+	utils.RegisterProviderSSHKeySource(ssmKeySourceScheme)
+}
+
+// LoadSSHKeySource loads the key of `--ssh-key-path tencent-ssm:<secret-name>:<version-id>` with the credential and
+// region of the provider, the key is kept in memory for the ssh connections to the nodes of the cluster.
+func (p *Tencent) LoadSSHKeySource() error {
+	if err := p.applyProfileCredential(); err != nil {
+		return err
+	}
+	scheme, ref, _ := strings.Cut(p.SSHKeyPath, ":")
+	if scheme != ssmKeySourceScheme || p.loadedSSHKeySource == p.SSHKeyPath {
+		return nil
+	}
+	source, err := p.newSSMKeySource(ref)
+	if err != nil {
+		return err
+	}
+	key, err := source.Load()
+	if err != nil {
+		return err
+	}
+	utils.SetSSHKey(p.SSHKeyPath, key)
+	p.loadedSSHKeySource = p.SSHKeyPath
+	return nil
+}
+
+// ssmKeySource loads the ssh private key from tencent ssm with the credential and region of the provider.
+type ssmKeySource struct {
+	p          *Tencent
+	secretName string
+	versionID  string
+}
+
+func (p *Tencent) newSSMKeySource(ref string) (utils.SSHKeySource, error) {
+	secretName, versionID, _ := strings.Cut(ref, ":")
+	if secretName == "" || versionID == "" {
+		return nil, fmt.Errorf("invalid ssh key source %s:%s, must be %s:<secret-name>:<version-id>", ssmKeySourceScheme, ref, ssmKeySourceScheme)
+	}
+	return &ssmKeySource{p: p, secretName: secretName, versionID: versionID}, nil
+}
+
+func (s *ssmKeySource) Load() ([]byte, error) {
+	p := s.p
+	if p.SecretID == "" || p.SecretKey == "" {
+		return nil, fmt.Errorf("[%s] credential is required to get secret %s from ssm", p.GetProviderName(), s.secretName)
+	}
+	client := newSSMClient(tencentCommon.NewCredential(p.SecretID, p.SecretKey), p.Region, p.newClientProfile(""))
+	client.WithHttpTransport(p.newRateLimitedTransport())
+	request := &getSecretValueRequest{
+		SecretName: tencentCommon.StringPtr(s.secretName),
+		VersionID:  tencentCommon.StringPtr(s.versionID),
+	}
+	response, err := client.GetSecretValue(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling getSecretValue error, secret: %s, msg: %v", p.GetProviderName(), s.secretName, err)
+	}
+	return getSecretValue(s.secretName, response)
+}
+
+// getSecretValue returns the text secret, or the decoded binary secret.
+func getSecretValue(secretName string, response *getSecretValueResponse) ([]byte, error) {
+	if response.Response == nil {
+		return nil, fmt.Errorf("secret %s is not returned", secretName)
+	}
+	if value := response.Response.SecretString; value != nil && *value != "" {
+		return []byte(*value), nil
+	}
+	if value := response.Response.SecretBinary; value != nil && *value != "" {
+		return base64.StdEncoding.DecodeString(*value)
+	}
+	return nil, fmt.Errorf("secret %s is empty", secretName)
+}
@@ -0,0 +1,138 @@
+package tencent
+
+import (
+	"fmt"
+	"sort"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+const (
+	subnetStrategySingle     = "single"
+	subnetStrategyRoundRobin = "round-robin"
+	subnetStrategyLeastUsed  = "least-used"
+)
+
+// subnetPlacement is the instances launched into the subnet by one request.
+type subnetPlacement struct {
+	SubnetID string
+	Count    int
+}
+
+// subnetCapacity is the ip usage of the subnet which the instances can be spread to.
+type subnetCapacity struct {
+	SubnetID  string
+	Available int
+	Total     int
+}
+
+// spreadSubnets returns true if the instances are spread over the subnets of the vpc in the zone.
+func (p *Tencent) spreadSubnets() bool {
+	return p.SubnetStrategy == subnetStrategyRoundRobin || p.SubnetStrategy == subnetStrategyLeastUsed
+}
+
+// getSubnetPlacements splits the instances over the subnets by --subnet-strategy. All instances are launched into
+// --subnet by the single strategy, as well as the masters with --master-private-ips which belong to --subnet.
+func (p *Tencent) getSubnetPlacements(num int, master bool) ([]subnetPlacement, error) {
+	if !p.spreadSubnets() || (master && len(p.MasterPrivateIPs) > 0) {
+		return []subnetPlacement{{SubnetID: p.SubnetID, Count: num}}, nil
+	}
+	subnets, err := p.describeZoneSubnets()
+	if err != nil {
+		return nil, err
+	}
+	available := 0
+	for _, s := range subnets {
+		available += s.Available
+	}
+	if available < num {
+		return nil, fmt.Errorf("[%s] subnets of vpc %s in zone %s only have %d available ips for %d instances",
+			p.GetProviderName(), p.VpcID, p.Zone, available, num)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < num; i++ {
+		var s *subnetCapacity
+		if p.SubnetStrategy == subnetStrategyRoundRobin {
+			s = p.nextRoundRobinSubnet(subnets)
+		} else {
+			s = leastUsedSubnet(subnets)
+		}
+		s.Available--
+		counts[s.SubnetID]++
+	}
+	placements := make([]subnetPlacement, 0, len(counts))
+	for _, s := range subnets {
+		if counts[s.SubnetID] > 0 {
+			placements = append(placements, subnetPlacement{SubnetID: s.SubnetID, Count: counts[s.SubnetID]})
+		}
+	}
+	return placements, nil
+}
+
+// nextRoundRobinSubnet returns the next subnet which has available ips, the cursor is kept across the requests of
+// the operation, so that masters and workers continue the rotation.
+func (p *Tencent) nextRoundRobinSubnet(subnets []*subnetCapacity) *subnetCapacity {
+	for range subnets {
+		s := subnets[p.subnetCursor%len(subnets)]
+		p.subnetCursor++
+		if s.Available > 0 {
+			return s
+		}
+	}
+	return nil
+}
+
+// leastUsedSubnet returns the subnet of the lowest ip utilization, the one with more available ips wins a tie.
+func leastUsedSubnet(subnets []*subnetCapacity) *subnetCapacity {
+	var least *subnetCapacity
+	for _, s := range subnets {
+		if s.Available <= 0 {
+			continue
+		}
+		// compare used/total without float: used1*total2 < used2*total1.
+		if least == nil {
+			least = s
+			continue
+		}
+		used, leastUsed := (s.Total-s.Available)*least.Total, (least.Total-least.Available)*s.Total
+		if used < leastUsed || (used == leastUsed && s.Available > least.Available) {
+			least = s
+		}
+	}
+	return least
+}
+
+// describeZoneSubnets returns the ip usage of the subnets of the vpc in the zone, sorted by subnet id.
+func (p *Tencent) describeZoneSubnets() ([]*subnetCapacity, error) {
+	request := vpc.NewDescribeSubnetsRequest()
+	request.Filters = []*vpc.Filter{
+		{Name: tencentCommon.StringPtr("vpc-id"), Values: tencentCommon.StringPtrs([]string{p.VpcID})},
+		{Name: tencentCommon.StringPtr("zone"), Values: tencentCommon.StringPtrs([]string{p.Zone})},
+	}
+	response, err := p.v.DescribeSubnets(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeSubnets error, vpc: %s, zone: %s, msg: %v", p.GetProviderName(), p.VpcID, p.Zone, err)
+	}
+	subnets := make([]*subnetCapacity, 0)
+	if response.Response != nil {
+		for _, s := range response.Response.SubnetSet {
+			if s.SubnetId == nil || s.AvailableIpAddressCount == nil || s.TotalIpAddressCount == nil {
+				continue
+			}
+			subnets = append(subnets, &subnetCapacity{
+				SubnetID:  *s.SubnetId,
+				Available: int(*s.AvailableIpAddressCount),
+				Total:     int(*s.TotalIpAddressCount),
+			})
+		}
+	}
+	if len(subnets) == 0 {
+		return nil, fmt.Errorf("[%s] no subnet of vpc %s is found in zone %s", p.GetProviderName(), p.VpcID, p.Zone)
+	}
+	sort.Slice(subnets, func(i, j int) bool {
+		return subnets[i].SubnetID < subnets[j].SubnetID
+	})
+	return subnets, nil
+}
@@ -0,0 +1,265 @@
+package tencent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types/tencent"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tag "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tag/v20180813"
+)
+
+const (
+	// maxTagResourcesPerCall is the max resources of a call of AttachResourcesTag and DetachResourcesTag.
+	maxTagResourcesPerCall = 50
+	// maxTaggedResourcesPerPage is the page size of DescribeResourcesByTags.
+	maxTaggedResourcesPerPage = 200
+)
+
+// reservedTagKeys are the tags set by autok3s, which the resources of the cluster and the roles of the nodes are found
+// by, so they can't be changed or removed by the tag operations.
+var reservedTagKeys = []string{"autok3s", "cluster", "master", "worker", poolTagKey}
+
+// taggedResourcePrefixes are the resources of the cluster which the tag operations apply to, i.e. instances and eips.
+var taggedResourcePrefixes = map[string]bool{"instance": true, "eip": true}
+
+// taggedResourceGroup is the resources of the same service, region and type, which can be tagged in a call.
+type taggedResourceGroup struct {
+	serviceType string
+	region      string
+	prefix      string
+}
+
+// TagCluster adds the tags in `key=value` format to the instances and eips of the cluster, the value is replaced if
+// the key exists. The tags are saved to the cluster state, so that the instances joined later are tagged as well.
+func (p *Tencent) TagCluster(tags []string) error {
+	parsed, err := parseTags(tags)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(parsed))
+	for _, t := range parsed {
+		keys = append(keys, *t.TagKey)
+	}
+	if err = p.checkReservedTagKeys(keys); err != nil {
+		return err
+	}
+	return p.updateClusterTags(func(groups map[taggedResourceGroup][]string) error {
+		for _, t := range parsed {
+			for group, ids := range groups {
+				for _, chunk := range chunkIDs(ids, maxTagResourcesPerCall) {
+					request := tag.NewAttachResourcesTagRequest()
+					request.ServiceType = tencentCommon.StringPtr(group.serviceType)
+					request.ResourceRegion = tencentCommon.StringPtr(group.region)
+					request.ResourcePrefix = tencentCommon.StringPtr(group.prefix)
+					request.ResourceIds = tencentCommon.StringPtrs(chunk)
+					request.TagKey = t.TagKey
+					request.TagValue = t.TagValue
+					if _, err := p.t.AttachResourcesTag(request); err != nil {
+						return fmt.Errorf("[%s] calling attachResourcesTag error, tag: %s, msg: %v", p.GetProviderName(), *t.TagKey, err)
+					}
+				}
+			}
+		}
+		return nil
+	}, func(options *tencent.Options) {
+		options.Tags = mergeTags(options.Tags, parsed)
+	})
+}
+
+// UntagCluster removes the tags of the keys from the instances and eips of the cluster and the cluster state,
+// the tags set by autok3s can't be removed.
+func (p *Tencent) UntagCluster(keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("[%s] must set the keys of the tags to remove", p.GetProviderName())
+	}
+	if err := p.checkReservedTagKeys(keys); err != nil {
+		return err
+	}
+	return p.updateClusterTags(func(groups map[taggedResourceGroup][]string) error {
+		for _, key := range keys {
+			for group, ids := range groups {
+				for _, chunk := range chunkIDs(ids, maxTagResourcesPerCall) {
+					request := tag.NewDetachResourcesTagRequest()
+					request.ServiceType = tencentCommon.StringPtr(group.serviceType)
+					request.ResourceRegion = tencentCommon.StringPtr(group.region)
+					request.ResourcePrefix = tencentCommon.StringPtr(group.prefix)
+					request.ResourceIds = tencentCommon.StringPtrs(chunk)
+					request.TagKey = tencentCommon.StringPtr(key)
+					if _, err := p.t.DetachResourcesTag(request); err != nil {
+						return fmt.Errorf("[%s] calling detachResourcesTag error, tag: %s, msg: %v", p.GetProviderName(), key, err)
+					}
+				}
+			}
+		}
+		return nil
+	}, func(options *tencent.Options) {
+		options.Tags = removeTags(options.Tags, keys)
+	})
+}
+
+// updateClusterTags applies the tag change to the instances and eips of the cluster, then saves the tags to state.
+func (p *Tencent) updateClusterTags(apply func(groups map[taggedResourceGroup][]string) error, update func(options *tencent.Options)) error {
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.GetProviderName())
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return cluster.NewClusterNotFoundError("[%s] cluster %s is not exist", p.GetProviderName(), p.Name)
+	}
+	if state.Status != common.StatusRunning {
+		return fmt.Errorf("[%s] cluster %s is %s, wait for the operation in progress to finish", p.GetProviderName(), p.Name, state.Status)
+	}
+	if p.t == nil {
+		if err = p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	groups, err := p.describeTaggedResourceGroups()
+	if err != nil {
+		return err
+	}
+	if err = apply(groups); err != nil {
+		return err
+	}
+
+	options := &tencent.Options{}
+	if err = json.Unmarshal(state.Options, options); err != nil {
+		return err
+	}
+	update(options)
+	if state.Options, err = json.Marshal(options); err != nil {
+		return err
+	}
+	if err = common.DefaultDB.SaveClusterState(state); err != nil {
+		return err
+	}
+	p.Tags = options.Tags
+	count := 0
+	for _, ids := range groups {
+		count += len(ids)
+	}
+	p.Logger.Infof("[%s] successfully updated tags of %d resource(s) of cluster %s, tags: %s", p.GetProviderName(), count, p.Name, options.Tags)
+	return nil
+}
+
+// describeTaggedResourceGroups returns the instances and eips tagged with the cluster by service, region and type.
+func (p *Tencent) describeTaggedResourceGroups() (map[taggedResourceGroup][]string, error) {
+	request := tag.NewDescribeResourcesByTagsRequest()
+	request.TagFilters = []*tag.TagFilter{
+		{TagKey: tencentCommon.StringPtr("autok3s"), TagValue: tencentCommon.StringPtrs([]string{"true"})},
+		{TagKey: tencentCommon.StringPtr("cluster"), TagValue: tencentCommon.StringPtrs([]string{common.TagClusterPrefix + p.ContextName})},
+	}
+	request.Limit = tencentCommon.Uint64Ptr(maxTaggedResourcesPerPage)
+	groups := map[taggedResourceGroup][]string{}
+	for offset := uint64(0); ; offset += maxTaggedResourcesPerPage {
+		request.Offset = tencentCommon.Uint64Ptr(offset)
+		response, err := p.t.DescribeResourcesByTags(request)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] calling describeResourcesByTags error, msg: %v", p.GetProviderName(), err)
+		}
+		if response.Response == nil {
+			break
+		}
+		for _, row := range response.Response.Rows {
+			if row.ServiceType == nil || row.ResourcePrefix == nil || row.ResourceId == nil || !taggedResourcePrefixes[*row.ResourcePrefix] {
+				continue
+			}
+			group := taggedResourceGroup{serviceType: *row.ServiceType, region: p.Region, prefix: *row.ResourcePrefix}
+			if row.ResourceRegion != nil && *row.ResourceRegion != "" {
+				group.region = *row.ResourceRegion
+			}
+			groups[group] = append(groups[group], *row.ResourceId)
+		}
+		if len(response.Response.Rows) < maxTaggedResourcesPerPage ||
+			(response.Response.TotalCount != nil && offset+maxTaggedResourcesPerPage >= *response.Response.TotalCount) {
+			break
+		}
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("[%s] no instance or eip of cluster %s is found by tags", p.GetProviderName(), p.Name)
+	}
+	return groups, nil
+}
+
+func (p *Tencent) checkReservedTagKeys(keys []string) error {
+	for _, key := range keys {
+		for _, reserved := range reservedTagKeys {
+			if key == reserved {
+				return fmt.Errorf("[%s] tag %s is set by autok3s to manage the cluster, it can't be changed or removed", p.GetProviderName(), key)
+			}
+		}
+	}
+	return nil
+}
+
+// parseTags parses the tags in `key=value` format, the same format as `--tags`.
+func parseTags(tags []string) ([]*tag.Tag, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("must set the tags in key=value format")
+	}
+	parsed := make([]*tag.Tag, 0, len(tags))
+	for _, v := range tags {
+		ss := strings.Split(v, "=")
+		if len(ss) != 2 || ss[0] == "" {
+			return nil, fmt.Errorf("tags %s invalid", v)
+		}
+		parsed = append(parsed, &tag.Tag{TagKey: tencentCommon.StringPtr(ss[0]), TagValue: tencentCommon.StringPtr(ss[1])})
+	}
+	return parsed, nil
+}
+
+// mergeTags returns the saved tags with the added ones, the value of the existing key is replaced in place.
+func mergeTags(saved []string, added []*tag.Tag) []string {
+	merged := make([]string, 0, len(saved)+len(added))
+	index := map[string]int{}
+	for _, v := range saved {
+		index[strings.Split(v, "=")[0]] = len(merged)
+		merged = append(merged, v)
+	}
+	for _, t := range added {
+		v := *t.TagKey + "=" + *t.TagValue
+		if i, ok := index[*t.TagKey]; ok {
+			merged[i] = v
+			continue
+		}
+		index[*t.TagKey] = len(merged)
+		merged = append(merged, v)
+	}
+	return merged
+}
+
+// removeTags returns the saved tags without the keys.
+func removeTags(saved []string, keys []string) []string {
+	removed := map[string]bool{}
+	for _, key := range keys {
+		removed[key] = true
+	}
+	tags := make([]string, 0, len(saved))
+	for _, v := range saved {
+		if !removed[strings.Split(v, "=")[0]] {
+			tags = append(tags, v)
+		}
+	}
+	return tags
+}
+
+// chunkIDs splits the ids into chunks of at most size ids.
+func chunkIDs(ids []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(ids)+size-1)/size)
+	for start := 0; start < len(ids); start += size {
+		end := start + size
+		if end > len(ids) {
+			end = len(ids)
+		}
+		chunks = append(chunks, ids[start:end])
+	}
+	return chunks
+}
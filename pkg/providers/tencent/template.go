@@ -122,7 +122,7 @@ spec:
           value: "true"
           effect: "NoSchedule"
       containers:
-        - image: ccr.ccs.tencentyun.com/library/tencentcloud-cloud-controller-manager:1.0.1
+        - image: ccr.ccs.tencentyun.com/library/tencentcloud-cloud-controller-manager:%s
           name: tencentcloud-cloud-controller-manager
           command:
             - /bin/tencentcloud-cloud-controller-manager
@@ -161,3 +161,406 @@ spec:
                   key: TENCENTCLOUD_CLOUD_CONTROLLER_MANAGER_VPC_ID
 ---
 `
+
+var tencentCSITmpl = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: cbs-csi-api-key
+  namespace: kube-system
+data:
+  TENCENTCLOUD_CBS_API_SECRET_ID: "%[1]s"
+  TENCENTCLOUD_CBS_API_SECRET_KEY: "%[2]s"
+  TENCENTCLOUD_API_REGION: "%[3]s"
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cbs-csi-controller-sa
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cbs-csi-node-sa
+  namespace: kube-system
+---
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: cbs-csi-controller-role
+rules:
+  - apiGroups: [""]
+    resources: ["persistentvolumes"]
+    verbs: ["get", "list", "watch", "create", "update", "patch", "delete"]
+  - apiGroups: [""]
+    resources: ["persistentvolumeclaims"]
+    verbs: ["get", "list", "watch", "update"]
+  - apiGroups: [""]
+    resources: ["persistentvolumeclaims/status"]
+    verbs: ["update", "patch"]
+  - apiGroups: [""]
+    resources: ["nodes", "pods"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: [""]
+    resources: ["events"]
+    verbs: ["get", "list", "watch", "create", "update", "patch"]
+  - apiGroups: ["storage.k8s.io"]
+    resources: ["storageclasses", "csinodes"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: ["storage.k8s.io"]
+    resources: ["volumeattachments", "volumeattachments/status"]
+    verbs: ["get", "list", "watch", "update", "patch"]
+  - apiGroups: ["coordination.k8s.io"]
+    resources: ["leases"]
+    verbs: ["get", "list", "watch", "create", "update", "patch", "delete"]
+---
+kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: cbs-csi-controller-binding
+subjects:
+  - kind: ServiceAccount
+    name: cbs-csi-controller-sa
+    namespace: kube-system
+roleRef:
+  kind: ClusterRole
+  name: cbs-csi-controller-role
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: storage.k8s.io/v1
+kind: CSIDriver
+metadata:
+  name: com.tencent.cloud.csi.cbs
+spec:
+  attachRequired: true
+  podInfoOnMount: false
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: cbs-csi-controller
+  namespace: kube-system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: cbs-csi-controller
+  template:
+    metadata:
+      labels:
+        app: cbs-csi-controller
+    spec:
+      serviceAccountName: cbs-csi-controller-sa
+      priorityClassName: system-cluster-critical
+      hostNetwork: true
+      tolerations:
+        - key: "node-role.kubernetes.io/master"
+          operator: "Exists"
+          effect: "NoSchedule"
+        - key: "node-role.kubernetes.io/control-plane"
+          operator: "Exists"
+          effect: "NoSchedule"
+      containers:
+        - name: csi-provisioner
+          image: ccr.ccs.tencentyun.com/tkeimages/csi-provisioner:v1.6.0
+          args:
+            - "--csi-address=$(ADDRESS)"
+            - "--feature-gates=Topology=true"
+            - "--enable-leader-election=true"
+            - "--leader-election-type=leases"
+          env:
+            - name: ADDRESS
+              value: /csi/csi.sock
+          volumeMounts:
+            - name: socket-dir
+              mountPath: /csi
+        - name: csi-attacher
+          image: ccr.ccs.tencentyun.com/tkeimages/csi-attacher:v2.2.0
+          args:
+            - "--csi-address=$(ADDRESS)"
+            - "--leader-election=true"
+          env:
+            - name: ADDRESS
+              value: /csi/csi.sock
+          volumeMounts:
+            - name: socket-dir
+              mountPath: /csi
+        - name: csi-resizer
+          image: ccr.ccs.tencentyun.com/tkeimages/csi-resizer:v0.5.0
+          args:
+            - "--csi-address=$(ADDRESS)"
+            - "--leader-election=true"
+          env:
+            - name: ADDRESS
+              value: /csi/csi.sock
+          volumeMounts:
+            - name: socket-dir
+              mountPath: /csi
+        - name: cbs-csi
+          image: ccr.ccs.tencentyun.com/tkeimages/csi-tencentcloud-cbs:%[4]s
+          args:
+            - "--v=5"
+            - "--logtostderr=true"
+            - "--endpoint=$(ADDRESS)"
+            - "--component_type=controller"
+          env:
+            - name: ADDRESS
+              value: unix:///csi/csi.sock
+            - name: TENCENTCLOUD_CBS_API_SECRET_ID
+              valueFrom:
+                secretKeyRef:
+                  name: cbs-csi-api-key
+                  key: TENCENTCLOUD_CBS_API_SECRET_ID
+            - name: TENCENTCLOUD_CBS_API_SECRET_KEY
+              valueFrom:
+                secretKeyRef:
+                  name: cbs-csi-api-key
+                  key: TENCENTCLOUD_CBS_API_SECRET_KEY
+            - name: TENCENTCLOUD_API_REGION
+              valueFrom:
+                secretKeyRef:
+                  name: cbs-csi-api-key
+                  key: TENCENTCLOUD_API_REGION
+          volumeMounts:
+            - name: socket-dir
+              mountPath: /csi
+      volumes:
+        - name: socket-dir
+          emptyDir: {}
+---
+kind: DaemonSet
+apiVersion: apps/v1
+metadata:
+  name: cbs-csi-node
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      app: cbs-csi-node
+  template:
+    metadata:
+      labels:
+        app: cbs-csi-node
+    spec:
+      serviceAccountName: cbs-csi-node-sa
+      priorityClassName: system-node-critical
+      hostNetwork: true
+      tolerations:
+        - operator: "Exists"
+      containers:
+        - name: driver-registrar
+          image: ccr.ccs.tencentyun.com/tkeimages/csi-node-driver-registrar:v1.2.0
+          args:
+            - "--csi-address=/csi/csi.sock"
+            - "--kubelet-registration-path=/var/lib/kubelet/plugins/com.tencent.cloud.csi.cbs/csi.sock"
+          volumeMounts:
+            - name: plugin-dir
+              mountPath: /csi
+            - name: registration-dir
+              mountPath: /registration
+        - name: cbs-csi
+          image: ccr.ccs.tencentyun.com/tkeimages/csi-tencentcloud-cbs:%[4]s
+          securityContext:
+            privileged: true
+          args:
+            - "--v=5"
+            - "--logtostderr=true"
+            - "--endpoint=unix:///csi/csi.sock"
+            - "--component_type=node"
+          env:
+            - name: TENCENTCLOUD_CBS_API_SECRET_ID
+              valueFrom:
+                secretKeyRef:
+                  name: cbs-csi-api-key
+                  key: TENCENTCLOUD_CBS_API_SECRET_ID
+            - name: TENCENTCLOUD_CBS_API_SECRET_KEY
+              valueFrom:
+                secretKeyRef:
+                  name: cbs-csi-api-key
+                  key: TENCENTCLOUD_CBS_API_SECRET_KEY
+            - name: TENCENTCLOUD_API_REGION
+              valueFrom:
+                secretKeyRef:
+                  name: cbs-csi-api-key
+                  key: TENCENTCLOUD_API_REGION
+          volumeMounts:
+            - name: plugin-dir
+              mountPath: /csi
+            - name: pods-mount-dir
+              mountPath: /var/lib/kubelet
+              mountPropagation: "Bidirectional"
+            - name: host-dev
+              mountPath: /dev
+      volumes:
+        - name: plugin-dir
+          hostPath:
+            path: /var/lib/kubelet/plugins/com.tencent.cloud.csi.cbs
+            type: DirectoryOrCreate
+        - name: registration-dir
+          hostPath:
+            path: /var/lib/kubelet/plugins_registry
+            type: Directory
+        - name: pods-mount-dir
+          hostPath:
+            path: /var/lib/kubelet
+            type: Directory
+        - name: host-dev
+          hostPath:
+            path: /dev
+---
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: cbs
+  annotations:
+    storageclass.kubernetes.io/is-default-class: "true"
+provisioner: com.tencent.cloud.csi.cbs
+parameters:
+  diskType: %[5]s
+reclaimPolicy: Delete
+allowVolumeExpansion: true
+volumeBindingMode: WaitForFirstConsumer
+---
+`
+
+// keepalivedTmpl holds the havip on the master with a healthy api-server, the config is generated on each master
+// as the unicast source and peers are different.
+var keepalivedTmpl = `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: autok3s-keepalived
+  namespace: kube-system
+  labels:
+    app: autok3s-keepalived
+spec:
+  selector:
+    matchLabels:
+      app: autok3s-keepalived
+  template:
+    metadata:
+      labels:
+        app: autok3s-keepalived
+    spec:
+      hostNetwork: true
+      nodeSelector:
+        node-role.kubernetes.io/master: "true"
+      tolerations:
+        - operator: Exists
+      priorityClassName: system-node-critical
+      containers:
+        - name: keepalived
+          image: %[1]s
+          command:
+            - /bin/sh
+            - -c
+            - |
+              set -e
+              IFACE=$(ip -o -4 addr show | awk -v ip="$NODE_IP" '$4 ~ "^"ip"/" {print $2; exit}')
+              mkdir -p /etc/keepalived
+              cat > /etc/keepalived/keepalived.conf <<EOF
+              global_defs {
+                enable_script_security
+                script_user root
+              }
+              vrrp_script chk_apiserver {
+                script "/usr/bin/nc -z -w 2 127.0.0.1 6443"
+                interval 3
+                fall 2
+                rise 2
+              }
+              vrrp_instance autok3s {
+                state BACKUP
+                interface $IFACE
+                virtual_router_id 51
+                priority 100
+                nopreempt
+                advert_int 1
+                unicast_src_ip $NODE_IP
+                unicast_peer {
+              $(for peer in $MASTER_IPS; do [ "$peer" = "$NODE_IP" ] || echo "    $peer"; done)
+                }
+                virtual_ipaddress {
+                  $VIP
+                }
+                track_script {
+                  chk_apiserver
+                }
+              }
+              EOF
+              exec keepalived --dont-fork --log-console --use-file /etc/keepalived/keepalived.conf
+          env:
+            - name: NODE_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.hostIP
+            - name: MASTER_IPS
+              value: "%[2]s"
+            - name: VIP
+              value: "%[3]s"
+          securityContext:
+            capabilities:
+              add:
+                - NET_ADMIN
+                - NET_BROADCAST
+                - NET_RAW
+---
+`
+
+var nvidiaDevicePluginTmpl = `
+apiVersion: node.k8s.io/v1
+kind: RuntimeClass
+metadata:
+  name: nvidia
+handler: nvidia
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: nvidia-device-plugin-daemonset
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      name: nvidia-device-plugin-ds
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        name: nvidia-device-plugin-ds
+    spec:
+      runtimeClassName: nvidia
+      priorityClassName: system-node-critical
+      nodeSelector:
+        nvidia.com/gpu.present: "true"
+      tolerations:
+        - key: nvidia.com/gpu
+          operator: Exists
+          effect: NoSchedule
+        - key: node-role.kubernetes.io/master
+          operator: Exists
+          effect: NoSchedule
+        - key: node-role.kubernetes.io/control-plane
+          operator: Exists
+          effect: NoSchedule
+      containers:
+        - name: nvidia-device-plugin-ctr
+          image: %s
+          env:
+            - name: FAIL_ON_INIT_ERROR
+              value: "false"
+          securityContext:
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop: ["ALL"]
+          volumeMounts:
+            - name: device-plugin
+              mountPath: /var/lib/kubelet/device-plugins
+      volumes:
+        - name: device-plugin
+          hostPath:
+            path: /var/lib/kubelet/device-plugins
+---
+`
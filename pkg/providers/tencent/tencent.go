@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,13 +24,19 @@ import (
 	"github.com/cnrancher/autok3s/pkg/types/tencent"
 	"github.com/cnrancher/autok3s/pkg/utils"
 
+	"github.com/rancher/wrangler/v2/pkg/schemas"
+	"github.com/sirupsen/logrus"
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
 	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	kms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/kms/v20190118"
 	tag "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tag/v20180813"
 	tke "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tke/v20180525"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -36,14 +45,21 @@ const (
 	secretID                 = "secret-id"
 	secretKey                = "secret-key"
 	spotInstanceChargeType   = "SPOTPAID"
+	onDemandChargeType       = "POSTPAID_BY_HOUR"
+	nodeOrderWorkersFirst    = "workers-first"
 	internetChargeType       = "TRAFFIC_POSTPAID_BY_HOUR"
 	defaultSecurityGroupName = "autok3s"
-	vpcName                  = "autok3s-tencent-vpc"
-	subnetName               = "autok3s-tencent-subnet"
+	defaultVpcName           = "autok3s-tencent-vpc"
+	defaultSubnetName        = "autok3s-tencent-subnet"
 	vpcCidrBlock             = "192.168.0.0/16"
 	subnetCidrBlock          = "192.168.3.0/24"
 	ipRange                  = "0.0.0.0/0"
 	defaultUser              = "ubuntu"
+	privateDNSRecordTTL      = 300
+	maxInstanceNameLength    = 60
+	maxResourceNameLength    = 60
+	// tencent limits the number of policies in each direction of a security group.
+	maxSecurityGroupPolicies = 100
 )
 
 // providerName is the name of this provider.
@@ -52,6 +68,31 @@ const providerName = "tencent"
 var (
 	k3sMirror        = "INSTALL_K3S_MIRROR=cn"
 	deployCCMCommand = "echo \"%s\" | base64 -d | tee \"%s/cloud-controller-manager.yaml\""
+	deployCSICommand = "echo \"%s\" | base64 -d | tee \"%s/cbs-csi.yaml\""
+	// retry 10 times with backoff, total about 300 seconds.
+	sshReadyBackoff = wait.Backoff{
+		Duration: 5 * time.Second,
+		Factor:   1.5,
+		Steps:    10,
+		Cap:      60 * time.Second,
+	}
+	instanceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	// regionRegexp matches regions like ap-guangzhou and ap-shanghai-fsi.
+	regionRegexp = regexp.MustCompile(`^[a-z]+-[a-z]+(-[a-z]+)?$`)
+	// instanceTypeRegexp matches instance types like SA2.MEDIUM4 and IT5c.8XLARGE128.
+	instanceTypeRegexp = regexp.MustCompile(`^[A-Za-z0-9]+\.[A-Za-z0-9]+$`)
+	// default login user of tencent public images by platform, only ubuntu doesn't use root.
+	platformDefaultUsers = map[string]string{
+		"ubuntu":      "ubuntu",
+		"centos":      "root",
+		"debian":      "root",
+		"tencentos":   "root",
+		"opencloudos": "root",
+		"rocky linux": "root",
+		"almalinux":   "root",
+		"fedora":      "root",
+		"opensuse":    "root",
+	}
 )
 
 // Tencent provider tencent struct.
@@ -59,11 +100,31 @@ type Tencent struct {
 	*cluster.ProviderBase `json:",inline"`
 	tencent.Options       `json:",inline"`
 
-	c *cvm.Client
-	v *vpc.Client
-	t *tag.Client
-	r *tke.Client
+	c cvmClient
+	v vpcClient
+	t tagClient
+	r tkeClient
+	b cbsClient
+	k kmsClient
+	l clbClient
+	d *privateDNSClient
+	e launchTemplateClient
 	m *sync.Map
+
+	// externalEIPs are the existing eips set by --eip-address which are not associated yet.
+	externalEIPs []*vpc.Address
+	// launchTemplate is the data of the launch template version set by --launch-template-id.
+	launchTemplate *launchTemplateVersionData
+	// subnetCursor is the next subnet of the round-robin --subnet-strategy.
+	subnetCursor int
+	// creating is true while the cluster is being created, the failed workers are joined after the masters are initialized.
+	creating bool
+	// failedWorkers is the number of workers which failed to be created, including the ones of failedPools.
+	failedWorkers int
+	// failedPools are the pools of the failed workers, the count of pool is the number of its failed workers.
+	failedPools []workerPool
+	// loadedSSHKeySource is the key source of --ssh-key-path which is loaded with the credential of the provider.
+	loadedSSHKeySource string
 }
 
 func init() {
@@ -98,45 +159,168 @@ func (p *Tencent) GenerateClusterName() string {
 
 // GenerateManifest generates manifest deploy command.
 func (p *Tencent) GenerateManifest() []string {
+	var extraManifests []string
 	if p.CloudControllerManager {
 		// deploy additional Tencent cloud-controller-manager manifests.
-		tencentCCM := &tencent.CloudControllerManager{
-			Region:                base64.StdEncoding.EncodeToString([]byte(p.Region)),
-			VpcID:                 base64.StdEncoding.EncodeToString([]byte(p.VpcID)),
-			NetworkRouteTableName: base64.StdEncoding.EncodeToString([]byte(p.NetworkRouteTableName)),
+		extraManifests = append(extraManifests, p.generateCCMManifest())
+	}
+	if p.EnableCSI {
+		// deploy additional Tencent cbs csi driver manifests, credentials are wired the same way as CCM.
+		extraManifests = append(extraManifests, p.generateCSIManifest())
+	}
+	if p.HaVip && p.HaVipAddress != "" {
+		extraManifests = append(extraManifests, p.generateKeepalivedManifest())
+	}
+	if p.GPU {
+		extraManifests = append(extraManifests, p.generateGPUManifest())
+	}
+	return extraManifests
+}
+
+// ApplyAddons re-applies CCM, CSI and custom manifests to an existing cluster.
+func (p *Tencent) ApplyAddons() error {
+	return p.ApplyManifests(p.GenerateManifest)
+}
+
+// CreateK3sCluster create K3S cluster.
+func (p *Tencent) CreateK3sCluster() (err error) {
+	p.creating = true
+	err = p.InitCluster(p.Options, p.GenerateManifest, p.generateInstance, nil, p.rollbackInstance)
+	p.creating = false
+	if err != nil {
+		if p.HaVip && p.Rollback {
+			p.Logger = common.NewLogger(nil)
+			if err := p.releaseHaVip(); err != nil {
+				p.Logger.Warnf("[%s] failed to release havip of cluster %s: %v", p.GetProviderName(), p.Name, err)
+			}
+		}
+		if p.NatGateway && p.Rollback {
+			p.Logger = common.NewLogger(nil)
+			if err := p.releaseNatGateway(); err != nil {
+				p.Logger.Warnf("[%s] failed to release nat gateway of cluster %s: %v", p.GetProviderName(), p.Name, err)
+			}
 		}
-		tencentCCM.SecretID = base64.StdEncoding.EncodeToString([]byte(p.SecretID))
-		tencentCCM.SecretKey = base64.StdEncoding.EncodeToString([]byte(p.SecretKey))
-		tmpl := fmt.Sprintf(tencentCCMTmpl, tencentCCM.Region, tencentCCM.SecretID, tencentCCM.SecretKey,
-			tencentCCM.VpcID, tencentCCM.NetworkRouteTableName, p.ClusterCidr)
+		return err
+	}
+	if !p.SkipInstall {
+		if err = p.postInstall(); err != nil {
+			return err
+		}
+	}
+	if p.failedWorkers > 0 {
+		if err = p.joinFailedWorkers(); err != nil {
+			return err
+		}
+	}
+	if p.OutputDir != "" {
+		return p.exportArtifacts()
+	}
+	return nil
+}
 
-		extraManifests := []string{fmt.Sprintf(deployCCMCommand,
-			base64.StdEncoding.EncodeToString([]byte(tmpl)), common.K3sManifestsDir)}
-		return extraManifests
+// joinFailedWorkers joins the workers which failed to be created with the cluster after the masters are initialized,
+// the workers of pools are joined with the specs of their pools, so that they keep the instance type, labels and taints.
+func (p *Tencent) joinFailedWorkers() error {
+	num, pools := p.failedWorkers, p.failedPools
+	p.failedWorkers, p.failedPools = 0, nil
+	if p.SkipInstall {
+		logrus.Warnf("[%s] %d workers of cluster %s failed to be created, join them by `autok3s join`", p.GetProviderName(), num, p.Name)
+		return nil
+	}
+	logrus.Infof("[%s] joining %d workers which failed to be created to cluster %s...", p.GetProviderName(), num, p.Name)
+	specs := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		specs = append(specs, pool.spec())
+	}
+	// the workers of pools are counted into --worker by JoinCheck.
+	p.Master, p.Worker, p.Pools = "0", strconv.Itoa(num-getPoolWorkerCount(pools)), specs
+	// the private ips are only used by the masters created before.
+	p.MasterPrivateIPs = nil
+	err := p.JoinCheck()
+	if err == nil {
+		err = p.JoinK3sNode()
+	}
+	if err != nil {
+		return fmt.Errorf("[%s] cluster %s is created, but failed to join %d workers, join them by `autok3s join`: %v", p.GetProviderName(), p.Name, num, err)
 	}
 	return nil
 }
 
-// CreateK3sCluster create K3S cluster.
-func (p *Tencent) CreateK3sCluster() (err error) {
-	if p.SSHUser == "" {
-		p.SSHUser = defaultUser
+// exportArtifacts copies kubeconfig and log of the cluster to the output dir,
+// a sub folder named by context is used so that concurrent clusters don't clobber each other.
+func (p *Tencent) exportArtifacts() error {
+	dir := filepath.Join(p.OutputDir, p.ContextName)
+	if err := utils.EnsureFolderExist(dir); err != nil {
+		return fmt.Errorf("[%s] failed to create output dir %s: %v", p.GetProviderName(), dir, err)
+	}
+	if err := common.FileManager.ExportCfg(p.ContextName, filepath.Join(dir, "kubeconfig")); err != nil {
+		return fmt.Errorf("[%s] failed to export kubeconfig to %s: %v", p.GetProviderName(), dir, err)
 	}
-	return p.InitCluster(p.Options, p.GenerateManifest, p.generateInstance, nil, p.rollbackInstance)
+	logContent, err := os.ReadFile(common.GetClusterLogFilePath(p.ContextName))
+	if err != nil {
+		return fmt.Errorf("[%s] failed to read log of cluster %s: %v", p.GetProviderName(), p.ContextName, err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "log"), logContent, 0644); err != nil {
+		return fmt.Errorf("[%s] failed to export log to %s: %v", p.GetProviderName(), dir, err)
+	}
+	// the cluster log file is closed after creation, so use the standard logger here.
+	logrus.Infof("[%s] kubeconfig and log of cluster %s are exported to %s", p.GetProviderName(), p.Name, dir)
+	return nil
 }
 
-// JoinK3sNode join K3S node.
-func (p *Tencent) JoinK3sNode() (err error) {
-	if p.SSHUser == "" {
-		p.SSHUser = defaultUser
+// InstallK3sCluster installs K3s to the instances of the cluster created with `--skip-install`.
+func (p *Tencent) InstallK3sCluster() error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	if err := p.InstallK3s(p.Options, p.GenerateManifest); err != nil {
+		return err
+	}
+	return p.postInstall()
+}
+
+// postInstall runs the steps which need K3s to be installed, it's shared by creating and installing.
+func (p *Tencent) postInstall() error {
+	if p.HaVip {
+		if err := p.useHaVipAddress(); err != nil {
+			return err
+		}
+	}
+	if p.CloudControllerManager {
+		// the log file of cluster is closed after installing, reopen it to record the waiting.
+		logFile, err := common.GetLogFile(p.ContextName)
+		if err != nil {
+			return err
+		}
+		p.Logger = common.NewLogger(logFile)
+		err = p.waitForCCMReady()
+		_ = logFile.Close()
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// JoinK3sNode join K3S node.
+// The ssh user saved in the cluster state is used, it's resolved by the image if it's not saved, see generateInstance.
+func (p *Tencent) JoinK3sNode() (err error) {
 	return p.JoinNodes(p.generateInstance, func() error { return nil }, false, p.rollbackInstance)
 }
 
 func (p *Tencent) rollbackInstance(ids []string) error {
 	if len(ids) > 0 {
-		if p.PublicIPAssignedEIP {
+		if p.PrivateDNSZone != "" {
+			if err := p.deregisterPrivateDNSRecords(ids); err != nil {
+				p.Logger.Warnf("[%s] failed to remove private dns records of instances %s: %v", p.GetProviderName(), ids, err)
+			}
+		}
+		if p.IngressLB != "" {
+			if err := p.deregisterIngressTargets(ids); err != nil {
+				p.Logger.Warnf("[%s] failed to deregister instances %s from ingress clb: %v", p.GetProviderName(), ids, err)
+			}
+		}
+		if p.eipEnabled() {
 			eips, err := p.describeAddresses(nil, tencentCommon.StringPtrs(ids))
 			if err != nil {
 				p.Logger.Errorf("[%s] error when query eip info", p.GetProviderName())
@@ -146,7 +330,10 @@ func (p *Tencent) rollbackInstance(ids []string) error {
 				taskIds []uint64
 			)
 			for _, eip := range eips {
-				eipIds = append(eipIds, *eip.AddressId)
+				// the existing eips set by --eip-address are only disassociated.
+				if !p.isExternalEIP(eip) {
+					eipIds = append(eipIds, *eip.AddressId)
+				}
 				if taskID, err := p.disassociateAddress(*eip.AddressId); err != nil {
 					p.Logger.Warnf("[%s] disassociate eip [%s] error", p.GetProviderName(), *eip.AddressId)
 				} else {
@@ -201,6 +388,130 @@ func (p *Tencent) SSHK3sNode(ip string) error {
 	return p.Connect(ip, &p.SSH, c, p.getInstanceNodes, p.isInstanceRunning, nil)
 }
 
+// RefreshState re-syncs ips and statuses of the cluster's instances to the saved state.
+func (p *Tencent) RefreshState() error {
+	return p.RefreshClusterState(p.getInstanceNodes)
+}
+
+// ResizeNode changes the instance type of the cluster's instance.
+func (p *Tencent) ResizeNode(instanceID, instanceType string, force bool) error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.ResizeClusterNode(instanceID, instanceType, force, p.resetInstanceType)
+}
+
+// ReplaceNode terminates the failed instance and joins a new instance of the same role to the cluster.
+func (p *Tencent) ReplaceNode(instanceID string, force bool) error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.ReplaceClusterNode(instanceID, force, p.terminateReplacedInstance, p.joinReplacement)
+}
+
+// Reconcile joins or removes instances to converge the cluster to the desired master and worker numbers.
+func (p *Tencent) Reconcile(force bool) error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	drainTimeout, err := p.getDrainTimeout()
+	if err != nil {
+		return err
+	}
+	return p.ReconcileCluster(force, drainTimeout, p.terminateReplacedInstance, p.joinReconciledNodes)
+}
+
+// ScaleWorkers scales the workers to the count by reconciling, the masters and their eips are kept.
+func (p *Tencent) ScaleWorkers(count string, force bool) error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	drainTimeout, err := p.getDrainTimeout()
+	if err != nil {
+		return err
+	}
+	return p.ScaleWorkersCluster(count, force, drainTimeout, p.terminateReplacedInstance, p.joinReconciledNodes)
+}
+
+func (p *Tencent) getDrainTimeout() (time.Duration, error) {
+	if p.DrainTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(p.DrainTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("[%s] invalid --drain-timeout %s: %v", p.GetProviderName(), p.DrainTimeout, err)
+	}
+	return timeout, nil
+}
+
+// joinReconciledNodes joins the missing nodes, the new workers don't belong to any pool.
+func (p *Tencent) joinReconciledNodes(masterNum, workerNum int) error {
+	p.Master, p.Worker, p.Pools = strconv.Itoa(masterNum), strconv.Itoa(workerNum), nil
+	if err := p.JoinCheck(); err != nil {
+		return err
+	}
+	return p.JoinK3sNode()
+}
+
+// terminateReplacedInstance terminates the instance and releases its eips allocated by autok3s,
+// the existing eips set by --eip-address are only disassociated.
+func (p *Tencent) terminateReplacedInstance(ids []string) error {
+	if p.eipEnabled() {
+		eips, err := p.describeAddresses(nil, tencentCommon.StringPtrs(ids))
+		if err != nil {
+			return fmt.Errorf("[%s] error when query eip info of instance %s: %v", p.GetProviderName(), ids, err)
+		}
+		taggedResource, err := p.describeResourcesByTags()
+		if err != nil {
+			return fmt.Errorf("[%s] error when query tagged eip(s), message: %v", p.GetProviderName(), err)
+		}
+		allocated := map[string]bool{}
+		for _, resource := range taggedResource {
+			if strings.EqualFold(tencent.ServiceTypeEIP, *resource.ServiceType) &&
+				strings.EqualFold(tencent.ResourcePrefixEIP, *resource.ResourcePrefix) {
+				allocated[*resource.ResourceId] = true
+			}
+		}
+		// the untagged eips are treated as the ones set by --eip-address during the rollback.
+		addresses := p.EIPAddresses
+		defer func() {
+			p.EIPAddresses = addresses
+		}()
+		for _, eip := range eips {
+			if !allocated[*eip.AddressId] && eip.AddressIp != nil {
+				p.EIPAddresses = append(p.EIPAddresses, *eip.AddressIp)
+			}
+		}
+	}
+	return p.rollbackInstance(ids)
+}
+
+// joinReplacement joins a new instance with the role and the worker pool of the replaced node.
+func (p *Tencent) joinReplacement(node types.Node) error {
+	p.Master, p.Worker, p.Pools = "0", "0", nil
+	p.EtcdOnlyMaster, p.ControlPlaneOnlyMaster = "", ""
+	switch {
+	case node.Master:
+		p.Master = "1"
+		if node.MasterRole == types.MasterRoleEtcd {
+			p.EtcdOnlyMaster = "1"
+		} else if node.MasterRole == types.MasterRoleControlPlane {
+			p.ControlPlaneOnlyMaster = "1"
+		}
+	case node.Pool != "":
+		// labels and taints of the pool are not saved in state, only the name and instance type are kept.
+		spec := fmt.Sprintf("name=%s,count=1", node.Pool)
+		if node.InstanceType != "" {
+			spec += ",type=" + node.InstanceType
+		}
+		p.Worker = "1"
+		p.Pools = []string{spec}
+	default:
+		p.Worker = "1"
+	}
+	return p.JoinK3sNode()
+}
+
 func (p *Tencent) isInstanceRunning(state string) bool {
 	return state == tencent.Running
 }
@@ -218,17 +529,27 @@ func (p *Tencent) getInstanceNodes() ([]types.Node, error) {
 	for _, instance := range instanceList {
 		instanceID := *instance.InstanceId
 		instanceState := *instance.InstanceState
-		master := false
+		master, pool := false, ""
+		tags := make(map[string]string, len(instance.Tags))
 		for _, tagPtr := range instance.Tags {
 			if strings.EqualFold(*tagPtr.Key, "master") && strings.EqualFold(*tagPtr.Value, "true") {
 				master = true
-				break
 			}
+			if *tagPtr.Key == poolTagKey {
+				pool = *tagPtr.Value
+			}
+			tags[*tagPtr.Key] = *tagPtr.Value
 		}
 		nodes = append(nodes, types.Node{
 			Master:            master,
+			Pool:              pool,
+			Tags:              tags,
+			Spot:              instance.InstanceChargeType != nil && *instance.InstanceChargeType == spotInstanceChargeType,
+			DiskEncrypted:     isDiskEncrypted(instance),
+			HostID:            getInstanceHostID(instance),
 			InstanceID:        instanceID,
 			InstanceStatus:    instanceState,
+			InstanceType:      *instance.InstanceType,
 			InternalIPAddress: tencentCommon.StringValues(instance.PrivateIpAddresses),
 			PublicIPAddress:   tencentCommon.StringValues(instance.PublicIpAddresses),
 		})
@@ -265,19 +586,64 @@ func (p *Tencent) IsClusterExist() (bool, []string, error) {
 
 // GenerateMasterExtraArgs generates K3S master extra args.
 func (p *Tencent) GenerateMasterExtraArgs(cluster *types.Cluster, master types.Node) string {
+	extraArgs := ""
 	if option, ok := cluster.Options.(tencent.Options); ok {
 		if option.CloudControllerManager {
-			extraArgs := fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=node-status-update-frequency=30s --kubelet-arg=provider-id=tencentcloud:///%s/%s --node-name=%s",
+			extraArgs += fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=node-status-update-frequency=30s --kubelet-arg=provider-id=tencentcloud:///%s/%s --node-name=%s",
 				option.Zone, master.InstanceID, master.InternalIPAddress[0])
-			return extraArgs
+		} else if option.SetHostname && master.LocalHostname != "" {
+			// the hostname is set to the node name before installing K3s.
+			extraArgs += " --node-name=" + master.LocalHostname
+		}
+		if option.GPU {
+			extraArgs += " --node-label=" + gpuNodeLabel
+		}
+		extraArgs += getHostLabelArgs(master)
+		if master.Master {
+			extraArgs += getMasterRoleArgs(master)
+			if master.MasterRole != types.MasterRoleEtcd {
+				extraArgs += getKubeComponentArgs(option, cluster.MasterExtraArgs)
+			}
+		}
+	}
+	return extraArgs
+}
+
+// getKubeComponentArgs translates the kube component options into K3s `--kube-*-arg` flags,
+// the duplicated ones and the ones already set by --master-extra-args are skipped.
+func getKubeComponentArgs(option tencent.Options, masterExtraArgs string) string {
+	existing := map[string]bool{}
+	for _, arg := range strings.Fields(masterExtraArgs) {
+		existing[arg] = true
+	}
+	extraArgs := ""
+	for _, component := range []struct {
+		flag string
+		args []string
+	}{
+		{flag: "--kube-apiserver-arg", args: option.KubeAPIServerArgs},
+		{flag: "--kube-controller-manager-arg", args: option.KubeControllerArgs},
+		{flag: "--kube-scheduler-arg", args: option.KubeSchedulerArgs},
+	} {
+		for _, value := range component.args {
+			arg := component.flag + "=" + value
+			if existing[arg] {
+				continue
+			}
+			existing[arg] = true
+			extraArgs += " " + arg
 		}
 	}
-	return ""
+	return extraArgs
 }
 
 // GenerateWorkerExtraArgs generates K3S worker extra args.
 func (p *Tencent) GenerateWorkerExtraArgs(cluster *types.Cluster, worker types.Node) string {
-	return p.GenerateMasterExtraArgs(cluster, worker)
+	extraArgs := p.GenerateMasterExtraArgs(cluster, worker)
+	if option, ok := cluster.Options.(tencent.Options); ok {
+		extraArgs += getPoolExtraArgs(option, worker)
+	}
+	return extraArgs
 }
 
 // GetCluster returns cluster status.
@@ -346,56 +712,134 @@ func (p *Tencent) GetProviderOptions(opt []byte) (interface{}, error) {
 	return options, err
 }
 
+// GetProviderOptionValues returns the fields of provider options with the current values.
+func (p *Tencent) GetProviderOptionValues() (map[string]schemas.Field, error) {
+	return p.GetOptionValues(p.Options, p.GetCredentialFlags())
+}
+
 func (p *Tencent) generateClientSDK() error {
+	if err := p.LoadSSHKeySource(); err != nil {
+		return err
+	}
 	credential := tencentCommon.NewCredential(
 		p.SecretID,
 		p.SecretKey,
 	)
-	cpf := profile.NewClientProfile()
-	if p.EndpointURL != "" {
-		cpf.HttpProfile.Endpoint = p.EndpointURL
-	}
-	if client, err := cvm.NewClient(credential, p.Region, cpf); err == nil {
+	// all the api calls are limited by the shared rate limiter.
+	transport := p.newRateLimitedTransport()
+	if client, err := cvm.NewClient(credential, p.Region, p.newClientProfile(p.CVMEndpoint)); err == nil {
+		client.WithHttpTransport(transport)
 		p.c = client
 	} else {
 		return err
 	}
 
-	if vpcClient, err := vpc.NewClient(credential, p.Region, cpf); err == nil {
+	if vpcClient, err := vpc.NewClient(credential, p.Region, p.newClientProfile(p.VPCEndpoint)); err == nil {
+		vpcClient.WithHttpTransport(transport)
 		p.v = vpcClient
 	} else {
 		return err
 	}
 
 	// region for tag clients is not necessary.
-	if tagClient, err := tag.NewClient(credential, p.Region, cpf); err == nil {
+	if tagClient, err := tag.NewClient(credential, p.Region, p.newClientProfile(p.TagEndpoint)); err == nil {
+		tagClient.WithHttpTransport(transport)
 		p.t = tagClient
 	} else {
 		return err
 	}
 
-	if tkeClient, err := tke.NewClient(credential, p.Region, cpf); err == nil {
+	if tkeClient, err := tke.NewClient(credential, p.Region, p.newClientProfile(p.TKEEndpoint)); err == nil {
+		tkeClient.WithHttpTransport(transport)
 		p.r = tkeClient
 	} else {
 		return err
 	}
+
+	if cbsClient, err := cbs.NewClient(credential, p.Region, p.newClientProfile(p.CBSEndpoint)); err == nil {
+		cbsClient.WithHttpTransport(transport)
+		p.b = cbsClient
+	} else {
+		return err
+	}
+
+	// kms is only used to check the key of disk encryption, it shares the endpoint of --endpoint-url.
+	if kmsClient, err := kms.NewClient(credential, p.Region, p.newClientProfile("")); err == nil {
+		kmsClient.WithHttpTransport(transport)
+		p.k = kmsClient
+	} else {
+		return err
+	}
+
+	// clb is only used by --ingress-lb, it shares the endpoint of --endpoint-url.
+	if clbClient, err := clb.NewClient(credential, p.Region, p.newClientProfile("")); err == nil {
+		clbClient.WithHttpTransport(transport)
+		p.l = clbClient
+	} else {
+		return err
+	}
+
+	if privateDNSClient, err := newPrivateDNSClient(credential, p.Region, p.newClientProfile(p.PrivateDNSEndpoint)); err == nil {
+		privateDNSClient.WithHttpTransport(transport)
+		p.d = privateDNSClient
+	} else {
+		return err
+	}
+
+	launchTemplateClient := newLaunchTemplateClient(credential, p.Region, p.newClientProfile(p.CVMEndpoint))
+	launchTemplateClient.WithHttpTransport(transport)
+	p.e = launchTemplateClient
 	return nil
 }
 
+// newClientProfile returns the client profile with the service endpoint, e.g. a private api gateway,
+// `--endpoint-url` is used by all the services which don't have their own endpoint.
+func (p *Tencent) newClientProfile(endpoint string) *profile.ClientProfile {
+	cpf := profile.NewClientProfile()
+	if endpoint == "" {
+		endpoint = p.EndpointURL
+	}
+	if endpoint != "" {
+		cpf.HttpProfile.Endpoint = endpoint
+	}
+	return cpf
+}
+
 func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	var err error
 	if err = p.generateClientSDK(); err != nil {
 		return nil, err
 	}
 
+	// the zone, image and network of the launch template are used by the instances.
+	if err = p.applyLaunchTemplate(); err != nil {
+		return nil, err
+	}
+	if err = p.resolveImageFamily(); err != nil {
+		return nil, err
+	}
+
+	if ssh.SSHUser == "" {
+		ssh.SSHUser = p.getImageDefaultUser()
+		p.SSHUser = ssh.SSHUser
+		p.Logger.Infof("[%s] use ssh user %s for image %s", p.GetProviderName(), ssh.SSHUser, p.ImageID)
+	}
+
 	// create key pair.
 	pk, err := putil.CreateKeyPair(ssh, p.GetProviderName(), p.ContextName, p.KeypairID)
 	if err != nil {
 		return nil, fmt.Errorf("[%s] Failed to create key pair: %v", p.GetProviderName(), err)
 	}
 
+	pools, err := parseWorkerPools(p.Pools)
+	if err != nil {
+		return nil, err
+	}
+
 	masterNum, _ := strconv.Atoi(p.Master)
+	// the workers of pools are included in --worker by preflight check.
 	workerNum, _ := strconv.Atoi(p.Worker)
+	defaultWorkerNum := workerNum - getPoolWorkerCount(pools)
 
 	p.Logger.Infof("[%s] %d masters and %d workers will be added", p.GetProviderName(), masterNum, workerNum)
 
@@ -415,6 +859,13 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 		}
 	}
 
+	// the nat gateway must be routed before the private instances install K3s.
+	if p.NatGateway {
+		if err = p.configNatGateway(); err != nil {
+			return nil, err
+		}
+	}
+
 	needUploadKeyPair := false
 	if ssh.SSHPassword == "" && p.KeypairID == "" {
 		needUploadKeyPair = true
@@ -436,22 +887,96 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 		p.InstanceChargeType = spotInstanceChargeType
 	}
 
-	// run ecs master instances.
-	if masterNum > 0 {
-		p.Logger.Infof("[%s] %d number of master instances will be created", p.GetProviderName(), masterNum)
-		if err := p.runInstances(masterNum, true, ssh.SSHPassword); err != nil {
+	if len(p.MasterPrivateIPs) > 0 {
+		if err = p.checkMasterPrivateIPs(masterNum); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.eipEnabled() {
+		eipNum := 0
+		if p.useEIP(true) {
+			eipNum += masterNum
+		}
+		if p.useEIP(false) {
+			eipNum += workerNum
+		}
+		if err = p.resolveEIPAddresses(eipNum); err != nil {
 			return nil, err
 		}
-		p.Logger.Infof("[%s] %d number of master instances successfully created", p.GetProviderName(), masterNum)
 	}
 
-	// run ecs worker instances.
-	if workerNum > 0 {
-		p.Logger.Infof("[%s] %d number of worker instances will be created", p.GetProviderName(), workerNum)
-		if err := p.runInstances(workerNum, false, ssh.SSHPassword); err != nil {
+	runMasters := func() error {
+		// run ecs master instances by role.
+		for _, group := range p.getMasterGroups(masterNum) {
+			p.Logger.Infof("[%s] %d number of %s master instances will be created", p.GetProviderName(), group.Count, group.displayRole())
+			if err := p.runMasterInstances(group, ssh.SSHPassword); err != nil {
+				return err
+			}
+			p.Logger.Infof("[%s] %d number of %s master instances successfully created", p.GetProviderName(), group.Count, group.displayRole())
+		}
+		return nil
+	}
+	// the failed workers don't abort the creation, so that the masters are always initialized, and the workers are
+	// joined after the cluster is created, see CreateK3sCluster.
+	runWorkers := func(num int, pool *workerPool) error {
+		name := ""
+		if pool != nil {
+			name = " of pool " + pool.Name
+		}
+		p.Logger.Infof("[%s] %d number of worker instances%s will be created", p.GetProviderName(), num, name)
+		if err := p.runInstances(num, false, ssh.SSHPassword, pool); err != nil {
+			if !p.creating {
+				return err
+			}
+			p.Logger.Warnf("[%s] failed to create %d worker instances%s, they will be joined after the cluster is created: %v",
+				p.GetProviderName(), num, name, err)
+			p.failedWorkers += num
+			if pool != nil {
+				failed := *pool
+				failed.Count = num
+				p.failedPools = append(p.failedPools, failed)
+			}
+			return nil
+		}
+		p.Logger.Infof("[%s] %d number of worker instances%s successfully created", p.GetProviderName(), num, name)
+		return nil
+	}
+	runAllWorkers := func() error {
+		// run ecs worker instances.
+		if defaultWorkerNum > 0 {
+			if err := runWorkers(defaultWorkerNum, nil); err != nil {
+				return err
+			}
+		}
+		// run ecs worker instances of pools.
+		for i := range pools {
+			if err := runWorkers(pools[i].Count, &pools[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p.failedWorkers, p.failedPools = 0, nil
+	if p.NodeOrder == nodeOrderWorkersFirst {
+		if err = runAllWorkers(); err != nil {
+			return nil, err
+		}
+		if err = runMasters(); err != nil {
 			return nil, err
 		}
-		p.Logger.Infof("[%s] %d number of worker instances successfully created", p.GetProviderName(), workerNum)
+	} else {
+		if err = runMasters(); err != nil {
+			return nil, err
+		}
+		if err = runAllWorkers(); err != nil {
+			return nil, err
+		}
+	}
+	if p.failedWorkers > 0 {
+		workerNum -= p.failedWorkers
+		p.Worker = strconv.Itoa(workerNum)
 	}
 
 	// wait ecs instances to be running status.
@@ -462,7 +987,7 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	var eipTaskIds []uint64
 
 	// allocate eip for master.
-	if masterNum > 0 && p.PublicIPAssignedEIP {
+	if masterNum > 0 && p.useEIP(true) {
 		taskIDs, err := p.allocateEIPForInstance(masterNum, true)
 		if err != nil {
 			return nil, err
@@ -471,7 +996,7 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	}
 
 	// allocate eip for worker.
-	if workerNum > 0 && p.PublicIPAssignedEIP {
+	if workerNum > 0 && p.useEIP(false) {
 		taskIDs, err := p.allocateEIPForInstance(workerNum, false)
 		if err != nil {
 			return nil, err
@@ -480,7 +1005,7 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	}
 
 	// wait eip to be InUse status.
-	if p.PublicIPAssignedEIP {
+	if p.eipEnabled() {
 		for _, taskID := range eipTaskIds {
 			if err = p.describeVpcTaskResult(taskID); err != nil {
 				return nil, err
@@ -493,6 +1018,43 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 		return nil, err
 	}
 
+	// the gpu driver and runtime must be ready before K3s is installed.
+	if p.GPU {
+		if err = p.setupGPUNodes(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the etcd-s3 config with the credentials must be written before K3s starts.
+	if err = p.setupEtcdS3Nodes(); err != nil {
+		return nil, err
+	}
+
+	if err = p.setupMetadataAccess(); err != nil {
+		return nil, err
+	}
+
+	// register private dns records for new instances.
+	if p.PrivateDNSZone != "" {
+		if err = p.registerPrivateDNSRecords(); err != nil {
+			return nil, err
+		}
+	}
+
+	// register the new workers to the ingress clb.
+	if p.IngressLB != "" {
+		if err = p.registerIngressTargets(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the havip is created with the cluster, the joined masters share it.
+	if p.HaVip && p.HaVipAddress == "" && masterNum > 0 {
+		if err = p.configHaVip(); err != nil {
+			return nil, err
+		}
+	}
+
 	c := &types.Cluster{
 		Metadata: p.Metadata,
 		Options:  p.Options,
@@ -521,7 +1083,7 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 	if !exist {
 		p.Logger.Errorf("[%s] cluster %s is not exist", p.GetProviderName(), p.Name)
 		if !f {
-			return "", fmt.Errorf("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
+			return "", cluster.NewClusterNotFoundError("[%s] calling preflight error: cluster name `%s` do not exist", p.GetProviderName(), p.Name)
 		}
 		return p.ContextName, nil
 	}
@@ -540,10 +1102,37 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 		}
 	}
 
+	if p.DrainTimeout != "" {
+		timeout, err := time.ParseDuration(p.DrainTimeout)
+		if err != nil {
+			return "", fmt.Errorf("[%s] invalid --drain-timeout %s: %v", p.GetProviderName(), p.DrainTimeout, err)
+		}
+		// the instances will be terminated anyway, draining is best effort within the timeout.
+		if err = p.DrainNodes(timeout); err != nil {
+			p.Logger.Warnf("%v, continue to delete cluster %s", err, p.Name)
+		}
+	}
+
+	var haVip *vpc.HaVip
+	if p.HaVip {
+		// the eip must be disassociated from the havip before it's released with the other tagged eips.
+		if haVip, err = p.disassociateHaVipAddress(); err != nil {
+			p.Logger.Errorf("[%s] failed to disassociate eip of havip, message: %v", p.GetProviderName(), err)
+		}
+	}
+
+	if p.NatGateway {
+		// the eip of nat gateway is released with the other tagged eips after the nat gateway is deleted.
+		if _, err = p.deleteNatGateway(); err != nil {
+			p.Logger.Errorf("[%s] failed to delete nat gateway, message: %v", p.GetProviderName(), err)
+		}
+	}
+
 	taggedResource, err := p.describeResourcesByTags()
 	if err != nil {
 		p.Logger.Errorf("[%s] error when query tagged eip(s), message: %v", p.GetProviderName(), err)
 	}
+	tagged := err == nil
 	var eipIds []string
 	if len(taggedResource) > 0 {
 		var taskIds []uint64
@@ -569,6 +1158,9 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 			}
 		}
 	}
+	if tagged {
+		p.disassociateExternalEIPs(eipIds)
+	}
 	if len(eipIds) > 0 {
 		taskID, err := p.releaseAddresses(eipIds)
 		if err != nil {
@@ -579,6 +1171,19 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 		}
 	}
 
+	if p.PrivateDNSZone != "" && len(ids) > 0 {
+		if err := p.deregisterPrivateDNSRecords(ids); err != nil {
+			p.Logger.Errorf("[%s] failed to remove private dns records, message: %v", p.GetProviderName(), err)
+		}
+	}
+
+	// the clb of --ingress-lb is kept, only the instances of the cluster are deregistered.
+	if p.IngressLB != "" && len(ids) > 0 {
+		if err := p.deregisterIngressTargets(ids); err != nil {
+			p.Logger.Errorf("[%s] failed to deregister instances from ingress clb, message: %v", p.GetProviderName(), err)
+		}
+	}
+
 	if len(ids) > 0 {
 		p.Logger.Infof("[%s] cluster %s will be deleted", p.GetProviderName(), p.Name)
 
@@ -586,6 +1191,14 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("[%s] calling deleteInstance error, msg: %v", p.GetProviderName(), err)
 		}
+		if err := p.deleteUnusedSecurityGroups(ids); err != nil {
+			p.Logger.Errorf("[%s] failed to delete security group(s), message: %v", p.GetProviderName(), err)
+		}
+		if haVip != nil {
+			if err := p.deleteHaVip(*haVip.HaVipId); err != nil {
+				p.Logger.Errorf("[%s] failed to delete havip, message: %v", p.GetProviderName(), err)
+			}
+		}
 	}
 	// remove default key-pair folder.
 	err = os.RemoveAll(common.GetClusterPath(p.ContextName, p.GetProviderName()))
@@ -597,31 +1210,379 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 
 // CreateCheck check create command and flags.
 func (p *Tencent) CreateCheck() error {
-	if err := p.CheckCreateArgs(p.IsClusterExist); err != nil {
+	if err := p.checkPools(); err != nil {
 		return err
 	}
-
-	if p.UserDataPath != "" {
-		_, err := os.Stat(p.UserDataPath)
-		if err != nil {
-			return err
-		}
+	if err := p.Validate(); err != nil {
+		return err
 	}
-	if err := p.ValidateRequireSSHPrivateKey(); p.KeypairID != "" && err != nil {
-		return fmt.Errorf("[%s] calling preflight error: %s with --key-pair %s", p.GetProviderName(), err.Error(), p.KeypairID)
+	if err := p.CheckClusterNotExist(p.IsClusterExist); err != nil {
+		return err
+	}
+	if err := p.checkKMSKey(); err != nil {
+		return err
+	}
+	if err := p.checkKeyPair(); err != nil {
+		return err
+	}
+	if err := p.checkZoneInstanceTypes(); err != nil {
+		return err
+	}
+	if err := p.checkGPUInstanceTypes(); err != nil {
+		return err
+	}
+	if err := p.checkIngressLB(); err != nil {
+		return err
+	}
+	if p.LaunchTemplateID != "" {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+		if _, err := p.describeLaunchTemplate(); err != nil {
+			return fmt.Errorf("[%s] calling preflight error: %v", p.GetProviderName(), err)
+		}
+	}
+	return p.checkDiskTypes()
+}
+
+// Validate runs the offline preflight checks of create, it doesn't require credentials or call the tencent api.
+// All problems are returned together as cluster.ValidationErrors, so that they can be fixed at once.
+func (p *Tencent) Validate() error {
+	errs := make([]error, 0)
+	if _, err := parseWorkerPools(p.Pools); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.GetProviderName(), err))
+	}
+	for _, validate := range []func() error{
+		p.ValidateCreateArgs,
+		p.validateOptions,
+		p.validateEIPAddresses,
+		p.validateDiskEncryption,
+		p.validateLaunchTemplate,
+		func() error { return p.validateMasterRoles(true) },
+		p.validateMasterPrivateIPs,
+		p.validateMetadataAccess,
+		p.validateIngressLB,
+		p.validateHostIDs,
+	} {
+		if err := validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if p.NatGatewayID != "" && !p.NatGateway {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: must set `--nat-gateway` if `--nat-gateway-id` is set", p.GetProviderName()))
+	}
+	if p.HaVip {
+		if !p.Cluster && p.DataStore == "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: must set `--cluster` or `--datastore` if `--ha-vip` is enabled", p.GetProviderName()))
+		}
+		if p.IP != "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--ip` can't be set with `--ha-vip`, the eip of havip is used", p.GetProviderName()))
+		}
+	}
+
+	for _, path := range []string{p.UserDataPath, p.MasterUserDataPath, p.WorkerUserDataPath} {
+		if path != "" {
+			if _, err := os.Stat(path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := p.ValidateRequireSSHPrivateKey(); p.KeypairID != "" && err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %s with --key-pair %s", p.GetProviderName(), err.Error(), p.KeypairID))
 	}
 
 	if p.CloudControllerManager && p.NetworkRouteTableName == "" {
-		return fmt.Errorf("[%s] calling preflight error: must set `--router` if enabled tencent cloud manager",
-			p.GetProviderName())
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: must set `--router` if enabled tencent cloud manager",
+			p.GetProviderName()))
+	}
+
+	if p.EnableCSI {
+		if p.CSIVersion == "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: must set `--csi-version` if enabled cbs csi driver", p.GetProviderName()))
+		}
+		if p.CSIDiskType == "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: must set `--csi-disk-type` if enabled cbs csi driver", p.GetProviderName()))
+		}
+	}
+
+	if p.EtcdSnapshotCOSBucket != "" && (!p.Cluster || p.DataStore != "") {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--etcd-snapshot-cos-bucket` can only be set with embedded etcd `--cluster`",
+			p.GetProviderName()))
+	}
+
+	if err := p.checkInstanceNameTemplate(); err != nil {
+		errs = append(errs, err)
 	}
+	return cluster.NewValidationErrors(errs...)
+}
+
+// validateOptions checks the format of tencent options, so that a typo fails before calling the api.
+func (p *Tencent) validateOptions() error {
+	errs := make([]error, 0)
+	if !regionRegexp.MatchString(p.Region) {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid `--region` %q, e.g. ap-guangzhou", p.GetProviderName(), p.Region))
+	}
+	if !strings.HasPrefix(p.Zone, p.Region+"-") {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: `--zone` %q is not in region %s, e.g. %s-3",
+			p.GetProviderName(), p.Zone, p.Region, p.Region))
+	}
+	instanceTypes := []string{p.InstanceType}
+	pools, _ := parseWorkerPools(p.Pools)
+	for _, pool := range pools {
+		if pool.InstanceType != "" {
+			instanceTypes = append(instanceTypes, pool.InstanceType)
+		}
+	}
+	for _, instanceType := range instanceTypes {
+		if !instanceTypeRegexp.MatchString(instanceType) {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid instance type %q, e.g. SA2.MEDIUM4", p.GetProviderName(), instanceType))
+		}
+	}
+	for _, option := range [][2]string{{"--disk-size", p.SystemDiskSize}, {"--internet-max-bandwidth-out", p.InternetMaxBandwidthOut}} {
+		if option[1] == "" {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `%s` must be set", p.GetProviderName(), option[0]))
+		}
+	}
+	// the allowed values and ranges of options are defined by the tags of tencent.Options, which are shared with the UI.
+	if err := utils.ValidateFields(p.Options); err != nil {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: %v", p.GetProviderName(), err))
+	}
+	// tencent limits the length of resource names, the zone is appended to the name of default subnet.
+	for _, option := range [][2]string{{"--vpc-name", p.getVpcName()}, {"--subnet-name", p.getSubnetName() + "-" + p.Zone},
+		{"--security-group-name", p.getSecurityGroupName()}} {
+		if len(option[1]) > maxResourceNameLength {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: `%s` %q is longer than %d characters", p.GetProviderName(), option[0], option[1], maxResourceNameLength))
+		}
+	}
+	if err := p.validateImageFamily(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(p.EgressCIDRs) > 0 && !p.RestrictEgress {
+		errs = append(errs, fmt.Errorf("[%s] calling preflight error: must set `--restrict-egress` if `--egress-cidr` is set", p.GetProviderName()))
+	}
+	for _, egressCidr := range p.EgressCIDRs {
+		if _, _, err := net.ParseCIDR(egressCidr); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] calling preflight error: invalid `--egress-cidr` %q: %v", p.GetProviderName(), egressCidr, err))
+		}
+	}
+	for _, component := range []struct {
+		flag string
+		args []string
+	}{
+		{flag: "--kube-apiserver-arg", args: p.KubeAPIServerArgs},
+		{flag: "--kube-controller-manager-arg", args: p.KubeControllerArgs},
+		{flag: "--kube-scheduler-arg", args: p.KubeSchedulerArgs},
+	} {
+		for _, arg := range component.args {
+			// the args are passed to the install script in a single-quoted env var.
+			if strings.HasPrefix(arg, "-") || !strings.Contains(arg, "=") || strings.ContainsAny(arg, " \t'") {
+				errs = append(errs, fmt.Errorf("[%s] calling preflight error: `%s` %q must be in key=value format without leading dashes or spaces",
+					p.GetProviderName(), component.flag, arg))
+			}
+		}
+	}
+	return cluster.NewValidationErrors(errs...)
+}
 
+// checkPools validates the worker pools and counts the workers of pools into --worker,
+// so that a cluster or join of pool workers only passes the worker number check.
+func (p *Tencent) checkPools() error {
+	pools, err := parseWorkerPools(p.Pools)
+	if err != nil {
+		return fmt.Errorf("[%s] calling preflight error: %v", p.GetProviderName(), err)
+	}
+	if len(pools) == 0 {
+		return nil
+	}
+	workerNum, err := strconv.Atoi(p.Worker)
+	if err != nil {
+		return fmt.Errorf("[%s] calling preflight error: invalid --worker %s", p.GetProviderName(), p.Worker)
+	}
+	p.Worker = strconv.Itoa(workerNum + getPoolWorkerCount(pools))
 	return nil
 }
 
+// checkDiskTypes validates the system disk type and the data disk type of csi storage class
+// against the cbs disk types offered in the zone, so that an unavailable type fails before running instances.
+func (p *Tencent) checkDiskTypes() error {
+	if p.b == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	if err := p.checkDiskType("SYSTEM_DISK", p.SystemDiskType, "--disk-category"); err != nil {
+		return err
+	}
+	if p.EnableCSI {
+		if err := p.checkDiskType("DATA_DISK", p.CSIDiskType, "--csi-disk-type"); err != nil {
+			return err
+		}
+	}
+	if err := p.checkPoolDiskTypes(); err != nil {
+		return err
+	}
+	return p.checkDiskSizes()
+}
+
+// getDiskSize returns the system disk size of the role, --master-disk-size and --worker-disk-size fall back to --disk-size.
+func (p *Tencent) getDiskSize(master bool) string {
+	size := p.WorkerDiskSize
+	if master {
+		size = p.MasterDiskSize
+	}
+	if size == "" {
+		return p.SystemDiskSize
+	}
+	return size
+}
+
+// checkDiskSizes validates the system disk sizes of masters and workers against the range of the disk type.
+func (p *Tencent) checkDiskSizes() error {
+	if p.SystemDiskType == "" {
+		return nil
+	}
+	configs, err := p.describeDiskConfigs("SYSTEM_DISK")
+	if err != nil {
+		return fmt.Errorf("[%s] calling preflight error: failed to describe disk configs of zone %s: %v", p.GetProviderName(), p.Zone, err)
+	}
+	for _, option := range [][2]string{{"--master-disk-size", p.getDiskSize(true)}, {"--worker-disk-size", p.getDiskSize(false)}} {
+		size, _ := strconv.ParseUint(option[1], 10, 64)
+		// the size is left to the default of the image if it's not set.
+		if size == 0 {
+			continue
+		}
+		if err := checkDiskSize(configs, p.SystemDiskType, size); err != nil {
+			return fmt.Errorf("[%s] calling preflight error: invalid `%s` %d: %v", p.GetProviderName(), option[0], size, err)
+		}
+	}
+	return nil
+}
+
+// checkDiskSize checks the size is within the range of the available configs of the disk type.
+func checkDiskSize(configs []*cbs.DiskConfig, diskType string, size uint64) error {
+	for _, config := range configs {
+		if config.Available == nil || !*config.Available || config.DiskType == nil || *config.DiskType != diskType ||
+			config.MinDiskSize == nil || config.MaxDiskSize == nil {
+			continue
+		}
+		if size < *config.MinDiskSize || size > *config.MaxDiskSize {
+			return fmt.Errorf("size of %s must be between %d and %d GB", diskType, *config.MinDiskSize, *config.MaxDiskSize)
+		}
+		return nil
+	}
+	return nil
+}
+
+// checkPoolDiskTypes validates the system disk types of worker pools.
+func (p *Tencent) checkPoolDiskTypes() error {
+	pools, _ := parseWorkerPools(p.Pools)
+	for _, pool := range pools {
+		if pool.DiskCategory == "" {
+			continue
+		}
+		if p.b == nil {
+			if err := p.generateClientSDK(); err != nil {
+				return err
+			}
+		}
+		if err := p.checkDiskType("SYSTEM_DISK", pool.DiskCategory, "--pool "+pool.Name+" disk-category"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Tencent) checkDiskType(usage, diskType, flag string) error {
+	if diskType == "" {
+		return nil
+	}
+	available, err := p.describeDiskTypes(usage)
+	if err != nil {
+		return fmt.Errorf("[%s] calling preflight error: failed to describe disk types of zone %s: %v", p.GetProviderName(), p.Zone, err)
+	}
+	for _, t := range available {
+		if t == diskType {
+			return nil
+		}
+	}
+	return fmt.Errorf("[%s] calling preflight error: `%s` %s is not available in zone %s, available types: %s",
+		p.GetProviderName(), flag, diskType, p.Zone, strings.Join(available, ", "))
+}
+
+// describeDiskTypes returns the available cbs disk types of the zone for the disk usage, i.e. SYSTEM_DISK or DATA_DISK.
+func (p *Tencent) describeDiskTypes(usage string) ([]string, error) {
+	configs, err := p.describeDiskConfigs(usage)
+	if err != nil {
+		return nil, err
+	}
+	diskTypes := make([]string, 0)
+	for _, config := range configs {
+		if config.Available != nil && *config.Available && config.DiskType != nil {
+			diskTypes = append(diskTypes, *config.DiskType)
+		}
+	}
+	return utils.UniqueArray(diskTypes), nil
+}
+
+// describeDiskConfigs returns the cbs disk configs of the zone for the disk usage.
+func (p *Tencent) describeDiskConfigs(usage string) ([]*cbs.DiskConfig, error) {
+	request := cbs.NewDescribeDiskConfigQuotaRequest()
+	request.InquiryType = tencentCommon.StringPtr("INQUIRY_CBS_CONFIG")
+	request.Zones = tencentCommon.StringPtrs([]string{p.Zone})
+	request.DiskUsage = tencentCommon.StringPtr(usage)
+	chargeType := onDemandChargeType
+	if p.InstanceChargeType == "PREPAID" {
+		chargeType = p.InstanceChargeType
+	}
+	request.DiskChargeType = tencentCommon.StringPtr(chargeType)
+
+	response, err := p.b.DescribeDiskConfigQuota(request)
+	if err != nil {
+		return nil, err
+	}
+	return response.Response.DiskConfigSet, nil
+}
+
 // JoinCheck check join command and flags.
 func (p *Tencent) JoinCheck() error {
-	return p.CheckJoinArgs(p.IsClusterExist)
+	if err := p.checkPools(); err != nil {
+		return err
+	}
+	if err := p.CheckJoinArgs(p.IsClusterExist); err != nil {
+		return err
+	}
+	if err := p.validateEIPAddresses(); err != nil {
+		return err
+	}
+	if err := p.checkPoolDiskTypes(); err != nil {
+		return err
+	}
+	if err := p.checkZoneInstanceTypes(); err != nil {
+		return err
+	}
+	if err := p.checkGPUInstanceTypes(); err != nil {
+		return err
+	}
+	if err := p.validateMasterRoles(false); err != nil {
+		return err
+	}
+	if err := p.validateMasterPrivateIPs(); err != nil {
+		return err
+	}
+	if err := p.validateHostIDs(); err != nil {
+		return err
+	}
+	return p.checkInstanceNameTemplate()
+}
+
+func (p *Tencent) checkInstanceNameTemplate() error {
+	if p.InstanceNameTemplate == "" {
+		return nil
+	}
+	if err := validateInstanceName(renderInstanceName(p.InstanceNameTemplate, p.Name, "master", p.Zone, "1")); err != nil {
+		return fmt.Errorf("[%s] calling preflight error: invalid --instance-name-template %s: %v", p.GetProviderName(), p.InstanceNameTemplate, err)
+	}
+	return nil
 }
 
 func (p *Tencent) assembleInstanceStatus(ssh *types.SSH, uploadKeyPair bool, publicKey string) error {
@@ -631,27 +1592,34 @@ func (p *Tencent) assembleInstanceStatus(ssh *types.SSH, uploadKeyPair bool, pub
 			p.GetProviderName(), p.Name, p.Region, p.Zone, err)
 	}
 
+	var eips map[string][]string
+	if p.eipEnabled() {
+		ids := make([]string, 0, len(instanceList))
+		for _, status := range instanceList {
+			ids = append(ids, *status.InstanceId)
+		}
+		if eips, err = p.describeInstanceAddresses(ids); err != nil {
+			p.Logger.Errorf("[%s] error when query eip info of instances %s", p.GetProviderName(), ids)
+			return err
+		}
+	}
+
 	for _, status := range instanceList {
-		InstanceID := *status.InstanceId
-		var eip []string
-		if p.PublicIPAssignedEIP {
-			eipInfos, err := p.describeAddresses(nil, []*string{status.InstanceId})
-			if err != nil {
-				p.Logger.Errorf("[%s] error when query eip info of instance:[%s]", p.GetProviderName(), *status.InstanceId)
-				return err
-			}
-			for _, eipInfo := range eipInfos {
-				eip = append(eip, *eipInfo.AddressId)
-			}
+		// the replaced instance is still listed while it's being terminated.
+		if status.InstanceState != nil && *status.InstanceState == tencent.StatusTerminating {
+			continue
 		}
+		InstanceID := *status.InstanceId
+		eip := eips[InstanceID]
 		if value, ok := p.M.Load(InstanceID); ok {
 			v := value.(types.Node)
 			// add only nodes that run the current command.
 			v.Current = true
 			v.InternalIPAddress = tencentCommon.StringValues(status.PrivateIpAddresses)
 			v.PublicIPAddress = tencentCommon.StringValues(status.PublicIpAddresses)
-			v.LocalHostname = ""
+			v.LocalHostname = p.getNodeHostname(status)
 			v.EipAllocationIds = eip
+			v.HostID = getInstanceHostID(status)
 
 			v.SSH = *ssh
 			// check upload keypair.
@@ -667,49 +1635,201 @@ func (p *Tencent) assembleInstanceStatus(ssh *types.SSH, uploadKeyPair bool, pub
 			continue
 		}
 
-		master := false
+		master, pool, role := false, "", ""
 		for _, tagPtr := range status.Tags {
 			if strings.EqualFold(*tagPtr.Key, "master") && strings.EqualFold(*tagPtr.Value, "true") {
 				master = true
-				break
+			}
+			if *tagPtr.Key == poolTagKey {
+				pool = *tagPtr.Value
+			}
+			if *tagPtr.Key == masterRoleTagKey {
+				role = *tagPtr.Value
 			}
 		}
 		p.M.Store(InstanceID, types.Node{
 			Master:            master,
+			MasterRole:        role,
+			Pool:              pool,
+			Spot:              status.InstanceChargeType != nil && *status.InstanceChargeType == spotInstanceChargeType,
+			DiskEncrypted:     isDiskEncrypted(status),
 			RollBack:          false,
 			InstanceID:        InstanceID,
 			InstanceStatus:    tencent.StatusRunning,
+			LocalHostname:     p.getNodeHostname(status),
 			InternalIPAddress: tencentCommon.StringValues(status.PrivateIpAddresses),
 			EipAllocationIds:  eip,
+			HostID:            getInstanceHostID(status),
 			PublicIPAddress:   tencentCommon.StringValues(status.PublicIpAddresses)})
 
 	}
 	return nil
 }
 
-func (p *Tencent) runInstances(num int, master bool, password string) error {
+// getUserData returns base64-encoded user data of the role, the role specific one takes precedence over
+// --user-data-path and --user-data-content which are shared by masters and workers.
+func (p *Tencent) getUserData(master bool) (string, error) {
+	path := p.WorkerUserDataPath
+	if master {
+		path = p.MasterUserDataPath
+	}
+	if path == "" {
+		return p.UserDataContent, nil
+	}
+	userDataBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(userDataBytes), nil
+}
+
+// runInstances runs instances of the role, the worker pool's configs take precedence over the cluster's if pool is set.
+func (p *Tencent) runInstances(num int, master bool, password string, pool *workerPool) error {
+	placements, err := p.getSubnetPlacements(num, master)
+	if err != nil {
+		return err
+	}
+	for _, placement := range placements {
+		request, err := p.newRunInstancesRequest(placement.Count, master, password, pool)
+		if err != nil {
+			return err
+		}
+		request.VirtualPrivateCloud.SubnetId = tencentCommon.StringPtr(placement.SubnetID)
+		if err = p.launchInstances(request, placement.Count, master, "", pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runMasterInstances runs the masters of the group, which are tagged with the role if it's dedicated.
+func (p *Tencent) runMasterInstances(group masterGroup, password string) error {
+	placements, err := p.getSubnetPlacements(group.Count, true)
+	if err != nil {
+		return err
+	}
+	for _, placement := range placements {
+		request, err := p.newRunInstancesRequest(placement.Count, true, password, nil)
+		if err != nil {
+			return err
+		}
+		request.VirtualPrivateCloud.SubnetId = tencentCommon.StringPtr(placement.SubnetID)
+		if group.Role != "" {
+			request.TagSpecification[0].Tags = append(request.TagSpecification[0].Tags,
+				&cvm.Tag{Key: tencentCommon.StringPtr(masterRoleTagKey), Value: tencentCommon.StringPtr(group.Role)})
+		}
+		if err = p.launchInstances(request, placement.Count, true, group.Role, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// launchInstances launches the instances of the request, the on-demand instances are launched instead if spot is sold out
+// and --spot-fallback is enabled.
+func (p *Tencent) launchInstances(request *cvm.RunInstancesRequest, num int, master bool, role string, pool *workerPool) error {
+	instanceType := *request.InstanceType
+	run := p.c.RunInstances
+	if p.LaunchTemplateID != "" {
+		templateRequest := p.useLaunchTemplate(request, master, pool)
+		run = func(*cvm.RunInstancesRequest) (*cvm.RunInstancesResponse, error) {
+			return p.e.RunInstances(templateRequest)
+		}
+	}
+
+	spot := request.InstanceChargeType != nil && *request.InstanceChargeType == spotInstanceChargeType
+	response, err := run(request)
+	if err != nil && p.SpotFallback && spot && isSpotSoldOut(err) {
+		p.Logger.Warnf("[%s] spot instances %s are sold out, launching on-demand instances instead: %v",
+			p.GetProviderName(), instanceType, err)
+		request.InstanceChargeType = tencentCommon.StringPtr(onDemandChargeType)
+		spot = false
+		response, err = run(request)
+	}
+	if err != nil || len(response.Response.InstanceIdSet) != num {
+		return fmt.Errorf("[%s] calling runInstances error. region: %s, zone: %s, "+"instanceName: %s, msg: [%v]",
+			p.GetProviderName(), p.Region, p.Zone, *request.InstanceName, err)
+	}
+	poolName := ""
+	if pool != nil {
+		poolName = pool.Name
+	}
+	for _, id := range response.Response.InstanceIdSet {
+		p.M.Store(*id, types.Node{Master: master, MasterRole: role, RollBack: true, InstanceID: *id, InstanceStatus: tencent.StatusPending, Pool: poolName, Spot: spot,
+			DiskEncrypted: len(request.DataDisks) > 0 && p.DiskEncrypt})
+	}
+
+	return nil
+}
+
+// isSpotSoldOut returns true if the instances can't be launched as there is no spot capacity of the instance type.
+func isSpotSoldOut(err error) bool {
+	te, ok := err.(*errors.TencentCloudSDKError)
+	if !ok {
+		return false
+	}
+	switch te.Code {
+	case "ResourceInsufficient.SpecifiedInstanceType", "ResourceInsufficient.ZoneSoldOutForSpecifiedInstance",
+		"ResourcesSoldOut.SpecifiedInstanceType", "ResourcesSoldOut.AvailableZone":
+		return true
+	}
+	return false
+}
+
+// newRunInstancesRequest builds the request of running instances of the role, it's shared by the price inquiry.
+func (p *Tencent) newRunInstancesRequest(num int, master bool, password string, pool *workerPool) (*cvm.RunInstancesRequest, error) {
 	request := cvm.NewRunInstancesRequest()
 
-	diskSize, _ := strconv.ParseInt(p.SystemDiskSize, 10, 64)
+	instanceType, diskType, diskSizeValue, chargeType := p.InstanceType, p.SystemDiskType, p.getDiskSize(master), p.InstanceChargeType
+	if pool != nil {
+		if pool.InstanceType != "" {
+			instanceType = pool.InstanceType
+		}
+		if pool.DiskCategory != "" {
+			diskType = pool.DiskCategory
+		}
+		if pool.DiskSize != "" {
+			diskSizeValue = pool.DiskSize
+		}
+		if pool.Spot {
+			chargeType = spotInstanceChargeType
+		}
+	}
+	diskSize, _ := strconv.ParseInt(diskSizeValue, 10, 64)
 	bandwidth, _ := strconv.ParseInt(p.InternetMaxBandwidthOut, 10, 64)
 
-	request.UserData = tencentCommon.StringPtr(p.UserDataContent)
+	userData, err := p.getUserData(master)
+	if err != nil {
+		return nil, err
+	}
+	request.UserData = tencentCommon.StringPtr(userData)
 	request.InstanceCount = tencentCommon.Int64Ptr(int64(num))
 	request.ImageId = tencentCommon.StringPtr(p.ImageID)
-	request.InstanceType = tencentCommon.StringPtr(p.InstanceType)
+	request.InstanceType = tencentCommon.StringPtr(instanceType)
 	request.Placement = &cvm.Placement{
 		Zone: tencentCommon.StringPtr(p.Zone),
 	}
-	request.InstanceChargeType = tencentCommon.StringPtr(p.InstanceChargeType)
+	request.InstanceChargeType = tencentCommon.StringPtr(chargeType)
+	p.setHostPlacement(request)
 	request.SecurityGroupIds = tencentCommon.StringPtrs(strings.Split(p.SecurityGroupIds, ","))
 	request.VirtualPrivateCloud = &cvm.VirtualPrivateCloud{
 		SubnetId: tencentCommon.StringPtr(p.SubnetID),
 		VpcId:    tencentCommon.StringPtr(p.VpcID),
 	}
+	if master && len(p.MasterPrivateIPs) > 0 {
+		// the masters of different roles are launched by separate requests, each takes the next ips.
+		launched := p.getLaunchedCount(true)
+		if launched+num > len(p.MasterPrivateIPs) {
+			return nil, fmt.Errorf("[%s] only %d ips of --master-private-ips are left for %d masters",
+				p.GetProviderName(), len(p.MasterPrivateIPs)-launched, num)
+		}
+		request.VirtualPrivateCloud.PrivateIpAddresses = tencentCommon.StringPtrs(p.MasterPrivateIPs[launched : launched+num])
+	}
 	request.SystemDisk = &cvm.SystemDisk{
-		DiskType: tencentCommon.StringPtr(p.SystemDiskType),
+		DiskType: tencentCommon.StringPtr(diskType),
 		DiskSize: tencentCommon.Int64Ptr(diskSize),
 	}
+	request.DataDisks = p.newDataDisks(diskType)
 	loginSettings := &cvm.LoginSettings{}
 	if password != "" {
 		loginSettings.Password = tencentCommon.StringPtr(password)
@@ -719,10 +1839,11 @@ func (p *Tencent) runInstances(num int, master bool, password string) error {
 		loginSettings.KeyIds = tencentCommon.StringPtrs([]string{p.KeypairID})
 	}
 	request.LoginSettings = loginSettings
+	// no public ip is assigned if eip is enabled, the instance either gets an eip associated or stays private.
 	request.InternetAccessible = &cvm.InternetAccessible{
 		InternetChargeType:      tencentCommon.StringPtr(internetChargeType),
 		InternetMaxBandwidthOut: tencentCommon.Int64Ptr(bandwidth),
-		PublicIpAssigned:        tencentCommon.BoolPtr(!p.PublicIPAssignedEIP),
+		PublicIpAssigned:        tencentCommon.BoolPtr(!p.eipEnabled()),
 	}
 
 	// set instance tags.
@@ -734,49 +1855,118 @@ func (p *Tencent) runInstances(num int, master bool, password string) error {
 	for _, v := range p.Tags {
 		ss := strings.Split(v, "=")
 		if len(ss) != 2 {
-			return fmt.Errorf("tags %s invalid", v)
+			return nil, fmt.Errorf("tags %s invalid", v)
 		}
 		tags = append(tags, &cvm.Tag{Key: tencentCommon.StringPtr(ss[0]), Value: tencentCommon.StringPtr(ss[1])})
 	}
 
+	instanceName, err := p.generateInstanceName(master, num)
+	if err != nil {
+		return nil, err
+	}
+	request.InstanceName = tencentCommon.StringPtr(instanceName)
 	if master {
-		request.InstanceName = tencentCommon.StringPtr(fmt.Sprintf(common.MasterInstanceName, p.ContextName))
 		tags = append(tags, &cvm.Tag{Key: tencentCommon.StringPtr("master"), Value: tencentCommon.StringPtr("true")})
 	} else {
-		request.InstanceName = tencentCommon.StringPtr(fmt.Sprintf(common.WorkerInstanceName, p.ContextName))
 		tags = append(tags, &cvm.Tag{Key: tencentCommon.StringPtr("worker"), Value: tencentCommon.StringPtr("true")})
 	}
+	if pool != nil {
+		tags = append(tags, &cvm.Tag{Key: tencentCommon.StringPtr(poolTagKey), Value: tencentCommon.StringPtr(pool.Name)})
+	}
 	request.TagSpecification = []*cvm.TagSpecification{{ResourceType: tencentCommon.StringPtr("instance"), Tags: tags}}
 
-	response, err := p.c.RunInstances(request)
-	if err != nil || len(response.Response.InstanceIdSet) != num {
-		return fmt.Errorf("[%s] calling runInstances error. region: %s, zone: %s, "+"instanceName: %s, msg: [%v]",
-			p.GetProviderName(), p.Region, p.Zone, *request.InstanceName, err)
+	return request, nil
+}
+
+func (p *Tencent) generateInstanceName(master bool, num int) (string, error) {
+	if p.InstanceNameTemplate == "" {
+		if master {
+			return fmt.Sprintf(common.MasterInstanceName, p.ContextName), nil
+		}
+		return fmt.Sprintf(common.WorkerInstanceName, p.ContextName), nil
 	}
-	for _, id := range response.Response.InstanceIdSet {
-		p.M.Store(*id, types.Node{Master: master, RollBack: true, InstanceID: *id, InstanceStatus: tencent.StatusPending})
+	role := "worker"
+	start := len(p.WorkerNodes) + 1
+	if master {
+		role = "master"
+		start = len(p.MasterNodes) + 1
+	}
+	// the indexes continue from the instances launched by the previous requests.
+	start += p.getLaunchedCount(master)
+	// validate with the largest index which will be generated.
+	if err := validateInstanceName(renderInstanceName(p.InstanceNameTemplate, p.Name, role, p.Zone, strconv.Itoa(start+num-1))); err != nil {
+		return "", fmt.Errorf("[%s] invalid --instance-name-template %s: %v", p.GetProviderName(), p.InstanceNameTemplate, err)
+	}
+	index := strconv.Itoa(start)
+	if num > 1 {
+		// tencent cloud will generate ascending numbers from `start` for batch instances.
+		index = fmt.Sprintf("{R:%d}", start)
+	}
+	return renderInstanceName(p.InstanceNameTemplate, p.Name, role, p.Zone, index), nil
+}
+
+// getNodeHostname returns the hostname of instance if --set-hostname is enabled, which is also the K3s node name.
+// The instance name is used if it's unique by {index}, otherwise the instance id is used.
+// The node name is the private ip if the cloud controller manager is enabled, so the hostname isn't set.
+func (p *Tencent) getNodeHostname(instance *cvm.Instance) string {
+	if !p.SetHostname || p.CloudControllerManager {
+		return ""
+	}
+	if strings.Contains(p.InstanceNameTemplate, "{index}") && instance.InstanceName != nil {
+		name := strings.Trim(strings.ReplaceAll(strings.ToLower(*instance.InstanceName), "_", "-"), "-.")
+		if len(validation.IsDNS1123Subdomain(name)) == 0 {
+			return name
+		}
 	}
+	return strings.ToLower(*instance.InstanceId)
+}
+
+func renderInstanceName(tmpl, cluster, role, zone, index string) string {
+	replacer := strings.NewReplacer(
+		"{cluster}", cluster,
+		"{role}", role,
+		"{zone}", zone,
+		"{index}", index,
+	)
+	return replacer.Replace(tmpl)
+}
 
+func validateInstanceName(name string) error {
+	if len(name) > maxInstanceNameLength {
+		return fmt.Errorf("instance name %s is longer than %d characters", name, maxInstanceNameLength)
+	}
+	if !instanceNameRegexp.MatchString(name) {
+		return fmt.Errorf("instance name %s contains unsupported placeholders or characters, only letters, numbers, '.', '-' and '_' are allowed", name)
+	}
 	return nil
 }
 
 func (p *Tencent) describeInstances() ([]*cvm.Instance, error) {
-	request := cvm.NewDescribeInstancesRequest()
-
-	limit := int64(20)
-	request.Limit = tencentCommon.Int64Ptr(limit)
 	// If there are multiple Filters, between the Filters is a logical AND (AND).
 	// If there are multiple Values in the same Filter, between Values under the same Filter is a logical OR (OR).
-	request.Filters = []*cvm.Filter{
+	return p.describeInstancesByFilters([]*cvm.Filter{
 		{Name: tencentCommon.StringPtr("tag:autok3s"), Values: tencentCommon.StringPtrs([]string{"true"})},
 		{Name: tencentCommon.StringPtr("tag:cluster"), Values: tencentCommon.StringPtrs([]string{common.TagClusterPrefix + p.ContextName})},
-	}
+	})
+}
+
+func (p *Tencent) describeInstancesByFilters(filters []*cvm.Filter) ([]*cvm.Instance, error) {
+	request := cvm.NewDescribeInstancesRequest()
+
+	// the api doesn't support projecting the fields of instances, so the page size is the only way to reduce round trips.
+	limit := p.getAPIPageSize()
+	request.Limit = tencentCommon.Int64Ptr(limit)
+	request.Filters = filters
 	offset := int64(0)
 	index := int64(0)
 	instanceList := make([]*cvm.Instance, 0)
 	for {
-		response, err := p.c.DescribeInstances(request)
-		if err != nil {
+		// a throttled page is retried, so it doesn't abort the preflight or deletion of the cluster.
+		var response *cvm.DescribeInstancesResponse
+		if err := p.retryTransient("describeInstances", func() (err error) {
+			response, err = p.c.DescribeInstances(request)
+			return err
+		}); err != nil {
 			return nil, err
 		}
 		if response.Response == nil || response.Response.InstanceSet == nil || len(response.Response.InstanceSet) == 0 {
@@ -805,13 +1995,82 @@ func (p *Tencent) terminateInstances(instanceIds []string) error {
 	_, err := p.c.TerminateInstances(request)
 
 	if err != nil {
-		return fmt.Errorf("[%s] calling deleteInstance error, msg: %v", p.GetProviderName(), err)
+		return fmt.Errorf("[%s] calling deleteInstance error, msg: %v", p.GetProviderName(), err)
+	}
+
+	return nil
+}
+
+// resetInstanceType stops the instance, changes its type and starts it again.
+func (p *Tencent) resetInstanceType(instanceID, instanceType string) error {
+	stopRequest := cvm.NewStopInstancesRequest()
+	stopRequest.InstanceIds = tencentCommon.StringPtrs([]string{instanceID})
+	if _, err := p.c.StopInstances(stopRequest); err != nil {
+		return fmt.Errorf("[%s] calling stopInstances error, msg: %v", p.GetProviderName(), err)
+	}
+	if err := p.waitForInstanceState(instanceID, tencent.StatusStopped); err != nil {
+		return err
+	}
+
+	resetRequest := cvm.NewResetInstancesTypeRequest()
+	resetRequest.InstanceIds = tencentCommon.StringPtrs([]string{instanceID})
+	resetRequest.InstanceType = tencentCommon.StringPtr(instanceType)
+	if _, err := p.c.ResetInstancesType(resetRequest); err != nil {
+		return fmt.Errorf("[%s] calling resetInstancesType error, msg: %v", p.GetProviderName(), err)
+	}
+	// the instance keeps stopped after its type is changed.
+	if err := p.waitForInstanceState(instanceID, tencent.StatusStopped); err != nil {
+		return err
+	}
+
+	startRequest := cvm.NewStartInstancesRequest()
+	startRequest.InstanceIds = tencentCommon.StringPtrs([]string{instanceID})
+	if _, err := p.c.StartInstances(startRequest); err != nil {
+		return fmt.Errorf("[%s] calling startInstances error, msg: %v", p.GetProviderName(), err)
+	}
+	return p.waitForInstanceState(instanceID, tencent.StatusRunning)
+}
+
+// waitForInstanceState waits for the instance to be in aim state and its latest operation to be finished.
+func (p *Tencent) waitForInstanceState(instanceID, aimState string) error {
+	p.Logger.Infof("[%s] waiting for the instance %s to be in `%s` status...", p.GetProviderName(), instanceID, aimState)
+	request := cvm.NewDescribeInstancesRequest()
+	request.InstanceIds = tencentCommon.StringPtrs([]string{instanceID})
+
+	return wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		response, err := p.c.DescribeInstances(request)
+		if err != nil || len(response.Response.InstanceSet) <= 0 {
+			return false, nil
+		}
+		instance := response.Response.InstanceSet[0]
+		if instance.LatestOperationState != nil {
+			switch *instance.LatestOperationState {
+			case "OPERATING":
+				return false, nil
+			case tencent.Failed:
+				return true, fmt.Errorf("[%s] latest operation of instance %s failed", p.GetProviderName(), instanceID)
+			}
+		}
+		return instance.InstanceState != nil && *instance.InstanceState == aimState, nil
+	})
+}
+
+func (p *Tencent) allocateAddresses(num int) ([]*string, uint64, error) {
+	request := p.newAllocateAddressesRequest(num)
+	response, err := p.v.AllocateAddresses(request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("[%s] calling allocateAddresses error, msg: %v", p.GetProviderName(), err)
+	}
+	taskID, err := strconv.ParseUint(*response.Response.TaskId, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("[%s] error when convert taskID: %s", p.GetProviderName(), *response.Response.TaskId)
 	}
-
-	return nil
+	p.SaveOperation(strconv.FormatUint(taskID, 10), "AllocateAddresses", types.OperationRunning)
+	return response.Response.AddressSet, taskID, nil
 }
 
-func (p *Tencent) allocateAddresses(num int) ([]*string, uint64, error) {
+// newAllocateAddressesRequest builds the request of allocating eips, it's shared by the price inquiry.
+func (p *Tencent) newAllocateAddressesRequest(num int) *vpc.AllocateAddressesRequest {
 	request := vpc.NewAllocateAddressesRequest()
 
 	request.AddressCount = tencentCommon.Int64Ptr(int64(num))
@@ -822,15 +2081,7 @@ func (p *Tencent) allocateAddresses(num int) ([]*string, uint64, error) {
 		{Key: tencentCommon.StringPtr("autok3s"), Value: tencentCommon.StringPtr("true")},
 		{Key: tencentCommon.StringPtr("cluster"), Value: tencentCommon.StringPtr(common.TagClusterPrefix + p.ContextName)},
 	}
-	response, err := p.v.AllocateAddresses(request)
-	if err != nil {
-		return nil, 0, fmt.Errorf("[%s] calling allocateAddresses error, msg: %v", p.GetProviderName(), err)
-	}
-	taskID, err := strconv.ParseUint(*response.Response.TaskId, 10, 64)
-	if err != nil {
-		return nil, 0, fmt.Errorf("[%s] error when convert taskID: %s", p.GetProviderName(), *response.Response.TaskId)
-	}
-	return response.Response.AddressSet, taskID, nil
+	return request
 }
 
 func (p *Tencent) releaseAddresses(addressIds []string) (uint64, error) {
@@ -847,6 +2098,7 @@ func (p *Tencent) releaseAddresses(addressIds []string) (uint64, error) {
 	if err != nil {
 		return 0, fmt.Errorf("[%s] error when convert taskID: %s", p.GetProviderName(), *response.Response.TaskId)
 	}
+	p.SaveOperation(strconv.FormatUint(taskID, 10), "ReleaseAddresses", types.OperationRunning)
 	return taskID, nil
 }
 
@@ -889,6 +2141,7 @@ func (p *Tencent) associateAddress(addressID, instanceID string) (uint64, error)
 	if err != nil {
 		return 0, fmt.Errorf("[%s] error when convert taskID: %s", p.GetProviderName(), *response.Response.TaskId)
 	}
+	p.SaveOperation(strconv.FormatUint(taskID, 10), "AssociateAddress", types.OperationRunning)
 	return taskID, nil
 }
 
@@ -909,6 +2162,7 @@ func (p *Tencent) disassociateAddress(addressID string) (uint64, error) {
 	if err != nil {
 		return 0, fmt.Errorf("[%s] error when convert taskID: %s", p.GetProviderName(), *response.Response.TaskId)
 	}
+	p.SaveOperation(strconv.FormatUint(taskID, 10), "DisassociateAddress", types.OperationRunning)
 	return taskID, nil
 }
 
@@ -922,19 +2176,67 @@ func (p *Tencent) describeVpcTaskResult(taskID uint64) error {
 			return false, nil
 		}
 
-		switch strings.ToUpper(*response.Response.Result) {
-		case tencent.Running:
+		status := toOperationStatus(*response.Response.Result)
+		if status == types.OperationRunning {
 			return false, nil
-		case tencent.Success:
-			return true, nil
-		case tencent.Failed:
+		}
+		p.updateOperation(strconv.FormatUint(taskID, 10), status)
+		if status == types.OperationFailed {
 			return true, fmt.Errorf("[%s] task failed %d", p.GetProviderName(), taskID)
 		}
-
 		return true, nil
 	})
 }
 
+// GetOperationStatus queries the result of the vpc task once, e.g. allocating or releasing eips, without waiting for it.
+func (p *Tencent) GetOperationStatus(taskID string) (*types.OperationStatus, error) {
+	id, err := strconv.ParseUint(taskID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] invalid task id %s", p.GetProviderName(), taskID)
+	}
+	o, err := p.GetOperation(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if p.v == nil {
+		if err = p.generateClientSDK(); err != nil {
+			return nil, err
+		}
+	}
+	request := vpc.NewDescribeTaskResultRequest()
+	request.TaskId = tencentCommon.Uint64Ptr(id)
+	response, err := p.v.DescribeTaskResult(request)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] calling describeTaskResult error, task: %s, msg: %v", p.GetProviderName(), taskID, err)
+	}
+	status := &types.OperationStatus{TaskID: taskID, Status: toOperationStatus(*response.Response.Result)}
+	if o != nil {
+		status.Action = o.Action
+		if o.Status != status.Status {
+			p.SaveOperation(taskID, o.Action, status.Status)
+		}
+	}
+	return status, nil
+}
+
+// updateOperation updates the status of the recorded operation when the task is done.
+func (p *Tencent) updateOperation(taskID, status string) {
+	if o, err := p.GetOperation(taskID); err == nil && o != nil {
+		p.SaveOperation(taskID, o.Action, status)
+	}
+}
+
+// toOperationStatus converts the result of tencent task, the unknown result is regarded as done as before.
+func toOperationStatus(result string) string {
+	switch strings.ToUpper(result) {
+	case tencent.Running:
+		return types.OperationRunning
+	case tencent.Failed:
+		return types.OperationFailed
+	}
+	return types.OperationSuccess
+}
+
 func (p *Tencent) getInstanceStatus(aimStatus string) error {
 	ids := make([]string, 0)
 	p.M.Range(func(key, value interface{}) bool {
@@ -992,13 +2294,37 @@ func (p *Tencent) describeResourcesByTags() ([]*tag.ResourceTag, error) {
 	return response.Response.Rows, err
 }
 
+// getVpcName returns the name of the default vpc, installs with different names use separate default networks.
+func (p *Tencent) getVpcName() string {
+	if p.VpcName != "" {
+		return p.VpcName
+	}
+	return defaultVpcName
+}
+
+// getSubnetName returns the name prefix of the default subnets, the subnet of each zone is suffixed by the zone.
+func (p *Tencent) getSubnetName() string {
+	if p.SubnetName != "" {
+		return p.SubnetName
+	}
+	return defaultSubnetName
+}
+
+// getSecurityGroupName returns the name of the default security group.
+func (p *Tencent) getSecurityGroupName() string {
+	if p.SecurityGroupName != "" {
+		return p.SecurityGroupName
+	}
+	return defaultSecurityGroupName
+}
+
 func (p *Tencent) configNetwork() error {
 	// find default vpc and subnet.
 	request := vpc.NewDescribeVpcsRequest()
 
 	request.Filters = []*vpc.Filter{
 		{
-			Values: tencentCommon.StringPtrs([]string{vpcName}),
+			Values: tencentCommon.StringPtrs([]string{p.getVpcName()}),
 			Name:   tencentCommon.StringPtr("vpc-name"),
 		},
 		{
@@ -1012,7 +2338,7 @@ func (p *Tencent) configNetwork() error {
 	}
 
 	if response != nil && response.Response != nil && len(response.Response.VpcSet) > 0 {
-		p.Logger.Infof("[%s] find existed default vpc %s for autok3s", p.GetProviderName(), vpcName)
+		p.Logger.Infof("[%s] find existed default vpc %s for autok3s", p.GetProviderName(), p.getVpcName())
 		defaultVPC := response.Response.VpcSet[0]
 		p.VpcID = *defaultVPC.VpcId
 		// find default subnet.
@@ -1023,6 +2349,10 @@ func (p *Tencent) configNetwork() error {
 				Name:   tencentCommon.StringPtr("tag:autok3s"),
 				Values: tencentCommon.StringPtrs([]string{"true"}),
 			},
+			{
+				Name:   tencentCommon.StringPtr("vpc-id"),
+				Values: tencentCommon.StringPtrs([]string{p.VpcID}),
+			},
 		}
 
 		resp, err := p.v.DescribeSubnets(args)
@@ -1032,9 +2362,9 @@ func (p *Tencent) configNetwork() error {
 
 		cidr := fmt.Sprintf("192.168.%d.0/24", utils.GenerateRand())
 		if resp != nil && resp.Response != nil && len(resp.Response.SubnetSet) > 0 {
-			p.Logger.Infof("[%s] find existed default subnet for vpc %s", p.GetProviderName(), vpcName)
+			p.Logger.Infof("[%s] find existed default subnet for vpc %s", p.GetProviderName(), p.getVpcName())
 			for _, subnet := range resp.Response.SubnetSet {
-				if *subnet.Zone == p.Zone && (*subnet.SubnetName == subnetName || *subnet.SubnetName == fmt.Sprintf("%s-%s", subnetName, p.Zone)) {
+				if *subnet.Zone == p.Zone && (*subnet.SubnetName == p.getSubnetName() || *subnet.SubnetName == fmt.Sprintf("%s-%s", p.getSubnetName(), p.Zone)) {
 					p.SubnetID = *subnet.SubnetId
 					break
 				} else if *subnet.CidrBlock == cidr {
@@ -1061,9 +2391,9 @@ func (p *Tencent) configNetwork() error {
 }
 
 func (p *Tencent) generateDefaultVPC() error {
-	p.Logger.Infof("[%s] generate default vpc %s in region %s", p.GetProviderName(), vpcName, p.Region)
+	p.Logger.Infof("[%s] generate default vpc %s in region %s", p.GetProviderName(), p.getVpcName(), p.Region)
 	request := vpc.NewCreateVpcRequest()
-	request.VpcName = tencentCommon.StringPtr(vpcName)
+	request.VpcName = tencentCommon.StringPtr(p.getVpcName())
 	request.CidrBlock = tencentCommon.StringPtr(vpcCidrBlock)
 	request.Tags = []*vpc.Tag{
 		{
@@ -1073,18 +2403,18 @@ func (p *Tencent) generateDefaultVPC() error {
 	}
 	response, err := p.v.CreateVpc(request)
 	if err != nil {
-		return fmt.Errorf("[%s] fail to create default vpc %s in region %s: %v", p.GetProviderName(), vpcName, p.Region, err)
+		return fmt.Errorf("[%s] fail to create default vpc %s in region %s: %v", p.GetProviderName(), p.getVpcName(), p.Region, err)
 	}
 
 	p.VpcID = *response.Response.Vpc.VpcId
-	p.Logger.Infof("[%s] generate default vpc %s in region %s successfully", p.GetProviderName(), vpcName, p.Region)
+	p.Logger.Infof("[%s] generate default vpc %s in region %s successfully", p.GetProviderName(), p.getVpcName(), p.Region)
 
 	return err
 }
 
 func (p *Tencent) generateDefaultSubnet(cidr string) error {
-	vsName := fmt.Sprintf("%s-%s", subnetName, p.Zone)
-	p.Logger.Infof("[%s] generate default subnet %s for vpc %s in region %s", p.GetProviderName(), vsName, vpcName, p.Region)
+	vsName := fmt.Sprintf("%s-%s", p.getSubnetName(), p.Zone)
+	p.Logger.Infof("[%s] generate default subnet %s for vpc %s in region %s", p.GetProviderName(), vsName, p.getVpcName(), p.Region)
 	request := vpc.NewCreateSubnetRequest()
 
 	request.Tags = []*vpc.Tag{
@@ -1106,12 +2436,12 @@ func (p *Tencent) generateDefaultSubnet(cidr string) error {
 		return fmt.Errorf("[%s] fail to create default subnet for vpc %s in region %s, zone %s: %v", p.GetProviderName(), p.VpcID, p.Region, p.Zone, err)
 	}
 	p.SubnetID = *response.Response.Subnet.SubnetId
-	p.Logger.Infof("[%s] generate default subnet %s for vpc %s in region %s successfully", p.GetProviderName(), subnetName, vpcName, p.Region)
+	p.Logger.Infof("[%s] generate default subnet %s for vpc %s in region %s successfully", p.GetProviderName(), vsName, p.getVpcName(), p.Region)
 	return nil
 }
 
 func (p *Tencent) configSecurityGroup() error {
-	p.Logger.Infof("[%s] check default security group %s in region %s", p.GetProviderName(), defaultSecurityGroupName, p.Region)
+	p.Logger.Infof("[%s] check default security group %s in region %s", p.GetProviderName(), p.getSecurityGroupName(), p.Region)
 	// find default security group.
 	request := vpc.NewDescribeSecurityGroupsRequest()
 
@@ -1121,7 +2451,7 @@ func (p *Tencent) configSecurityGroup() error {
 			Name:   tencentCommon.StringPtr("tag:autok3s"),
 		},
 		{
-			Values: tencentCommon.StringPtrs([]string{defaultSecurityGroupName}),
+			Values: tencentCommon.StringPtrs([]string{p.getSecurityGroupName()}),
 			Name:   tencentCommon.StringPtr("security-group-name"),
 		},
 	}
@@ -1138,10 +2468,10 @@ func (p *Tencent) configSecurityGroup() error {
 
 	if securityGroupID == "" {
 		// create default security group.
-		p.Logger.Infof("[%s] create default security group %s in region %s", p.GetProviderName(), defaultSecurityGroupName, p.Region)
+		p.Logger.Infof("[%s] create default security group %s in region %s", p.GetProviderName(), p.getSecurityGroupName(), p.Region)
 		err = p.generateDefaultSecurityGroup()
 		if err != nil {
-			return fmt.Errorf("[%s] fail to create default security group %s: %v", p.GetProviderName(), defaultSecurityGroupName, err)
+			return fmt.Errorf("[%s] fail to create default security group %s: %v", p.GetProviderName(), p.getSecurityGroupName(), err)
 		}
 	}
 	err = p.configDefaultSecurityPermission()
@@ -1152,13 +2482,12 @@ func (p *Tencent) configSecurityGroup() error {
 func (p *Tencent) generateDefaultSecurityGroup() error {
 	request := vpc.NewCreateSecurityGroupRequest()
 
-	request.Tags = []*vpc.Tag{
-		{
-			Key:   tencentCommon.StringPtr("autok3s"),
-			Value: tencentCommon.StringPtr("true"),
-		},
+	tags, err := p.getSecurityGroupTags()
+	if err != nil {
+		return err
 	}
-	request.GroupName = tencentCommon.StringPtr(defaultSecurityGroupName)
+	request.Tags = tags
+	request.GroupName = tencentCommon.StringPtr(p.getSecurityGroupName())
 	request.GroupDescription = tencentCommon.StringPtr("generated by autok3s")
 
 	response, err := p.v.CreateSecurityGroup(request)
@@ -1190,20 +2519,60 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 	} else {
 		cidr = subnetCidrBlock
 	}
+	var set *vpc.SecurityGroupPolicySet
+	if response != nil && response.Response != nil {
+		set = response.Response.SecurityGroupPolicySet
+	}
+	ingress, egress, err := p.getMissingSecurityPolicies(set, cidr)
+	if err != nil {
+		return err
+	}
+
+	if len(ingress) > 0 {
+		args := vpc.NewCreateSecurityGroupPoliciesRequest()
+		args.SecurityGroupId = tencentCommon.StringPtr(p.SecurityGroupIds)
+		args.SecurityGroupPolicySet = &vpc.SecurityGroupPolicySet{
+			Ingress: ingress,
+		}
+		_, err = p.v.CreateSecurityGroupPolicies(args)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(egress) > 0 {
+		args := vpc.NewCreateSecurityGroupPoliciesRequest()
+		args.SecurityGroupId = tencentCommon.StringPtr(p.SecurityGroupIds)
+		args.SecurityGroupPolicySet = &vpc.SecurityGroupPolicySet{
+			Egress: egress,
+		}
+		_, err = p.v.CreateSecurityGroupPolicies(args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getMissingSecurityPolicies returns the ingress and egress policies required by K3s which are missing in the set,
+// policies which already exist with the same protocol, port, cidr and action are skipped,
+// so that clusters sharing the security group don't accumulate duplicated rules.
+func (p *Tencent) getMissingSecurityPolicies(set *vpc.SecurityGroupPolicySet, cidr string) ([]*vpc.SecurityGroupPolicy, []*vpc.SecurityGroupPolicy, error) {
 	hasSSHPort := false
 	hasAPIServerPort := false
 	hasKubeletPort := false
-	hasVXlanPort := false
 	hasEgress := false
 	hasEtcdServerPort := false
 	hasEtcdPeerPort := false
-	if response != nil && response.Response != nil &&
-		response.Response.SecurityGroupPolicySet != nil && response.Response.SecurityGroupPolicySet.Ingress != nil {
-		rules := response.Response.SecurityGroupPolicySet.Ingress
+	openPorts := map[string]bool{}
+	if set != nil && set.Ingress != nil {
+		rules := set.Ingress
 		for _, rule := range rules {
 			ports := *rule.Port
 			portArray := strings.Split(ports, ",")
 			for _, p := range portArray {
+				openPorts[p] = true
 				fromPort, _ := strconv.Atoi(p)
 				switch fromPort {
 				case 22:
@@ -1212,8 +2581,6 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 					hasAPIServerPort = true
 				case 10250:
 					hasKubeletPort = true
-				case 8472:
-					hasVXlanPort = true
 				case 2379:
 					if *rule.CidrBlock == cidr || *rule.CidrBlock == ipRange {
 						hasEtcdServerPort = true
@@ -1226,7 +2593,7 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 			}
 
 		}
-		eRules := response.Response.SecurityGroupPolicySet.Egress
+		eRules := set.Egress
 		if len(eRules) > 0 {
 			hasEgress = true
 		}
@@ -1234,6 +2601,7 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 
 	perms := make([]*vpc.SecurityGroupPolicy, 0)
 
+	p.explainSecurityRule("ingress", "TCP", "22", ipRange, hasSSHPort)
 	if !hasSSHPort {
 		perms = append(perms, &vpc.SecurityGroupPolicy{
 			Protocol:          tencentCommon.StringPtr("TCP"),
@@ -1244,18 +2612,27 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 		})
 	}
 
-	if (p.Network == "" || p.Network == "vxlan") && !hasVXlanPort {
-		// udp 8472 for flannel vxLan.
-		perms = append(perms, &vpc.SecurityGroupPolicy{
-			Protocol:          tencentCommon.StringPtr("UDP"),
-			Port:              tencentCommon.StringPtr("8472"),
-			CidrBlock:         tencentCommon.StringPtr(ipRange),
-			Action:            tencentCommon.StringPtr("ACCEPT"),
-			PolicyDescription: tencentCommon.StringPtr("accept for k3s vxlan(generated by autok3s)"),
-		})
+	// ports for the pod network between nodes, e.g. udp 8472 for flannel vxlan.
+	cniPorts := cluster.GetCNIPorts(p.CNI, p.Network)
+	if len(cniPorts) == 0 {
+		p.Logger.Debugf("[%s] security group %s: no port is required by cni %q and flannel backend %q, skip",
+			p.GetProviderName(), p.SecurityGroupIds, p.CNI, p.Network)
+	}
+	for _, port := range cniPorts {
+		p.explainSecurityRule("ingress", port.Protocol, port.Port, ipRange, openPorts[port.Port])
+		if !openPorts[port.Port] {
+			perms = append(perms, &vpc.SecurityGroupPolicy{
+				Protocol:          tencentCommon.StringPtr(port.Protocol),
+				Port:              tencentCommon.StringPtr(port.Port),
+				CidrBlock:         tencentCommon.StringPtr(ipRange),
+				Action:            tencentCommon.StringPtr("ACCEPT"),
+				PolicyDescription: tencentCommon.StringPtr(fmt.Sprintf("accept for %s(generated by autok3s)", port.Description)),
+			})
+		}
 	}
 
 	// port 6443 for kubernetes api-server.
+	p.explainSecurityRule("ingress", "TCP", "6443", ipRange, hasAPIServerPort)
 	if !hasAPIServerPort {
 		perms = append(perms, &vpc.SecurityGroupPolicy{
 			Protocol:          tencentCommon.StringPtr("TCP"),
@@ -1267,6 +2644,7 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 	}
 
 	// 10250 for kubelet.
+	p.explainSecurityRule("ingress", "TCP", "10250", ipRange, hasKubeletPort)
 	if !hasKubeletPort {
 		perms = append(perms, &vpc.SecurityGroupPolicy{
 			Protocol:          tencentCommon.StringPtr("TCP"),
@@ -1277,6 +2655,9 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 		})
 	}
 
+	// etcd rules are created in pairs, so both of them are created if any one is missing.
+	p.explainSecurityRule("ingress", "TCP", "2379", cidr, hasEtcdServerPort && hasEtcdPeerPort)
+	p.explainSecurityRule("ingress", "TCP", "2380", cidr, hasEtcdServerPort && hasEtcdPeerPort)
 	if !hasEtcdServerPort || !hasEtcdPeerPort {
 		perms = append(perms, &vpc.SecurityGroupPolicy{
 			Protocol:          tencentCommon.StringPtr("TCP"),
@@ -1294,57 +2675,107 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 		})
 	}
 
-	if len(perms) > 0 {
-		args := vpc.NewCreateSecurityGroupPoliciesRequest()
-		args.SecurityGroupId = tencentCommon.StringPtr(p.SecurityGroupIds)
-		args.SecurityGroupPolicySet = &vpc.SecurityGroupPolicySet{
-			Ingress: perms,
-		}
-		_, err = p.v.CreateSecurityGroupPolicies(args)
-		if err != nil {
-			return err
-		}
+	var existIngress, existEgress []*vpc.SecurityGroupPolicy
+	if set != nil {
+		existIngress, existEgress = set.Ingress, set.Egress
 	}
 
 	// check egress.
-	if !hasEgress {
-		args := vpc.NewCreateSecurityGroupPoliciesRequest()
-		args.SecurityGroupId = tencentCommon.StringPtr(p.SecurityGroupIds)
-		args.SecurityGroupPolicySet = &vpc.SecurityGroupPolicySet{
-			Egress: []*vpc.SecurityGroupPolicy{
-				{
-					Protocol:          tencentCommon.StringPtr("ALL"),
-					Port:              tencentCommon.StringPtr("all"),
-					CidrBlock:         tencentCommon.StringPtr(ipRange),
-					Action:            tencentCommon.StringPtr("ACCEPT"),
-					PolicyDescription: tencentCommon.StringPtr("allow all egress(generated by autok3s)"),
-				},
-			},
+	ePerms := make([]*vpc.SecurityGroupPolicy, 0)
+	if p.RestrictEgress {
+		// the existing policies are kept, they may be used by the other clusters sharing the security group.
+		if hasAllowAllEgress(existEgress) {
+			p.Logger.Warnf("[%s] security group %s already allows all egress, remove the policy manually to restrict egress",
+				p.GetProviderName(), p.SecurityGroupIds)
 		}
-		_, err = p.v.CreateSecurityGroupPolicies(args)
-		if err != nil {
-			return err
+		ePerms = p.getRestrictedEgressPolicies(cidr)
+	} else {
+		p.explainSecurityRule("egress", "ALL", "all", ipRange, hasEgress)
+		if !hasEgress {
+			ePerms = append(ePerms, newAcceptPolicy("ALL", "all", ipRange, "allow all egress"))
 		}
 	}
+	perms = dedupSecurityPolicies(existIngress, perms)
+	ePerms = dedupSecurityPolicies(existEgress, ePerms)
+	if len(existIngress)+len(perms) > maxSecurityGroupPolicies || len(existEgress)+len(ePerms) > maxSecurityGroupPolicies {
+		return nil, nil, fmt.Errorf("[%s] security group %s will exceed the limit of %d policies per direction, please clean up unused policies or use another security group",
+			p.GetProviderName(), p.SecurityGroupIds, maxSecurityGroupPolicies)
+	}
+	return perms, ePerms, nil
+}
 
-	return nil
+type securityPolicyKey struct {
+	protocol, port, cidr, action string
 }
 
-func (p *Tencent) allocateEIPForInstance(num int, master bool) ([]uint64, error) {
-	eipIds := make([]uint64, 0)
-	eips, taskID, err := p.allocateAddresses(num)
-	if err != nil {
-		return nil, err
+func newSecurityPolicyKey(policy *vpc.SecurityGroupPolicy) securityPolicyKey {
+	key := securityPolicyKey{}
+	if policy.Protocol != nil {
+		key.protocol = strings.ToUpper(*policy.Protocol)
 	}
-	if err = p.describeVpcTaskResult(taskID); err != nil {
-		p.Logger.Errorf("[%s] failed to allocate eip(s) for instance(s): taskId:[%d]", p.GetProviderName(), taskID)
-		return nil, err
+	if policy.Port != nil {
+		key.port = strings.ToLower(*policy.Port)
 	}
-	eipAddresses, err := p.describeAddresses(eips, nil)
-	if err != nil {
-		p.Logger.Errorf("[%s] error when query eip info:[%s]", p.GetProviderName(), tencentCommon.StringValues(eips))
-		return nil, err
+	if policy.CidrBlock != nil {
+		key.cidr = *policy.CidrBlock
+	}
+	if policy.Action != nil {
+		key.action = strings.ToUpper(*policy.Action)
+	}
+	return key
+}
+
+// dedupSecurityPolicies removes the policies which already exist or are duplicated with each other.
+func dedupSecurityPolicies(exist, policies []*vpc.SecurityGroupPolicy) []*vpc.SecurityGroupPolicy {
+	keys := make(map[securityPolicyKey]bool, len(exist)+len(policies))
+	for _, policy := range exist {
+		keys[newSecurityPolicyKey(policy)] = true
+	}
+	rtn := make([]*vpc.SecurityGroupPolicy, 0, len(policies))
+	for _, policy := range policies {
+		key := newSecurityPolicyKey(policy)
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		rtn = append(rtn, policy)
+	}
+	return rtn
+}
+
+// explainSecurityRule logs whether the required rule is present in security group or will be created,
+// it's only visible with debug log level and helps to find out why a custom security group doesn't get the expected rules.
+func (p *Tencent) explainSecurityRule(direction, protocol, port, cidr string, present bool) {
+	if present {
+		p.Logger.Debugf("[%s] security group %s: %s %s %s is present, skip", p.GetProviderName(), p.SecurityGroupIds, direction, protocol, port)
+		return
+	}
+	p.Logger.Debugf("[%s] security group %s: %s %s %s is missing, will be created with cidr %s",
+		p.GetProviderName(), p.SecurityGroupIds, direction, protocol, port, cidr)
+}
+
+func (p *Tencent) allocateEIPForInstance(num int, master bool) ([]uint64, error) {
+	eipIds := make([]uint64, 0)
+	// the existing eips set by --eip-address are used first.
+	eipAddresses := p.takeExternalEIPs(num)
+	if allocateNum := num - len(eipAddresses); allocateNum > 0 {
+		eips, taskID, err := p.allocateAddresses(allocateNum)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.describeVpcTaskResult(taskID); err != nil {
+			p.Logger.Errorf("[%s] failed to allocate eip(s) for instance(s): taskId:[%d]", p.GetProviderName(), taskID)
+			return nil, err
+		}
+		allocated, err := p.describeAddresses(eips, nil)
+		if err != nil {
+			p.Logger.Errorf("[%s] error when query eip info:[%s]", p.GetProviderName(), tencentCommon.StringValues(eips))
+			return nil, err
+		}
+		eipAddresses = append(eipAddresses, allocated...)
 	}
+	var err error
+	var taskID uint64
 
 	if eipAddresses != nil {
 		p.Logger.Infof("[%s] associating %d eip(s) for instance(s)", p.GetProviderName(), num)
@@ -1370,19 +2801,26 @@ func (p *Tencent) allocateEIPForInstance(num int, master bool) ([]uint64, error)
 }
 
 func (p *Tencent) uploadKeyPair(node types.Node, publicKey string) error {
-	dialer, err := dialer.NewSSHDialer(&node, true, p.Logger)
+	if err := p.waitForSSHReady(node); err != nil {
+		return err
+	}
+
+	sshDialer, err := dialer.NewSSHDialer(&node, true, p.Logger)
 	if err != nil {
+		if dialer.IsAuthError(err) {
+			return fmt.Errorf("[%s] authentication failed when uploading keypair to instance %s: %w", p.GetProviderName(), node.InstanceID, err)
+		}
 		return err
 	}
 
 	defer func() {
-		_ = dialer.Close()
+		_ = sshDialer.Close()
 	}()
 
 	command := fmt.Sprintf("mkdir -p ~/.ssh; echo '%s' > ~/.ssh/authorized_keys", strings.Trim(publicKey, "\n"))
 
 	p.Logger.Infof("[%s] upload the public key with command: %s", p.GetProviderName(), command)
-	output, err := dialer.ExecuteCommands(command)
+	output, err := sshDialer.ExecuteCommands(command)
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, output)
 	}
@@ -1392,6 +2830,168 @@ func (p *Tencent) uploadKeyPair(node types.Node, publicKey string) error {
 	return nil
 }
 
+// waitForSSHReady waits until the sshd of the instance accepts connections,
+// the instance is in running status before sshd is started.
+func (p *Tencent) waitForSSHReady(node types.Node) error {
+	// the private address behind the bastion can't be probed directly, the ssh dialer retries instead.
+	if node.SSHBastion != "" || (len(node.PublicIPAddress) == 0 && len(node.InternalIPAddress) == 0) {
+		return nil
+	}
+	if node.SSHPort == "" {
+		node.SSHPort = "22"
+	}
+	// the addresses are probed in the order of the ssh dialer, the private one is the fallback of the public one.
+	addresses := dialer.GetSSHAddresses(&node)
+	if err := wait.ExponentialBackoff(sshReadyBackoff, func() (bool, error) {
+		for _, address := range addresses {
+			conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+			if err != nil {
+				p.Logger.Debugf("[%s] ssh %s of instance %s is not ready yet: %v", p.GetProviderName(), address, node.InstanceID, err)
+				continue
+			}
+			_ = conn.Close()
+			return true, nil
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("[%s] timed out waiting for ssh %s of instance %s to be ready", p.GetProviderName(), strings.Join(addresses, ","), node.InstanceID)
+	}
+	return nil
+}
+
+// runOnCurrentNodes runs fn on the instances added by the current command in parallel, the errors are joined.
+func (p *Tencent) runOnCurrentNodes(fn func(node types.Node) error) error {
+	nodes := make([]types.Node, 0)
+	p.M.Range(func(key, value interface{}) bool {
+		if v := value.(types.Node); v.Current {
+			nodes = append(nodes, v)
+		}
+		return true
+	})
+
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	errs := make([]string, 0)
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node types.Node) {
+			defer wg.Done()
+			if err := fn(node); err != nil {
+				l.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", node.InstanceID, err))
+				l.Unlock()
+			}
+		}(node)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// executeOnNode runs the commands on the instance over ssh once its sshd is ready.
+func (p *Tencent) executeOnNode(node types.Node, cmds ...string) error {
+	if err := p.waitForSSHReady(node); err != nil {
+		return err
+	}
+	sshDialer, err := dialer.NewSSHDialer(&node, true, p.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sshDialer.Close()
+	}()
+	output, err := sshDialer.ExecuteCommands(cmds...)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
+
+func (p *Tencent) getImageDefaultUser() string {
+	request := cvm.NewDescribeImagesRequest()
+	request.ImageIds = tencentCommon.StringPtrs([]string{p.ImageID})
+	response, err := p.c.DescribeImages(request)
+	if err != nil {
+		p.Logger.Warnf("[%s] failed to query image %s, fallback to ssh user %s: %v", p.GetProviderName(), p.ImageID, defaultUser, err)
+		return defaultUser
+	}
+	if response.Response == nil || len(response.Response.ImageSet) == 0 {
+		return defaultUser
+	}
+	image := response.Response.ImageSet[0]
+	if image.Platform != nil {
+		if user, ok := platformDefaultUsers[strings.ToLower(*image.Platform)]; ok {
+			return user
+		}
+	}
+	return defaultUser
+}
+
+// validateMasterPrivateIPs checks there is an ip of --master-private-ips for each master to launch, the masters of all
+// roles take the ips in order, so that none of them is launched with the ips of the others.
+func (p *Tencent) validateMasterPrivateIPs() error {
+	if len(p.MasterPrivateIPs) == 0 {
+		return nil
+	}
+	masterNum, _ := strconv.Atoi(p.Master)
+	if len(p.MasterPrivateIPs) != masterNum {
+		return fmt.Errorf("[%s] calling preflight error: got %d ips of `--master-private-ips` for %d masters to launch",
+			p.GetProviderName(), len(p.MasterPrivateIPs), masterNum)
+	}
+	return nil
+}
+
+// checkMasterPrivateIPs checks the ips set by --master-private-ips against the subnet and the existing nodes.
+func (p *Tencent) checkMasterPrivateIPs(masterNum int) error {
+	cidr := subnetCidrBlock
+	if p.SubnetID != "" {
+		c, err := p.getSubnetCidr()
+		if err != nil {
+			return err
+		}
+		cidr = c
+	}
+	used := map[string]bool{}
+	for _, nodes := range [][]types.Node{p.MasterNodes, p.WorkerNodes} {
+		for _, n := range nodes {
+			for _, ip := range n.InternalIPAddress {
+				used[ip] = true
+			}
+		}
+	}
+	if err := validatePrivateIPs(p.MasterPrivateIPs, masterNum, cidr, used); err != nil {
+		return fmt.Errorf("[%s] invalid --master-private-ips: %v", p.GetProviderName(), err)
+	}
+	return nil
+}
+
+func validatePrivateIPs(ips []string, num int, cidr string, used map[string]bool) error {
+	if len(ips) != num {
+		return fmt.Errorf("got %d ips for %d masters", len(ips), num)
+	}
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("failed to parse subnet cidr %s: %v", cidr, err)
+	}
+	seen := map[string]bool{}
+	for _, ip := range ips {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return fmt.Errorf("%s is not a valid ip", ip)
+		}
+		if !subnet.Contains(parsed) {
+			return fmt.Errorf("%s is not within subnet %s", ip, cidr)
+		}
+		if seen[ip] || used[ip] {
+			return fmt.Errorf("%s is already in use", ip)
+		}
+		seen[ip] = true
+	}
+	return nil
+}
+
 func (p *Tencent) getSubnetCidr() (string, error) {
 	request := vpc.NewDescribeSubnetsRequest()
 	request.SubnetIds = tencentCommon.StringPtrs([]string{p.SubnetID})
@@ -1405,3 +3005,113 @@ func (p *Tencent) getSubnetCidr() (string, error) {
 	}
 	return "", nil
 }
+
+func (p *Tencent) getPrivateZoneID() (string, error) {
+	request := &describePrivateZoneListRequest{}
+	request.Filters = []*privateDNSFilter{
+		{Name: tencentCommon.StringPtr("Domain"), Values: tencentCommon.StringPtrs([]string{p.PrivateDNSZone})},
+	}
+	response, err := p.d.DescribePrivateZoneList(request)
+	if err != nil {
+		return "", fmt.Errorf("[%s] calling describePrivateZoneList error, msg: %v", p.GetProviderName(), err)
+	}
+	if response.Response != nil {
+		for _, zone := range response.Response.PrivateZoneSet {
+			if strings.EqualFold(*zone.Domain, p.PrivateDNSZone) {
+				return *zone.ZoneID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("[%s] private dns zone %s is not exist", p.GetProviderName(), p.PrivateDNSZone)
+}
+
+func (p *Tencent) registerPrivateDNSRecords() error {
+	zoneID, err := p.getPrivateZoneID()
+	if err != nil {
+		return err
+	}
+	var nodes []types.Node
+	p.M.Range(func(key, value interface{}) bool {
+		v := value.(types.Node)
+		if v.Current && len(v.InternalIPAddress) > 0 {
+			nodes = append(nodes, v)
+		}
+		return true
+	})
+	for _, node := range nodes {
+		name := getPrivateDNSRecordName(node.LocalHostname, node.InstanceID)
+		request := &createPrivateZoneRecordRequest{
+			ZoneID:      tencentCommon.StringPtr(zoneID),
+			RecordType:  tencentCommon.StringPtr("A"),
+			SubDomain:   tencentCommon.StringPtr(name),
+			RecordValue: tencentCommon.StringPtr(node.InternalIPAddress[0]),
+			TTL:         tencentCommon.Int64Ptr(privateDNSRecordTTL),
+		}
+		response, err := p.d.CreatePrivateZoneRecord(request)
+		if err != nil {
+			return fmt.Errorf("[%s] calling createPrivateZoneRecord error, msg: %v", p.GetProviderName(), err)
+		}
+		// the private dns records don't support tags, the record id is saved with the node so that only
+		// the records created by autok3s are removed with the node.
+		if response.Response != nil && response.Response.RecordID != nil {
+			node.PrivateDNSRecord = *response.Response.RecordID
+			p.M.Store(node.InstanceID, node)
+		}
+		p.Logger.Infof("[%s] registered private dns record %s.%s -> %s", p.GetProviderName(), name, p.PrivateDNSZone, node.InternalIPAddress[0])
+	}
+	return nil
+}
+
+// getPrivateDNSRecordName returns the sub domain of the node's record, which is the K3s node name set by
+// --set-hostname, or the instance id in lower case, the same as the hostname of the instance id.
+func getPrivateDNSRecordName(hostname, instanceID string) string {
+	if hostname != "" {
+		return hostname
+	}
+	return strings.ToLower(instanceID)
+}
+
+// getPrivateDNSRecords returns the ids of the private dns records registered for the instances, which are saved
+// with the nodes of the current command or the cluster state.
+func (p *Tencent) getPrivateDNSRecords(instanceIds []string) []string {
+	instances := make(map[string]bool, len(instanceIds))
+	for _, id := range instanceIds {
+		instances[id] = true
+	}
+	recordIds := make([]string, 0)
+	found := map[string]bool{}
+	addRecord := func(node types.Node) {
+		if instances[node.InstanceID] && node.PrivateDNSRecord != "" && !found[node.PrivateDNSRecord] {
+			found[node.PrivateDNSRecord] = true
+			recordIds = append(recordIds, node.PrivateDNSRecord)
+		}
+	}
+	p.M.Range(func(key, value interface{}) bool {
+		addRecord(value.(types.Node))
+		return true
+	})
+	for _, node := range append(append([]types.Node{}, p.MasterNodes...), p.WorkerNodes...) {
+		addRecord(node)
+	}
+	return recordIds
+}
+
+func (p *Tencent) deregisterPrivateDNSRecords(instanceIds []string) error {
+	recordIds := p.getPrivateDNSRecords(instanceIds)
+	if len(recordIds) == 0 {
+		return nil
+	}
+	zoneID, err := p.getPrivateZoneID()
+	if err != nil {
+		return err
+	}
+	args := &deletePrivateZoneRecordRequest{
+		ZoneID:      tencentCommon.StringPtr(zoneID),
+		RecordIDSet: tencentCommon.StringPtrs(recordIds),
+	}
+	if _, err = p.d.DeletePrivateZoneRecord(args); err != nil {
+		return fmt.Errorf("[%s] calling deletePrivateZoneRecord error, msg: %v", p.GetProviderName(), err)
+	}
+	p.Logger.Infof("[%s] removed private dns records %s from zone %s", p.GetProviderName(), recordIds, p.PrivateDNSZone)
+	return nil
+}
@@ -0,0 +1,1574 @@
+package tencent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/types/tencent"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	kms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/kms/v20190118"
+	tag "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tag/v20180813"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGenerateInstanceName(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.Name = "demo"
+	p.Zone = "ap-guangzhou-6"
+	p.ContextName = "demo.ap-guangzhou.tencent"
+
+	name, err := p.generateInstanceName(true, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "autok3s.demo.ap-guangzhou.tencent.master", name)
+
+	p.InstanceNameTemplate = "k3s-{cluster}-{role}-{index}-{zone}"
+	name, err = p.generateInstanceName(true, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "k3s-demo-master-1-ap-guangzhou-6", name)
+
+	name, err = p.generateInstanceName(false, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "k3s-demo-worker-{R:1}-ap-guangzhou-6", name)
+
+	p.InstanceNameTemplate = "k3s-{cluster}-{unknown}"
+	_, err = p.generateInstanceName(true, 1)
+	assert.NotNil(t, err)
+
+	p.InstanceNameTemplate = "k3s {cluster}"
+	_, err = p.generateInstanceName(true, 1)
+	assert.NotNil(t, err)
+
+	p.InstanceNameTemplate = strings.Repeat("a", 59) + "-{index}"
+	_, err = p.generateInstanceName(true, 1)
+	assert.NotNil(t, err)
+}
+
+func TestValidatePrivateIPs(t *testing.T) {
+	cidr := "192.168.3.0/24"
+	assert.Nil(t, validatePrivateIPs([]string{"192.168.3.10", "192.168.3.11"}, 2, cidr, nil))
+	assert.NotNil(t, validatePrivateIPs([]string{"192.168.3.10"}, 2, cidr, nil))
+	assert.NotNil(t, validatePrivateIPs([]string{"192.168.4.10"}, 1, cidr, nil))
+	assert.NotNil(t, validatePrivateIPs([]string{"192.168.3"}, 1, cidr, nil))
+	assert.NotNil(t, validatePrivateIPs([]string{"192.168.3.10", "192.168.3.10"}, 2, cidr, nil))
+	assert.NotNil(t, validatePrivateIPs([]string{"192.168.3.10"}, 1, cidr, map[string]bool{"192.168.3.10": true}))
+}
+
+func TestGetMissingSecurityPolicies(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.Logger = logrus.New()
+	policy := func(protocol, port, cidr string) *vpc.SecurityGroupPolicy {
+		return &vpc.SecurityGroupPolicy{
+			Protocol:  tencentCommon.StringPtr(protocol),
+			Port:      tencentCommon.StringPtr(port),
+			CidrBlock: tencentCommon.StringPtr(cidr),
+			Action:    tencentCommon.StringPtr("ACCEPT"),
+		}
+	}
+	set := &vpc.SecurityGroupPolicySet{
+		Ingress: []*vpc.SecurityGroupPolicy{
+			policy("TCP", "22", ipRange),
+			policy("tcp", "6443", ipRange),
+			// etcd server rule of the same subnet is added by another cluster.
+			policy("TCP", "2379", "192.168.3.0/24"),
+		},
+		Egress: []*vpc.SecurityGroupPolicy{
+			policy("ALL", "all", ipRange),
+		},
+	}
+
+	ingress, egress, err := p.getMissingSecurityPolicies(set, "192.168.3.0/24")
+	assert.Nil(t, err)
+	assert.Empty(t, egress)
+	ports := make([]string, 0)
+	for _, policy := range ingress {
+		ports = append(ports, *policy.Port)
+	}
+	assert.Equal(t, []string{"8472", "10250", "2380"}, ports)
+
+	for i := 0; i < maxSecurityGroupPolicies; i++ {
+		set.Ingress = append(set.Ingress, policy("TCP", "80", ipRange))
+	}
+	_, _, err = p.getMissingSecurityPolicies(set, "192.168.3.0/24")
+	assert.NotNil(t, err)
+}
+
+func TestGetRestrictedEgressPolicies(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.Logger = logrus.New()
+	p.RestrictEgress = true
+	set := &vpc.SecurityGroupPolicySet{}
+
+	_, egress, err := p.getMissingSecurityPolicies(set, "192.168.3.0/24")
+	assert.Nil(t, err)
+	assert.Len(t, egress, 6)
+	assert.False(t, hasAllowAllEgress(egress))
+
+	// the policies are only created once.
+	set.Egress = egress
+	_, egress, err = p.getMissingSecurityPolicies(set, "192.168.3.0/24")
+	assert.Nil(t, err)
+	assert.Empty(t, egress)
+
+	p.EgressCIDRs = []string{"10.0.0.0/8"}
+	_, egress, err = p.getMissingSecurityPolicies(set, "192.168.3.0/24")
+	assert.Nil(t, err)
+	assert.Len(t, egress, 1)
+	assert.Equal(t, "10.0.0.0/8", *egress[0].CidrBlock)
+}
+
+type fakeCVMClient struct {
+	cvmClient
+	instances   []*cvm.Instance
+	requests    []*cvm.DescribeInstancesRequest
+	spotSoldOut bool
+	chargeTypes []string
+	runRequests []*cvm.RunInstancesRequest
+	keyPairs    []map[string]interface{}
+	// describeErrors are returned by the next calls of DescribeInstances.
+	describeErrors []error
+	// instanceTypeGPUs are the gpu numbers of the instance types in the zone.
+	instanceTypeGPUs map[string]int64
+	// zoneInstanceTypes are the status of the instance types by zone.
+	zoneInstanceTypes map[string]map[string]string
+	// images are the public images of the region.
+	images              []map[string]interface{}
+	describeImagesCalls int
+}
+
+func (f *fakeCVMClient) DescribeZoneInstanceConfigInfos(request *cvm.DescribeZoneInstanceConfigInfosRequest) (*cvm.DescribeZoneInstanceConfigInfosResponse, error) {
+	items := make([]map[string]interface{}, 0)
+	for zone, instanceTypes := range f.zoneInstanceTypes {
+		for instanceType, status := range instanceTypes {
+			if *request.Filters[0].Values[0] == instanceType {
+				items = append(items, map[string]interface{}{"Zone": zone, "InstanceType": instanceType, "Status": status})
+			}
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"InstanceTypeQuotaSet": items}})
+	if err != nil {
+		return nil, err
+	}
+	response := cvm.NewDescribeZoneInstanceConfigInfosResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeCVMClient) DescribeInstanceTypeConfigs(request *cvm.DescribeInstanceTypeConfigsRequest) (*cvm.DescribeInstanceTypeConfigsResponse, error) {
+	configs := make([]map[string]interface{}, 0)
+	for instanceType, gpu := range f.instanceTypeGPUs {
+		configs = append(configs, map[string]interface{}{"InstanceType": instanceType, "GPU": gpu})
+	}
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"InstanceTypeConfigSet": configs}})
+	if err != nil {
+		return nil, err
+	}
+	response := cvm.NewDescribeInstanceTypeConfigsResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeCVMClient) RunInstances(request *cvm.RunInstancesRequest) (*cvm.RunInstancesResponse, error) {
+	f.runRequests = append(f.runRequests, request)
+	chargeType := ""
+	if request.InstanceChargeType != nil {
+		chargeType = *request.InstanceChargeType
+	}
+	f.chargeTypes = append(f.chargeTypes, chargeType)
+	if f.spotSoldOut && chargeType == spotInstanceChargeType {
+		return nil, errors.NewTencentCloudSDKError("ResourcesSoldOut.SpecifiedInstanceType", "sold out", "")
+	}
+	ids := make([]string, 0, *request.InstanceCount)
+	for i := int64(0); i < *request.InstanceCount; i++ {
+		ids = append(ids, fmt.Sprintf("ins-%d", i))
+	}
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"InstanceIdSet": ids}})
+	if err != nil {
+		return nil, err
+	}
+	response := cvm.NewRunInstancesResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeCVMClient) DescribeKeyPairs(request *cvm.DescribeKeyPairsRequest) (*cvm.DescribeKeyPairsResponse, error) {
+	offset, end := int(*request.Offset), int(*request.Offset+*request.Limit)
+	if end > len(f.keyPairs) {
+		end = len(f.keyPairs)
+	}
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{
+		"TotalCount": len(f.keyPairs),
+		"KeyPairSet": f.keyPairs[offset:end],
+	}})
+	if err != nil {
+		return nil, err
+	}
+	response := cvm.NewDescribeKeyPairsResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeCVMClient) DescribeInstances(request *cvm.DescribeInstancesRequest) (*cvm.DescribeInstancesResponse, error) {
+	copied := *request
+	f.requests = append(f.requests, &copied)
+	if len(f.describeErrors) > 0 {
+		err := f.describeErrors[0]
+		f.describeErrors = f.describeErrors[1:]
+		return nil, err
+	}
+	offset := 0
+	if request.Offset != nil {
+		offset = int(*request.Offset)
+	}
+	end := offset + int(*request.Limit)
+	if end > len(f.instances) {
+		end = len(f.instances)
+	}
+	// the response params is built from json, as it's an anonymous struct in sdk.
+	body, err := json.Marshal(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"TotalCount":  len(f.instances),
+			"InstanceSet": f.instances[offset:end],
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := cvm.NewDescribeInstancesResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func TestDescribeInstances(t *testing.T) {
+	fake := &fakeCVMClient{}
+	for i := 0; i < 45; i++ {
+		fake.instances = append(fake.instances, &cvm.Instance{InstanceId: tencentCommon.StringPtr(fmt.Sprintf("ins-%d", i))})
+	}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.ContextName = "demo.ap-guangzhou.tencent"
+
+	instances, err := p.describeInstances()
+	assert.Nil(t, err)
+	assert.Len(t, instances, 45)
+	assert.Len(t, fake.requests, 3)
+	assert.Equal(t, "ins-44", *instances[44].InstanceId)
+
+	// the instances are described by one request with a larger page size.
+	fake.requests = nil
+	p.APIPageSize = "100"
+	instances, err = p.describeInstances()
+	assert.Nil(t, err)
+	assert.Len(t, instances, 45)
+	assert.Len(t, fake.requests, 1)
+	assert.Equal(t, int64(100), *fake.requests[0].Limit)
+}
+
+func TestIsClusterExistRetry(t *testing.T) {
+	backoff := apiRetryBackoff
+	defer func() { apiRetryBackoff = backoff }()
+	apiRetryBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	throttled := errors.NewTencentCloudSDKError("RequestLimitExceeded", "too many requests", "")
+	fake := &fakeCVMClient{
+		instances:      []*cvm.Instance{{InstanceId: tencentCommon.StringPtr("ins-1")}},
+		describeErrors: []error{throttled, throttled},
+	}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	exist, ids, err := p.IsClusterExist()
+	assert.Nil(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, []string{"ins-1"}, ids)
+	assert.Len(t, fake.requests, 3)
+
+	// it fails after the retries are exhausted.
+	fake.requests = nil
+	fake.describeErrors = []error{throttled, throttled, throttled}
+	_, _, err = p.IsClusterExist()
+	assert.NotNil(t, err)
+	assert.Len(t, fake.requests, 3)
+
+	// auth errors fail fast without retrying.
+	fake.requests = nil
+	fake.describeErrors = []error{errors.NewTencentCloudSDKError("AuthFailure.SecretIdNotFound", "secret id not found", "")}
+	_, _, err = p.IsClusterExist()
+	assert.Contains(t, err.Error(), "invalid credential")
+	assert.Len(t, fake.requests, 1)
+}
+
+type fakeCBSClient struct {
+	cbsClient
+	configs map[string][]map[string]interface{}
+}
+
+func (f *fakeCBSClient) DescribeDiskConfigQuota(request *cbs.DescribeDiskConfigQuotaRequest) (*cbs.DescribeDiskConfigQuotaResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"DiskConfigSet": f.configs[*request.DiskUsage],
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := cbs.NewDescribeDiskConfigQuotaResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func TestCheckDiskTypes(t *testing.T) {
+	fake := &fakeCBSClient{configs: map[string][]map[string]interface{}{
+		"SYSTEM_DISK": {
+			{"DiskType": "CLOUD_PREMIUM", "Available": true},
+			{"DiskType": "CLOUD_SSD", "Available": false},
+		},
+		"DATA_DISK": {
+			{"DiskType": "CLOUD_PREMIUM", "Available": true},
+			{"DiskType": "CLOUD_SSD", "Available": true},
+		},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), b: fake}
+	p.Zone = "ap-guangzhou-6"
+
+	p.SystemDiskType = "CLOUD_PREMIUM"
+	p.EnableCSI = true
+	p.CSIDiskType = "CLOUD_SSD"
+	assert.Nil(t, p.checkDiskTypes())
+
+	p.SystemDiskType = "CLOUD_SSD"
+	err := p.checkDiskTypes()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "available types: CLOUD_PREMIUM")
+}
+
+func TestCheckDiskSizes(t *testing.T) {
+	fake := &fakeCBSClient{configs: map[string][]map[string]interface{}{
+		"SYSTEM_DISK": {
+			{"DiskType": "CLOUD_PREMIUM", "Available": true, "MinDiskSize": 50, "MaxDiskSize": 1024},
+		},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), b: fake}
+	p.Zone = "ap-guangzhou-6"
+	p.SystemDiskType = "CLOUD_PREMIUM"
+	p.SystemDiskSize = "50"
+	p.MasterDiskSize = "200"
+	assert.Equal(t, "200", p.getDiskSize(true))
+	assert.Equal(t, "50", p.getDiskSize(false))
+	assert.Nil(t, p.checkDiskSizes())
+
+	p.WorkerDiskSize = "20"
+	err := p.checkDiskSizes()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "between 50 and 1024 GB")
+}
+
+func TestParseWorkerPools(t *testing.T) {
+	pools, err := parseWorkerPools([]string{
+		"name=gpu,type=GN7.LARGE,count=2,disk-size=100,spot=true,labels=gpu=true;team=ml,taints=gpu=true:NoSchedule",
+		"name=edge,count=1",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, pools, 2)
+	assert.Equal(t, workerPool{
+		Name:         "gpu",
+		Count:        2,
+		InstanceType: "GN7.LARGE",
+		DiskSize:     "100",
+		Spot:         true,
+		Labels:       []string{"gpu=true", "team=ml"},
+		Taints:       []string{"gpu=true:NoSchedule"},
+	}, pools[0])
+	assert.Equal(t, 3, getPoolWorkerCount(pools))
+	// the failed workers of pool are joined by the spec of the pool.
+	parsed, err := parseWorkerPool(pools[0].spec())
+	assert.Nil(t, err)
+	assert.Equal(t, pools[0], parsed)
+
+	args := getPoolExtraArgs(tencent.Options{Pools: []string{"name=gpu,count=2,labels=gpu=true,taints=gpu:NoExecute"}}, types.Node{Pool: "gpu"})
+	assert.Equal(t, " --node-label=gpu=true --node-taint=gpu:NoExecute", args)
+
+	for _, spec := range []string{
+		"name=gpu",
+		"count=1",
+		"name=GPU,count=1",
+		"name=gpu,count=0",
+		"name=gpu,count=1,unknown=1",
+		"name=gpu,count=1,taints=gpu=true:Never",
+		"name=gpu,count=1,labels=gpu",
+	} {
+		_, err = parseWorkerPools([]string{spec})
+		assert.NotNil(t, err, spec)
+	}
+	_, err = parseWorkerPools([]string{"name=gpu,count=1", "name=gpu,count=2"})
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.Provider = providerName
+	p.Name = "demo"
+	p.Master = "1"
+	p.Worker = "1"
+	p.Region = "ap-guangzhou"
+	p.Zone = "ap-guangzhou-6"
+	p.InstanceType = "SA2.MEDIUM4"
+	p.SystemDiskSize = "50"
+	p.InternetMaxBandwidthOut = "5"
+	// no credential or api client is needed.
+	assert.Nil(t, p.Validate())
+
+	p.Zone = "ap-shanghai-2"
+	assert.NotNil(t, p.Validate())
+	p.Zone = "ap-guangzhou-6"
+
+	p.Pools = []string{"name=gpu,count=1,type=GN7"}
+	assert.NotNil(t, p.Validate())
+	p.Pools = nil
+
+	p.SystemDiskSize = "large"
+	assert.NotNil(t, p.Validate())
+	p.SystemDiskSize = "50"
+
+	p.SubnetName = strings.Repeat("a", 50)
+	assert.NotNil(t, p.Validate())
+	p.SubnetName = "team-a-subnet"
+	assert.Nil(t, p.Validate())
+
+	p.Master = "3"
+	assert.NotNil(t, p.Validate())
+	p.Master = "1"
+
+	// the havip is only for HA cluster.
+	p.HaVip = true
+	assert.NotNil(t, p.Validate())
+	p.Cluster = true
+	assert.Nil(t, p.Validate())
+	p.IP = "1.2.3.4"
+	assert.NotNil(t, p.Validate())
+	p.HaVip = false
+	p.IP = ""
+	p.Cluster = false
+
+	// all problems are reported at once.
+	p.Master = "3"
+	p.EgressCIDRs = []string{"10.0.0.0/33"}
+	p.CloudControllerManager = true
+	err := p.Validate()
+	errs, ok := err.(cluster.ValidationErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 4)
+	assert.Contains(t, err.Error(), "need to set `--cluster` or `--datastore` when `--master` number > 1")
+	assert.Contains(t, err.Error(), "must set `--restrict-egress` if `--egress-cidr` is set")
+	assert.Contains(t, err.Error(), "invalid `--egress-cidr` \"10.0.0.0/33\"")
+	assert.Contains(t, err.Error(), "must set `--router` if enabled tencent cloud manager")
+}
+
+type fakeVPCClient struct {
+	vpcClient
+	addresses []map[string]interface{}
+	// securityGroups are the security groups tagged by autok3s.
+	securityGroups []string
+	deleted        []string
+	subnets        []map[string]interface{}
+	routeTables    []map[string]interface{}
+	routes         []*vpc.Route
+	taskResult     string
+	// describeAddressesCalls is the number of calls of DescribeAddresses.
+	describeAddressesCalls int
+}
+
+func (f *fakeVPCClient) DescribeSubnets(request *vpc.DescribeSubnetsRequest) (*vpc.DescribeSubnetsResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"TotalCount": len(f.subnets),
+			"SubnetSet":  f.subnets,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := vpc.NewDescribeSubnetsResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeVPCClient) DescribeAddresses(request *vpc.DescribeAddressesRequest) (*vpc.DescribeAddressesResponse, error) {
+	f.describeAddressesCalls++
+	ips := map[string]bool{}
+	instances := map[string]bool{}
+	tagged := false
+	for _, filter := range request.Filters {
+		if *filter.Name == "address-ip" {
+			for _, ip := range filter.Values {
+				ips[*ip] = true
+			}
+		}
+		if *filter.Name == "instance-id" {
+			for _, id := range filter.Values {
+				instances[*id] = true
+			}
+		}
+		if *filter.Name == "tag:autok3s" {
+			tagged = true
+		}
+	}
+	addresses := make([]map[string]interface{}, 0)
+	for _, address := range f.addresses {
+		instanceID, _ := address["InstanceId"].(string)
+		if ips[address["AddressIp"].(string)] || instances[instanceID] || (tagged && address["TagSet"] != nil) {
+			addresses = append(addresses, address)
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"TotalCount": len(addresses),
+			"AddressSet": addresses,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := vpc.NewDescribeAddressesResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func TestAssembleInstanceStatusEIPs(t *testing.T) {
+	cvmFake := &fakeCVMClient{}
+	vpcFake := &fakeVPCClient{}
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("ins-%d", i)
+		cvmFake.instances = append(cvmFake.instances, &cvm.Instance{InstanceId: tencentCommon.StringPtr(id)})
+		vpcFake.addresses = append(vpcFake.addresses, map[string]interface{}{
+			"AddressId": fmt.Sprintf("eip-%d", i), "AddressIp": fmt.Sprintf("1.2.3.%d", i), "InstanceId": id,
+		})
+	}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: cvmFake, v: vpcFake}
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.PublicIPAssignedEIP = true
+
+	assert.Nil(t, p.assembleInstanceStatus(&types.SSH{}, false, ""))
+	// the eips of all instances are described with a single call.
+	assert.Equal(t, 1, vpcFake.describeAddressesCalls)
+	for i := 0; i < 30; i++ {
+		value, ok := p.M.Load(fmt.Sprintf("ins-%d", i))
+		assert.True(t, ok)
+		assert.Equal(t, []string{fmt.Sprintf("eip-%d", i)}, value.(types.Node).EipAllocationIds)
+	}
+}
+
+func TestResolveEIPAddresses(t *testing.T) {
+	fake := &fakeVPCClient{addresses: []map[string]interface{}{
+		{"AddressId": "eip-1", "AddressIp": "1.2.3.4", "AddressStatus": "UNBIND"},
+		{"AddressId": "eip-2", "AddressIp": "1.2.3.5", "AddressStatus": "BIND"},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), v: fake}
+	p.Region = "ap-guangzhou"
+
+	p.EIPAddresses = []string{"1.2.3.4"}
+	assert.Nil(t, p.resolveEIPAddresses(2))
+	assert.True(t, p.isExternalEIP(p.externalEIPs[0]))
+	taken := p.takeExternalEIPs(2)
+	assert.Len(t, taken, 1)
+	assert.Equal(t, "eip-1", *taken[0].AddressId)
+	assert.Empty(t, p.takeExternalEIPs(1))
+
+	// bound eip.
+	p.EIPAddresses = []string{"1.2.3.5"}
+	assert.NotNil(t, p.resolveEIPAddresses(2))
+	// not found in the region.
+	p.EIPAddresses = []string{"1.2.3.6"}
+	assert.NotNil(t, p.resolveEIPAddresses(2))
+	// more eips than instances.
+	p.EIPAddresses = []string{"1.2.3.4", "1.2.3.5"}
+	assert.NotNil(t, p.resolveEIPAddresses(1))
+}
+
+func TestUseEIP(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	assert.False(t, p.eipEnabled())
+
+	p.MasterEIP = true
+	assert.True(t, p.eipEnabled())
+	assert.True(t, p.useEIP(true))
+	assert.False(t, p.useEIP(false))
+
+	p.PublicIPAssignedEIP = true
+	assert.True(t, p.useEIP(false))
+}
+
+func TestNewClientProfile(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	assert.Equal(t, "", p.newClientProfile("").HttpProfile.Endpoint)
+
+	p.EndpointURL = "api.example.com"
+	assert.Equal(t, "api.example.com", p.newClientProfile(p.CVMEndpoint).HttpProfile.Endpoint)
+
+	p.CVMEndpoint = "cvm.example.com"
+	assert.Equal(t, "cvm.example.com", p.newClientProfile(p.CVMEndpoint).HttpProfile.Endpoint)
+}
+
+func TestNewCostItem(t *testing.T) {
+	hourly := newCostItem("master", 3, tencentCommon.Float64Ptr(0.5), nil, tencentCommon.StringPtr("HOUR"))
+	assert.Equal(t, 0.5, hourly.Price)
+	assert.InDelta(t, 1095, hourly.Monthly, 0.001)
+
+	prepaid := newCostItem("worker", 2, nil, tencentCommon.Float64Ptr(100), nil)
+	assert.Equal(t, "MONTH", prepaid.ChargeUnit)
+	assert.Equal(t, float64(200), prepaid.Monthly)
+
+	traffic := newCostItem("eip traffic", 5, tencentCommon.Float64Ptr(0.8), nil, tencentCommon.StringPtr("GB"))
+	assert.Equal(t, float64(0), traffic.Monthly)
+}
+
+func TestGetKubeComponentArgs(t *testing.T) {
+	option := tencent.Options{
+		KubeAPIServerArgs:  []string{"audit-log-path=/var/log/k3s-audit.log", "audit-log-maxage=30", "audit-log-path=/var/log/k3s-audit.log"},
+		KubeControllerArgs: []string{"node-monitor-grace-period=20s"},
+		KubeSchedulerArgs:  []string{"v=2"},
+	}
+	assert.Equal(t, " --kube-apiserver-arg=audit-log-path=/var/log/k3s-audit.log --kube-controller-manager-arg=node-monitor-grace-period=20s --kube-scheduler-arg=v=2",
+		getKubeComponentArgs(option, "--kube-apiserver-arg=audit-log-maxage=30"))
+	assert.Equal(t, "", getKubeComponentArgs(tencent.Options{}, ""))
+}
+
+func TestGetAPIRateLimiter(t *testing.T) {
+	p := &Tencent{}
+	qps, burst := p.getAPIRateLimit()
+	assert.Equal(t, float32(defaultAPIQPS), qps)
+	assert.Equal(t, defaultAPIBurst, burst)
+
+	p.APIQPS, p.APIBurst = "5", "10"
+	qps, burst = p.getAPIRateLimit()
+	assert.Equal(t, float32(5), qps)
+	assert.Equal(t, 10, burst)
+
+	// the limiter is shared until the limit is changed.
+	limiter := getAPIRateLimiter(qps, burst)
+	assert.True(t, limiter == getAPIRateLimiter(qps, burst))
+	assert.False(t, limiter == getAPIRateLimiter(qps, burst+1))
+}
+
+func TestGetSecretValue(t *testing.T) {
+	response := &getSecretValueResponse{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"Response":{"SecretName":"key","SecretString":"private key"}}`), response))
+	value, err := getSecretValue("key", response)
+	assert.Nil(t, err)
+	assert.Equal(t, "private key", string(value))
+
+	response = &getSecretValueResponse{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"Response":{"SecretName":"key","SecretBinary":"cHJpdmF0ZSBrZXk="}}`), response))
+	value, err = getSecretValue("key", response)
+	assert.Nil(t, err)
+	assert.Equal(t, "private key", string(value))
+
+	_, err = (&Tencent{}).newSSMKeySource("key")
+	assert.NotNil(t, err)
+}
+
+func (f *fakeVPCClient) DescribeSecurityGroups(request *vpc.DescribeSecurityGroupsRequest) (*vpc.DescribeSecurityGroupsResponse, error) {
+	groups := make([]map[string]interface{}, 0)
+	for _, id := range request.SecurityGroupIds {
+		for _, group := range f.securityGroups {
+			if *id == group {
+				groups = append(groups, map[string]interface{}{"SecurityGroupId": group})
+			}
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"TotalCount":       len(groups),
+			"SecurityGroupSet": groups,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := vpc.NewDescribeSecurityGroupsResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeVPCClient) DeleteSecurityGroup(request *vpc.DeleteSecurityGroupRequest) (*vpc.DeleteSecurityGroupResponse, error) {
+	f.deleted = append(f.deleted, *request.SecurityGroupId)
+	return vpc.NewDeleteSecurityGroupResponse(), nil
+}
+
+func TestDeleteUnusedSecurityGroups(t *testing.T) {
+	fakeVPC := &fakeVPCClient{securityGroups: []string{"sg-autok3s"}}
+	fakeCVM := &fakeCVMClient{instances: []*cvm.Instance{
+		{InstanceId: tencentCommon.StringPtr("ins-1")},
+		{InstanceId: tencentCommon.StringPtr("ins-2")},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), v: fakeVPC, c: fakeCVM}
+	p.Logger = logrus.New()
+	p.ContextName = "demo.ap-guangzhou.tencent"
+
+	// shared with the instance of other cluster.
+	p.SecurityGroupIds = "sg-autok3s"
+	assert.Nil(t, p.deleteUnusedSecurityGroups([]string{"ins-1"}))
+	assert.Empty(t, fakeVPC.deleted)
+	assert.Equal(t, "security-group-id", *fakeCVM.requests[0].Filters[0].Name)
+
+	// the security group which isn't created by autok3s is never deleted.
+	p.SecurityGroupIds = "sg-custom"
+	assert.Nil(t, p.deleteUnusedSecurityGroups([]string{"ins-1", "ins-2"}))
+	assert.Empty(t, fakeVPC.deleted)
+
+	// dedicated to the cluster.
+	p.SecurityGroupIds = "sg-autok3s,sg-custom"
+	assert.Nil(t, p.deleteUnusedSecurityGroups([]string{"ins-1", "ins-2"}))
+	assert.Equal(t, []string{"sg-autok3s"}, fakeVPC.deleted)
+}
+
+func TestGetSecurityGroupTags(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.Tags = []string{"env=dev"}
+	tags, err := p.getSecurityGroupTags()
+	assert.Nil(t, err)
+	assert.Len(t, tags, 3)
+	assert.Equal(t, "cluster", *tags[1].Key)
+	assert.Equal(t, "env", *tags[2].Key)
+
+	p.Tags = []string{"env"}
+	_, err = p.getSecurityGroupTags()
+	assert.NotNil(t, err)
+}
+
+func TestGenerateKeepalivedManifest(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.HaVip = true
+	p.HaVipAddress = "192.168.3.100"
+	p.Status.MasterNodes = []types.Node{
+		{InternalIPAddress: []string{"192.168.3.2"}},
+		{InternalIPAddress: []string{"192.168.3.3"}},
+	}
+	assert.Equal(t, "autok3s-demo.ap-guangzhou.tencent", p.getHaVipName())
+
+	manifests := p.GenerateManifest()
+	assert.Len(t, manifests, 1)
+	encoded := strings.Split(manifests[0], "\"")[1]
+	manifest, err := base64.StdEncoding.DecodeString(encoded)
+	assert.Nil(t, err)
+	assert.Contains(t, string(manifest), `value: "192.168.3.2 192.168.3.3"`)
+	assert.Contains(t, string(manifest), `value: "192.168.3.100"`)
+	assert.Contains(t, string(manifest), "image: "+keepalivedImage)
+}
+
+func TestRunInstancesSpotFallback(t *testing.T) {
+	fake := &fakeCVMClient{spotSoldOut: true}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.Logger = logrus.New()
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.InstanceChargeType = spotInstanceChargeType
+
+	// aborted without fallback.
+	assert.NotNil(t, p.runInstances(1, true, "", nil))
+
+	p.SpotFallback = true
+	assert.Nil(t, p.runInstances(1, true, "", nil))
+	assert.Equal(t, []string{spotInstanceChargeType, spotInstanceChargeType, onDemandChargeType}, fake.chargeTypes)
+	node, ok := p.M.Load("ins-0")
+	assert.True(t, ok)
+	assert.False(t, node.(types.Node).Spot)
+
+	fake.spotSoldOut = false
+	assert.Nil(t, p.runInstances(1, true, "", nil))
+	node, _ = p.M.Load("ins-0")
+	assert.True(t, node.(types.Node).Spot)
+}
+
+func TestDescribeUnassociatedAddresses(t *testing.T) {
+	tags := []map[string]string{{"Key": "autok3s", "Value": "true"}}
+	fake := &fakeVPCClient{addresses: []map[string]interface{}{
+		{"AddressId": "eip-1", "AddressIp": "1.2.3.4", "AddressStatus": "UNBIND", "TagSet": tags},
+		{"AddressId": "eip-2", "AddressIp": "1.2.3.5", "AddressStatus": "BIND", "InstanceId": "ins-1", "TagSet": tags},
+		{"AddressId": "eip-3", "AddressIp": "1.2.3.6", "AddressStatus": "UNBIND"},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), v: fake}
+
+	addresses, err := p.describeUnassociatedAddresses()
+	assert.Nil(t, err)
+	assert.Len(t, addresses, 1)
+	assert.Equal(t, "eip-1", *addresses[0].AddressId)
+}
+
+type fakeKMSClient struct {
+	kmsClient
+	// keys are the states of kms keys by id.
+	keys         map[string]string
+	unauthorized bool
+}
+
+func (f *fakeKMSClient) DescribeKey(request *kms.DescribeKeyRequest) (*kms.DescribeKeyResponse, error) {
+	if f.unauthorized {
+		return nil, errors.NewTencentCloudSDKError("UnauthorizedOperation", "no permission", "")
+	}
+	state, ok := f.keys[*request.KeyId]
+	if !ok {
+		return nil, errors.NewTencentCloudSDKError("ResourceUnavailable.CmkNotFound", "key not found", "")
+	}
+	response := kms.NewDescribeKeyResponse()
+	return response, response.FromJsonString(fmt.Sprintf(`{"Response":{"KeyMetadata":{"KeyId":"%s","KeyState":"%s"}}}`, *request.KeyId, state))
+}
+
+func TestDiskEncryption(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.SystemDiskType = "CLOUD_PREMIUM"
+	p.KmsKeyID = "key-1"
+	assert.NotNil(t, p.validateDiskEncryption())
+	p.DiskEncrypt = true
+	assert.NotNil(t, p.validateDiskEncryption())
+	p.DataDiskSize = "100"
+	assert.Nil(t, p.validateDiskEncryption())
+	p.Pools = []string{"name=basic,disk-category=CLOUD_BASIC"}
+	assert.NotNil(t, p.validateDiskEncryption())
+
+	disks := p.newDataDisks("CLOUD_SSD")
+	assert.Len(t, disks, 1)
+	assert.Equal(t, int64(100), *disks[0].DiskSize)
+	assert.True(t, *disks[0].Encrypt)
+	assert.Equal(t, "key-1", *disks[0].KmsKeyId)
+
+	fake := &fakeKMSClient{keys: map[string]string{"key-1": "Enabled", "key-2": "Disabled"}}
+	p.k = fake
+	assert.Nil(t, p.checkKMSKey())
+	p.KmsKeyID = "key-2"
+	assert.NotNil(t, p.checkKMSKey())
+	p.KmsKeyID = "key-3"
+	assert.NotNil(t, p.checkKMSKey())
+	fake.unauthorized = true
+	err := p.checkKMSKey()
+	assert.Contains(t, err.Error(), "kms:DescribeKey")
+}
+
+func TestCCMReadiness(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	assert.False(t, isDeploymentAvailable(deployment))
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{
+		{Type: appsv1.DeploymentProgressing, Status: v1.ConditionTrue},
+		{Type: appsv1.DeploymentAvailable, Status: v1.ConditionFalse},
+	}
+	assert.False(t, isDeploymentAvailable(deployment))
+	deployment.Status.Conditions[1].Status = v1.ConditionTrue
+	assert.True(t, isDeploymentAvailable(deployment))
+
+	nodes := []v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "master-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "master-2"}, Spec: v1.NodeSpec{Taints: []v1.Taint{
+			{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule},
+			{Key: uninitializedTaint, Value: "true", Effect: v1.TaintEffectNoSchedule},
+		}}},
+	}
+	assert.Equal(t, []string{"master-2"}, getUninitializedNodes(nodes))
+	assert.Empty(t, getUninitializedNodes(nodes[:1]))
+}
+
+func TestAddonRollout(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.CloudControllerManager = true
+	p.ClusterCidr = "10.42.0.0/16"
+	decode := func(cmd string) string {
+		manifest, err := base64.StdEncoding.DecodeString(strings.Split(cmd, "\"")[1])
+		assert.Nil(t, err)
+		return string(manifest)
+	}
+	// the clusters created before --ccm-version keep the former image.
+	assert.Contains(t, decode(p.generateCCMManifest()), "image: ccr.ccs.tencentyun.com/library/tencentcloud-cloud-controller-manager:1.0.1\n")
+	p.CCMVersion = "1.1.0"
+	manifest := decode(p.generateCCMManifest())
+	assert.Contains(t, manifest, "tencentcloud-cloud-controller-manager:1.1.0\n")
+	assert.Contains(t, manifest, "--cluster-cidr=10.42.0.0/16")
+
+	image := csiImage + ":v1.2.4"
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 2}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}}
+	deployment.Spec.Template.Spec.Containers = []v1.Container{{Image: "ccr.ccs.tencentyun.com/tkeimages/csi-tencentcloud-cbs:v1.2.3"}}
+	done, _ := isDeploymentRolledOut(deployment, image)
+	assert.False(t, done)
+	deployment.Spec.Template.Spec.Containers[0].Image = "ccr.ccs.tencentyun.com/tkeimages/" + image
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2}
+	// the pod of the former version isn't terminated yet.
+	done, reason := isDeploymentRolledOut(deployment, image)
+	assert.False(t, done)
+	assert.NotEmpty(t, reason)
+	deployment.Status.Replicas = 2
+	done, _ = isDeploymentRolledOut(deployment, image)
+	assert.True(t, done)
+
+	ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+	ds.Spec.Template.Spec.Containers = []v1.Container{{Image: "ccr.ccs.tencentyun.com/tkeimages/" + image}}
+	ds.Status = appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}
+	done, _ = isDaemonSetRolledOut(ds, image)
+	assert.False(t, done)
+	ds.Status.ObservedGeneration = 3
+	ds.Status.NumberAvailable = 2
+	done, _ = isDaemonSetRolledOut(ds, image)
+	assert.False(t, done)
+	ds.Status.NumberAvailable = 3
+	done, _ = isDaemonSetRolledOut(ds, image)
+	assert.True(t, done)
+}
+
+func TestWriteAddonLogs(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "ccm-1", Namespace: ccmNamespace, Labels: map[string]string{"app": ccmDeploymentName}},
+			Spec:       v1.PodSpec{NodeName: "master-1", Containers: []v1.Container{{Name: "ccm"}}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-node-1", Namespace: ccmNamespace, Labels: map[string]string{"app": csiNodeDaemonSet}},
+			Spec:       v1.PodSpec{NodeName: "worker-1", Containers: []v1.Container{{Name: "driver-registrar"}, {Name: "cbs-csi"}}},
+		},
+	)
+	out := &strings.Builder{}
+	assert.Nil(t, writeAddonLogs(client, addonSelectors[addonCCM], 100, out))
+	assert.Contains(t, out.String(), "==> kube-system/ccm-1/ccm (master-1) <==\n")
+	assert.NotContains(t, out.String(), "csi-node-1")
+
+	out.Reset()
+	assert.Nil(t, writeAddonLogs(client, addonSelectors[addonCSI], 0, out))
+	assert.Contains(t, out.String(), "==> kube-system/csi-node-1/driver-registrar (worker-1) <==\n")
+	assert.Contains(t, out.String(), "==> kube-system/csi-node-1/cbs-csi (worker-1) <==\n")
+	assert.NotContains(t, out.String(), "ccm-1")
+
+	out.Reset()
+	assert.Nil(t, writeAddonLogs(k8sfake.NewSimpleClientset(), addonSelectors[addonCCM], 0, out))
+	assert.Contains(t, out.String(), "no pod found")
+}
+
+// fakeLaunchTemplateClient launches the instances with the fake cvm client.
+type fakeLaunchTemplateClient struct {
+	cvm *fakeCVMClient
+	// launchTemplates are the data of the default version of launch templates by id.
+	launchTemplates map[string]string
+	runRequests     []*runInstancesRequest
+}
+
+func (f *fakeLaunchTemplateClient) DescribeLaunchTemplateVersions(request *describeLaunchTemplateVersionsRequest) (*describeLaunchTemplateVersionsResponse, error) {
+	versions := make([]map[string]interface{}, 0)
+	if data, ok := f.launchTemplates[*request.LaunchTemplateID]; ok {
+		versions = append(versions, map[string]interface{}{"LaunchTemplateVersionData": json.RawMessage(data)})
+	}
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"LaunchTemplateVersionSet": versions}})
+	if err != nil {
+		return nil, err
+	}
+	response := &describeLaunchTemplateVersionsResponse{}
+	return response, json.Unmarshal(body, response)
+}
+
+func (f *fakeLaunchTemplateClient) RunInstances(request *runInstancesRequest) (*cvm.RunInstancesResponse, error) {
+	f.runRequests = append(f.runRequests, request)
+	return f.cvm.RunInstances(request.RunInstancesRequest)
+}
+
+func TestLaunchTemplate(t *testing.T) {
+	fake := &fakeLaunchTemplateClient{cvm: &fakeCVMClient{}, launchTemplates: map[string]string{
+		"lt-1": `{"Placement":{"Zone":"ap-guangzhou-3"},"ImageId":"img-1","InstanceType":"S5.LARGE8",
+			"VirtualPrivateCloud":{"VpcId":"vpc-1","SubnetId":"subnet-1"},"SecurityGroupIds":["sg-1","sg-2"]}`,
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake.cvm, e: fake}
+	p.Logger = logrus.New()
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.Zone = "ap-guangzhou-6"
+	p.InstanceType = "S5.MEDIUM4"
+	p.InstanceChargeType = onDemandChargeType
+	p.SystemDiskType = "CLOUD_SSD"
+	p.SystemDiskSize = "50"
+	p.InternetMaxBandwidthOut = "5"
+
+	p.LaunchTemplateVersion = "1"
+	assert.NotNil(t, p.validateLaunchTemplate())
+	p.LaunchTemplateID = "lt-2"
+	assert.NotNil(t, p.applyLaunchTemplate())
+	p.LaunchTemplateID, p.LaunchTemplateVersion = "lt-1", ""
+	assert.Nil(t, p.validateLaunchTemplate())
+	assert.Nil(t, p.applyLaunchTemplate())
+	assert.Equal(t, "ap-guangzhou-3", p.Zone)
+	assert.Equal(t, "img-1", p.ImageID)
+	assert.Equal(t, "S5.LARGE8", p.InstanceType)
+	assert.Equal(t, "vpc-1", p.VpcID)
+	assert.Equal(t, "subnet-1", p.SubnetID)
+	assert.Equal(t, "sg-1,sg-2", p.SecurityGroupIds)
+
+	// the fields of template are removed from the request, except the instance type of pool.
+	assert.Nil(t, p.runInstances(1, true, "", nil))
+	assert.Nil(t, p.runInstances(1, false, "", &workerPool{Name: "gpu", InstanceType: "GN7.2XLARGE32"}))
+	for i, request := range fake.runRequests {
+		assert.Equal(t, "lt-1", *request.LaunchTemplate.LaunchTemplateID)
+		assert.Nil(t, request.LaunchTemplate.LaunchTemplateVersion)
+		assert.Nil(t, request.Placement)
+		assert.Nil(t, request.ImageId)
+		assert.Nil(t, request.VirtualPrivateCloud)
+		assert.Nil(t, request.SecurityGroupIds)
+		assert.NotNil(t, request.SystemDisk)
+		assert.NotNil(t, request.UserData)
+		assert.NotEmpty(t, request.TagSpecification)
+		// the template is sent along with the fields of the sdk request.
+		body, err := json.Marshal(request)
+		assert.Nil(t, err)
+		assert.Contains(t, string(body), `"LaunchTemplate":{"LaunchTemplateId":"lt-1"}`)
+		assert.Contains(t, string(body), `"InstanceCount":1`)
+		if i == 0 {
+			assert.Nil(t, request.InstanceType)
+		} else {
+			assert.Equal(t, "GN7.2XLARGE32", *request.InstanceType)
+		}
+	}
+}
+
+func TestListKeyPairs(t *testing.T) {
+	fake := &fakeCVMClient{}
+	for i := 0; i < 150; i++ {
+		fake.keyPairs = append(fake.keyPairs, map[string]interface{}{
+			"KeyId": fmt.Sprintf("skey-%d", i), "KeyName": fmt.Sprintf("key%d", i), "AssociatedInstanceIds": []string{"ins-1"},
+		})
+	}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.Logger = logrus.New()
+
+	keyPairs, err := p.describeKeyPairs()
+	assert.Nil(t, err)
+	assert.Len(t, keyPairs, 150)
+	assert.Equal(t, KeyPair{ID: "skey-149", Name: "key149", Instances: 1}, keyPairs[149])
+
+	assert.Nil(t, p.checkKeyPair())
+	p.KeypairID = "skey-1"
+	assert.Nil(t, p.checkKeyPair())
+	p.KeypairID = "skey-unknown"
+	err = p.checkKeyPair()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "skey-149(key149)")
+}
+
+func TestNodeHostname(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	instance := &cvm.Instance{InstanceId: tencentCommon.StringPtr("ins-AbC123"), InstanceName: tencentCommon.StringPtr("K3s_demo-master-2")}
+	assert.Equal(t, "", p.getNodeHostname(instance))
+
+	p.SetHostname = true
+	// the default instance names are shared by the instances of the same role.
+	assert.Equal(t, "ins-abc123", p.getNodeHostname(instance))
+	p.InstanceNameTemplate = "k3s_{cluster}-{role}-{index}"
+	assert.Equal(t, "k3s-demo-master-2", p.getNodeHostname(instance))
+	p.CloudControllerManager = true
+	assert.Equal(t, "", p.getNodeHostname(instance))
+
+	p.CloudControllerManager = false
+	node := types.Node{InstanceID: "ins-AbC123", InternalIPAddress: []string{"10.0.0.2"}, LocalHostname: "k3s-demo-master-2"}
+	c := &types.Cluster{Options: p.Options}
+	assert.Contains(t, p.GenerateMasterExtraArgs(c, node), " --node-name=k3s-demo-master-2")
+	p.CloudControllerManager = true
+	c.Options = p.Options
+	assert.Contains(t, p.GenerateMasterExtraArgs(c, node), " --node-name=10.0.0.2")
+	assert.NotContains(t, p.GenerateMasterExtraArgs(c, node), "k3s-demo-master-2")
+}
+
+func TestHostPlacement(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.Zone = "ap-guangzhou-3"
+	p.InstanceChargeType = "POSTPAID_BY_HOUR"
+	request, err := p.newRunInstancesRequest(1, false, "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, request.Placement.HostIds)
+	assert.Equal(t, "POSTPAID_BY_HOUR", *request.InstanceChargeType)
+
+	p.HostIDs = []string{"host-1", "host-2"}
+	assert.Nil(t, p.validateHostIDs())
+	request, err = p.newRunInstancesRequest(1, false, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host-1", "host-2"}, tencentCommon.StringValues(request.Placement.HostIds))
+	assert.Equal(t, cdhInstanceChargeType, *request.InstanceChargeType)
+
+	p.Pools = []string{"name=spot,count=1,spot=true"}
+	assert.NotNil(t, p.validateHostIDs())
+
+	// the nodes which aren't on dedicated hosts are not labeled.
+	c := &types.Cluster{Options: p.Options}
+	node := types.Node{InstanceID: "ins-1", InternalIPAddress: []string{"10.0.0.2"}}
+	assert.NotContains(t, p.GenerateWorkerExtraArgs(c, node), hostNodeLabel)
+	node.HostID = getInstanceHostID(&cvm.Instance{Placement: &cvm.Placement{HostId: tencentCommon.StringPtr("host-1")}})
+	assert.Contains(t, p.GenerateWorkerExtraArgs(c, node), " --node-label=topology.autok3s.io/host=host-1")
+}
+
+func TestMasterRoles(t *testing.T) {
+	fake := &fakeCVMClient{}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.Logger = logrus.New()
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.Name = "demo"
+	p.Master = "3"
+	p.EtcdOnlyMaster = "2"
+	assert.NotNil(t, p.validateMasterRoles(true))
+	p.Cluster = true
+	assert.Nil(t, p.validateMasterRoles(true))
+	p.ControlPlaneOnlyMaster = "1"
+	// the first master of a new cluster runs both etcd and control-plane.
+	assert.NotNil(t, p.validateMasterRoles(true))
+	assert.Nil(t, p.validateMasterRoles(false))
+	assert.Equal(t, []masterGroup{{Role: types.MasterRoleEtcd, Count: 2}, {Role: types.MasterRoleControlPlane, Count: 1}}, p.getMasterGroups(3))
+	p.ControlPlaneOnlyMaster = ""
+	assert.Equal(t, []masterGroup{{Count: 1}, {Role: types.MasterRoleEtcd, Count: 2}}, p.getMasterGroups(3))
+
+	// the etcd-only masters take the ips and indexes after the master launched before them.
+	p.MasterPrivateIPs = []string{"192.168.3.10", "192.168.3.11", "192.168.3.12"}
+	p.InstanceNameTemplate = "{cluster}-{role}-{index}"
+	p.M.Store("ins-full", types.Node{Master: true, RollBack: true, InstanceID: "ins-full"})
+	assert.Nil(t, p.runMasterInstances(masterGroup{Role: types.MasterRoleEtcd, Count: 2}, ""))
+	request := fake.runRequests[0]
+	assert.Equal(t, []string{"192.168.3.11", "192.168.3.12"}, tencentCommon.StringValues(request.VirtualPrivateCloud.PrivateIpAddresses))
+	assert.Equal(t, "demo-master-{R:2}", *request.InstanceName)
+	tags := map[string]string{}
+	for _, tag := range request.TagSpecification[0].Tags {
+		tags[*tag.Key] = *tag.Value
+	}
+	assert.Equal(t, types.MasterRoleEtcd, tags[masterRoleTagKey])
+	node, ok := p.M.Load("ins-1")
+	assert.True(t, ok)
+	assert.Equal(t, types.MasterRoleEtcd, node.(types.Node).MasterRole)
+	// the ips are used up by the masters launched before.
+	assert.NotNil(t, p.runMasterInstances(masterGroup{Role: types.MasterRoleControlPlane, Count: 1}, ""))
+	assert.Nil(t, p.validateMasterPrivateIPs())
+	p.Master = "2"
+	assert.NotNil(t, p.validateMasterPrivateIPs())
+	p.Master = "3"
+
+	c := &types.Cluster{Metadata: types.Metadata{Cluster: true}, Options: p.Options}
+	p.KubeAPIServerArgs = []string{"audit-log-maxage=30"}
+	c.Options = p.Options
+	args := p.GenerateMasterExtraArgs(c, node.(types.Node))
+	assert.Contains(t, args, " --disable-apiserver --disable-controller-manager --disable-scheduler")
+	assert.NotContains(t, args, "--kube-apiserver-arg")
+	args = p.GenerateMasterExtraArgs(c, types.Node{Master: true, MasterRole: types.MasterRoleControlPlane, InternalIPAddress: []string{"192.168.3.13"}})
+	assert.Contains(t, args, " --disable-etcd")
+	assert.Contains(t, args, " --kube-apiserver-arg=audit-log-maxage=30")
+}
+
+func TestLoadProfileCredential(t *testing.T) {
+	dir := t.TempDir()
+	defaultDir := credentialDir
+	credentialDir = func() string { return dir }
+	defer func() { credentialDir = defaultDir }()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "prod.credential"), []byte(`{"secretId": "id-prod", "secretKey": "key-prod"}`), 0600))
+
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	secrets, err := p.LoadProfileCredential()
+	assert.Nil(t, err)
+	assert.Nil(t, secrets)
+
+	p.Profile = "prod"
+	secrets, err = p.LoadProfileCredential()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{secretID: "id-prod", secretKey: "key-prod"}, secrets)
+
+	// the secret set by flags takes precedence over the profile.
+	p.SecretID = "id-flag"
+	assert.Nil(t, p.applyProfileCredential())
+	assert.Equal(t, "id-flag", p.SecretID)
+	assert.Equal(t, "key-prod", p.SecretKey)
+
+	p.Profile = "test"
+	_, err = p.LoadProfileCredential()
+	assert.NotNil(t, err)
+	p.Profile = "../prod"
+	_, err = p.LoadProfileCredential()
+	assert.NotNil(t, err)
+}
+
+func TestSubnetStrategy(t *testing.T) {
+	fake := &fakeCVMClient{}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake, v: &fakeVPCClient{subnets: []map[string]interface{}{
+		{"SubnetId": "subnet-b", "AvailableIpAddressCount": 200, "TotalIpAddressCount": 250},
+		{"SubnetId": "subnet-a", "AvailableIpAddressCount": 2, "TotalIpAddressCount": 250},
+		{"SubnetId": "subnet-c", "AvailableIpAddressCount": 100, "TotalIpAddressCount": 100},
+	}}}
+	p.Logger = logrus.New()
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.Name = "demo"
+	p.SubnetID = "subnet-a"
+
+	placements, err := p.getSubnetPlacements(5, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []subnetPlacement{{SubnetID: "subnet-a", Count: 5}}, placements)
+
+	// the full subnet-a is skipped after its ips are used up.
+	p.SubnetStrategy = subnetStrategyRoundRobin
+	placements, err = p.getSubnetPlacements(7, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []subnetPlacement{{SubnetID: "subnet-a", Count: 2}, {SubnetID: "subnet-b", Count: 3}, {SubnetID: "subnet-c", Count: 2}}, placements)
+
+	// subnet-c is unused and subnet-b is 20% used.
+	p.SubnetStrategy = subnetStrategyLeastUsed
+	placements, err = p.getSubnetPlacements(3, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []subnetPlacement{{SubnetID: "subnet-c", Count: 3}}, placements)
+	_, err = p.getSubnetPlacements(400, false)
+	assert.NotNil(t, err)
+
+	// the masters with private ips stay in --subnet.
+	p.MasterPrivateIPs = []string{"192.168.3.10"}
+	placements, err = p.getSubnetPlacements(1, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []subnetPlacement{{SubnetID: "subnet-a", Count: 1}}, placements)
+
+	p.SubnetStrategy = subnetStrategyRoundRobin
+	assert.Nil(t, p.runInstances(3, false, "", nil))
+	subnets := make([]string, 0)
+	for _, request := range fake.runRequests {
+		subnets = append(subnets, *request.VirtualPrivateCloud.SubnetId)
+	}
+	assert.Equal(t, []string{"subnet-a", "subnet-b", "subnet-c"}, subnets)
+}
+
+func TestGPU(t *testing.T) {
+	fake := &fakeCVMClient{instanceTypeGPUs: map[string]int64{"GN7.2XLARGE32": 1, "SA2.MEDIUM4": 0}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.Zone = "ap-guangzhou-3"
+	p.InstanceType = "SA2.MEDIUM4"
+	assert.Nil(t, p.checkGPUInstanceTypes())
+
+	p.GPU = true
+	assert.NotNil(t, p.checkGPUInstanceTypes())
+	p.InstanceType = "GN7.2XLARGE32"
+	assert.Nil(t, p.checkGPUInstanceTypes())
+	p.Pools = []string{"name=cpu,count=1,type=S5.LARGE8"}
+	// the instance type isn't available in the zone.
+	assert.NotNil(t, p.checkGPUInstanceTypes())
+
+	manifests := p.GenerateManifest()
+	assert.Len(t, manifests, 1)
+	manifest, err := base64.StdEncoding.DecodeString(strings.Split(manifests[0], "\"")[1])
+	assert.Nil(t, err)
+	assert.Contains(t, string(manifest), "image: "+nvidiaDevicePluginImage)
+	assert.Contains(t, p.GenerateWorkerExtraArgs(&types.Cluster{Options: p.Options}, types.Node{}), " --node-label="+gpuNodeLabel)
+}
+
+func (f *fakeVPCClient) DescribeRouteTables(request *vpc.DescribeRouteTablesRequest) (*vpc.DescribeRouteTablesResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"TotalCount":    len(f.routeTables),
+			"RouteTableSet": f.routeTables,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := vpc.NewDescribeRouteTablesResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeVPCClient) CreateRoutes(request *vpc.CreateRoutesRequest) (*vpc.CreateRoutesResponse, error) {
+	f.routes = append(f.routes, request.Routes...)
+	return vpc.NewCreateRoutesResponse(), nil
+}
+
+func TestAddNatGatewayRoute(t *testing.T) {
+	fakeVPC := &fakeVPCClient{}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), v: fakeVPC}
+	p.Logger = logrus.New()
+	p.ContextName = "demo.ap-guangzhou.tencent"
+	p.NatGatewayID = "nat-1"
+
+	// the default route is added if it's not exist.
+	fakeVPC.routeTables = []map[string]interface{}{
+		{"RouteTableId": "rtb-1", "RouteSet": []map[string]interface{}{{"DestinationCidrBlock": "10.0.0.0/8", "GatewayId": "pcx-1"}}},
+	}
+	assert.Nil(t, p.addNatGatewayRoute("rtb-1"))
+	assert.Equal(t, 1, len(fakeVPC.routes))
+	assert.Equal(t, "0.0.0.0/0", *fakeVPC.routes[0].DestinationCidrBlock)
+	assert.Equal(t, "NAT", *fakeVPC.routes[0].GatewayType)
+	assert.Equal(t, "nat-1", *fakeVPC.routes[0].GatewayId)
+
+	// the existing route to the nat gateway is skipped.
+	fakeVPC.routes = nil
+	fakeVPC.routeTables = []map[string]interface{}{
+		{"RouteTableId": "rtb-1", "RouteSet": []map[string]interface{}{{"DestinationCidrBlock": "0.0.0.0/0", "GatewayId": "nat-1"}}},
+	}
+	assert.Nil(t, p.addNatGatewayRoute("rtb-1"))
+	assert.Equal(t, 0, len(fakeVPC.routes))
+
+	// the default route to the other gateway isn't replaced.
+	fakeVPC.routeTables = []map[string]interface{}{
+		{"RouteTableId": "rtb-1", "RouteSet": []map[string]interface{}{{"DestinationCidrBlock": "0.0.0.0/0", "GatewayId": "nat-2"}}},
+	}
+	assert.NotNil(t, p.addNatGatewayRoute("rtb-1"))
+	assert.Equal(t, 0, len(fakeVPC.routes))
+}
+
+func TestMetadataAccess(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+
+	// the access isn't restricted by default.
+	assert.Equal(t, 0, len(p.getMetadataAccessRules()))
+	p.MetadataAccess = "all"
+	assert.Equal(t, 0, len(p.getMetadataAccessRules()))
+
+	p.MetadataAccess = "host-only"
+	assert.Equal(t, []string{
+		"iptables -t raw -C PREROUTING -d 169.254.0.23/32 -j DROP 2>/dev/null || iptables -t raw -I PREROUTING -d 169.254.0.23/32 -j DROP",
+	}, p.getMetadataAccessRules())
+	p.CloudControllerManager = true
+	assert.Nil(t, p.validateMetadataAccess())
+
+	p.MetadataAccess = "none"
+	assert.Equal(t, 2, len(p.getMetadataAccessRules()))
+	assert.NotNil(t, p.validateMetadataAccess())
+	p.CloudControllerManager = false
+	assert.Nil(t, p.validateMetadataAccess())
+}
+
+func (f *fakeVPCClient) DescribeTaskResult(request *vpc.DescribeTaskResultRequest) (*vpc.DescribeTaskResultResponse, error) {
+	response := vpc.NewDescribeTaskResultResponse()
+	return response, response.FromJsonString(fmt.Sprintf(`{"Response":{"TaskId":%d,"Result":%q}}`, *request.TaskId, f.taskResult))
+}
+
+func TestGetOperationStatus(t *testing.T) {
+	fakeVPC := &fakeVPCClient{taskResult: "RUNNING"}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), v: fakeVPC}
+	p.Logger = logrus.New()
+
+	status, err := p.GetOperationStatus("123")
+	assert.Nil(t, err)
+	assert.Equal(t, &types.OperationStatus{TaskID: "123", Status: types.OperationRunning}, status)
+
+	fakeVPC.taskResult = "FAILED"
+	status, err = p.GetOperationStatus("123")
+	assert.Nil(t, err)
+	assert.Equal(t, types.OperationFailed, status.Status)
+
+	fakeVPC.taskResult = "SUCCESS"
+	status, err = p.GetOperationStatus("123")
+	assert.Nil(t, err)
+	assert.Equal(t, types.OperationSuccess, status.Status)
+
+	_, err = p.GetOperationStatus("task-1")
+	assert.NotNil(t, err)
+}
+
+func TestCheckZoneInstanceTypes(t *testing.T) {
+	fake := &fakeCVMClient{zoneInstanceTypes: map[string]map[string]string{
+		"ap-guangzhou-3": {"SA2.MEDIUM4": "SELL", "S5.LARGE8": "SOLD_OUT"},
+		"ap-guangzhou-4": {"S5.LARGE8": "SELL"},
+		"ap-guangzhou-7": {"S5.LARGE8": "SELL"},
+		"ap-guangzhou-6": {"S5.LARGE8": "SELL"},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.Zone = "ap-guangzhou-3"
+	p.InstanceType = "SA2.MEDIUM4"
+	assert.Nil(t, p.checkZoneInstanceTypes())
+
+	p.Pools = []string{"name=cpu,count=1,type=S5.LARGE8"}
+	err := p.checkZoneInstanceTypes()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "instance type S5.LARGE8 is sold out or unavailable in zone ap-guangzhou-3")
+	assert.Contains(t, err.Error(), "available zones: ap-guangzhou-4, ap-guangzhou-6, ap-guangzhou-7")
+
+	p.Pools = nil
+	p.InstanceType = "GN7.2XLARGE32"
+	err = p.checkZoneInstanceTypes()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no other zone of the region sells it")
+}
+
+func TestGetProviderOptionValues(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	assert.Nil(t, p.SetOptions([]byte(`{"zone":"ap-guangzhou-6","instance-type":"S5.LARGE8","secret-key":"secret"}`)))
+	fields, err := p.GetProviderOptionValues()
+	assert.Nil(t, err)
+	assert.Equal(t, "ap-guangzhou-6", fields["zone"].Default)
+	assert.Equal(t, "S5.LARGE8", fields["instance-type"].Default)
+	// the credentials are excluded.
+	assert.NotContains(t, fields, "secret-key")
+	assert.NotContains(t, fields, "secret-id")
+}
+
+type fakeCLBClient struct {
+	clbClient
+	// listeners are the listeners of the clb with the registered targets.
+	listeners    []map[string]interface{}
+	deregistered []*clb.DeregisterTargetsRequest
+}
+
+func (f *fakeCLBClient) DescribeTargets(request *clb.DescribeTargetsRequest) (*clb.DescribeTargetsResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"Listeners": f.listeners}})
+	if err != nil {
+		return nil, err
+	}
+	response := clb.NewDescribeTargetsResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func (f *fakeCLBClient) DeregisterTargets(request *clb.DeregisterTargetsRequest) (*clb.DeregisterTargetsResponse, error) {
+	f.deregistered = append(f.deregistered, request)
+	response := clb.NewDeregisterTargetsResponse()
+	return response, response.FromJsonString(`{"Response":{"RequestId":"task-1"}}`)
+}
+
+func (f *fakeCLBClient) DescribeTaskStatus(request *clb.DescribeTaskStatusRequest) (*clb.DescribeTaskStatusResponse, error) {
+	response := clb.NewDescribeTaskStatusResponse()
+	return response, response.FromJsonString(`{"Response":{"Status":0}}`)
+}
+
+func TestDeregisterIngressTargets(t *testing.T) {
+	fake := &fakeCLBClient{listeners: []map[string]interface{}{
+		{"ListenerId": "lbl-80", "Port": 80, "Targets": []map[string]interface{}{{"InstanceId": "ins-1", "Port": 80}, {"InstanceId": "ins-2", "Port": 80}}},
+		{"ListenerId": "lbl-443", "Port": 443, "Targets": []map[string]interface{}{{"InstanceId": "ins-2", "Port": 443}}},
+		{"ListenerId": "lbl-8080", "Port": 8080, "Targets": []map[string]interface{}{{"InstanceId": "ins-1", "Port": 8080}}},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), l: fake}
+	p.Logger = logrus.New()
+	p.IngressLB = "lb-1"
+
+	// only the registered instances on the ingress ports are deregistered.
+	assert.Nil(t, p.deregisterIngressTargets([]string{"ins-1", "ins-3"}))
+	assert.Len(t, fake.deregistered, 1)
+	assert.Equal(t, "lbl-80", *fake.deregistered[0].ListenerId)
+	assert.Len(t, fake.deregistered[0].Targets, 1)
+	assert.Equal(t, "ins-1", *fake.deregistered[0].Targets[0].InstanceId)
+	assert.Equal(t, int64(80), *fake.deregistered[0].Targets[0].Port)
+
+	p.CloudControllerManager = true
+	assert.NotNil(t, p.validateIngressLB())
+}
+
+type fakeTagClient struct {
+	tagClient
+	rows []map[string]interface{}
+}
+
+func (f *fakeTagClient) DescribeResourcesByTags(request *tag.DescribeResourcesByTagsRequest) (*tag.DescribeResourcesByTagsResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"Rows": f.rows, "TotalCount": len(f.rows)}})
+	if err != nil {
+		return nil, err
+	}
+	response := tag.NewDescribeResourcesByTagsResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func TestClusterTags(t *testing.T) {
+	fake := &fakeTagClient{rows: []map[string]interface{}{
+		{"ServiceType": "cvm", "ResourceRegion": "ap-guangzhou", "ResourcePrefix": "instance", "ResourceId": "ins-1"},
+		{"ServiceType": "cvm", "ResourceRegion": "ap-guangzhou", "ResourcePrefix": "instance", "ResourceId": "ins-2"},
+		{"ServiceType": "cvm", "ResourceRegion": "ap-guangzhou", "ResourcePrefix": "eip", "ResourceId": "eip-1"},
+		// the other resources of the cluster are not tagged.
+		{"ServiceType": "cvm", "ResourceRegion": "ap-guangzhou", "ResourcePrefix": "sg", "ResourceId": "sg-1"},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), t: fake}
+	p.Region = "ap-guangzhou"
+	groups, err := p.describeTaggedResourceGroups()
+	assert.Nil(t, err)
+	assert.Equal(t, map[taggedResourceGroup][]string{
+		{serviceType: "cvm", region: "ap-guangzhou", prefix: "instance"}: {"ins-1", "ins-2"},
+		{serviceType: "cvm", region: "ap-guangzhou", prefix: "eip"}:      {"eip-1"},
+	}, groups)
+
+	parsed, err := parseTags([]string{"team=infra", "cost-center=dev"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"team=infra", "env=prod", "cost-center=dev"}, mergeTags([]string{"team=app", "env=prod"}, parsed))
+	assert.Equal(t, []string{"env=prod"}, removeTags([]string{"team=app", "env=prod"}, []string{"team", "missing"}))
+	_, err = parseTags([]string{"team"})
+	assert.NotNil(t, err)
+
+	// the tags set by autok3s can't be changed or removed.
+	assert.NotNil(t, p.checkReservedTagKeys([]string{"team", "cluster"}))
+	assert.Nil(t, p.checkReservedTagKeys([]string{"team"}))
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, chunkIDs([]string{"a", "b", "c"}, 2))
+}
+
+func (f *fakeCVMClient) DescribeImages(request *cvm.DescribeImagesRequest) (*cvm.DescribeImagesResponse, error) {
+	f.describeImagesCalls++
+	body, err := json.Marshal(map[string]interface{}{"Response": map[string]interface{}{"ImageSet": f.images, "TotalCount": len(f.images)}})
+	if err != nil {
+		return nil, err
+	}
+	response := cvm.NewDescribeImagesResponse()
+	return response, response.FromJsonString(string(body))
+}
+
+func TestResolveImageFamily(t *testing.T) {
+	fake := &fakeCVMClient{images: []map[string]interface{}{
+		{"ImageId": "img-old", "Platform": "Ubuntu", "OsName": "Ubuntu Server 22.04 LTS 64bit", "ImageState": "NORMAL", "CreatedTime": "2022-05-01T00:00:00+00:00"},
+		{"ImageId": "img-new", "Platform": "Ubuntu", "OsName": "Ubuntu Server 22.04 LTS 64bit", "ImageState": "NORMAL", "CreatedTime": "2023-06-01T00:00:00+00:00"},
+		{"ImageId": "img-2004", "Platform": "Ubuntu", "OsName": "Ubuntu Server 20.04 LTS 64bit", "ImageState": "NORMAL", "CreatedTime": "2024-01-01T00:00:00+00:00"},
+		{"ImageId": "img-centos", "Platform": "CentOS", "OsName": "CentOS 7.9.2009 64bit", "ImageState": "NORMAL", "CreatedTime": "2021-01-01T00:00:00+00:00"},
+	}}
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider(), c: fake}
+	p.Logger = logrus.New()
+	p.Region = "ap-test-resolve"
+
+	// the latest image of the default family is used if neither --image nor --image-family is set.
+	assert.Nil(t, p.resolveImageFamily())
+	assert.Equal(t, "img-new", p.ImageID)
+	// the resolution is cached.
+	p.ImageID = ""
+	assert.Nil(t, p.resolveImageFamily())
+	assert.Equal(t, "img-new", p.ImageID)
+	assert.Equal(t, 1, fake.describeImagesCalls)
+
+	p.ImageID, p.ImageFamily = "", "centos-7.9"
+	assert.Nil(t, p.resolveImageFamily())
+	assert.Equal(t, "img-centos", p.ImageID)
+
+	p.ImageID, p.ImageFamily = "", "debian-12"
+	assert.NotNil(t, p.resolveImageFamily())
+
+	// --image takes precedence.
+	p.ImageID = "img-custom"
+	assert.Nil(t, p.resolveImageFamily())
+	assert.Equal(t, "img-custom", p.ImageID)
+
+	p.ImageFamily = "Ubuntu 22.04"
+	assert.NotNil(t, p.validateImageFamily())
+}
+
+func TestEtcdS3(t *testing.T) {
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.Region = "ap-guangzhou"
+	p.Cluster = true
+	p.EtcdSnapshotCOSBucket = "examplebucket-1250000000"
+	p.SecretID, p.SecretKey = "AKIDEXAMPLE", "secret-example"
+
+	// only the masters running embedded etcd upload snapshots.
+	assert.True(t, p.useEtcdS3(types.Node{Master: true}))
+	assert.True(t, p.useEtcdS3(types.Node{Master: true, MasterRole: types.MasterRoleEtcd}))
+	assert.False(t, p.useEtcdS3(types.Node{Master: true, MasterRole: types.MasterRoleControlPlane}))
+	assert.False(t, p.useEtcdS3(types.Node{}))
+
+	config := string(p.getEtcdS3Config())
+	assert.Contains(t, config, "etcd-s3-endpoint: \"cos.ap-guangzhou.myqcloud.com\"\n")
+	assert.Contains(t, config, "etcd-s3-secret-key: \"secret-example\"\n")
+
+	// the credentials are never passed by the args of K3s, which are logged.
+	c := &types.Cluster{Metadata: p.Metadata, Options: p.Options}
+	node := types.Node{InstanceID: "ins-1", InternalIPAddress: []string{"10.0.0.2"}}
+	assert.NotContains(t, p.GenerateWorkerExtraArgs(c, node), "etcd-s3")
+	node.Master = true
+	assert.NotContains(t, p.GenerateMasterExtraArgs(c, node), p.SecretKey)
+}
+
+func TestGetPrivateDNSRecords(t *testing.T) {
+	assert.Equal(t, "k3s-demo-master-1", getPrivateDNSRecordName("k3s-demo-master-1", "ins-AbC123"))
+	assert.Equal(t, "ins-abc123", getPrivateDNSRecordName("", "ins-AbC123"))
+
+	// only the records saved with the nodes are removed, the same-named records of others are kept.
+	p := &Tencent{ProviderBase: cluster.NewBaseProvider()}
+	p.MasterNodes = []types.Node{{InstanceID: "ins-1", PrivateDNSRecord: "record-1"}}
+	p.WorkerNodes = []types.Node{{InstanceID: "ins-2"}, {InstanceID: "ins-3", PrivateDNSRecord: "record-3"}}
+	p.M.Store("ins-1", types.Node{InstanceID: "ins-1", PrivateDNSRecord: "record-1"})
+	p.M.Store("ins-4", types.Node{InstanceID: "ins-4", PrivateDNSRecord: "record-4"})
+	assert.ElementsMatch(t, []string{"record-1", "record-4"}, p.getPrivateDNSRecords([]string{"ins-1", "ins-2", "ins-4"}))
+	assert.Empty(t, p.getPrivateDNSRecords([]string{"ins-5"}))
+}
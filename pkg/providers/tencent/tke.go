@@ -0,0 +1,77 @@
+package tencent
+
+import (
+	"fmt"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tke "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tke/v20180525"
+)
+
+// TKECluster brief of TKE managed cluster, it helps to pick vpc/subnet settings consistent with the managed clusters.
+type TKECluster struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Status      string `json:"status"`
+	VpcID       string `json:"vpc"`
+	ClusterCidr string `json:"cluster-cidr"`
+	NodeNum     uint64 `json:"node-num"`
+}
+
+// ListTKEClusters lists TKE managed clusters in the region.
+func (p *Tencent) ListTKEClusters() ([]TKECluster, error) {
+	if err := p.generateClientSDK(); err != nil {
+		return nil, err
+	}
+	request := tke.NewDescribeClustersRequest()
+	limit := int64(100)
+	request.Limit = tencentCommon.Int64Ptr(limit)
+	offset := int64(0)
+	clusters := make([]TKECluster, 0)
+	for {
+		request.Offset = tencentCommon.Int64Ptr(offset)
+		response, err := p.r.DescribeClusters(request)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] calling describeTKEClusters error, msg: %v", p.GetProviderName(), err)
+		}
+		if response.Response == nil || len(response.Response.Clusters) == 0 {
+			break
+		}
+		for _, c := range response.Response.Clusters {
+			clusters = append(clusters, convertTKECluster(c))
+		}
+		offset += limit
+		if uint64(offset) >= *response.Response.TotalCount {
+			break
+		}
+	}
+	return clusters, nil
+}
+
+func convertTKECluster(c *tke.Cluster) TKECluster {
+	cluster := TKECluster{}
+	if c.ClusterId != nil {
+		cluster.ID = *c.ClusterId
+	}
+	if c.ClusterName != nil {
+		cluster.Name = *c.ClusterName
+	}
+	if c.ClusterVersion != nil {
+		cluster.Version = *c.ClusterVersion
+	}
+	if c.ClusterStatus != nil {
+		cluster.Status = *c.ClusterStatus
+	}
+	if c.ClusterNodeNum != nil {
+		cluster.NodeNum = *c.ClusterNodeNum
+	}
+	if settings := c.ClusterNetworkSettings; settings != nil {
+		if settings.VpcId != nil {
+			cluster.VpcID = *settings.VpcId
+		}
+		if settings.ClusterCIDR != nil {
+			cluster.ClusterCidr = *settings.ClusterCIDR
+		}
+	}
+	return cluster
+}
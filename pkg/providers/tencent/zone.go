@@ -0,0 +1,117 @@
+package tencent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// instanceTypeSell is the status of the instance type which is sellable in the zone.
+const instanceTypeSell = "SELL"
+
+// checkZoneInstanceTypes checks the instance types of the cluster and pools are sellable in the zone before any
+// resource is created, otherwise the creation fails after the security group and eips are prepared.
+func (p *Tencent) checkZoneInstanceTypes() error {
+	if p.Zone == "" {
+		return nil
+	}
+	instanceTypes := make([]string, 0)
+	if p.InstanceType != "" {
+		instanceTypes = append(instanceTypes, p.InstanceType)
+	}
+	pools, _ := parseWorkerPools(p.Pools)
+	for _, pool := range pools {
+		if pool.InstanceType != "" {
+			instanceTypes = append(instanceTypes, pool.InstanceType)
+		}
+	}
+	if len(instanceTypes) == 0 {
+		return nil
+	}
+	if p.c == nil {
+		if err := p.generateClientSDK(); err != nil {
+			return err
+		}
+	}
+	checked := map[string]bool{}
+	for _, instanceType := range instanceTypes {
+		if checked[instanceType] {
+			continue
+		}
+		checked[instanceType] = true
+		zones, available, err := p.describeInstanceTypeZones(instanceType)
+		if err != nil {
+			return err
+		}
+		if available {
+			continue
+		}
+		suggestion := "no other zone of the region sells it"
+		if len(zones) > 0 {
+			suggestion = "available zones: " + strings.Join(zones, ", ")
+		}
+		return fmt.Errorf("[%s] calling preflight error: instance type %s is sold out or unavailable in zone %s, %s",
+			p.GetProviderName(), instanceType, p.Zone, suggestion)
+	}
+	return nil
+}
+
+// describeInstanceTypeZones returns the zones of the region where the instance type is sellable, sorted by the
+// distance to the zone of the cluster, and whether it's sellable in the zone of the cluster.
+func (p *Tencent) describeInstanceTypeZones(instanceType string) ([]string, bool, error) {
+	request := cvm.NewDescribeZoneInstanceConfigInfosRequest()
+	request.Filters = []*cvm.Filter{
+		{Name: tencentCommon.StringPtr("instance-type"), Values: tencentCommon.StringPtrs([]string{instanceType})},
+	}
+	response, err := p.c.DescribeZoneInstanceConfigInfos(request)
+	if err != nil {
+		return nil, false, fmt.Errorf("[%s] calling describeZoneInstanceConfigInfos error, instance type: %s, msg: %v",
+			p.GetProviderName(), instanceType, err)
+	}
+	available := false
+	zones := make([]string, 0)
+	seen := map[string]bool{}
+	if response.Response != nil {
+		for _, item := range response.Response.InstanceTypeQuotaSet {
+			if item.Zone == nil || item.Status == nil || *item.Status != instanceTypeSell ||
+				item.InstanceType == nil || !strings.EqualFold(*item.InstanceType, instanceType) {
+				continue
+			}
+			if *item.Zone == p.Zone {
+				available = true
+				continue
+			}
+			if !seen[*item.Zone] {
+				seen[*item.Zone] = true
+				zones = append(zones, *item.Zone)
+			}
+		}
+	}
+	// zones are named as <region>-<number>, the ones with the nearest numbers are listed first.
+	sort.SliceStable(zones, func(i, j int) bool {
+		di, dj := zoneDistance(p.Zone, zones[i]), zoneDistance(p.Zone, zones[j])
+		if di != dj {
+			return di < dj
+		}
+		return zones[i] < zones[j]
+	})
+	return zones, available, nil
+}
+
+// zoneDistance returns the difference between the numbers of the zones, e.g. ap-guangzhou-3 and ap-guangzhou-6 is 3.
+func zoneDistance(zone, other string) int {
+	var a, b int
+	if _, err := fmt.Sscanf(zone[strings.LastIndex(zone, "-")+1:], "%d", &a); err != nil {
+		return 0
+	}
+	if _, err := fmt.Sscanf(other[strings.LastIndex(other, "-")+1:], "%d", &b); err != nil {
+		return 0
+	}
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
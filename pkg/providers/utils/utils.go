@@ -2,7 +2,6 @@ package utils
 
 import (
 	"crypto/rand"
-	"io/ioutil"
 
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/types"
@@ -69,10 +68,6 @@ func CreateKeyPair(ssh *types.SSH, providerName, name, keypair string) ([]byte,
 	}
 
 	ssh.SSHKeyPath = keyPath
-	publicKey, err := ioutil.ReadFile(keyPath + ".pub")
-	if err != nil {
-		return nil, err
-	}
-
-	return publicKey, nil
+	// the key path may reference a key source without public key file, e.g. a secret manager.
+	return utils.SSHPublicKey(keyPath, ssh.SSHKeyPassphrase)
 }
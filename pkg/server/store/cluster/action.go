@@ -10,7 +10,9 @@ import (
 
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
+	pkgtypes "github.com/cnrancher/autok3s/pkg/types"
 	autok3stypes "github.com/cnrancher/autok3s/pkg/types/apis"
+	"github.com/cnrancher/autok3s/pkg/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/rancher/apiserver/pkg/apierror"
@@ -25,6 +27,8 @@ import (
 const (
 	actionJoin               = "join"
 	linkNodes                = "nodes"
+	linkOperations           = "operations"
+	linkOptions              = "options"
 	actionEnableExplorer     = "enable-explorer"
 	actionDisableExplorer    = "disable-explorer"
 	actionDownloadKubeconfig = "download-kubeconfig"
@@ -34,6 +38,8 @@ const (
 // Formatter cluster's formatter.
 func Formatter(request *types.APIRequest, resource *types.RawResource) {
 	resource.Links[linkNodes] = request.URLBuilder.Link(resource.Schema, resource.ID, linkNodes)
+	resource.Links[linkOperations] = request.URLBuilder.Link(resource.Schema, resource.ID, linkOperations)
+	resource.Links[linkOptions] = request.URLBuilder.Link(resource.Schema, resource.ID, linkOptions)
 	resource.AddAction(request, actionJoin)
 }
 
@@ -56,6 +62,12 @@ func LinkCluster(request *types.APIRequest) (types.APIObject, error) {
 	if request.Link == linkNodes {
 		return nodesHandler(request, request.Schema, request.Name)
 	}
+	if request.Link == linkOperations {
+		return operationsHandler(request, request.Schema, request.Name)
+	}
+	if request.Link == linkOptions {
+		return optionsHandler(request, request.Schema, request.Name)
+	}
 
 	return request.Schema.Store.ByID(request, request.Schema, request.Name)
 }
@@ -124,7 +136,7 @@ func (j join) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
 			return
 		}
 		go func() {
-			err = provider.UpgradeK3sCluster(state.Name, upgradeInput.InstallScript, upgradeInput.K3sChannel, upgradeInput.K3sVersion, upgradeInput.PackageName, upgradeInput.PackagePath)
+			err = provider.UpgradeK3sCluster(state.Name, upgradeInput.InstallScript, upgradeInput.K3sChannel, upgradeInput.K3sVersion, upgradeInput.PackageName, upgradeInput.PackagePath, upgradeInput.K3sSHA256)
 			if err != nil {
 				logrus.Errorf("failed to upgrade cluster %s: %v", clusterID, err)
 			}
@@ -161,6 +173,76 @@ func nodesHandler(_ *types.APIRequest, schema *types.APISchema, id string) (type
 	}, nil
 }
 
+// operationsHandler returns the async operations of the cluster, the in-flight ones are refreshed from the provider,
+// so that the UI can poll them without blocking.
+func operationsHandler(_ *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	state, err := common.DefaultDB.GetClusterByID(id)
+	if err != nil || state == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found, got error: %v", id, err))
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, err.Error())
+	}
+	provider.SetMetadata(&state.Metadata)
+	_ = provider.SetOptions(state.Options)
+	operations, err := common.DefaultDB.ListOperations(state.ContextName)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+	result := make([]*pkgtypes.OperationStatus, 0, len(operations))
+	for _, o := range operations {
+		status := &pkgtypes.OperationStatus{TaskID: o.TaskID, Action: o.Action, Status: o.Status}
+		if o.Status == pkgtypes.OperationRunning {
+			if s, err := provider.GetOperationStatus(o.TaskID); err == nil {
+				status = s
+			} else {
+				logrus.Debugf("failed to query status of operation %s: %v", o.TaskID, err)
+			}
+		}
+		result = append(result, status)
+	}
+	return types.APIObject{
+		Type:   schema.ID,
+		ID:     id,
+		Object: result,
+	}, nil
+}
+
+// optionsHandler returns the fields of the options and configs with the values the cluster was created with, so that
+// the form of editing cluster is pre-filled.
+func optionsHandler(_ *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	state, err := common.DefaultDB.GetClusterByID(id)
+	if err != nil || state == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found, got error: %v", id, err))
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, err.Error())
+	}
+	provider.SetMetadata(&state.Metadata)
+	if err = provider.SetOptions(state.Options); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+	options, err := provider.GetProviderOptionValues()
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+	config, err := utils.ConvertToFields(state.Metadata)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+	return types.APIObject{
+		Type: schema.ID,
+		ID:   id,
+		Object: autok3stypes.Provider{
+			Name:    state.Provider,
+			Options: options,
+			Config:  config,
+		},
+	}, nil
+}
+
 type explorer struct{}
 
 func (e explorer) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
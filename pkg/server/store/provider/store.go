@@ -3,6 +3,7 @@ package provider
 import (
 	"github.com/cnrancher/autok3s/pkg/providers"
 	"github.com/cnrancher/autok3s/pkg/server/store/utils"
+	pkgtypes "github.com/cnrancher/autok3s/pkg/types"
 	autok3stypes "github.com/cnrancher/autok3s/pkg/types/apis"
 
 	"github.com/rancher/apiserver/pkg/apierror"
@@ -85,6 +86,16 @@ func toProviderObject(provider providers.Provider, schema *types.APISchema, id s
 		Required:    false,
 		Default:     "",
 	}
+	// set allowed values and ranges of fields from the tags of provider options.
+	providerOptions, err := provider.GetProviderOptions([]byte("{}"))
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	for _, fields := range []map[string]schemas.Field{options, config} {
+		if err := utils.SetFieldsConstraints(fields, providerOptions, pkgtypes.Metadata{}); err != nil {
+			return types.APIObject{}, err
+		}
+	}
 	obj := types.APIObject{
 		Type: schema.ID,
 		ID:   id,
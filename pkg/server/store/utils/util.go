@@ -8,6 +8,7 @@ import (
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
 	"github.com/cnrancher/autok3s/pkg/types"
+	autok3sutils "github.com/cnrancher/autok3s/pkg/utils"
 
 	"github.com/rancher/wrangler/v2/pkg/schemas"
 	"github.com/sirupsen/logrus"
@@ -72,3 +73,25 @@ func ConvertFlagsToFields(flags []types.Flag) map[string]schemas.Field {
 	}
 	return result
 }
+
+// SetFieldsConstraints sets the allowed values and ranges from the tags of objs to fields with the same name,
+// so that the UI renders the options as dropdowns and validates the values as the CLI does.
+func SetFieldsConstraints(fields map[string]schemas.Field, objs ...interface{}) error {
+	for _, obj := range objs {
+		objFields, err := autok3sutils.ConvertToFields(obj)
+		if err != nil {
+			return err
+		}
+		for name, objField := range objFields {
+			field, ok := fields[name]
+			if !ok {
+				continue
+			}
+			field.Options = objField.Options
+			field.Min = objField.Min
+			field.Max = objField.Max
+			fields[name] = field
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package websocket
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -148,6 +149,12 @@ func logHandler(apiOp *types.APIRequest) error {
 					_, _ = w.Write([]byte("event: close\ndata: close\n\n"))
 					return err
 				}
+				// the summary of the created cluster is sent as the last event, so clients needn't parse the logs.
+				if s.Summary != nil {
+					if b, err := json.Marshal(s.Summary); err == nil {
+						_, _ = w.Write([]byte(fmt.Sprintf("event: summary\ndata: %s\n\n", b)))
+					}
+				}
 				close(result)
 				_, _ = w.Write([]byte("event: close\ndata: close\n\n"))
 				return nil
@@ -77,4 +77,5 @@ type UpgradeInput struct {
 	K3sVersion    string `json:"k3s-version,omitempty"`
 	PackageName   string `json:"package-name,omitempty"`
 	PackagePath   string `json:"package-path,omitempty"`
+	K3sSHA256     string `json:"k3s-sha256,omitempty"`
 }
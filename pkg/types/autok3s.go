@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // AutoK3s struct for autok3s.
@@ -25,29 +26,50 @@ type Cluster struct {
 type Metadata struct {
 	Name                     string      `json:"name" yaml:"name"`
 	Provider                 string      `json:"provider" yaml:"provider"`
-	Master                   string      `json:"master" yaml:"master"`
-	Worker                   string      `json:"worker" yaml:"worker"`
+	Master                   string      `json:"master" yaml:"master" min:"0"`
+	Worker                   string      `json:"worker" yaml:"worker" min:"0"`
 	Token                    string      `json:"token,omitempty" yaml:"token,omitempty"`
 	IP                       string      `json:"ip,omitempty" yaml:"ip,omitempty"`
 	TLSSans                  StringArray `json:"tls-sans,omitempty" yaml:"tls-sans,omitempty" gorm:"type:text"`
 	ClusterCidr              string      `json:"cluster-cidr,omitempty" yaml:"cluster-cidr,omitempty"`
+	ClusterDomain            string      `json:"cluster-domain,omitempty" yaml:"cluster-domain,omitempty"`
+	AdvertiseAddress         string      `json:"advertise-address,omitempty" yaml:"advertise-address,omitempty"`
+	NodeIP                   string      `json:"node-ip,omitempty" yaml:"node-ip,omitempty"`
 	MasterExtraArgs          string      `json:"master-extra-args,omitempty" yaml:"master-extra-args,omitempty"`
 	WorkerExtraArgs          string      `json:"worker-extra-args,omitempty" yaml:"worker-extra-args,omitempty"`
 	Registry                 string      `json:"registry,omitempty" yaml:"registry,omitempty"`
 	SystemDefaultRegistry    string      `json:"system-default-registry,omitempty" yaml:"system-default-registry,omitempty"`
+	PullThroughCache         bool        `json:"pull-through-cache" yaml:"pull-through-cache" gorm:"type:bool"`
+	PullThroughCacheUpstream string      `json:"pull-through-cache-upstream,omitempty" yaml:"pull-through-cache-upstream,omitempty"`
 	DataStore                string      `json:"datastore,omitempty" yaml:"datastore,omitempty"`
+	EtcdSnapshotScheduleCron string      `json:"etcd-snapshot-schedule-cron,omitempty" yaml:"etcd-snapshot-schedule-cron,omitempty"`
+	EtcdSnapshotRetention    int         `json:"etcd-snapshot-retention,omitempty" yaml:"etcd-snapshot-retention,omitempty"`
+	EtcdSnapshotDir          string      `json:"etcd-snapshot-dir,omitempty" yaml:"etcd-snapshot-dir,omitempty"`
 	K3sVersion               string      `json:"k3s-version,omitempty" yaml:"k3s-version,omitempty"`
 	K3sChannel               string      `json:"k3s-channel,omitempty" yaml:"k3s-channel,omitempty"`
+	K3sSHA256                string      `json:"k3s-sha256,omitempty" yaml:"k3s-sha256,omitempty"`
+	UpgradeWindow            string      `json:"upgrade-window,omitempty" yaml:"upgrade-window,omitempty"`
 	InstallScript            string      `json:"k3s-install-script,omitempty" yaml:"k3s-install-script,omitempty"`
 	Mirror                   string      `json:"k3s-install-mirror,omitempty" yaml:"k3s-install-mirror,omitempty"`
 	DockerMirror             string      `json:"dockerMirror,omitempty" yaml:"dockerMirror,omitempty"`
 	DockerArg                string      `json:"docker-arg,omitempty" yaml:"docker-arg,omitempty"`
 	DockerScript             string      `json:"docker-script,omitempty" yaml:"docker-script,omitempty"`
 	Network                  string      `json:"network,omitempty" yaml:"network,omitempty"`
+	CNI                      string      `json:"cni,omitempty" yaml:"cni,omitempty"`
+	Snapshotter              string      `json:"snapshotter,omitempty" yaml:"snapshotter,omitempty"`
+	MinCPU                   int         `json:"min-cpu,omitempty" yaml:"min-cpu,omitempty"`
+	MinMemory                string      `json:"min-memory,omitempty" yaml:"min-memory,omitempty"`
+	MinDisk                  string      `json:"min-disk,omitempty" yaml:"min-disk,omitempty"`
+	NodeHTTPProxy            string      `json:"node-http-proxy,omitempty" yaml:"node-http-proxy,omitempty"`
+	NodeNoProxy              string      `json:"node-no-proxy,omitempty" yaml:"node-no-proxy,omitempty"`
 	UI                       bool        `json:"ui" yaml:"ui" gorm:"type:bool"` // Deprecated
 	Cluster                  bool        `json:"cluster" yaml:"cluster" gorm:"type:bool"`
 	ContextName              string      `json:"context-name" yaml:"context-name"`
 	RegistryContent          string      `json:"registry-content,omitempty" yaml:"registry-content,omitempty"`
+	RegistryCA               StringArray `json:"registry-ca,omitempty" yaml:"registry-ca,omitempty" gorm:"type:stringArray"`
+	RegistryCert             StringArray `json:"registry-cert,omitempty" yaml:"registry-cert,omitempty" gorm:"type:stringArray"`
+	RegistryKey              StringArray `json:"registry-key,omitempty" yaml:"registry-key,omitempty" gorm:"type:stringArray"`
+	RegistryTLSContent       StringMap   `json:"registry-tls-content,omitempty" yaml:"registry-tls-content,omitempty" gorm:"type:stringMap"`
 	Manifests                string      `json:"manifests,omitempty" yaml:"manifests,omitempty"`
 	Enable                   StringArray `json:"enable,omitempty" yaml:"enable,omitempty" gorm:"type:stringArray"`
 	PackagePath              string      `json:"package-path,omitempty" yaml:"package-path,omitempty"`
@@ -58,8 +80,58 @@ type Metadata struct {
 	DataStoreCAFileContent   string      `json:"datastore-cafile-content,omitempty" yaml:"datastore-cafile-content,omitempty"`
 	DataStoreCertFileContent string      `json:"datastore-certfile-content,omitempty" yaml:"datastore-certfile-content,omitempty"`
 	DataStoreKeyFileContent  string      `json:"datastore-keyfile-content,omitempty" yaml:"datastore-keyfile-content,omitempty"`
+	AuditPolicyFile          string      `json:"audit-policy-file,omitempty" yaml:"audit-policy-file,omitempty"`
+	AuditPolicyFileContent   string      `json:"audit-policy-file-content,omitempty" yaml:"audit-policy-file-content,omitempty"`
+	CACertFile               string      `json:"ca-cert-file,omitempty" yaml:"ca-cert-file,omitempty"`
+	CAKeyFile                string      `json:"ca-key-file,omitempty" yaml:"ca-key-file,omitempty"`
 	Rollback                 bool        `json:"rollback" yaml:"rollback" gorm:"type:bool"`
+	NodeReadyTimeout         string      `json:"node-ready-timeout,omitempty" yaml:"node-ready-timeout,omitempty"`
+	JoinRetries              int         `json:"join-retries" yaml:"join-retries"`
+	PreviousWorker           string      `json:"previous-worker,omitempty" yaml:"previous-worker,omitempty"`
+	KubeconfigRotated        bool        `json:"kubeconfig-rotated,omitempty" yaml:"kubeconfig-rotated,omitempty" gorm:"type:bool"`
 	Values                   StringMap   `json:"values,omitempty" yaml:"values,omitempty" gorm:"type:stringMap"`
+	ClusterSpec              string      `json:"cluster-spec,omitempty" yaml:"cluster-spec,omitempty"`
+}
+
+// ClusterSpec struct for the autok3s version and the redacted args which the cluster is created with.
+type ClusterSpec struct {
+	AutoK3sVersion string                 `json:"autok3s-version" yaml:"autok3s-version"`
+	CreatedAt      time.Time              `json:"created-at" yaml:"created-at"`
+	Args           map[string]interface{} `json:"args" yaml:"args"`
+}
+
+// ClusterSummary struct for the result of creating a cluster, so that wrappers don't need to parse the log.
+type ClusterSummary struct {
+	Name        string        `json:"name" yaml:"name"`
+	ContextName string        `json:"context-name" yaml:"context-name"`
+	Provider    string        `json:"provider" yaml:"provider"`
+	Status      string        `json:"status" yaml:"status"`
+	APIServer   string        `json:"api-server,omitempty" yaml:"api-server,omitempty"`
+	KubeConfig  string        `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	UIURL       string        `json:"ui-url,omitempty" yaml:"ui-url,omitempty"`
+	Nodes       []SummaryNode `json:"nodes" yaml:"nodes"`
+}
+
+// SummaryNode struct for the addresses of the node in cluster summary.
+type SummaryNode struct {
+	InstanceID string `json:"instance-id" yaml:"instance-id"`
+	Role       string `json:"role" yaml:"role"`
+	PublicIP   string `json:"public-ip,omitempty" yaml:"public-ip,omitempty"`
+	InternalIP string `json:"internal-ip,omitempty" yaml:"internal-ip,omitempty"`
+}
+
+// The statuses of async operations of the cloud provider.
+const (
+	OperationRunning = "Running"
+	OperationSuccess = "Success"
+	OperationFailed  = "Failed"
+)
+
+// OperationStatus struct for the status of an async operation of the cloud provider, which is queried by its task id.
+type OperationStatus struct {
+	TaskID string `json:"task-id" yaml:"task-id"`
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	Status string `json:"status" yaml:"status"`
 }
 
 // Status struct for status.
@@ -76,15 +148,25 @@ type Node struct {
 
 	InstanceID        string   `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
 	InstanceStatus    string   `json:"instance-status,omitempty" yaml:"instance-status,omitempty"`
+	InstanceType      string   `json:"instance-type,omitempty" yaml:"instance-type,omitempty"`
 	PublicIPAddress   []string `json:"public-ip-address,omitempty" yaml:"public-ip-address,omitempty"`
 	InternalIPAddress []string `json:"internal-ip-address,omitempty" yaml:"internal-ip-address,omitempty"`
 	EipAllocationIds  []string `json:"eip-allocation-ids,omitempty" yaml:"eip-allocation-ids,omitempty"`
 	Master            bool     `json:"master,omitempty" yaml:"master,omitempty"`
+	Pool              string   `json:"pool,omitempty" yaml:"pool,omitempty"`
+	MasterRole        string   `json:"master-role,omitempty" yaml:"master-role,omitempty"`
+	Spot              bool     `json:"spot,omitempty" yaml:"spot,omitempty"`
+	DiskEncrypted     bool     `json:"disk-encrypted,omitempty" yaml:"disk-encrypted,omitempty"`
+	HostID            string   `json:"host-id,omitempty" yaml:"host-id,omitempty"`
+	PrivateDNSRecord  string   `json:"private-dns-record,omitempty" yaml:"private-dns-record,omitempty"`
 	RollBack          bool     `json:"-" yaml:"-"`
 	Current           bool     `json:"-" yaml:"-"`
 	Standalone        bool     `json:"standalone"`
 
 	LocalHostname string `json:"local-hostname,omitempty" yaml:"local-hostname,omitempty"`
+
+	// Tags are the cloud tags of the instance, they're only used by describe and not saved to state.
+	Tags map[string]string `json:"-" yaml:"-"`
 }
 
 // SSH struct for ssh.
@@ -96,6 +178,7 @@ type SSH struct {
 	SSHCertPath      string `json:"ssh-cert-path,omitempty" yaml:"ssh-cert-path,omitempty"`
 	SSHKeyPassphrase string `json:"ssh-key-passphrase,omitempty" yaml:"ssh-key-passphrase,omitempty"`
 	SSHAgentAuth     bool   `json:"ssh-agent-auth,omitempty" yaml:"ssh-agent-auth,omitempty"`
+	SSHBastion       string `json:"ssh-bastion,omitempty" yaml:"ssh-bastion,omitempty"`
 
 	SSHKeyName string `json:"ssh-key-name,omitempty" yaml:"ssh-key-name,omitempty" norman:"type=reference[sshkey]"`
 	SSHKey     string `json:"ssh-key,omitempty" yaml:"ssh-key,omitempty" norman:"type=password" gorm:"-:all"`
@@ -124,6 +207,13 @@ const (
 	ClusterStatusUnknown = "Unknown"
 )
 
+const (
+	// MasterRoleEtcd is the role of masters which only run etcd, the empty role runs both etcd and control-plane.
+	MasterRoleEtcd = "etcd"
+	// MasterRoleControlPlane is the role of masters which only run control-plane components without etcd.
+	MasterRoleControlPlane = "control-plane"
+)
+
 // ClusterInfo struct for cluster info.
 type ClusterInfo struct {
 	ID            string        `json:"id,omitempty"`
@@ -142,17 +232,50 @@ type ClusterInfo struct {
 
 // ClusterNode struct for cluster node.
 type ClusterNode struct {
-	InstanceID              string   `json:"instance-id,omitempty"`
-	InstanceStatus          string   `json:"instance-status,omitempty"`
-	ExternalIP              []string `json:"external-ip,omitempty"`
-	InternalIP              []string `json:"internal-ip,omitempty"`
-	Roles                   string   `json:"roles,omitempty"`
-	Status                  string   `json:"status,omitempty"`
-	HostName                string   `json:"hostname,omitempty"`
-	ContainerRuntimeVersion string   `json:"containerRuntimeVersion,omitempty"`
-	Version                 string   `json:"version,omitempty"`
-	Master                  bool     `json:"-"`
-	Standalone              bool     `json:"standalone"`
+	InstanceID              string            `json:"instance-id,omitempty"`
+	InstanceStatus          string            `json:"instance-status,omitempty"`
+	ExternalIP              []string          `json:"external-ip,omitempty"`
+	InternalIP              []string          `json:"internal-ip,omitempty"`
+	Roles                   string            `json:"roles,omitempty"`
+	Status                  string            `json:"status,omitempty"`
+	HostName                string            `json:"hostname,omitempty"`
+	ContainerRuntimeVersion string            `json:"containerRuntimeVersion,omitempty"`
+	Version                 string            `json:"version,omitempty"`
+	Tags                    map[string]string `json:"tags,omitempty"`
+	Master                  bool              `json:"-"`
+	Standalone              bool              `json:"standalone"`
+}
+
+// PruneResource is a leaked resource of the failed runs which is removed by prune.
+type PruneResource struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+}
+
+// NodeCommandResult struct for the result of running a command on a node, error is set if the command isn't run.
+type NodeCommandResult struct {
+	InstanceID string `json:"instance-id,omitempty"`
+	Master     bool   `json:"master"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitCode   int    `json:"exit-code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CostEstimate struct for the itemized cost estimate of creating a cluster.
+type CostEstimate struct {
+	Items []CostItem `json:"items,omitempty"`
+}
+
+// CostItem struct for an item of the cost estimate, the price is for one resource per charge unit,
+// e.g. an hour, a month or a GB of traffic. Monthly is 0 for the usage based items.
+type CostItem struct {
+	Name       string  `json:"name,omitempty"`
+	Count      int     `json:"count,omitempty"`
+	Price      float64 `json:"price,omitempty"`
+	ChargeUnit string  `json:"charge-unit,omitempty"`
+	Monthly    float64 `json:"monthly,omitempty"`
 }
 
 // StringArray gorm custom string array flag type.
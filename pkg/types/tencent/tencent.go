@@ -5,6 +5,10 @@ const (
 	StatusPending = "PENDING"
 	// StatusRunning tencent instance running status.
 	StatusRunning = "RUNNING"
+	// StatusStopped tencent instance stopped status.
+	StatusStopped = "STOPPED"
+	// StatusTerminating tencent instance terminating status.
+	StatusTerminating = "TERMINATING"
 
 	// Success tencent task success result.
 	Success = "SUCCESS"
@@ -23,26 +27,82 @@ const (
 type Options struct {
 	SecretID                string   `json:"secret-id,omitempty" yaml:"secret-id,omitempty"`
 	SecretKey               string   `json:"secret-key,omitempty" yaml:"secret-key,omitempty"`
+	Profile                 string   `json:"profile,omitempty" yaml:"profile,omitempty"`
 	Region                  string   `json:"region,omitempty" yaml:"region,omitempty"`
 	Zone                    string   `json:"zone,omitempty" yaml:"zone,omitempty"`
 	EndpointURL             string   `json:"endpoint-url,omitempty" yaml:"endpoint-url,omitempty"`
+	CVMEndpoint             string   `json:"cvm-endpoint,omitempty" yaml:"cvm-endpoint,omitempty"`
+	VPCEndpoint             string   `json:"vpc-endpoint,omitempty" yaml:"vpc-endpoint,omitempty"`
+	TagEndpoint             string   `json:"tag-endpoint,omitempty" yaml:"tag-endpoint,omitempty"`
+	TKEEndpoint             string   `json:"tke-endpoint,omitempty" yaml:"tke-endpoint,omitempty"`
+	CBSEndpoint             string   `json:"cbs-endpoint,omitempty" yaml:"cbs-endpoint,omitempty"`
+	PrivateDNSEndpoint      string   `json:"privatedns-endpoint,omitempty" yaml:"privatedns-endpoint,omitempty"`
+	APIQPS                  string   `json:"api-qps,omitempty" yaml:"api-qps,omitempty" min:"1"`
+	APIBurst                string   `json:"api-burst,omitempty" yaml:"api-burst,omitempty" min:"1"`
+	APIPageSize             string   `json:"api-page-size,omitempty" yaml:"api-page-size,omitempty" min:"1" max:"100"`
 	SecurityGroupIds        string   `json:"security-group,omitempty" yaml:"security-group,omitempty"`
 	KeypairID               string   `json:"keypair-id,omitempty" yaml:"keypair-id,omitempty"`
 	VpcID                   string   `json:"vpc,omitempty" yaml:"vpc,omitempty"`
 	SubnetID                string   `json:"subnet,omitempty" yaml:"subnet,omitempty"`
+	SubnetStrategy          string   `json:"subnet-strategy,omitempty" yaml:"subnet-strategy,omitempty" options:"single,round-robin,least-used"`
+	VpcName                 string   `json:"vpc-name,omitempty" yaml:"vpc-name,omitempty"`
+	SubnetName              string   `json:"subnet-name,omitempty" yaml:"subnet-name,omitempty"`
+	SecurityGroupName       string   `json:"security-group-name,omitempty" yaml:"security-group-name,omitempty"`
 	ImageID                 string   `json:"image,omitempty" yaml:"image,omitempty"`
+	ImageFamily             string   `json:"image-family,omitempty" yaml:"image-family,omitempty"`
 	InstanceType            string   `json:"instance-type,omitempty" yaml:"instance-type,omitempty"`
-	InstanceChargeType      string   `json:"instance-charge-type,omitempty" yaml:"instance-charge-type,omitempty"`
-	SystemDiskType          string   `json:"disk-category,omitempty" yaml:"disk-category,omitempty"`
-	SystemDiskSize          string   `json:"disk-size,omitempty" yaml:"disk-size,omitempty"`
-	InternetMaxBandwidthOut string   `json:"internet-max-bandwidth-out,omitempty" yaml:"internet-max-bandwidth-out,omitempty"`
+	LaunchTemplateID        string   `json:"launch-template-id,omitempty" yaml:"launch-template-id,omitempty"`
+	LaunchTemplateVersion   string   `json:"launch-template-version,omitempty" yaml:"launch-template-version,omitempty"`
+	InstanceChargeType      string   `json:"instance-charge-type,omitempty" yaml:"instance-charge-type,omitempty" options:"POSTPAID_BY_HOUR,PREPAID,SPOTPAID"`
+	SystemDiskType          string   `json:"disk-category,omitempty" yaml:"disk-category,omitempty" options:"LOCAL_BASIC,LOCAL_SSD,CLOUD_BASIC,CLOUD_SSD,CLOUD_PREMIUM,CLOUD_BSSD,CLOUD_HSSD,CLOUD_TSSD"`
+	SystemDiskSize          string   `json:"disk-size,omitempty" yaml:"disk-size,omitempty" min:"20" max:"1024"`
+	MasterDiskSize          string   `json:"master-disk-size,omitempty" yaml:"master-disk-size,omitempty" min:"20" max:"1024"`
+	WorkerDiskSize          string   `json:"worker-disk-size,omitempty" yaml:"worker-disk-size,omitempty" min:"20" max:"1024"`
+	DataDiskSize            string   `json:"data-disk-size,omitempty" yaml:"data-disk-size,omitempty" min:"10" max:"32000"`
+	DiskEncrypt             bool     `json:"disk-encrypt,omitempty" yaml:"disk-encrypt,omitempty"`
+	KmsKeyID                string   `json:"kms-key-id,omitempty" yaml:"kms-key-id,omitempty"`
+	InternetMaxBandwidthOut string   `json:"internet-max-bandwidth-out,omitempty" yaml:"internet-max-bandwidth-out,omitempty" min:"0" max:"200"`
+	RestrictEgress          bool     `json:"restrict-egress,omitempty" yaml:"restrict-egress,omitempty"`
+	EgressCIDRs             []string `json:"egress-cidr,omitempty" yaml:"egress-cidr,omitempty"`
 	PublicIPAssignedEIP     bool     `json:"eip" yaml:"eip"`
+	MasterEIP               bool     `json:"master-eip,omitempty" yaml:"master-eip,omitempty"`
+	WorkerEIP               bool     `json:"worker-eip,omitempty" yaml:"worker-eip,omitempty"`
+	EIPAddresses            []string `json:"eip-addresses,omitempty" yaml:"eip-addresses,omitempty"`
+	HaVip                   bool     `json:"ha-vip,omitempty" yaml:"ha-vip,omitempty"`
+	HaVipAddress            string   `json:"ha-vip-address,omitempty" yaml:"ha-vip-address,omitempty"`
+	NatGateway              bool     `json:"nat-gateway,omitempty" yaml:"nat-gateway,omitempty"`
+	NatGatewayID            string   `json:"nat-gateway-id,omitempty" yaml:"nat-gateway-id,omitempty"`
+	IngressLB               string   `json:"ingress-lb,omitempty" yaml:"ingress-lb,omitempty"`
 	NetworkRouteTableName   string   `json:"router,omitempty" yaml:"network-route-table-name,omitempty"`
 	Tags                    []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	MasterPrivateIPs        []string `json:"master-private-ips,omitempty" yaml:"master-private-ips,omitempty"`
+	EtcdOnlyMaster          string   `json:"etcd-only-master,omitempty" yaml:"etcd-only-master,omitempty" min:"0"`
+	ControlPlaneOnlyMaster  string   `json:"control-plane-only-master,omitempty" yaml:"control-plane-only-master,omitempty" min:"0"`
+	NodeOrder               string   `json:"node-order,omitempty" yaml:"node-order,omitempty" options:"masters-first,workers-first"`
+	Pools                   []string `json:"pools,omitempty" yaml:"pools,omitempty"`
+	KubeAPIServerArgs       []string `json:"kube-apiserver-arg,omitempty" yaml:"kube-apiserver-arg,omitempty"`
+	KubeControllerArgs      []string `json:"kube-controller-manager-arg,omitempty" yaml:"kube-controller-manager-arg,omitempty"`
+	KubeSchedulerArgs       []string `json:"kube-scheduler-arg,omitempty" yaml:"kube-scheduler-arg,omitempty"`
 	CloudControllerManager  bool     `json:"cloud-controller-manager" yaml:"cloud-controller-manager"`
+	CCMVersion              string   `json:"ccm-version,omitempty" yaml:"ccm-version,omitempty"`
+	EnableCSI               bool     `json:"enable-csi,omitempty" yaml:"enable-csi,omitempty"`
+	CSIVersion              string   `json:"csi-version,omitempty" yaml:"csi-version,omitempty"`
+	CSIDiskType             string   `json:"csi-disk-type,omitempty" yaml:"csi-disk-type,omitempty" options:"CLOUD_PREMIUM,CLOUD_SSD"`
+	GPU                     bool     `json:"gpu,omitempty" yaml:"gpu,omitempty"`
+	EtcdSnapshotCOSBucket   string   `json:"etcd-snapshot-cos-bucket,omitempty" yaml:"etcd-snapshot-cos-bucket,omitempty"`
 	UserDataPath            string   `json:"user-data-path,omitempty" yaml:"user-data-path,omitempty"`
 	UserDataContent         string   `json:"user-data-content,omitempty" yaml:"user-data-content,omitempty"`
+	MasterUserDataPath      string   `json:"master-user-data-path,omitempty" yaml:"master-user-data-path,omitempty"`
+	WorkerUserDataPath      string   `json:"worker-user-data-path,omitempty" yaml:"worker-user-data-path,omitempty"`
 	Spot                    bool     `json:"spot,omitempty" yaml:"spot,omitempty"`
+	SpotFallback            bool     `json:"spot-fallback,omitempty" yaml:"spot-fallback,omitempty"`
+	HostIDs                 []string `json:"host-id,omitempty" yaml:"host-id,omitempty"`
+	MetadataAccess          string   `json:"metadata-access,omitempty" yaml:"metadata-access,omitempty" options:"all,host-only,none"`
+	PrivateDNSZone          string   `json:"private-dns-zone,omitempty" yaml:"private-dns-zone,omitempty"`
+	OutputDir               string   `json:"output-dir,omitempty" yaml:"output-dir,omitempty"`
+	InstanceNameTemplate    string   `json:"instance-name-template,omitempty" yaml:"instance-name-template,omitempty"`
+	SetHostname             bool     `json:"set-hostname,omitempty" yaml:"set-hostname,omitempty"`
+	DrainTimeout            string   `json:"drain-timeout,omitempty" yaml:"drain-timeout,omitempty"`
 }
 
 // CloudControllerManager struct for tencent cloud-controller-manager.
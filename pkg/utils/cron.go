@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronFieldRanges are the value ranges of minute, hour, day of month, month and day of week in a standard cron expression.
+var cronFieldRanges = [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// cronFieldNames are the names which can be used in place of numbers of month and day of week fields.
+var cronFieldNames = map[int][]string{
+	3: {"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"},
+	4: {"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"},
+}
+
+// cronDescriptors are the predefined schedules which can be used in place of a cron expression.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// CronSchedule is a parsed standard cron expression, which matches the minutes to run at.
+type CronSchedule struct {
+	fields [5]map[int]bool
+	// the day matches if either day of month or day of week matches when both of them are restricted.
+	domRestricted bool
+	dowRestricted bool
+}
+
+// ParseCronSchedule parses the standard cron expression with 5 fields or a descriptor like `@daily`.
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@") {
+		spec, ok := cronDescriptors[expr]
+		if !ok {
+			return nil, fmt.Errorf("unsupported descriptor %s", expr)
+		}
+		expr = spec
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFieldRanges) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(cronFieldRanges), len(fields))
+	}
+	s := &CronSchedule{
+		domRestricted: fields[2] != "*" && fields[2] != "?",
+		dowRestricted: fields[4] != "*" && fields[4] != "?",
+	}
+	for i, field := range fields {
+		s.fields[i] = map[int]bool{}
+		for _, item := range strings.Split(field, ",") {
+			if err := parseCronItem(i, item, s.fields[i]); err != nil {
+				return nil, fmt.Errorf("invalid field %q: %v", field, err)
+			}
+		}
+	}
+	// both 0 and 7 are sunday.
+	if s.fields[4][7] {
+		s.fields[4][0] = true
+	}
+	return s, nil
+}
+
+// Match returns whether the minute of t is scheduled.
+func (s *CronSchedule) Match(t time.Time) bool {
+	if !s.fields[0][t.Minute()] || !s.fields[1][t.Hour()] || !s.fields[3][int(t.Month())] {
+		return false
+	}
+	dom, dow := s.fields[2][t.Day()], s.fields[4][int(t.Weekday())]
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func parseCronItem(index int, item string, values map[int]bool) error {
+	valueRange, step := item, 1
+	if i := strings.Index(item, "/"); i >= 0 {
+		valueRange = item[:i]
+		n, err := strconv.Atoi(item[i+1:])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid step %q", item[i+1:])
+		}
+		step = n
+	}
+	start, end := cronFieldRanges[index][0], cronFieldRanges[index][1]
+	if valueRange != "*" && valueRange != "?" {
+		bounds := strings.SplitN(valueRange, "-", 2)
+		value, err := parseCronValue(index, bounds[0])
+		if err != nil {
+			return err
+		}
+		start = value
+		// a single value with step runs from the value to the end of range.
+		if len(bounds) == 1 && step == 1 {
+			end = value
+		}
+		if len(bounds) == 2 {
+			if end, err = parseCronValue(index, bounds[1]); err != nil {
+				return err
+			}
+			if start > end {
+				return fmt.Errorf("invalid range %q", valueRange)
+			}
+		}
+	}
+	for v := start; v <= end; v += step {
+		values[v] = true
+	}
+	return nil
+}
+
+func parseCronValue(index int, value string) (int, error) {
+	min, max := cronFieldRanges[index][0], cronFieldRanges[index][1]
+	for i, name := range cronFieldNames[index] {
+		if strings.EqualFold(value, name) {
+			// month names start from 1, and day of week names start from 0.
+			return i + min, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
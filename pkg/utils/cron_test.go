@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronScheduleMatch(t *testing.T) {
+	// 2024-06-01 is a saturday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.June, day, hour, minute, 0, 0, time.UTC)
+	}
+	s, err := ParseCronSchedule("30 2 * * SAT")
+	assert.Nil(t, err)
+	assert.True(t, s.Match(at(1, 2, 30)))
+	assert.False(t, s.Match(at(1, 2, 31)))
+	assert.False(t, s.Match(at(2, 2, 30)))
+
+	s, err = ParseCronSchedule("*/15 1-3 * * *")
+	assert.Nil(t, err)
+	assert.True(t, s.Match(at(3, 3, 45)))
+	assert.False(t, s.Match(at(3, 4, 0)))
+
+	// either day of month or day of week matches when both are restricted.
+	s, err = ParseCronSchedule("0 0 15 * 7")
+	assert.Nil(t, err)
+	assert.True(t, s.Match(at(2, 0, 0)))
+	assert.True(t, s.Match(at(15, 0, 0)))
+	assert.False(t, s.Match(at(3, 0, 0)))
+
+	s, err = ParseCronSchedule("@daily")
+	assert.Nil(t, err)
+	assert.True(t, s.Match(at(4, 0, 0)))
+
+	for _, expr := range []string{"@every 6h", "0 0 * *", "0 25 * * *", "0 0 * FOO *"} {
+		_, err = ParseCronSchedule(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"runtime"
 
 	"github.com/sirupsen/logrus"
 )
@@ -43,6 +44,32 @@ func EnsureFileExist(file string) error {
 	return nil
 }
 
+// EnsureFilePrivate ensures the file is only accessible by the current user, the permission is repaired to 0600 if the
+// group or others can access it. The file owned by another user is left as it is with a warning, as changing the owner
+// may break the setup of that user. It's skipped if the file doesn't exist or on windows which doesn't support chmod.
+func EnsureFilePrivate(file string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if uid, ok := fileOwner(info); ok && uid != os.Getuid() {
+		logrus.Warnf("[autoK3s] file %s is owned by user %d instead of the current user %d, make sure it's only accessible by the trusted users", file, uid, os.Getuid())
+		return nil
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err = os.Chmod(file, 0600); err != nil {
+			return fmt.Errorf("failed to restrict permission of file %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
 // UserHome returns user's home dir.
 func UserHome() string {
 	u, err := user.Current()
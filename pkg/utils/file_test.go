@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureFilePrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("windows does not support chmod")
+	}
+	file := filepath.Join(t.TempDir(), "config")
+	assert.Nil(t, EnsureFilePrivate(file))
+
+	assert.Nil(t, os.WriteFile(file, []byte("secret"), 0644))
+	assert.Nil(t, os.Chmod(file, 0644))
+	assert.Nil(t, EnsureFilePrivate(file))
+	info, err := os.Stat(file)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the more restrictive permission is kept.
+	assert.Nil(t, os.Chmod(file, 0400))
+	assert.Nil(t, EnsureFilePrivate(file))
+	info, err = os.Stat(file)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0400), info.Mode().Perm())
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import "os"
+
+// fileOwner isn't supported on windows, the files are protected by the acl of user profile.
+func fileOwner(_ os.FileInfo) (int, bool) {
+	return 0, false
+}
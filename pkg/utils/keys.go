@@ -83,12 +83,14 @@ func (kp *KeyPair) WriteToFile(privateKeyPath string, publicKeyPath string) erro
 				}
 			}
 		}
-		f, err := os.Create(v.File)
+		// create the file with 0600 so that the key is never readable by the others, even before chmod.
+		f, err := os.OpenFile(v.File, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 		if err != nil {
 			return ErrUnableToWriteFile
 		}
 
 		if _, err := f.Write(v.Value); err != nil {
+			_ = f.Close()
 			return ErrUnableToWriteFile
 		}
 
@@ -96,9 +98,13 @@ func (kp *KeyPair) WriteToFile(privateKeyPath string, publicKeyPath string) erro
 		switch runtime.GOOS {
 		case "darwin", "freebsd", "linux", "openbsd":
 			if err := f.Chmod(0600); err != nil {
+				_ = f.Close()
 				return err
 			}
 		}
+		if err := f.Close(); err != nil {
+			return ErrUnableToWriteFile
+		}
 	}
 
 	return nil
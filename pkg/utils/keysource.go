@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshKeySourceFile  = "file"
+	sshKeySourceEnv   = "env"
+	sshKeySourceStdin = "stdin"
+)
+
+// SSHKeySource loads the ssh private key referenced by --ssh-key-path.
+type SSHKeySource interface {
+	Load() ([]byte, error)
+}
+
+// SSHKeySourceFactory returns the key source of the reference without the scheme prefix.
+type SSHKeySourceFactory func(ref string) (SSHKeySource, error)
+
+var (
+	sshKeySources = map[string]SSHKeySourceFactory{
+		sshKeySourceFile: func(ref string) (SSHKeySource, error) {
+			return fileKeySource(ref), nil
+		},
+		sshKeySourceEnv: func(ref string) (SSHKeySource, error) {
+			if ref == "" {
+				return nil, fmt.Errorf("env name is required, e.g. env:SSH_PRIVATE_KEY")
+			}
+			return envKeySource(ref), nil
+		},
+		sshKeySourceStdin: func(_ string) (SSHKeySource, error) {
+			return stdinKeySource{}, nil
+		},
+	}
+	providerKeySourcesMutex sync.RWMutex
+	// providerKeySources are the schemes of the key sources loaded by providers with the credential of the cluster,
+	// e.g. a secret manager, the keys are set by SetSSHKey before the ssh connections.
+	providerKeySources = map[string]bool{}
+	// the keys which aren't from files are cached, the stdin can only be read once and secret managers are remote.
+	sshKeyCache = sync.Map{}
+)
+
+type fileKeySource string
+
+func (s fileKeySource) Load() ([]byte, error) {
+	return GetFileContent(string(s))
+}
+
+type envKeySource string
+
+func (s envKeySource) Load() ([]byte, error) {
+	value := os.Getenv(string(s))
+	if value == "" {
+		return nil, fmt.Errorf("env %s is empty", string(s))
+	}
+	return []byte(value), nil
+}
+
+type stdinKeySource struct{}
+
+func (stdinKeySource) Load() ([]byte, error) {
+	return io.ReadAll(os.Stdin)
+}
+
+// providerKeySource is the key source loaded by provider, the key must be set by SetSSHKey before it's used.
+type providerKeySource string
+
+func (s providerKeySource) Load() ([]byte, error) {
+	return nil, fmt.Errorf("ssh key %s isn't loaded, the credential of provider is required to load it", string(s))
+}
+
+// RegisterProviderSSHKeySource registers the scheme of key source which is loaded by provider, e.g. a secret manager.
+// The provider loads the key with the credential and region of the cluster, and sets it by SetSSHKey.
+func RegisterProviderSSHKeySource(scheme string) {
+	providerKeySourcesMutex.Lock()
+	defer providerKeySourcesMutex.Unlock()
+	providerKeySources[scheme] = true
+}
+
+// SetSSHKey sets the key of the reference loaded by provider.
+func SetSSHKey(ref string, key []byte) {
+	sshKeyCache.Store(ref, key)
+}
+
+// ParseSSHKeySource returns the key source of reference in format `<scheme>:<ref>`, i.e. `env:SSH_PRIVATE_KEY`,
+// `stdin` and `tencent-ssm:<secret-name>:<version-id>`. The reference without registered scheme is a file path.
+func ParseSSHKeySource(ref string) (SSHKeySource, error) {
+	scheme, value, _ := strings.Cut(ref, ":")
+	providerKeySourcesMutex.RLock()
+	loadedByProvider := providerKeySources[scheme]
+	providerKeySourcesMutex.RUnlock()
+	if loadedByProvider {
+		return providerKeySource(ref), nil
+	}
+	factory, ok := sshKeySources[scheme]
+	if !ok {
+		return fileKeySource(ref), nil
+	}
+	return factory(value)
+}
+
+// SSHKeyFilePath returns the file path if the key is referenced by a file.
+func SSHKeyFilePath(ref string) (string, bool) {
+	source, err := ParseSSHKeySource(ref)
+	if err != nil {
+		return "", false
+	}
+	path, ok := source.(fileKeySource)
+	return string(path), ok
+}
+
+// LoadSSHKey loads the ssh private key by the reference.
+func LoadSSHKey(ref string) ([]byte, error) {
+	if key, ok := sshKeyCache.Load(ref); ok {
+		return key.([]byte), nil
+	}
+	source, err := ParseSSHKeySource(ref)
+	if err != nil {
+		return nil, err
+	}
+	key, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := source.(fileKeySource); !ok {
+		sshKeyCache.Store(ref, key)
+	}
+	return key, nil
+}
+
+// SSHPublicKey returns the public key in authorized_keys format of the referenced private key.
+// The `.pub` file next to the private key file is preferred, otherwise the public key is derived from the private key.
+func SSHPublicKey(ref, passphrase string) ([]byte, error) {
+	if path, ok := SSHKeyFilePath(ref); ok {
+		if _, err := os.Stat(StripUserHome(path) + ".pub"); err == nil {
+			return GetFileContent(path + ".pub")
+		}
+	}
+	key, err := LoadSSHKey(ref)
+	if err != nil {
+		return nil, err
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = parsePrivateKeyWithPassphrase(string(key), passphrase)
+	} else {
+		signer, err = parsePrivateKey(string(key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh private key %s: %v", ref, err)
+	}
+	return ssh.MarshalAuthorizedKey(signer.PublicKey()), nil
+}
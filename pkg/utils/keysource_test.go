@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSSHKeySource(t *testing.T) {
+	path, ok := SSHKeyFilePath("~/.ssh/id_rsa")
+	assert.True(t, ok)
+	assert.Equal(t, "~/.ssh/id_rsa", path)
+	path, ok = SSHKeyFilePath("file:/tmp/id_rsa")
+	assert.True(t, ok)
+	assert.Equal(t, "/tmp/id_rsa", path)
+	_, ok = SSHKeyFilePath("env:SSH_PRIVATE_KEY")
+	assert.False(t, ok)
+	_, ok = SSHKeyFilePath("stdin")
+	assert.False(t, ok)
+	_, err := ParseSSHKeySource("env:")
+	assert.NotNil(t, err)
+}
+
+func TestProviderSSHKeySource(t *testing.T) {
+	RegisterProviderSSHKeySource("test-secret")
+	ref := "test-secret:key:v1"
+	_, ok := SSHKeyFilePath(ref)
+	assert.False(t, ok)
+	// the key can't be loaded without provider.
+	_, err := LoadSSHKey(ref)
+	assert.NotNil(t, err)
+
+	SetSSHKey(ref, []byte("private key"))
+	key, err := LoadSSHKey(ref)
+	assert.Nil(t, err)
+	assert.Equal(t, "private key", string(key))
+}
+
+func TestSSHPublicKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "id_rsa")
+	assert.Nil(t, GenerateSSHKey(keyPath))
+	privateKey, err := os.ReadFile(keyPath)
+	assert.Nil(t, err)
+	publicKey, err := os.ReadFile(keyPath + ".pub")
+	assert.Nil(t, err)
+
+	key, err := SSHPublicKey(keyPath, "")
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey, key)
+
+	// the public key is derived from the private key without public key file.
+	t.Setenv("AUTOK3S_TEST_SSH_KEY", string(privateKey))
+	key, err = SSHPublicKey("env:AUTOK3S_TEST_SSH_KEY", "")
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey, key)
+}
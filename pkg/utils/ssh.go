@@ -30,11 +30,11 @@ func GetFileContent(path string) ([]byte, error) {
 	return buff, nil
 }
 
-// SSHPrivateKeyPath returns ssh private key content from given path.
+// SSHPrivateKeyPath returns ssh private key content from given path or key source reference.
 func SSHPrivateKeyPath(sshKey string) (string, error) {
-	content, err := GetFileContent(sshKey)
+	content, err := LoadSSHKey(sshKey)
 	if err != nil {
-		return "", fmt.Errorf("error while reading SSH key file: %v", err)
+		return "", fmt.Errorf("error while loading SSH key %s: %v", sshKey, err)
 	}
 	return string(content), nil
 }
@@ -10,6 +10,8 @@ import (
 	mrand "math/rand"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"text/template"
@@ -24,6 +26,10 @@ import (
 )
 
 const (
+	// AssumeYesEnv is the env to confirm the deletion of clusters without prompt, for the automation which can't pass
+	// `--force` or `--yes` easily.
+	AssumeYesEnv = "AUTOK3S_ASSUME_YES"
+
 	tmpl = `
 {{- range $key, $value := .}}
   - {{$key}}: {{$value}}
@@ -77,6 +83,17 @@ func AskForConfirmation(s string, def bool) (rtn bool) {
 	return
 }
 
+// AskForConfirmationOrAssumeYes confirms without prompt if AUTOK3S_ASSUME_YES is true, otherwise asks for
+// confirmation from os.Stdin. It's only used by the prompts which are intended to be skipped by the env, the other
+// destructive prompts still require their own flags.
+func AskForConfirmationOrAssumeYes(s string, def bool) bool {
+	if assumeYes, _ := strconv.ParseBool(os.Getenv(AssumeYesEnv)); assumeYes {
+		logrus.Infof("%s, confirmed by %s", s, AssumeYesEnv)
+		return true
+	}
+	return AskForConfirmation(s, def)
+}
+
 // AskForSelectItem ask for select item from the given map key.
 func AskForSelectItem(s string, ss map[string]string) string {
 	reader := bufio.NewReader(os.Stdin)
@@ -109,6 +126,8 @@ func WaitFor(fn func() (bool, error)) error {
 }
 
 // ConvertToFields convert interface to schemas' field.
+// The `options` tag of the field lists its allowed values separated by comma,
+// the `min` and `max` tags limit the range of its number value.
 func ConvertToFields(obj interface{}) (map[string]schemas.Field, error) {
 	t := reflect.TypeOf(obj)
 	if t.Kind() == reflect.Ptr {
@@ -117,6 +136,7 @@ func ConvertToFields(obj interface{}) (map[string]schemas.Field, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("can't convert non struct type obj %v", obj)
 	}
+	value := reflect.Indirect(reflect.ValueOf(obj))
 	num := t.NumField()
 	fields := make(map[string]schemas.Field, 0)
 	for i := 0; i < num; i++ {
@@ -125,7 +145,10 @@ func ConvertToFields(obj interface{}) (map[string]schemas.Field, error) {
 			fieldName := strings.Split(v, ",")[0]
 			field := schemas.Field{
 				Type:    f.Type.String(),
-				Default: reflect.ValueOf(obj).Field(i).Interface(),
+				Default: value.Field(i).Interface(),
+			}
+			if err := setFieldConstraints(&field, f.Tag); err != nil {
+				return nil, fmt.Errorf("invalid tag of field %s: %v", f.Name, err)
 			}
 			fields[fieldName] = field
 		}
@@ -133,6 +156,80 @@ func ConvertToFields(obj interface{}) (map[string]schemas.Field, error) {
 	return fields, nil
 }
 
+// ValidateFields validates the values of obj against the `options`, `min` and `max` tags of its fields,
+// so the values from CLI are limited as the UI does. Empty values are skipped as they are not set.
+func ValidateFields(obj interface{}) error {
+	fields, err := ConvertToFields(obj)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := validateField(name, fields[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setFieldConstraints(field *schemas.Field, tag reflect.StructTag) error {
+	if v, ok := tag.Lookup("options"); ok {
+		field.Options = strings.Split(v, ",")
+	}
+	for key, p := range map[string]**int64{"min": &field.Min, "max": &field.Max} {
+		v, ok := tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s %q is not a number", key, v)
+		}
+		*p = &n
+	}
+	return nil
+}
+
+func isOption(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateField(name string, field schemas.Field) error {
+	if len(field.Options) == 0 && field.Min == nil && field.Max == nil {
+		return nil
+	}
+	value := fmt.Sprintf("%v", field.Default)
+	if value == "" {
+		return nil
+	}
+	if len(field.Options) > 0 && !isOption(field.Options, value) {
+		return fmt.Errorf("`--%s` must be one of %s, got %q", name, strings.Join(field.Options, ", "), value)
+	}
+	if field.Min == nil && field.Max == nil {
+		return nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("`--%s` must be a number, got %q", name, value)
+	}
+	if field.Min != nil && n < *field.Min {
+		return fmt.Errorf("`--%s` must be >= %d, got %d", name, *field.Min, n)
+	}
+	if field.Max != nil && n > *field.Max {
+		return fmt.Errorf("`--%s` must be <= %d, got %d", name, *field.Max, n)
+	}
+	return nil
+}
+
 // MergeConfig merge config.
 func MergeConfig(source, target reflect.Value) {
 	if source.Kind() == reflect.Ptr {
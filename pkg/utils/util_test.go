@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOptions struct {
+	DiskType string `json:"disk-type,omitempty" options:"CLOUD_PREMIUM,CLOUD_SSD"`
+	DiskSize string `json:"disk-size,omitempty" min:"20" max:"1024"`
+	Count    int    `json:"count" min:"0"`
+	Name     string `json:"name,omitempty"`
+}
+
+func TestConvertToFields(t *testing.T) {
+	fields, err := ConvertToFields(&testOptions{DiskType: "CLOUD_SSD"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CLOUD_PREMIUM", "CLOUD_SSD"}, fields["disk-type"].Options)
+	assert.Equal(t, "CLOUD_SSD", fields["disk-type"].Default)
+	assert.Equal(t, int64(20), *fields["disk-size"].Min)
+	assert.Equal(t, int64(1024), *fields["disk-size"].Max)
+	assert.Nil(t, fields["count"].Max)
+	assert.Nil(t, fields["name"].Options)
+}
+
+func TestValidateFields(t *testing.T) {
+	assert.Nil(t, ValidateFields(testOptions{}))
+	assert.Nil(t, ValidateFields(testOptions{DiskType: "CLOUD_PREMIUM", DiskSize: "50", Name: "demo"}))
+	assert.NotNil(t, ValidateFields(testOptions{DiskType: "LOCAL_SSD"}))
+	assert.NotNil(t, ValidateFields(testOptions{DiskSize: "10"}))
+	assert.NotNil(t, ValidateFields(testOptions{DiskSize: "2048"}))
+	assert.NotNil(t, ValidateFields(testOptions{DiskSize: "large"}))
+	assert.NotNil(t, ValidateFields(testOptions{Count: -1}))
+}